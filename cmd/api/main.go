@@ -4,6 +4,10 @@ import (
 	"context"
 	"fmt"
 	"github.com/aviseu/go-sample/internal/app/application"
+	"github.com/aviseu/go-sample/internal/app/application/api"
+	"github.com/aviseu/go-sample/internal/app/application/outbox"
+	"github.com/aviseu/go-sample/internal/app/application/stream"
+	"github.com/aviseu/go-sample/internal/app/application/webhook"
 	"github.com/aviseu/go-sample/internal/app/domain"
 	"github.com/aviseu/go-sample/internal/app/infrastructure"
 	"github.com/aviseu/go-sample/internal/app/infrastructure/postgres"
@@ -13,12 +17,46 @@ import (
 	"os"
 	"os/signal"
 	"syscall"
+	"time"
 )
 
 type config struct {
 	Log struct {
 		Level slog.Level `default:"info"`
 	}
+	Trash struct {
+		Retention time.Duration `default:"720h"`
+	}
+	DueDates struct {
+		RejectPast bool `default:"true"`
+	}
+	Subtasks struct {
+		MaxDepth         int                     `default:"5"`
+		ParentCompletion domain.ParentCompletion `default:"block"`
+	}
+	Tasks struct {
+		Store         string `default:"state"`
+		SnapshotEvery int    `default:"50"`
+	}
+	Outbox struct {
+		Interval    time.Duration `default:"1s"`
+		BatchSize   int           `default:"100"`
+		MaxAttempts int           `default:"10"`
+		Backoff     time.Duration `default:"1s"`
+	}
+	Events struct {
+		Replay       int           `default:"1000"`
+		Buffer       int           `default:"100"`
+		MinReconnect time.Duration `default:"1s"`
+		MaxReconnect time.Duration `default:"1m"`
+	}
+	Webhooks struct {
+		Interval    time.Duration `default:"1s"`
+		BatchSize   int           `default:"20"`
+		MaxAttempts int           `default:"8"`
+		Backoff     time.Duration `default:"10s"`
+		Timeout     time.Duration `default:"10s"`
+	}
 	DB  infrastructure.Config
 	API application.Config
 }
@@ -40,6 +78,46 @@ func run(ctx context.Context) error {
 		return fmt.Errorf("failed to process env vars: %w", err)
 	}
 
+	if !cfg.Subtasks.ParentCompletion.Valid() {
+		return fmt.Errorf("invalid parent completion %q: must be block or auto", cfg.Subtasks.ParentCompletion)
+	}
+	if cfg.Tasks.Store != "state" && cfg.Tasks.Store != "events" {
+		return fmt.Errorf("invalid task store %q: must be state or events", cfg.Tasks.Store)
+	}
+	if cfg.Tasks.SnapshotEvery < 1 {
+		return fmt.Errorf("invalid snapshot interval %d: must be positive", cfg.Tasks.SnapshotEvery)
+	}
+	if cfg.Outbox.BatchSize < 1 {
+		return fmt.Errorf("invalid outbox batch size %d: must be positive", cfg.Outbox.BatchSize)
+	}
+	if cfg.Outbox.MaxAttempts < 1 {
+		return fmt.Errorf("invalid outbox max attempts %d: must be positive", cfg.Outbox.MaxAttempts)
+	}
+	if cfg.Events.Replay < 0 {
+		return fmt.Errorf("invalid event replay size %d: must not be negative", cfg.Events.Replay)
+	}
+	if cfg.Events.Buffer < 1 {
+		return fmt.Errorf("invalid event buffer size %d: must be positive", cfg.Events.Buffer)
+	}
+	if cfg.Events.MinReconnect <= 0 || cfg.Events.MaxReconnect < cfg.Events.MinReconnect {
+		return fmt.Errorf("invalid event reconnect interval %s to %s: must be positive and increasing", cfg.Events.MinReconnect, cfg.Events.MaxReconnect)
+	}
+	if cfg.Webhooks.BatchSize < 1 {
+		return fmt.Errorf("invalid webhook batch size %d: must be positive", cfg.Webhooks.BatchSize)
+	}
+	if cfg.Webhooks.MaxAttempts < 1 {
+		return fmt.Errorf("invalid webhook max attempts %d: must be positive", cfg.Webhooks.MaxAttempts)
+	}
+	if cfg.API.DefaultPageSize < 1 || cfg.API.MaxPageSize < cfg.API.DefaultPageSize {
+		return fmt.Errorf("invalid page size %d up to %d: must be positive and at most the max", cfg.API.DefaultPageSize, cfg.API.MaxPageSize)
+	}
+	if cfg.API.IdempotencyTTL <= 0 {
+		return fmt.Errorf("invalid idempotency TTL %s: must be positive", cfg.API.IdempotencyTTL)
+	}
+	if cfg.API.LiveWindow < 1 {
+		return fmt.Errorf("invalid live window %d: must be positive", cfg.API.LiveWindow)
+	}
+
 	// set logging
 	slog.Info("setting logging...")
 	log := slog.New(slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{Level: cfg.Log.Level}))
@@ -54,12 +132,106 @@ func run(ctx context.Context) error {
 
 	// Setup services & repositories
 	log.Info("setting up services & repositories...")
-	tr := postgres.NewTaskRepository(db)
-	ts := domain.NewService(tr)
+	var tr interface {
+		domain.Repository
+		api.Repository
+		postgres.TaskStore
+	}
+	tr = postgres.NewTaskRepository(db)
+	if cfg.Tasks.Store == "events" {
+		tr = postgres.NewEventTaskRepository(db, postgres.EventTaskRepositoryWithSnapshotEvery(cfg.Tasks.SnapshotEvery))
+	}
+	broker := stream.NewBroker(stream.BrokerWithReplay(cfg.Events.Replay), stream.BrokerWithBuffer(cfg.Events.Buffer))
+	opts := []domain.ServiceOptional{
+		domain.ServiceWithTrashRetention(cfg.Trash.Retention),
+		domain.ServiceWithMaxDepth(cfg.Subtasks.MaxDepth),
+		domain.ServiceWithParentCompletion(cfg.Subtasks.ParentCompletion),
+	}
+	if cfg.DueDates.RejectPast {
+		opts = append(opts, domain.ServiceWithPastDueDatesRejected())
+	}
+	ts := domain.NewService(tr, opts...)
+	pr := postgres.NewProjectRepository(db, tr)
+	ps := domain.NewProjectService(pr)
+	wr := postgres.NewWebhookRepository(db)
+	ws := domain.NewWebhookService(wr)
+	ir := postgres.NewIdempotencyRepository(db)
+
+	// start relay
+	log.Info("starting up outbox relay...")
+	wdr := postgres.NewWebhookDeliveryRepository(db)
+	publishers := outbox.Publishers{outbox.NewLogPublisher(log), webhook.NewPublisher(wdr)}
+	relay := outbox.NewRelay(log, postgres.NewOutboxRepository(db), publishers,
+		outbox.RelayWithInterval(cfg.Outbox.Interval),
+		outbox.RelayWithBatchSize(cfg.Outbox.BatchSize),
+		outbox.RelayWithMaxAttempts(cfg.Outbox.MaxAttempts),
+		outbox.RelayWithBackoff(cfg.Outbox.Backoff),
+	)
+	stopRelay := background(ctx, relay.Run)
+	defer func() {
+		log.Info("shutting down outbox relay...")
+		stopRelay()
+	}()
+
+	// start change listener, which feeds the broker with the changes of all
+	// instances, this one included
+	log.Info("starting up change listener...")
+	listener := postgres.NewChangeListener(log, db, cfg.DB.DSN, broker,
+		postgres.ChangeListenerWithReconnect(cfg.Events.MinReconnect, cfg.Events.MaxReconnect),
+	)
+	stopListener := background(ctx, listener.Run)
+	defer func() {
+		log.Info("shutting down change listener...")
+		stopListener()
+	}()
+
+	// purge expired idempotency keys
+	stopPurge := background(ctx, func(ctx context.Context) {
+		ticker := time.NewTicker(time.Hour)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				n, err := ir.Purge(ctx)
+				if err != nil {
+					log.Error(err.Error())
+					continue
+				}
+				log.Info(fmt.Sprintf("purged %d expired idempotency keys", n))
+			}
+		}
+	})
+	defer stopPurge()
+
+	// start webhook dispatcher
+	log.Info("starting up webhook dispatcher...")
+	dispatcher := webhook.NewDispatcher(log, wdr,
+		webhook.DispatcherWithClient(webhook.NewClient(cfg.Webhooks.Timeout)),
+		webhook.DispatcherWithInterval(cfg.Webhooks.Interval),
+		webhook.DispatcherWithBatchSize(cfg.Webhooks.BatchSize),
+		webhook.DispatcherWithMaxAttempts(cfg.Webhooks.MaxAttempts),
+		webhook.DispatcherWithBackoff(cfg.Webhooks.Backoff),
+	)
+	stopDispatcher := background(ctx, dispatcher.Run)
+	defer func() {
+		log.Info("shutting down webhook dispatcher...")
+		stopDispatcher()
+	}()
 
 	// setup server
 	log.Info("setting up server...")
-	server := application.SetupServer(cfg.API, application.APIHandler(log, ts, tr))
+	h := application.APIHandler(cfg.API, log, ts, tr,
+		api.HandlerWithProjects(ps, pr),
+		api.HandlerWithWebhooks(ws, wr),
+		api.HandlerWithBroker(broker),
+		api.HandlerWithIdempotency(ir),
+	)
+	server := application.SetupServer(cfg.API, h)
+	// event streams only end when their client goes away, so they are closed
+	// for the server to shut down
+	server.RegisterOnShutdown(broker.Close)
 	serverErrors := make(chan error, 1)
 
 	go func() {
@@ -86,3 +258,19 @@ func run(ctx context.Context) error {
 
 	return nil
 }
+
+// background runs fn until the returned function is called, which stops fn and
+// waits for it to return.
+func background(ctx context.Context, fn func(context.Context)) func() {
+	ctx, cancel := context.WithCancel(ctx)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		fn(ctx)
+	}()
+
+	return func() {
+		cancel()
+		<-done
+	}
+}
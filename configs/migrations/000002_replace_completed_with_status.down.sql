@@ -0,0 +1,5 @@
+alter table tasks add column completed boolean not null default false;
+
+update tasks set completed = true where status = 'done';
+
+alter table tasks drop column status;
@@ -0,0 +1,6 @@
+alter table tasks add column status text not null default 'todo'
+    check (status in ('todo', 'in_progress', 'blocked', 'done', 'cancelled'));
+
+update tasks set status = 'done' where completed;
+
+alter table tasks drop column completed;
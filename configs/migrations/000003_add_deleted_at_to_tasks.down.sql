@@ -0,0 +1,3 @@
+drop index tasks_deleted_at_idx;
+
+alter table tasks drop column deleted_at;
@@ -0,0 +1,3 @@
+alter table tasks add column deleted_at timestamptz;
+
+create index tasks_deleted_at_idx on tasks (deleted_at) where deleted_at is not null;
@@ -0,0 +1 @@
+alter table tasks add column version integer not null default 1;
@@ -0,0 +1 @@
+drop index tasks_title_id_idx;
@@ -0,0 +1 @@
+create index tasks_title_id_idx on tasks (title, id) where deleted_at is null;
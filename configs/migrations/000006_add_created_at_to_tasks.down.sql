@@ -0,0 +1,3 @@
+drop index tasks_created_at_id_idx;
+
+alter table tasks drop column created_at;
@@ -0,0 +1,3 @@
+alter table tasks add column created_at timestamptz not null default now();
+
+create index tasks_created_at_id_idx on tasks (created_at, id) where deleted_at is null;
@@ -0,0 +1,3 @@
+drop index tasks_search_idx;
+
+alter table tasks drop column search;
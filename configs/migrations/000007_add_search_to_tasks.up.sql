@@ -0,0 +1,4 @@
+alter table tasks add column search tsvector
+    generated always as (to_tsvector('english', title)) stored;
+
+create index tasks_search_idx on tasks using gin (search);
@@ -0,0 +1,3 @@
+alter table tasks
+    drop column completed_at,
+    drop column updated_at;
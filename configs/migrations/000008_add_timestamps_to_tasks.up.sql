@@ -0,0 +1,7 @@
+alter table tasks
+    add column updated_at timestamptz not null default now(),
+    add column completed_at timestamptz;
+
+update tasks set updated_at = created_at;
+
+update tasks set completed_at = created_at where status = 'done';
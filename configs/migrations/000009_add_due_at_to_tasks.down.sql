@@ -0,0 +1,3 @@
+drop index tasks_due_at_idx;
+
+alter table tasks drop column due_at;
@@ -0,0 +1,4 @@
+alter table tasks add column due_at timestamptz;
+
+create index tasks_due_at_idx on tasks (due_at)
+    where deleted_at is null and status not in ('done', 'cancelled');
@@ -0,0 +1,3 @@
+drop table task_tags;
+
+drop table tags;
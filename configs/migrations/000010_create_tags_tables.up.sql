@@ -0,0 +1,12 @@
+create table tags (
+    id uuid primary key,
+    name text not null unique
+);
+
+create table task_tags (
+    task_id uuid not null references tasks (id) on delete cascade,
+    tag_id uuid not null references tags (id) on delete cascade,
+    primary key (task_id, tag_id)
+);
+
+create index task_tags_tag_id_idx on task_tags (tag_id);
@@ -0,0 +1,3 @@
+drop index tasks_parent_id_idx;
+
+alter table tasks drop column parent_id;
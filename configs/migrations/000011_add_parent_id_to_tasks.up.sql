@@ -0,0 +1,3 @@
+alter table tasks add column parent_id uuid references tasks (id) on delete set null;
+
+create index tasks_parent_id_idx on tasks (parent_id) where parent_id is not null;
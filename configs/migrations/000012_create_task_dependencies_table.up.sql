@@ -0,0 +1,8 @@
+create table task_dependencies (
+    task_id uuid not null references tasks (id) on delete cascade,
+    depends_on_id uuid not null references tasks (id) on delete cascade,
+    primary key (task_id, depends_on_id),
+    check (task_id <> depends_on_id)
+);
+
+create index task_dependencies_depends_on_id_idx on task_dependencies (depends_on_id);
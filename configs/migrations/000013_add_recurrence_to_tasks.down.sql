@@ -0,0 +1,5 @@
+drop index tasks_series_id_occurrence_idx;
+
+alter table tasks drop column occurrence;
+alter table tasks drop column series_id;
+alter table tasks drop column recurrence;
@@ -0,0 +1,5 @@
+alter table tasks add column recurrence text;
+alter table tasks add column series_id uuid;
+alter table tasks add column occurrence integer not null default 0;
+
+create unique index tasks_series_id_occurrence_idx on tasks (series_id, occurrence) where series_id is not null;
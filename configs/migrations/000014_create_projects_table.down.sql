@@ -0,0 +1,5 @@
+drop index tasks_project_id_idx;
+
+alter table tasks drop column project_id;
+
+drop table projects;
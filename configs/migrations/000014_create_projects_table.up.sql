@@ -0,0 +1,16 @@
+create table projects (
+    id uuid primary key,
+    name text not null,
+    inbox boolean not null default false,
+    created_at timestamptz not null default now(),
+    updated_at timestamptz not null default now(),
+    version integer not null default 1
+);
+
+create unique index projects_inbox_idx on projects (inbox) where inbox;
+
+insert into projects (id, name, inbox) values ('00000000-0000-0000-0000-000000000001', 'Inbox', true);
+
+alter table tasks add column project_id uuid not null default '00000000-0000-0000-0000-000000000001' references projects (id);
+
+create index tasks_project_id_idx on tasks (project_id);
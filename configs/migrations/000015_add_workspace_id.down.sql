@@ -0,0 +1,20 @@
+drop index projects_workspace_id_name_idx;
+
+alter table projects drop constraint projects_workspace_id_check;
+alter table projects drop column workspace_id;
+
+alter table tags drop constraint tags_workspace_id_name_key;
+alter table tags drop column workspace_id;
+alter table tags add constraint tags_name_key unique (name);
+
+drop index tasks_workspace_id_deleted_at_idx;
+drop index tasks_workspace_id_due_at_idx;
+drop index tasks_workspace_id_created_at_id_idx;
+drop index tasks_workspace_id_title_id_idx;
+
+alter table tasks drop column workspace_id;
+
+create index tasks_title_id_idx on tasks (title, id) where deleted_at is null;
+create index tasks_created_at_id_idx on tasks (created_at, id) where deleted_at is null;
+create index tasks_due_at_idx on tasks (due_at)
+    where deleted_at is null and status not in ('done', 'cancelled');
@@ -0,0 +1,22 @@
+alter table tasks add column workspace_id uuid not null default '00000000-0000-0000-0000-000000000000';
+
+drop index tasks_title_id_idx;
+drop index tasks_created_at_id_idx;
+drop index tasks_due_at_idx;
+
+create index tasks_workspace_id_title_id_idx on tasks (workspace_id, title, id) where deleted_at is null;
+create index tasks_workspace_id_created_at_id_idx on tasks (workspace_id, created_at, id) where deleted_at is null;
+create index tasks_workspace_id_due_at_idx on tasks (workspace_id, due_at)
+    where deleted_at is null and status not in ('done', 'cancelled');
+create index tasks_workspace_id_deleted_at_idx on tasks (workspace_id, deleted_at) where deleted_at is not null;
+
+alter table tags add column workspace_id uuid not null default '00000000-0000-0000-0000-000000000000';
+alter table tags drop constraint tags_name_key;
+alter table tags add constraint tags_workspace_id_name_key unique (workspace_id, name);
+
+-- the inbox is shared by all workspaces, every other project belongs to one
+alter table projects add column workspace_id uuid default '00000000-0000-0000-0000-000000000000';
+update projects set workspace_id = null where inbox;
+alter table projects add constraint projects_workspace_id_check check (inbox = (workspace_id is null));
+
+create index projects_workspace_id_name_idx on projects (workspace_id, name);
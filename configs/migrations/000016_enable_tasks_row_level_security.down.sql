@@ -0,0 +1,8 @@
+drop policy tasks_tenant_isolation on tasks;
+alter table tasks disable row level security;
+
+alter default privileges in schema public revoke all on tables from app_tenant;
+revoke all on all tables in schema public from app_tenant;
+revoke usage on schema public from app_tenant;
+revoke app_tenant from current_user;
+drop role app_tenant;
@@ -0,0 +1,19 @@
+-- the repositories switch to this role for every transaction that touches tasks,
+-- so the policy below applies even when they connect as the table owner
+do $$
+begin
+    if not exists (select 1 from pg_roles where rolname = 'app_tenant') then
+        create role app_tenant nologin;
+    end if;
+end
+$$;
+
+grant app_tenant to current_user;
+grant usage on schema public to app_tenant;
+grant select, insert, update, delete on all tables in schema public to app_tenant;
+alter default privileges in schema public grant select, insert, update, delete on tables to app_tenant;
+
+-- without app.tenant_id the setting is null or empty and no task matches
+alter table tasks enable row level security;
+create policy tasks_tenant_isolation on tasks to app_tenant
+    using (workspace_id = nullif(current_setting('app.tenant_id', true), '')::uuid);
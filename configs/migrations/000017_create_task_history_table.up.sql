@@ -0,0 +1,20 @@
+-- no foreign key on purpose, the history outlives purged tasks
+create table task_history (
+    id uuid primary key,
+    workspace_id uuid not null,
+    task_id uuid not null,
+    action text not null,
+    actor text not null,
+    before jsonb not null,
+    after jsonb not null,
+    created_at timestamptz not null
+);
+
+create index task_history_task_id_created_at_id_idx on task_history (task_id, created_at, id);
+
+-- the history can only be appended to
+revoke update, delete on task_history from app_tenant;
+
+alter table task_history enable row level security;
+create policy task_history_tenant_isolation on task_history to app_tenant
+    using (workspace_id = nullif(current_setting('app.tenant_id', true), '')::uuid);
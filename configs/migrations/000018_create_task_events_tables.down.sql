@@ -0,0 +1,2 @@
+drop table task_snapshots;
+drop table task_events;
@@ -0,0 +1,31 @@
+-- events and snapshots only disappear together with a purged task
+create table task_events (
+    task_id uuid not null references tasks (id) on delete cascade,
+    sequence integer not null,
+    workspace_id uuid not null,
+    type text not null,
+    data jsonb not null,
+    version integer not null,
+    created_at timestamptz not null,
+    primary key (task_id, sequence)
+);
+
+create table task_snapshots (
+    task_id uuid not null references tasks (id) on delete cascade,
+    sequence integer not null,
+    workspace_id uuid not null,
+    data jsonb not null,
+    version integer not null,
+    created_at timestamptz not null,
+    primary key (task_id, sequence)
+);
+
+revoke update, delete on task_events, task_snapshots from app_tenant;
+
+alter table task_events enable row level security;
+create policy task_events_tenant_isolation on task_events to app_tenant
+    using (workspace_id = nullif(current_setting('app.tenant_id', true), '')::uuid);
+
+alter table task_snapshots enable row level security;
+create policy task_snapshots_tenant_isolation on task_snapshots to app_tenant
+    using (workspace_id = nullif(current_setting('app.tenant_id', true), '')::uuid);
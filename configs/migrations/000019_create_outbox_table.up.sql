@@ -0,0 +1,22 @@
+create table outbox (
+    id uuid primary key,
+    sequence bigint generated always as identity,
+    workspace_id uuid not null,
+    task_id uuid not null,
+    type text not null,
+    payload jsonb not null,
+    occurred_at timestamptz not null,
+    status text not null default 'pending',
+    attempts integer not null default 0,
+    last_error text,
+    available_at timestamptz not null default now(),
+    locked_until timestamptz,
+    delivered_at timestamptz,
+    constraint outbox_status_check check (status in ('pending', 'delivered', 'dead'))
+);
+
+create index outbox_pending_idx on outbox (task_id, sequence) where status = 'pending';
+
+-- tasks only ever add to the outbox, the relay reads it across all workspaces
+-- as the table owner
+revoke select, update, delete on outbox from app_tenant;
@@ -0,0 +1,55 @@
+-- an empty events array subscribes to every event
+create table webhooks (
+    id uuid primary key,
+    workspace_id uuid not null,
+    url text not null,
+    events text[] not null default '{}',
+    secret text not null,
+    created_at timestamptz not null
+);
+
+create table webhook_deliveries (
+    id uuid primary key,
+    webhook_id uuid not null references webhooks (id) on delete cascade,
+    workspace_id uuid not null,
+    event_id uuid not null,
+    event_type text not null,
+    payload jsonb not null,
+    status text not null default 'pending',
+    failures integer not null default 0,
+    available_at timestamptz not null default now(),
+    locked_until timestamptz,
+    created_at timestamptz not null default now(),
+    constraint webhook_deliveries_status_check check (status in ('pending', 'delivered', 'dead')),
+    constraint webhook_deliveries_webhook_id_event_id_key unique (webhook_id, event_id)
+);
+
+create index webhook_deliveries_pending_idx on webhook_deliveries (available_at) where status = 'pending';
+
+create table webhook_attempts (
+    id uuid primary key,
+    delivery_id uuid not null references webhook_deliveries (id) on delete cascade,
+    workspace_id uuid not null,
+    status text not null,
+    response_code integer,
+    error text,
+    attempted_at timestamptz not null,
+    constraint webhook_attempts_status_check check (status in ('succeeded', 'failed'))
+);
+
+create index webhook_attempts_delivery_id_attempted_at_idx on webhook_attempts (delivery_id, attempted_at);
+
+-- the attempts are a record of what happened and can only be appended to
+revoke update, delete on webhook_attempts from app_tenant;
+
+alter table webhooks enable row level security;
+create policy webhooks_tenant_isolation on webhooks to app_tenant
+    using (workspace_id = nullif(current_setting('app.tenant_id', true), '')::uuid);
+
+alter table webhook_deliveries enable row level security;
+create policy webhook_deliveries_tenant_isolation on webhook_deliveries to app_tenant
+    using (workspace_id = nullif(current_setting('app.tenant_id', true), '')::uuid);
+
+alter table webhook_attempts enable row level security;
+create policy webhook_attempts_tenant_isolation on webhook_attempts to app_tenant
+    using (workspace_id = nullif(current_setting('app.tenant_id', true), '')::uuid);
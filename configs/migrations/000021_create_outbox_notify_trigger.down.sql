@@ -0,0 +1,2 @@
+drop trigger outbox_notify_task_change on outbox;
+drop function notify_task_change();
@@ -0,0 +1,13 @@
+-- every event added to the outbox is announced on the task_changes channel, so
+-- that all instances learn about the changes made by any of them. The
+-- notification is only sent once the transaction commits.
+create function notify_task_change() returns trigger as $$
+begin
+    perform pg_notify('task_changes', new.id::text);
+    return null;
+end;
+$$ language plpgsql;
+
+create trigger outbox_notify_task_change
+    after insert on outbox
+    for each row execute function notify_task_change();
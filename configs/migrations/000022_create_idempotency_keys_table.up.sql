@@ -0,0 +1,22 @@
+-- a key without a status code belongs to a request that is still in flight,
+-- which another request may take over once locked_until has passed; the token
+-- tells the request that holds the key apart from the ones before it
+create table idempotency_keys (
+    workspace_id uuid not null,
+    key text not null,
+    token uuid not null,
+    fingerprint text not null,
+    status_code integer,
+    header jsonb,
+    body bytea,
+    locked_until timestamptz not null,
+    expires_at timestamptz not null,
+    created_at timestamptz not null default now(),
+    primary key (workspace_id, key)
+);
+
+create index idempotency_keys_expires_at_idx on idempotency_keys (expires_at);
+
+alter table idempotency_keys enable row level security;
+create policy idempotency_keys_tenant_isolation on idempotency_keys to app_tenant
+    using (workspace_id = nullif(current_setting('app.tenant_id', true), '')::uuid);
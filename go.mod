@@ -3,6 +3,7 @@ module github.com/aviseu/go-sample
 go 1.23.6
 
 require (
+	github.com/coder/websocket v1.8.13
 	github.com/go-chi/chi/v5 v5.2.1
 	github.com/golang-migrate/migrate/v4 v4.18.2
 	github.com/google/uuid v1.6.0
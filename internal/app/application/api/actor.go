@@ -0,0 +1,20 @@
+package api
+
+import (
+	"github.com/aviseu/go-sample/internal/app/infrastructure"
+	"net/http"
+)
+
+// Actor records the X-Actor header as the one making the changes of the
+// request, which end up in the history of the tasks.
+func (h *Handler) Actor(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		actor := r.Header.Get("X-Actor")
+		if actor == "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		next.ServeHTTP(w, r.WithContext(infrastructure.WithActor(r.Context(), actor)))
+	})
+}
@@ -5,4 +5,28 @@ import (
 	"github.com/aviseu/go-sample/internal/errs"
 )
 
-var ErrInvalidID = errs.NewValidationError(errors.New("invalid ID"))
+var (
+	ErrInvalidTaskID       = errs.NewValidationError(errors.New("invalid task ID"))
+	ErrInvalidProjectID    = errs.NewValidationError(errors.New("invalid project ID"))
+	ErrInvalidTagID        = errs.NewValidationError(errors.New("invalid tag ID"))
+	ErrInvalidWebhookID    = errs.NewValidationError(errors.New("invalid webhook ID"))
+	ErrInvalidDependencyID = errs.NewValidationError(errors.New("invalid dependency ID"))
+	ErrInvalidDeliveryID   = errs.NewValidationError(errors.New("invalid delivery ID"))
+	ErrInvalidRequest      = errs.NewValidationError(errors.New("invalid request body"))
+	ErrInvalidLimit        = errs.NewValidationError(errors.New("invalid limit"))
+	ErrInvalidCompleted    = errs.NewValidationError(errors.New("completed must be true or false"))
+	ErrInvalidSort         = errs.NewValidationError(errors.New("sort must be one of title, -title, created_at, -created_at"))
+	ErrQueryIsRequired     = errs.NewValidationError(errors.New("q is required"))
+	ErrInvalidWithin       = errs.NewValidationError(errors.New("within must be a positive duration"))
+	ErrInvalidTag          = errs.NewValidationError(errors.New("invalid tag"))
+	ErrInvalidWorkspace    = errs.NewValidationError(errors.New("invalid workspace ID"))
+	ErrWorkspaceRequired   = errs.NewValidationError(errors.New("X-Workspace-ID is required"))
+	ErrInvalidEventID      = errs.NewValidationError(errors.New("invalid Last-Event-ID"))
+	ErrUnknownCommand      = errs.NewValidationError(errors.New("unknown command"))
+	ErrUnknownEvent        = errs.NewValidationError(errors.New("event is not awaiting acknowledgement"))
+
+	ErrInvalidIdempotencyKey = errs.NewValidationError(errors.New("Idempotency-Key must be at most 255 characters"))
+
+	ErrUnsupportedMediaType = errors.New("unsupported media type")
+	ErrRequestTooLarge      = errors.New("request body too large")
+)
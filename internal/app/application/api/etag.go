@@ -0,0 +1,59 @@
+package api
+
+import (
+	"github.com/aviseu/go-sample/internal/app/domain"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+func etag(version int) string {
+	return strconv.Quote(strconv.Itoa(version))
+}
+
+// ifMatch hands the versions from an If-Match header to the domain service,
+// which refuses the change when the task has moved on or does not exist. The
+// header holds either "*", which any stored version matches, or a comma
+// separated list of entity tags. Weak and malformed entity tags can never match
+// a stored version, so a list of nothing else fails the precondition straight
+// away.
+func (h *Handler) ifMatch(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		header := strings.TrimSpace(r.Header.Get("If-Match"))
+		if header == "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+		if header == "*" {
+			next.ServeHTTP(w, r.WithContext(domain.WithExpectedVersion(r.Context())))
+			return
+		}
+
+		var versions []int
+		for _, tag := range strings.Split(header, ",") {
+			if v, ok := version(strings.TrimSpace(tag)); ok {
+				versions = append(versions, v)
+			}
+		}
+		if len(versions) == 0 {
+			h.handleFail(w, r, domain.ErrVersionMismatch)
+			return
+		}
+
+		next.ServeHTTP(w, r.WithContext(domain.WithExpectedVersion(r.Context(), versions...)))
+	})
+}
+
+// version returns the version a strong entity tag stands for.
+func version(tag string) (int, bool) {
+	if len(tag) < 2 || !strings.HasPrefix(tag, `"`) || !strings.HasSuffix(tag, `"`) {
+		return 0, false
+	}
+
+	v, err := strconv.Atoi(tag[1 : len(tag)-1])
+	if err != nil {
+		return 0, false
+	}
+
+	return v, true
+}
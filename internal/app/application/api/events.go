@@ -0,0 +1,87 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/aviseu/go-sample/internal/app/infrastructure"
+	"github.com/aviseu/go-sample/internal/app/infrastructure/aggregators"
+	"github.com/google/uuid"
+	"net/http"
+	"time"
+)
+
+// Events streams the events of the tasks in the workspace as Server-Sent
+// Events. A client that reconnects with the Last-Event-ID header first gets
+// the events it missed, as far as the broker still keeps them. Comments are
+// sent as heartbeats to keep idle connections open.
+func (h *Handler) Events(w http.ResponseWriter, r *http.Request) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		h.handleError(w, r, errors.New("streaming is not supported"))
+		return
+	}
+
+	var lastEventID *uuid.UUID
+	if v := r.Header.Get("Last-Event-ID"); v != "" {
+		id, err := uuid.Parse(v)
+		if err != nil {
+			h.handleFail(w, r, ErrInvalidEventID)
+			return
+		}
+		lastEventID = &id
+	}
+
+	sub, missed := h.b.Subscribe(infrastructure.Workspace(r.Context()), lastEventID)
+	defer sub.Close()
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+
+	for _, c := range missed {
+		if err := writeEvent(w, c.Event); err != nil {
+			return
+		}
+	}
+	flusher.Flush()
+
+	var heartbeat <-chan time.Time
+	if h.heartbeat > 0 {
+		ticker := time.NewTicker(h.heartbeat)
+		defer ticker.Stop()
+		heartbeat = ticker.C
+	}
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case c, ok := <-sub.Changes():
+			if !ok {
+				return
+			}
+			if err := writeEvent(w, c.Event); err != nil {
+				return
+			}
+		case <-heartbeat:
+			if _, err := fmt.Fprint(w, ": heartbeat\n\n"); err != nil {
+				return
+			}
+		}
+		flusher.Flush()
+	}
+}
+
+func writeEvent(w http.ResponseWriter, e *aggregators.Event) error {
+	data, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+
+	_, err = fmt.Fprintf(w, "id: %s\nevent: %s\ndata: %s\n\n", e.ID, e.Type, data)
+
+	return err
+}
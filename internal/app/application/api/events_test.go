@@ -0,0 +1,168 @@
+package api_test
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"github.com/aviseu/go-sample/internal/app/application"
+	"github.com/aviseu/go-sample/internal/app/application/api"
+	"github.com/aviseu/go-sample/internal/app/application/stream"
+	"github.com/aviseu/go-sample/internal/app/domain"
+	"github.com/aviseu/go-sample/internal/app/infrastructure/aggregators"
+	"github.com/aviseu/go-sample/internal/testutils"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/suite"
+	"io"
+	oghttp "net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestEvents(t *testing.T) {
+	t.Parallel()
+	suite.Run(t, new(EventsSuite))
+}
+
+type EventsSuite struct {
+	suite.Suite
+}
+
+// serve starts a server for the API and opens an event stream on it, which
+// ends with the test.
+func (suite *EventsSuite) serve(cfg application.Config, s *domain.Service, b *stream.Broker, header oghttp.Header) (*oghttp.Response, *bufio.Reader) {
+	r := testutils.NewTaskRepository()
+	_, log := testutils.NewLogger()
+	pr := testutils.NewProjectRepository(r)
+	wr := testutils.NewWebhookRepository()
+	server := httptest.NewServer(application.APIHandler(cfg, log, s, r, api.HandlerWithProjects(domain.NewProjectService(pr), pr), api.HandlerWithWebhooks(domain.NewWebhookService(wr), wr), api.HandlerWithBroker(b)))
+	suite.T().Cleanup(server.Close)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	suite.T().Cleanup(cancel)
+	req, err := oghttp.NewRequestWithContext(ctx, oghttp.MethodGet, server.URL+"/api/tasks/events", nil)
+	suite.Require().NoError(err)
+	for k, v := range header {
+		req.Header[k] = v
+	}
+
+	resp, err := server.Client().Do(req)
+	suite.Require().NoError(err)
+	suite.T().Cleanup(func() { _ = resp.Body.Close() })
+
+	return resp, bufio.NewReader(resp.Body)
+}
+
+// frame reads the lines of the next event or comment from the stream.
+func (suite *EventsSuite) frame(r *bufio.Reader) []string {
+	var lines []string
+	for {
+		line, err := r.ReadString('\n')
+		suite.Require().NoError(err)
+		if line == "\n" {
+			return lines
+		}
+		lines = append(lines, strings.TrimSuffix(line, "\n"))
+	}
+}
+
+func (suite *EventsSuite) TestStreamTaskChanges() {
+	// Prepare
+	b := stream.NewBroker()
+	r := testutils.NewTaskRepository(testutils.TaskRepositoryWithBus(b))
+	s := domain.NewService(r)
+	resp, body := suite.serve(application.Config{DefaultPageSize: 20, MaxPageSize: 100, DefaultWorkspace: true}, s, b, nil)
+
+	// Execute
+	task, err := s.Create(context.Background(), domain.TaskCreate{Title: "task 1"})
+	suite.Require().NoError(err)
+	suite.Require().NoError(s.MarkCompleted(context.Background(), task.ID))
+
+	// Assert
+	suite.Equal(oghttp.StatusOK, resp.StatusCode)
+	suite.Equal("text/event-stream", resp.Header.Get("Content-Type"))
+	suite.Equal("no-cache", resp.Header.Get("Cache-Control"))
+	suite.Require().Len(r.EventRecords, 2)
+	for i, typ := range []string{"task.created", "task.completed"} {
+		lines := suite.frame(body)
+		suite.Require().Len(lines, 3)
+		suite.Equal("id: "+r.EventRecords[i].ID.String(), lines[0])
+		suite.Equal("event: "+typ, lines[1])
+		suite.True(strings.HasPrefix(lines[2], "data: "))
+		var e aggregators.Event
+		suite.NoError(json.Unmarshal([]byte(strings.TrimPrefix(lines[2], "data: ")), &e))
+		suite.Equal(r.EventRecords[i].ID, e.ID)
+		suite.Equal(task.ID, e.TaskID)
+		suite.Equal(typ, e.Type)
+	}
+}
+
+func (suite *EventsSuite) TestStreamResumesFromLastEventID() {
+	// Prepare
+	b := stream.NewBroker()
+	task := &aggregators.Task{ID: uuid.New(), Title: "task 1"}
+	e1 := &aggregators.Event{ID: uuid.New(), TaskID: task.ID, Type: "task.created", Payload: []byte(`{}`)}
+	e2 := &aggregators.Event{ID: uuid.New(), TaskID: task.ID, Type: "task.updated", Payload: []byte(`{}`)}
+	task.Events = []*aggregators.Event{e1, e2}
+	b.Notify(task)
+
+	// Execute
+	_, body := suite.serve(application.Config{DefaultPageSize: 20, MaxPageSize: 100, DefaultWorkspace: true}, domain.NewService(testutils.NewTaskRepository()), b, oghttp.Header{"Last-Event-Id": {e1.ID.String()}})
+
+	// Assert
+	lines := suite.frame(body)
+	suite.Require().Len(lines, 3)
+	suite.Equal("id: "+e2.ID.String(), lines[0])
+	suite.Equal("event: task.updated", lines[1])
+}
+
+func (suite *EventsSuite) TestStreamSendsHeartbeats() {
+	// Prepare
+	b := stream.NewBroker()
+	cfg := application.Config{DefaultPageSize: 20, MaxPageSize: 100, DefaultWorkspace: true, EventsHeartbeat: 10 * time.Millisecond}
+
+	// Execute
+	_, body := suite.serve(cfg, domain.NewService(testutils.NewTaskRepository()), b, nil)
+
+	// Assert
+	suite.Equal([]string{": heartbeat"}, suite.frame(body))
+	suite.Equal([]string{": heartbeat"}, suite.frame(body))
+}
+
+func (suite *EventsSuite) TestStreamEndsWhenBrokerCloses() {
+	// Prepare
+	b := stream.NewBroker()
+	_, body := suite.serve(application.Config{DefaultPageSize: 20, MaxPageSize: 100, DefaultWorkspace: true}, domain.NewService(testutils.NewTaskRepository()), b, nil)
+
+	// Execute
+	b.Close()
+
+	// Assert
+	rest, err := io.ReadAll(body)
+	suite.NoError(err)
+	suite.Empty(rest)
+}
+
+func (suite *EventsSuite) TestInvalidLastEventID() {
+	// Prepare
+	r := testutils.NewTaskRepository()
+	lbuf, log := testutils.NewLogger()
+	pr := testutils.NewProjectRepository(r)
+	wr := testutils.NewWebhookRepository()
+	h := application.APIHandler(application.Config{DefaultPageSize: 20, MaxPageSize: 100, DefaultWorkspace: true}, log, domain.NewService(r), r, api.HandlerWithProjects(domain.NewProjectService(pr), pr), api.HandlerWithWebhooks(domain.NewWebhookService(wr), wr))
+
+	req := httptest.NewRequest(oghttp.MethodGet, "/api/tasks/events", nil)
+	req.Header.Set("Last-Event-ID", "nope")
+	rr := httptest.NewRecorder()
+
+	// Execute
+	h.ServeHTTP(rr, req)
+
+	// Assert result
+	suite.Equal(oghttp.StatusBadRequest, rr.Code)
+	assertProblem(suite.T(), rr, "invalid_last_event_id", "invalid Last-Event-ID")
+
+	// Assert log
+	suite.Empty(lbuf.String())
+}
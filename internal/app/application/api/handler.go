@@ -3,51 +3,251 @@ package api
 import (
 	"context"
 	"encoding/json"
-	"errors"
+	"fmt"
+	"github.com/aviseu/go-sample/internal/app/application/stream"
 	"github.com/aviseu/go-sample/internal/app/domain"
 	"github.com/aviseu/go-sample/internal/app/infrastructure"
 	"github.com/aviseu/go-sample/internal/app/infrastructure/aggregators"
-	"github.com/aviseu/go-sample/internal/errs"
 	"github.com/go-chi/chi/v5"
 	"github.com/google/uuid"
 	"log/slog"
+	"mime"
 	"net/http"
+	"strconv"
+	"time"
 )
 
 type Repository interface {
-	All(ctx context.Context) ([]*aggregators.Task, error)
+	List(ctx context.Context, f infrastructure.TaskFilter, p infrastructure.Page) ([]*aggregators.Task, *infrastructure.Cursor, error)
+	Search(ctx context.Context, q string, limit int) ([]*aggregators.TaskMatch, error)
+	Trash(ctx context.Context) ([]*aggregators.Task, error)
 	Find(ctx context.Context, id uuid.UUID) (*aggregators.Task, error)
+	Descendants(ctx context.Context, id uuid.UUID) ([]*aggregators.Task, error)
+	History(ctx context.Context, id uuid.UUID, p infrastructure.Page) ([]*aggregators.TaskChange, *infrastructure.Cursor, error)
+	Tags(ctx context.Context) ([]*aggregators.Tag, error)
+}
+
+type ProjectRepository interface {
+	All(ctx context.Context) ([]*aggregators.Project, error)
+	Find(ctx context.Context, id uuid.UUID) (*aggregators.Project, error)
+}
+
+type WebhookRepository interface {
+	All(ctx context.Context) ([]*aggregators.Webhook, error)
+	Find(ctx context.Context, id uuid.UUID) (*aggregators.Webhook, error)
+	Deliveries(ctx context.Context, webhookID uuid.UUID, limit int) ([]*aggregators.WebhookDelivery, error)
+}
+
+type HandlerOptional func(*Handler)
+
+// HandlerWithPageSize sets the number of tasks a page holds when the request
+// does not ask for a limit, and the largest limit it may ask for.
+func HandlerWithPageSize(defaultLimit, maxLimit int) HandlerOptional {
+	return func(h *Handler) {
+		h.defaultLimit = defaultLimit
+		h.maxLimit = maxLimit
+	}
+}
+
+// HandlerWithProjects serves the projects, which ProjectRoutes needs.
+func HandlerWithProjects(ps *domain.ProjectService, pr ProjectRepository) HandlerOptional {
+	return func(h *Handler) {
+		h.ps = ps
+		h.pr = pr
+	}
+}
+
+// HandlerWithWebhooks serves the webhooks, which WebhookRoutes needs.
+func HandlerWithWebhooks(ws *domain.WebhookService, wr WebhookRepository) HandlerOptional {
+	return func(h *Handler) {
+		h.ws = ws
+		h.wr = wr
+	}
+}
+
+// HandlerWithBroker replaces the broker the event streams subscribe to.
+func HandlerWithBroker(b *stream.Broker) HandlerOptional {
+	return func(h *Handler) {
+		h.b = b
+	}
+}
+
+// HandlerWithHeartbeat sets how often an idle event stream sends a comment to
+// keep its connection open.
+func HandlerWithHeartbeat(d time.Duration) HandlerOptional {
+	return func(h *Handler) {
+		h.heartbeat = d
+	}
+}
+
+// HandlerWithLive sets how many events a live connection may have awaiting
+// acknowledgement and how often it is pinged.
+func HandlerWithLive(window int, ping time.Duration) HandlerOptional {
+	return func(h *Handler) {
+		h.window = window
+		h.ping = ping
+	}
+}
+
+// HandlerWithIdempotency honours the Idempotency-Key header of the requests
+// that support it, which is ignored otherwise.
+func HandlerWithIdempotency(ir IdempotencyRepository) HandlerOptional {
+	return func(h *Handler) {
+		h.ir = ir
+	}
+}
+
+// HandlerWithIdempotencyTTL sets how long the response to a request with an
+// Idempotency-Key is replayed.
+func HandlerWithIdempotencyTTL(ttl time.Duration) HandlerOptional {
+	return func(h *Handler) {
+		h.idempotencyTTL = ttl
+	}
+}
+
+// HandlerWithDefaultWorkspace scopes requests without an X-Workspace-ID header
+// to the default workspace instead of rejecting them.
+func HandlerWithDefaultWorkspace() HandlerOptional {
+	return func(h *Handler) {
+		h.defaultWorkspace = true
+	}
 }
 
 type Handler struct {
-	log *slog.Logger
-	s   *domain.Service
-	r   Repository
+	log            *slog.Logger
+	s              *domain.Service
+	ps             *domain.ProjectService
+	r              Repository
+	pr             ProjectRepository
+	ws             *domain.WebhookService
+	wr             WebhookRepository
+	b              *stream.Broker
+	ir             IdempotencyRepository
+	defaultLimit   int
+	maxLimit       int
+	heartbeat      time.Duration
+	window         int
+	ping           time.Duration
+	idempotencyTTL time.Duration
+
+	defaultWorkspace bool
 }
 
-func NewHandler(log *slog.Logger, s *domain.Service, r Repository) *Handler {
-	return &Handler{
-		log: log,
-		s:   s,
-		r:   r,
+func NewHandler(log *slog.Logger, s *domain.Service, r Repository, opts ...HandlerOptional) *Handler {
+	h := &Handler{
+		log:            log,
+		s:              s,
+		r:              r,
+		b:              stream.NewBroker(),
+		defaultLimit:   20,
+		maxLimit:       100,
+		heartbeat:      15 * time.Second,
+		window:         32,
+		ping:           30 * time.Second,
+		idempotencyTTL: 24 * time.Hour,
+	}
+	for _, opt := range opts {
+		opt(h)
 	}
+
+	return h
 }
 
 func (h *Handler) Routes() http.Handler {
 	r := chi.NewRouter()
 
 	r.Get("/", h.All)
-	r.Post("/", h.Create)
+	r.With(h.idempotent).Post("/", h.Create)
+	r.Get("/search", h.Search)
+	r.Get("/overdue", h.Overdue)
+	r.Get("/ready", h.Ready)
+	r.Get("/upcoming", h.Upcoming)
+	r.Get("/trash", h.Trash)
+	r.Get("/events", h.Events)
+	r.Get("/live", h.Live)
+	r.Delete("/trash", h.Purge)
 	r.Get("/{id}", h.Find)
-	r.Put("/{id}/complete", h.MarkCompleted)
+	r.Get("/{id}/children", h.Children)
+	r.Get("/{id}/tree", h.Tree)
+	r.Get("/{id}/history", h.History)
+
+	r.Group(func(r chi.Router) {
+		r.Use(h.ifMatch)
+
+		r.Patch("/{id}", h.Update)
+		r.Delete("/{id}", h.Delete)
+		r.Post("/{id}/restore", h.Restore)
+		r.Put("/{id}/complete", h.MarkCompleted)
+		r.Put("/{id}/status", h.UpdateStatus)
+		r.Put("/{id}/tags/{tag}", h.AddTag)
+		r.Delete("/{id}/tags/{tag}", h.RemoveTag)
+		r.Post("/{id}/dependencies", h.AddDependency)
+		r.Delete("/{id}/dependencies/{dependency}", h.RemoveDependency)
+	})
 
 	return r
 }
 
 func (h *Handler) All(w http.ResponseWriter, r *http.Request) {
-	tasks, err := h.r.All(r.Context())
+	f, err := filter(r)
+	if err != nil {
+		h.handleFail(w, r, err)
+		return
+	}
+
+	p, err := h.page(r, f.Sort)
 	if err != nil {
-		h.handleError(err, w)
+		h.handleFail(w, r, err)
+		return
+	}
+
+	tasks, next, err := h.r.List(r.Context(), f, p)
+	if err != nil {
+		h.handleError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	resp := NewTaskListResponse(tasks).WithNextCursor(next)
+	if err := json.NewEncoder(w).Encode(resp); err != nil {
+		h.handleError(w, r, err)
+		return
+	}
+}
+
+func (h *Handler) Search(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query().Get("q")
+	if q == "" {
+		h.handleFail(w, r, ErrQueryIsRequired)
+		return
+	}
+
+	limit, err := h.limit(r)
+	if err != nil {
+		h.handleFail(w, r, err)
+		return
+	}
+
+	matches, err := h.r.Search(r.Context(), q, limit)
+	if err != nil {
+		h.handleError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	resp := NewTaskSearchResponse(matches)
+	if err := json.NewEncoder(w).Encode(resp); err != nil {
+		h.handleError(w, r, err)
+		return
+	}
+}
+
+func (h *Handler) Overdue(w http.ResponseWriter, r *http.Request) {
+	tasks, err := h.s.Overdue(r.Context())
+	if err != nil {
+		h.handleError(w, r, err)
 		return
 	}
 
@@ -55,7 +255,81 @@ func (h *Handler) All(w http.ResponseWriter, r *http.Request) {
 	w.WriteHeader(http.StatusOK)
 	resp := NewTaskListResponse(tasks)
 	if err := json.NewEncoder(w).Encode(resp); err != nil {
-		h.handleError(err, w)
+		h.handleError(w, r, err)
+		return
+	}
+}
+
+func (h *Handler) Ready(w http.ResponseWriter, r *http.Request) {
+	tasks, err := h.s.Ready(r.Context())
+	if err != nil {
+		h.handleError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	resp := NewTaskListResponse(tasks)
+	if err := json.NewEncoder(w).Encode(resp); err != nil {
+		h.handleError(w, r, err)
+		return
+	}
+}
+
+func (h *Handler) Upcoming(w http.ResponseWriter, r *http.Request) {
+	within := 72 * time.Hour
+	if v := r.URL.Query().Get("within"); v != "" {
+		d, err := time.ParseDuration(v)
+		if err != nil || d <= 0 {
+			h.handleFail(w, r, fmt.Errorf("%w: %s", ErrInvalidWithin, v))
+			return
+		}
+		within = d
+	}
+
+	tasks, err := h.s.Upcoming(r.Context(), within)
+	if err != nil {
+		h.handleError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	resp := NewTaskListResponse(tasks)
+	if err := json.NewEncoder(w).Encode(resp); err != nil {
+		h.handleError(w, r, err)
+		return
+	}
+}
+
+func (h *Handler) Trash(w http.ResponseWriter, r *http.Request) {
+	tasks, err := h.r.Trash(r.Context())
+	if err != nil {
+		h.handleError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	resp := NewTaskListResponse(tasks)
+	if err := json.NewEncoder(w).Encode(resp); err != nil {
+		h.handleError(w, r, err)
+		return
+	}
+}
+
+func (h *Handler) Purge(w http.ResponseWriter, r *http.Request) {
+	n, err := h.s.Purge(r.Context())
+	if err != nil {
+		h.handleError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	resp := NewPurgeResponse(n)
+	if err := json.NewEncoder(w).Encode(resp); err != nil {
+		h.handleError(w, r, err)
 		return
 	}
 }
@@ -63,25 +337,21 @@ func (h *Handler) All(w http.ResponseWriter, r *http.Request) {
 func (h *Handler) Create(w http.ResponseWriter, r *http.Request) {
 	var req RequestTaskCreate
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		h.handleFail(ErrInvalidID, http.StatusBadRequest, w)
+		h.handleFail(w, r, ErrInvalidRequest)
 		return
 	}
 
-	task, err := h.s.Create(r.Context(), req.Title)
+	task, err := h.s.Create(r.Context(), req.toDomain())
 	if err != nil {
-		if errs.IsValidationError(err) {
-			h.handleFail(err, http.StatusBadRequest, w)
-			return
-		}
-
-		h.handleError(err, w)
+		h.handleFail(w, r, err)
 		return
 	}
 
 	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("ETag", etag(task.Version))
 	w.WriteHeader(http.StatusCreated)
 	if err := json.NewEncoder(w).Encode(task); err != nil {
-		h.handleError(err, w)
+		h.handleError(w, r, err)
 		return
 	}
 }
@@ -90,60 +360,347 @@ func (h *Handler) MarkCompleted(w http.ResponseWriter, r *http.Request) {
 	idStr := chi.URLParam(r, "id")
 	id, err := uuid.Parse(idStr)
 	if err != nil {
-		h.handleFail(errors.New("invalid task ID"), http.StatusBadRequest, w)
+		h.handleFail(w, r, ErrInvalidTaskID)
 		return
 	}
 
 	if err := h.s.MarkCompleted(r.Context(), id); err != nil {
-		if errors.Is(err, domain.ErrTaskNotFound) {
-			h.handleFail(err, http.StatusNotFound, w)
-			return
-		}
+		h.handleFail(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *Handler) UpdateStatus(w http.ResponseWriter, r *http.Request) {
+	idStr := chi.URLParam(r, "id")
+	id, err := uuid.Parse(idStr)
+	if err != nil {
+		h.handleFail(w, r, ErrInvalidTaskID)
+		return
+	}
+
+	var req RequestTaskStatus
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.handleFail(w, r, ErrInvalidRequest)
+		return
+	}
 
-		h.handleError(err, w)
+	if err := h.s.Transition(r.Context(), id, domain.Status(req.Status)); err != nil {
+		h.handleFail(w, r, err)
 		return
 	}
 
 	w.WriteHeader(http.StatusNoContent)
 }
 
+func (h *Handler) Update(w http.ResponseWriter, r *http.Request) {
+	idStr := chi.URLParam(r, "id")
+	id, err := uuid.Parse(idStr)
+	if err != nil {
+		h.handleFail(w, r, ErrInvalidTaskID)
+		return
+	}
+
+	if ct, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type")); ct != "application/merge-patch+json" && ct != "application/json" {
+		h.handleFail(w, r, ErrUnsupportedMediaType)
+		return
+	}
+
+	var req RequestTaskUpdate
+	dec := json.NewDecoder(r.Body)
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&req); err != nil {
+		h.handleFail(w, r, ErrInvalidRequest)
+		return
+	}
+
+	task, err := h.s.Update(r.Context(), id, req.toDomain())
+	if err != nil {
+		h.handleFail(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("ETag", etag(task.Version))
+	w.WriteHeader(http.StatusOK)
+	if err := json.NewEncoder(w).Encode(task); err != nil {
+		h.handleError(w, r, err)
+		return
+	}
+}
+
+func (h *Handler) AddDependency(w http.ResponseWriter, r *http.Request) {
+	idStr := chi.URLParam(r, "id")
+	id, err := uuid.Parse(idStr)
+	if err != nil {
+		h.handleFail(w, r, ErrInvalidTaskID)
+		return
+	}
+
+	var req RequestTaskDependency
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.handleFail(w, r, ErrInvalidRequest)
+		return
+	}
+
+	task, err := h.s.AddDependency(r.Context(), id, req.DependsOn)
+	h.dependencyResponse(task, err, w, r)
+}
+
+func (h *Handler) RemoveDependency(w http.ResponseWriter, r *http.Request) {
+	idStr := chi.URLParam(r, "id")
+	id, err := uuid.Parse(idStr)
+	if err != nil {
+		h.handleFail(w, r, ErrInvalidTaskID)
+		return
+	}
+
+	dependency, err := uuid.Parse(chi.URLParam(r, "dependency"))
+	if err != nil {
+		h.handleFail(w, r, ErrInvalidDependencyID)
+		return
+	}
+
+	task, err := h.s.RemoveDependency(r.Context(), id, dependency)
+	h.dependencyResponse(task, err, w, r)
+}
+
+func (h *Handler) dependencyResponse(task *aggregators.Task, err error, w http.ResponseWriter, r *http.Request) {
+	if err != nil {
+		h.handleFail(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("ETag", etag(task.Version))
+	w.WriteHeader(http.StatusOK)
+	if err := json.NewEncoder(w).Encode(task); err != nil {
+		h.handleError(w, r, err)
+		return
+	}
+}
+
+func (h *Handler) Delete(w http.ResponseWriter, r *http.Request) {
+	idStr := chi.URLParam(r, "id")
+	id, err := uuid.Parse(idStr)
+	if err != nil {
+		h.handleFail(w, r, ErrInvalidTaskID)
+		return
+	}
+
+	if err := h.s.Delete(r.Context(), id); err != nil {
+		h.handleFail(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *Handler) Restore(w http.ResponseWriter, r *http.Request) {
+	idStr := chi.URLParam(r, "id")
+	id, err := uuid.Parse(idStr)
+	if err != nil {
+		h.handleFail(w, r, ErrInvalidTaskID)
+		return
+	}
+
+	task, err := h.s.Restore(r.Context(), id)
+	if err != nil {
+		h.handleFail(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("ETag", etag(task.Version))
+	w.WriteHeader(http.StatusOK)
+	if err := json.NewEncoder(w).Encode(task); err != nil {
+		h.handleError(w, r, err)
+		return
+	}
+}
+
 func (h *Handler) Find(w http.ResponseWriter, r *http.Request) {
 	idStr := chi.URLParam(r, "id")
 	id, err := uuid.Parse(idStr)
 	if err != nil {
-		h.handleFail(errors.New("invalid task ID"), http.StatusBadRequest, w)
+		h.handleFail(w, r, ErrInvalidTaskID)
 		return
 	}
 
 	task, err := h.r.Find(r.Context(), id)
 	if err != nil {
-		if errors.Is(err, infrastructure.ErrTaskNotFound) {
-			h.handleFail(err, http.StatusNotFound, w)
-			return
-		}
-
-		h.handleError(err, w)
+		h.handleFail(w, r, err)
 		return
 	}
 
 	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("ETag", etag(task.Version))
 	w.WriteHeader(http.StatusOK)
 	if err := json.NewEncoder(w).Encode(task); err != nil {
-		h.handleError(err, w)
+		h.handleError(w, r, err)
+		return
+	}
+}
+
+func (h *Handler) Children(w http.ResponseWriter, r *http.Request) {
+	idStr := chi.URLParam(r, "id")
+	id, err := uuid.Parse(idStr)
+	if err != nil {
+		h.handleFail(w, r, ErrInvalidTaskID)
+		return
+	}
+
+	f, err := filter(r)
+	if err != nil {
+		h.handleFail(w, r, err)
+		return
+	}
+	f.ParentID = &id
+
+	p, err := h.page(r, f.Sort)
+	if err != nil {
+		h.handleFail(w, r, err)
+		return
+	}
+
+	if _, err := h.r.Find(r.Context(), id); err != nil {
+		h.handleFail(w, r, err)
+		return
+	}
+
+	tasks, next, err := h.r.List(r.Context(), f, p)
+	if err != nil {
+		h.handleError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	resp := NewTaskListResponse(tasks).WithNextCursor(next)
+	if err := json.NewEncoder(w).Encode(resp); err != nil {
+		h.handleError(w, r, err)
 		return
 	}
 }
 
-func (h *Handler) handleError(err error, w http.ResponseWriter) {
-	h.log.Error(err.Error())
-	h.handleFail(errors.New(http.StatusText(http.StatusInternalServerError)), http.StatusInternalServerError, w)
+func (h *Handler) Tree(w http.ResponseWriter, r *http.Request) {
+	idStr := chi.URLParam(r, "id")
+	id, err := uuid.Parse(idStr)
+	if err != nil {
+		h.handleFail(w, r, ErrInvalidTaskID)
+		return
+	}
+
+	task, err := h.r.Find(r.Context(), id)
+	if err != nil {
+		h.handleFail(w, r, err)
+		return
+	}
+
+	descendants, err := h.r.Descendants(r.Context(), id)
+	if err != nil {
+		h.handleError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	resp := NewTaskTreeResponse(task, descendants)
+	if err := json.NewEncoder(w).Encode(resp); err != nil {
+		h.handleError(w, r, err)
+		return
+	}
 }
 
-func (h *Handler) handleFail(err error, status int, w http.ResponseWriter) {
+func filter(r *http.Request) (infrastructure.TaskFilter, error) {
+	q := r.URL.Query()
+	f := infrastructure.TaskFilter{
+		TitleContains: q.Get("title_contains"),
+		Tags:          q["tag"],
+		Sort:          infrastructure.SortTitle,
+	}
+
+	if v := q.Get("completed"); v != "" {
+		completed, err := strconv.ParseBool(v)
+		if err != nil {
+			return f, fmt.Errorf("%w: %s", ErrInvalidCompleted, v)
+		}
+		f.Completed = &completed
+	}
+
+	if v := q.Get("sort"); v != "" {
+		f.Sort = infrastructure.Sort(v)
+		if !f.Sort.Valid() {
+			return f, fmt.Errorf("%w: %s", ErrInvalidSort, v)
+		}
+	}
+
+	return f, nil
+}
+
+func (h *Handler) History(w http.ResponseWriter, r *http.Request) {
+	idStr := chi.URLParam(r, "id")
+	id, err := uuid.Parse(idStr)
+	if err != nil {
+		h.handleFail(w, r, ErrInvalidTaskID)
+		return
+	}
+
+	p, err := h.page(r, infrastructure.SortCreatedAt)
+	if err != nil {
+		h.handleFail(w, r, err)
+		return
+	}
+
+	changes, next, err := h.r.History(r.Context(), id, p)
+	if err != nil {
+		h.handleFail(w, r, err)
+		return
+	}
+
 	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(status)
-	resp := NewErrorResponse(err)
+	w.WriteHeader(http.StatusOK)
+	resp := NewTaskHistoryResponse(changes).WithNextCursor(next)
 	if err := json.NewEncoder(w).Encode(resp); err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		h.handleError(w, r, err)
+		return
+	}
+}
+
+func (h *Handler) limit(r *http.Request) (int, error) {
+	v := r.URL.Query().Get("limit")
+	if v == "" {
+		return h.defaultLimit, nil
+	}
+
+	limit, err := strconv.Atoi(v)
+	if err != nil || limit < 1 || limit > h.maxLimit {
+		return 0, fmt.Errorf("%w: must be between 1 and %d", ErrInvalidLimit, h.maxLimit)
+	}
+
+	return limit, nil
+}
+
+func (h *Handler) page(r *http.Request, s infrastructure.Sort) (infrastructure.Page, error) {
+	var p infrastructure.Page
+
+	limit, err := h.limit(r)
+	if err != nil {
+		return p, err
 	}
+	p.Limit = limit
+
+	if v := r.URL.Query().Get("cursor"); v != "" {
+		c, err := infrastructure.DecodeCursor(v)
+		if err != nil {
+			return p, err
+		}
+		if c.Sort != s {
+			return p, infrastructure.ErrInvalidCursor
+		}
+		p.After = c
+	}
+
+	return p, nil
 }
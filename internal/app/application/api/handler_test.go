@@ -1,9 +1,13 @@
 package api_test
 
 import (
+	"context"
+	"encoding/json"
 	"errors"
 	"github.com/aviseu/go-sample/internal/app/application"
+	"github.com/aviseu/go-sample/internal/app/application/api"
 	"github.com/aviseu/go-sample/internal/app/domain"
+	"github.com/aviseu/go-sample/internal/app/infrastructure"
 	"github.com/aviseu/go-sample/internal/app/infrastructure/aggregators"
 	"github.com/aviseu/go-sample/internal/testutils"
 	"github.com/google/uuid"
@@ -12,6 +16,7 @@ import (
 	"net/http/httptest"
 	"strings"
 	"testing"
+	"time"
 )
 
 func TestHandler(t *testing.T) {
@@ -26,9 +31,12 @@ type HandlerSuite struct {
 func (suite *HandlerSuite) TestCreateSuccess() {
 	// Prepare
 	r := testutils.NewTaskRepository()
-	s := domain.NewService(r)
+	now := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+	s := domain.NewService(r, domain.ServiceWithClock(func() time.Time { return now }))
 	lbuf, log := testutils.NewLogger()
-	h := application.APIHandler(log, s, r)
+	pr := testutils.NewProjectRepository(r)
+	wr := testutils.NewWebhookRepository()
+	h := application.APIHandler(application.Config{DefaultPageSize: 20, MaxPageSize: 100, DefaultWorkspace: true}, log, s, r, api.HandlerWithProjects(domain.NewProjectService(pr), pr), api.HandlerWithWebhooks(domain.NewWebhookService(wr), wr))
 
 	req := httptest.NewRequest(oghttp.MethodPost, "/api/tasks", strings.NewReader(`{"title":"task 1"}`))
 	rr := httptest.NewRecorder()
@@ -45,12 +53,12 @@ func (suite *HandlerSuite) TestCreateSuccess() {
 	suite.NotNil(task)
 	suite.NotEmpty(task.ID)
 	suite.Equal("task 1", task.Title)
-	suite.False(task.Completed)
+	suite.Equal("todo", task.Status)
 
 	// Assert result
 	suite.Equal(oghttp.StatusCreated, rr.Code)
 	suite.Equal("application/json", rr.Header().Get("Content-Type"))
-	suite.Equal(`{"id":"`+task.ID.String()+`","title":"task 1","completed":false}`+"\n", rr.Body.String())
+	suite.Equal(`{"id":"`+task.ID.String()+`","project_id":"00000000-0000-0000-0000-000000000001","title":"task 1","status":"todo","created_at":"2025-03-01T12:00:00Z","updated_at":"2025-03-01T12:00:00Z","version":1}`+"\n", rr.Body.String())
 
 	// Assert log
 	suite.Empty(lbuf.String())
@@ -61,7 +69,9 @@ func (suite *HandlerSuite) TestCreateInvalidRequest() {
 	r := testutils.NewTaskRepository()
 	s := domain.NewService(r)
 	lbuf, log := testutils.NewLogger()
-	h := application.APIHandler(log, s, r)
+	pr := testutils.NewProjectRepository(r)
+	wr := testutils.NewWebhookRepository()
+	h := application.APIHandler(application.Config{DefaultPageSize: 20, MaxPageSize: 100, DefaultWorkspace: true}, log, s, r, api.HandlerWithProjects(domain.NewProjectService(pr), pr), api.HandlerWithWebhooks(domain.NewWebhookService(wr), wr))
 
 	req := httptest.NewRequest(oghttp.MethodPost, "/api/tasks", strings.NewReader(`{"invalid":"task 1"}`))
 	rr := httptest.NewRecorder()
@@ -74,8 +84,34 @@ func (suite *HandlerSuite) TestCreateInvalidRequest() {
 
 	// Assert result
 	suite.Equal(oghttp.StatusBadRequest, rr.Code)
-	suite.Equal("application/json", rr.Header().Get("Content-Type"))
-	suite.Equal(`{"message":"title is required"}`+"\n", rr.Body.String())
+	suite.Equal("application/problem+json", rr.Header().Get("Content-Type"))
+	assertProblem(suite.T(), rr, "title_required", "title is required")
+
+	// Assert log
+	suite.Empty(lbuf.String())
+}
+
+func (suite *HandlerSuite) TestCreateMalformedRequest() {
+	// Prepare
+	r := testutils.NewTaskRepository()
+	s := domain.NewService(r)
+	lbuf, log := testutils.NewLogger()
+	pr := testutils.NewProjectRepository(r)
+	wr := testutils.NewWebhookRepository()
+	h := application.APIHandler(application.Config{DefaultPageSize: 20, MaxPageSize: 100, DefaultWorkspace: true}, log, s, r, api.HandlerWithProjects(domain.NewProjectService(pr), pr), api.HandlerWithWebhooks(domain.NewWebhookService(wr), wr))
+
+	req := httptest.NewRequest(oghttp.MethodPost, "/api/tasks", strings.NewReader(`not json`))
+	rr := httptest.NewRecorder()
+
+	// Execute
+	h.ServeHTTP(rr, req)
+
+	// Assert state
+	suite.Empty(r.Records)
+
+	// Assert result
+	suite.Equal(oghttp.StatusBadRequest, rr.Code)
+	assertProblem(suite.T(), rr, "invalid_request", "invalid request body")
 
 	// Assert log
 	suite.Empty(lbuf.String())
@@ -86,7 +122,9 @@ func (suite *HandlerSuite) TestCreateRepositoryFail() {
 	r := testutils.NewTaskRepository(testutils.TaskRepositoryWithError(errors.New("boom!")))
 	s := domain.NewService(r)
 	lbuf, log := testutils.NewLogger()
-	h := application.APIHandler(log, s, r)
+	pr := testutils.NewProjectRepository(r)
+	wr := testutils.NewWebhookRepository()
+	h := application.APIHandler(application.Config{DefaultPageSize: 20, MaxPageSize: 100, DefaultWorkspace: true}, log, s, r, api.HandlerWithProjects(domain.NewProjectService(pr), pr), api.HandlerWithWebhooks(domain.NewWebhookService(wr), wr))
 
 	req := httptest.NewRequest(oghttp.MethodPost, "/api/tasks", strings.NewReader(`{"title":"task 1"}`))
 	rr := httptest.NewRecorder()
@@ -99,7 +137,7 @@ func (suite *HandlerSuite) TestCreateRepositoryFail() {
 
 	// Assert result
 	suite.Equal(oghttp.StatusInternalServerError, rr.Code)
-	suite.Equal(`{"message":"Internal Server Error"}`+"\n", rr.Body.String())
+	assertProblem(suite.T(), rr, "internal_error", "")
 
 	// Assert log
 	logs := testutils.LogLines(lbuf)
@@ -111,10 +149,12 @@ func (suite *HandlerSuite) TestCreateRepositoryFail() {
 func (suite *HandlerSuite) TestMarkCompletedSuccess() {
 	// Prepare
 	id := uuid.New()
-	r := testutils.NewTaskRepository(testutils.TaskRepositoryWithTask(&aggregators.Task{ID: id, Title: "task 1", Completed: false}))
+	r := testutils.NewTaskRepository(testutils.TaskRepositoryWithTask(&aggregators.Task{ID: id, Title: "task 1", Status: "todo"}))
 	s := domain.NewService(r)
 	lbuf, log := testutils.NewLogger()
-	h := application.APIHandler(log, s, r)
+	pr := testutils.NewProjectRepository(r)
+	wr := testutils.NewWebhookRepository()
+	h := application.APIHandler(application.Config{DefaultPageSize: 20, MaxPageSize: 100, DefaultWorkspace: true}, log, s, r, api.HandlerWithProjects(domain.NewProjectService(pr), pr), api.HandlerWithWebhooks(domain.NewWebhookService(wr), wr))
 
 	req := httptest.NewRequest(oghttp.MethodPut, "/api/tasks/"+id.String()+"/complete", nil)
 	rr := httptest.NewRecorder()
@@ -123,7 +163,7 @@ func (suite *HandlerSuite) TestMarkCompletedSuccess() {
 	h.ServeHTTP(rr, req)
 
 	// Assert state
-	suite.True(r.Records[id].Completed)
+	suite.Equal("done", r.Records[id].Status)
 
 	// Assert result
 	suite.Equal(oghttp.StatusNoContent, rr.Code)
@@ -138,7 +178,9 @@ func (suite *HandlerSuite) TestMarkCompletedInvalidID() {
 	r := testutils.NewTaskRepository()
 	s := domain.NewService(r)
 	lbuf, log := testutils.NewLogger()
-	h := application.APIHandler(log, s, r)
+	pr := testutils.NewProjectRepository(r)
+	wr := testutils.NewWebhookRepository()
+	h := application.APIHandler(application.Config{DefaultPageSize: 20, MaxPageSize: 100, DefaultWorkspace: true}, log, s, r, api.HandlerWithProjects(domain.NewProjectService(pr), pr), api.HandlerWithWebhooks(domain.NewWebhookService(wr), wr))
 
 	req := httptest.NewRequest(oghttp.MethodPut, "/api/tasks/invalid/complete", nil)
 	rr := httptest.NewRecorder()
@@ -151,8 +193,8 @@ func (suite *HandlerSuite) TestMarkCompletedInvalidID() {
 
 	// Assert result
 	suite.Equal(oghttp.StatusBadRequest, rr.Code)
-	suite.Equal("application/json", rr.Header().Get("Content-Type"))
-	suite.Equal(`{"message":"invalid task ID"}`+"\n", rr.Body.String())
+	suite.Equal("application/problem+json", rr.Header().Get("Content-Type"))
+	assertProblem(suite.T(), rr, "invalid_task_id", "invalid task ID")
 
 	// Assert log
 	suite.Empty(lbuf.String())
@@ -163,7 +205,9 @@ func (suite *HandlerSuite) TestMarkCompletedNotFound() {
 	r := testutils.NewTaskRepository()
 	s := domain.NewService(r)
 	lbuf, log := testutils.NewLogger()
-	h := application.APIHandler(log, s, r)
+	pr := testutils.NewProjectRepository(r)
+	wr := testutils.NewWebhookRepository()
+	h := application.APIHandler(application.Config{DefaultPageSize: 20, MaxPageSize: 100, DefaultWorkspace: true}, log, s, r, api.HandlerWithProjects(domain.NewProjectService(pr), pr), api.HandlerWithWebhooks(domain.NewWebhookService(wr), wr))
 
 	id := uuid.New().String()
 	req := httptest.NewRequest(oghttp.MethodPut, "/api/tasks/"+id+"/complete", nil)
@@ -177,8 +221,8 @@ func (suite *HandlerSuite) TestMarkCompletedNotFound() {
 
 	// Assert result
 	suite.Equal(oghttp.StatusNotFound, rr.Code)
-	suite.Equal("application/json", rr.Header().Get("Content-Type"))
-	suite.Equal(`{"message":"task not found: `+id+`"}`+"\n", rr.Body.String())
+	suite.Equal("application/problem+json", rr.Header().Get("Content-Type"))
+	assertProblem(suite.T(), rr, "task_not_found", "task not found: "+id)
 
 	// Assert log
 	suite.Empty(lbuf.String())
@@ -190,7 +234,9 @@ func (suite *HandlerSuite) TestMarkCompletedRepositoryFail() {
 	r := testutils.NewTaskRepository(testutils.TaskRepositoryWithError(errors.New("boom!")))
 	s := domain.NewService(r)
 	lbuf, log := testutils.NewLogger()
-	h := application.APIHandler(log, s, r)
+	pr := testutils.NewProjectRepository(r)
+	wr := testutils.NewWebhookRepository()
+	h := application.APIHandler(application.Config{DefaultPageSize: 20, MaxPageSize: 100, DefaultWorkspace: true}, log, s, r, api.HandlerWithProjects(domain.NewProjectService(pr), pr), api.HandlerWithWebhooks(domain.NewWebhookService(wr), wr))
 
 	req := httptest.NewRequest(oghttp.MethodPut, "/api/tasks/"+id.String()+"/complete", nil)
 	rr := httptest.NewRecorder()
@@ -203,7 +249,7 @@ func (suite *HandlerSuite) TestMarkCompletedRepositoryFail() {
 
 	// Assert result
 	suite.Equal(oghttp.StatusInternalServerError, rr.Code)
-	suite.Equal(`{"message":"Internal Server Error"}`+"\n", rr.Body.String())
+	assertProblem(suite.T(), rr, "internal_error", "")
 
 	// Assert log
 	logs := testutils.LogLines(lbuf)
@@ -211,3 +257,2714 @@ func (suite *HandlerSuite) TestMarkCompletedRepositoryFail() {
 	suite.Contains(lbuf.String(), `"level":"ERROR"`)
 	suite.Contains(lbuf.String(), `"msg":"failed to find task: boom!"`)
 }
+
+func (suite *HandlerSuite) TestMarkCompletedConflict() {
+	// Prepare
+	id := uuid.New()
+	r := testutils.NewTaskRepository(testutils.TaskRepositoryWithTask(&aggregators.Task{ID: id, Title: "task 1", Status: "cancelled"}))
+	s := domain.NewService(r)
+	lbuf, log := testutils.NewLogger()
+	pr := testutils.NewProjectRepository(r)
+	wr := testutils.NewWebhookRepository()
+	h := application.APIHandler(application.Config{DefaultPageSize: 20, MaxPageSize: 100, DefaultWorkspace: true}, log, s, r, api.HandlerWithProjects(domain.NewProjectService(pr), pr), api.HandlerWithWebhooks(domain.NewWebhookService(wr), wr))
+
+	req := httptest.NewRequest(oghttp.MethodPut, "/api/tasks/"+id.String()+"/complete", nil)
+	rr := httptest.NewRecorder()
+
+	// Execute
+	h.ServeHTTP(rr, req)
+
+	// Assert state
+	suite.Equal("cancelled", r.Records[id].Status)
+
+	// Assert result
+	suite.Equal(oghttp.StatusConflict, rr.Code)
+	suite.Equal("application/problem+json", rr.Header().Get("Content-Type"))
+	assertProblem(suite.T(), rr, "invalid_transition", "invalid status transition: from cancelled to done")
+
+	// Assert log
+	suite.Empty(lbuf.String())
+}
+
+func (suite *HandlerSuite) TestUpdateStatusSuccess() {
+	// Prepare
+	id := uuid.New()
+	r := testutils.NewTaskRepository(testutils.TaskRepositoryWithTask(&aggregators.Task{ID: id, Title: "task 1", Status: "todo"}))
+	s := domain.NewService(r)
+	lbuf, log := testutils.NewLogger()
+	pr := testutils.NewProjectRepository(r)
+	wr := testutils.NewWebhookRepository()
+	h := application.APIHandler(application.Config{DefaultPageSize: 20, MaxPageSize: 100, DefaultWorkspace: true}, log, s, r, api.HandlerWithProjects(domain.NewProjectService(pr), pr), api.HandlerWithWebhooks(domain.NewWebhookService(wr), wr))
+
+	req := httptest.NewRequest(oghttp.MethodPut, "/api/tasks/"+id.String()+"/status", strings.NewReader(`{"status":"in_progress"}`))
+	rr := httptest.NewRecorder()
+
+	// Execute
+	h.ServeHTTP(rr, req)
+
+	// Assert state
+	suite.Equal("in_progress", r.Records[id].Status)
+
+	// Assert result
+	suite.Equal(oghttp.StatusNoContent, rr.Code)
+	suite.Empty(rr.Body.String())
+
+	// Assert log
+	suite.Empty(lbuf.String())
+}
+
+func (suite *HandlerSuite) TestUpdateStatusInvalidStatus() {
+	// Prepare
+	id := uuid.New()
+	r := testutils.NewTaskRepository(testutils.TaskRepositoryWithTask(&aggregators.Task{ID: id, Title: "task 1", Status: "todo"}))
+	s := domain.NewService(r)
+	lbuf, log := testutils.NewLogger()
+	pr := testutils.NewProjectRepository(r)
+	wr := testutils.NewWebhookRepository()
+	h := application.APIHandler(application.Config{DefaultPageSize: 20, MaxPageSize: 100, DefaultWorkspace: true}, log, s, r, api.HandlerWithProjects(domain.NewProjectService(pr), pr), api.HandlerWithWebhooks(domain.NewWebhookService(wr), wr))
+
+	req := httptest.NewRequest(oghttp.MethodPut, "/api/tasks/"+id.String()+"/status", strings.NewReader(`{"status":"finished"}`))
+	rr := httptest.NewRecorder()
+
+	// Execute
+	h.ServeHTTP(rr, req)
+
+	// Assert state
+	suite.Equal("todo", r.Records[id].Status)
+
+	// Assert result
+	suite.Equal(oghttp.StatusBadRequest, rr.Code)
+	suite.Equal("application/problem+json", rr.Header().Get("Content-Type"))
+	assertProblem(suite.T(), rr, "invalid_status", "invalid status: finished")
+
+	// Assert log
+	suite.Empty(lbuf.String())
+}
+
+func (suite *HandlerSuite) TestUpdateStatusInvalidRequest() {
+	// Prepare
+	id := uuid.New()
+	r := testutils.NewTaskRepository(testutils.TaskRepositoryWithTask(&aggregators.Task{ID: id, Title: "task 1", Status: "todo"}))
+	s := domain.NewService(r)
+	lbuf, log := testutils.NewLogger()
+	pr := testutils.NewProjectRepository(r)
+	wr := testutils.NewWebhookRepository()
+	h := application.APIHandler(application.Config{DefaultPageSize: 20, MaxPageSize: 100, DefaultWorkspace: true}, log, s, r, api.HandlerWithProjects(domain.NewProjectService(pr), pr), api.HandlerWithWebhooks(domain.NewWebhookService(wr), wr))
+
+	req := httptest.NewRequest(oghttp.MethodPut, "/api/tasks/"+id.String()+"/status", strings.NewReader(`not json`))
+	rr := httptest.NewRecorder()
+
+	// Execute
+	h.ServeHTTP(rr, req)
+
+	// Assert state
+	suite.Equal("todo", r.Records[id].Status)
+
+	// Assert result
+	suite.Equal(oghttp.StatusBadRequest, rr.Code)
+	assertProblem(suite.T(), rr, "invalid_request", "invalid request body")
+
+	// Assert log
+	suite.Empty(lbuf.String())
+}
+
+func (suite *HandlerSuite) TestUpdateStatusNotFound() {
+	// Prepare
+	r := testutils.NewTaskRepository()
+	s := domain.NewService(r)
+	lbuf, log := testutils.NewLogger()
+	pr := testutils.NewProjectRepository(r)
+	wr := testutils.NewWebhookRepository()
+	h := application.APIHandler(application.Config{DefaultPageSize: 20, MaxPageSize: 100, DefaultWorkspace: true}, log, s, r, api.HandlerWithProjects(domain.NewProjectService(pr), pr), api.HandlerWithWebhooks(domain.NewWebhookService(wr), wr))
+
+	id := uuid.New().String()
+	req := httptest.NewRequest(oghttp.MethodPut, "/api/tasks/"+id+"/status", strings.NewReader(`{"status":"done"}`))
+	rr := httptest.NewRecorder()
+
+	// Execute
+	h.ServeHTTP(rr, req)
+
+	// Assert result
+	suite.Equal(oghttp.StatusNotFound, rr.Code)
+	assertProblem(suite.T(), rr, "task_not_found", "task not found: "+id)
+
+	// Assert log
+	suite.Empty(lbuf.String())
+}
+
+func (suite *HandlerSuite) TestUpdateSuccess() {
+	// Prepare
+	id := uuid.New()
+	createdAt := time.Date(2025, 2, 1, 9, 0, 0, 0, time.UTC)
+	r := testutils.NewTaskRepository(testutils.TaskRepositoryWithTask(&aggregators.Task{ID: id, Title: "task 1", Status: "done", CompletedAt: &createdAt, CreatedAt: createdAt, UpdatedAt: createdAt}))
+	now := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+	s := domain.NewService(r, domain.ServiceWithClock(func() time.Time { return now }))
+	lbuf, log := testutils.NewLogger()
+	pr := testutils.NewProjectRepository(r)
+	wr := testutils.NewWebhookRepository()
+	h := application.APIHandler(application.Config{DefaultPageSize: 20, MaxPageSize: 100, DefaultWorkspace: true}, log, s, r, api.HandlerWithProjects(domain.NewProjectService(pr), pr), api.HandlerWithWebhooks(domain.NewWebhookService(wr), wr))
+
+	req := httptest.NewRequest(oghttp.MethodPatch, "/api/tasks/"+id.String(), strings.NewReader(`{"title":"task 1 renamed","status":"todo"}`))
+	req.Header.Set("Content-Type", "application/merge-patch+json")
+	rr := httptest.NewRecorder()
+
+	// Execute
+	h.ServeHTTP(rr, req)
+
+	// Assert state
+	suite.Equal("task 1 renamed", r.Records[id].Title)
+	suite.Equal("todo", r.Records[id].Status)
+
+	// Assert result
+	suite.Equal(oghttp.StatusOK, rr.Code)
+	suite.Equal("application/json", rr.Header().Get("Content-Type"))
+	suite.Equal(`{"id":"`+id.String()+`","project_id":"00000000-0000-0000-0000-000000000001","title":"task 1 renamed","status":"todo","created_at":"2025-02-01T09:00:00Z","updated_at":"2025-03-01T12:00:00Z","version":1}`+"\n", rr.Body.String())
+
+	// Assert log
+	suite.Empty(lbuf.String())
+}
+
+func (suite *HandlerSuite) TestUpdatePartialSuccess() {
+	// Prepare
+	id := uuid.New()
+	createdAt := time.Date(2025, 2, 1, 9, 0, 0, 0, time.UTC)
+	r := testutils.NewTaskRepository(testutils.TaskRepositoryWithTask(&aggregators.Task{ID: id, Title: "task 1", Status: "in_progress", CreatedAt: createdAt, UpdatedAt: createdAt}))
+	now := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+	s := domain.NewService(r, domain.ServiceWithClock(func() time.Time { return now }))
+	lbuf, log := testutils.NewLogger()
+	pr := testutils.NewProjectRepository(r)
+	wr := testutils.NewWebhookRepository()
+	h := application.APIHandler(application.Config{DefaultPageSize: 20, MaxPageSize: 100, DefaultWorkspace: true}, log, s, r, api.HandlerWithProjects(domain.NewProjectService(pr), pr), api.HandlerWithWebhooks(domain.NewWebhookService(wr), wr))
+
+	req := httptest.NewRequest(oghttp.MethodPatch, "/api/tasks/"+id.String(), strings.NewReader(`{"title":"task 1 renamed"}`))
+	req.Header.Set("Content-Type", "application/json")
+	rr := httptest.NewRecorder()
+
+	// Execute
+	h.ServeHTTP(rr, req)
+
+	// Assert result
+	suite.Equal(oghttp.StatusOK, rr.Code)
+	suite.Equal(`{"id":"`+id.String()+`","project_id":"00000000-0000-0000-0000-000000000001","title":"task 1 renamed","status":"in_progress","created_at":"2025-02-01T09:00:00Z","updated_at":"2025-03-01T12:00:00Z","version":1}`+"\n", rr.Body.String())
+
+	// Assert log
+	suite.Empty(lbuf.String())
+}
+
+func (suite *HandlerSuite) TestUpdateNullTitle() {
+	// Prepare
+	id := uuid.New()
+	r := testutils.NewTaskRepository(testutils.TaskRepositoryWithTask(&aggregators.Task{ID: id, Title: "task 1", Status: "todo"}))
+	s := domain.NewService(r)
+	lbuf, log := testutils.NewLogger()
+	pr := testutils.NewProjectRepository(r)
+	wr := testutils.NewWebhookRepository()
+	h := application.APIHandler(application.Config{DefaultPageSize: 20, MaxPageSize: 100, DefaultWorkspace: true}, log, s, r, api.HandlerWithProjects(domain.NewProjectService(pr), pr), api.HandlerWithWebhooks(domain.NewWebhookService(wr), wr))
+
+	req := httptest.NewRequest(oghttp.MethodPatch, "/api/tasks/"+id.String(), strings.NewReader(`{"title":null}`))
+	req.Header.Set("Content-Type", "application/merge-patch+json")
+	rr := httptest.NewRecorder()
+
+	// Execute
+	h.ServeHTTP(rr, req)
+
+	// Assert state
+	suite.Equal("task 1", r.Records[id].Title)
+
+	// Assert result
+	suite.Equal(oghttp.StatusBadRequest, rr.Code)
+	assertProblem(suite.T(), rr, "title_required", "title is required")
+
+	// Assert log
+	suite.Empty(lbuf.String())
+}
+
+func (suite *HandlerSuite) TestUpdateUnknownField() {
+	// Prepare
+	id := uuid.New()
+	r := testutils.NewTaskRepository(testutils.TaskRepositoryWithTask(&aggregators.Task{ID: id, Title: "task 1", Status: "todo"}))
+	s := domain.NewService(r)
+	lbuf, log := testutils.NewLogger()
+	pr := testutils.NewProjectRepository(r)
+	wr := testutils.NewWebhookRepository()
+	h := application.APIHandler(application.Config{DefaultPageSize: 20, MaxPageSize: 100, DefaultWorkspace: true}, log, s, r, api.HandlerWithProjects(domain.NewProjectService(pr), pr), api.HandlerWithWebhooks(domain.NewWebhookService(wr), wr))
+
+	req := httptest.NewRequest(oghttp.MethodPatch, "/api/tasks/"+id.String(), strings.NewReader(`{"id":"`+uuid.New().String()+`"}`))
+	req.Header.Set("Content-Type", "application/merge-patch+json")
+	rr := httptest.NewRecorder()
+
+	// Execute
+	h.ServeHTTP(rr, req)
+
+	// Assert state
+	suite.Equal(id, r.Records[id].ID)
+
+	// Assert result
+	suite.Equal(oghttp.StatusBadRequest, rr.Code)
+	assertProblem(suite.T(), rr, "invalid_request", "invalid request body")
+
+	// Assert log
+	suite.Empty(lbuf.String())
+}
+
+func (suite *HandlerSuite) TestUpdateUnsupportedMediaType() {
+	// Prepare
+	id := uuid.New()
+	r := testutils.NewTaskRepository(testutils.TaskRepositoryWithTask(&aggregators.Task{ID: id, Title: "task 1", Status: "todo"}))
+	s := domain.NewService(r)
+	lbuf, log := testutils.NewLogger()
+	pr := testutils.NewProjectRepository(r)
+	wr := testutils.NewWebhookRepository()
+	h := application.APIHandler(application.Config{DefaultPageSize: 20, MaxPageSize: 100, DefaultWorkspace: true}, log, s, r, api.HandlerWithProjects(domain.NewProjectService(pr), pr), api.HandlerWithWebhooks(domain.NewWebhookService(wr), wr))
+
+	req := httptest.NewRequest(oghttp.MethodPatch, "/api/tasks/"+id.String(), strings.NewReader(`title=task`))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	rr := httptest.NewRecorder()
+
+	// Execute
+	h.ServeHTTP(rr, req)
+
+	// Assert result
+	suite.Equal(oghttp.StatusUnsupportedMediaType, rr.Code)
+	assertProblem(suite.T(), rr, "unsupported_media_type", "unsupported media type")
+
+	// Assert log
+	suite.Empty(lbuf.String())
+}
+
+func (suite *HandlerSuite) TestUpdateConflict() {
+	// Prepare
+	id := uuid.New()
+	r := testutils.NewTaskRepository(testutils.TaskRepositoryWithTask(&aggregators.Task{ID: id, Title: "task 1", Status: "cancelled"}))
+	s := domain.NewService(r)
+	lbuf, log := testutils.NewLogger()
+	pr := testutils.NewProjectRepository(r)
+	wr := testutils.NewWebhookRepository()
+	h := application.APIHandler(application.Config{DefaultPageSize: 20, MaxPageSize: 100, DefaultWorkspace: true}, log, s, r, api.HandlerWithProjects(domain.NewProjectService(pr), pr), api.HandlerWithWebhooks(domain.NewWebhookService(wr), wr))
+
+	req := httptest.NewRequest(oghttp.MethodPatch, "/api/tasks/"+id.String(), strings.NewReader(`{"status":"done"}`))
+	req.Header.Set("Content-Type", "application/merge-patch+json")
+	rr := httptest.NewRecorder()
+
+	// Execute
+	h.ServeHTTP(rr, req)
+
+	// Assert result
+	suite.Equal(oghttp.StatusConflict, rr.Code)
+	assertProblem(suite.T(), rr, "invalid_transition", "invalid status transition: from cancelled to done")
+
+	// Assert log
+	suite.Empty(lbuf.String())
+}
+
+func (suite *HandlerSuite) TestUpdateNotFound() {
+	// Prepare
+	r := testutils.NewTaskRepository()
+	s := domain.NewService(r)
+	lbuf, log := testutils.NewLogger()
+	pr := testutils.NewProjectRepository(r)
+	wr := testutils.NewWebhookRepository()
+	h := application.APIHandler(application.Config{DefaultPageSize: 20, MaxPageSize: 100, DefaultWorkspace: true}, log, s, r, api.HandlerWithProjects(domain.NewProjectService(pr), pr), api.HandlerWithWebhooks(domain.NewWebhookService(wr), wr))
+
+	id := uuid.New().String()
+	req := httptest.NewRequest(oghttp.MethodPatch, "/api/tasks/"+id, strings.NewReader(`{"title":"task 1"}`))
+	req.Header.Set("Content-Type", "application/merge-patch+json")
+	rr := httptest.NewRecorder()
+
+	// Execute
+	h.ServeHTTP(rr, req)
+
+	// Assert result
+	suite.Equal(oghttp.StatusNotFound, rr.Code)
+	assertProblem(suite.T(), rr, "task_not_found", "task not found: "+id)
+
+	// Assert log
+	suite.Empty(lbuf.String())
+}
+
+func (suite *HandlerSuite) TestDeleteSuccess() {
+	// Prepare
+	id := uuid.New()
+	r := testutils.NewTaskRepository(testutils.TaskRepositoryWithTask(&aggregators.Task{ID: id, Title: "task 1", Status: "todo"}))
+	s := domain.NewService(r)
+	lbuf, log := testutils.NewLogger()
+	pr := testutils.NewProjectRepository(r)
+	wr := testutils.NewWebhookRepository()
+	h := application.APIHandler(application.Config{DefaultPageSize: 20, MaxPageSize: 100, DefaultWorkspace: true}, log, s, r, api.HandlerWithProjects(domain.NewProjectService(pr), pr), api.HandlerWithWebhooks(domain.NewWebhookService(wr), wr))
+
+	req := httptest.NewRequest(oghttp.MethodDelete, "/api/tasks/"+id.String(), nil)
+	rr := httptest.NewRecorder()
+
+	// Execute
+	h.ServeHTTP(rr, req)
+
+	// Assert state
+	suite.Len(r.Records, 1)
+	suite.NotNil(r.Records[id].DeletedAt)
+
+	// Assert result
+	suite.Equal(oghttp.StatusNoContent, rr.Code)
+	suite.Empty(rr.Body.String())
+
+	// Assert log
+	suite.Empty(lbuf.String())
+}
+
+func (suite *HandlerSuite) TestDeleteNotFound() {
+	// Prepare
+	r := testutils.NewTaskRepository()
+	s := domain.NewService(r)
+	lbuf, log := testutils.NewLogger()
+	pr := testutils.NewProjectRepository(r)
+	wr := testutils.NewWebhookRepository()
+	h := application.APIHandler(application.Config{DefaultPageSize: 20, MaxPageSize: 100, DefaultWorkspace: true}, log, s, r, api.HandlerWithProjects(domain.NewProjectService(pr), pr), api.HandlerWithWebhooks(domain.NewWebhookService(wr), wr))
+
+	id := uuid.New().String()
+	req := httptest.NewRequest(oghttp.MethodDelete, "/api/tasks/"+id, nil)
+	rr := httptest.NewRecorder()
+
+	// Execute
+	h.ServeHTTP(rr, req)
+
+	// Assert result
+	suite.Equal(oghttp.StatusNotFound, rr.Code)
+	assertProblem(suite.T(), rr, "task_not_found", "task not found: "+id)
+
+	// Assert log
+	suite.Empty(lbuf.String())
+}
+
+func (suite *HandlerSuite) TestTrashSuccess() {
+	// Prepare
+	id1, id2 := uuid.New(), uuid.New()
+	deletedAt := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+	r := testutils.NewTaskRepository(
+		testutils.TaskRepositoryWithTask(&aggregators.Task{ID: id1, Title: "task 1", Status: "todo", CreatedAt: deletedAt, UpdatedAt: deletedAt, DeletedAt: &deletedAt, Version: 2}),
+		testutils.TaskRepositoryWithTask(&aggregators.Task{ID: id2, Title: "task 2", Status: "todo", Version: 1}),
+	)
+	s := domain.NewService(r)
+	lbuf, log := testutils.NewLogger()
+	pr := testutils.NewProjectRepository(r)
+	wr := testutils.NewWebhookRepository()
+	h := application.APIHandler(application.Config{DefaultPageSize: 20, MaxPageSize: 100, DefaultWorkspace: true}, log, s, r, api.HandlerWithProjects(domain.NewProjectService(pr), pr), api.HandlerWithWebhooks(domain.NewWebhookService(wr), wr))
+
+	req := httptest.NewRequest(oghttp.MethodGet, "/api/tasks/trash", nil)
+	rr := httptest.NewRecorder()
+
+	// Execute
+	h.ServeHTTP(rr, req)
+
+	// Assert result
+	suite.Equal(oghttp.StatusOK, rr.Code)
+	suite.Equal("application/json", rr.Header().Get("Content-Type"))
+	suite.Equal(`{"tasks":[{"id":"`+id1.String()+`","project_id":"00000000-0000-0000-0000-000000000001","title":"task 1","status":"todo","created_at":"2025-03-01T12:00:00Z","updated_at":"2025-03-01T12:00:00Z","deleted_at":"2025-03-01T12:00:00Z","version":2}]}`+"\n", rr.Body.String())
+
+	// Assert log
+	suite.Empty(lbuf.String())
+}
+
+func (suite *HandlerSuite) TestRestoreSuccess() {
+	// Prepare
+	id := uuid.New()
+	deletedAt := time.Now()
+	createdAt := time.Date(2025, 2, 1, 9, 0, 0, 0, time.UTC)
+	r := testutils.NewTaskRepository(testutils.TaskRepositoryWithTask(&aggregators.Task{ID: id, Title: "task 1", Status: "todo", CreatedAt: createdAt, UpdatedAt: deletedAt, DeletedAt: &deletedAt}))
+	now := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+	s := domain.NewService(r, domain.ServiceWithClock(func() time.Time { return now }))
+	lbuf, log := testutils.NewLogger()
+	pr := testutils.NewProjectRepository(r)
+	wr := testutils.NewWebhookRepository()
+	h := application.APIHandler(application.Config{DefaultPageSize: 20, MaxPageSize: 100, DefaultWorkspace: true}, log, s, r, api.HandlerWithProjects(domain.NewProjectService(pr), pr), api.HandlerWithWebhooks(domain.NewWebhookService(wr), wr))
+
+	req := httptest.NewRequest(oghttp.MethodPost, "/api/tasks/"+id.String()+"/restore", nil)
+	rr := httptest.NewRecorder()
+
+	// Execute
+	h.ServeHTTP(rr, req)
+
+	// Assert state
+	suite.Nil(r.Records[id].DeletedAt)
+
+	// Assert result
+	suite.Equal(oghttp.StatusOK, rr.Code)
+	suite.Equal("application/json", rr.Header().Get("Content-Type"))
+	suite.Equal(`{"id":"`+id.String()+`","project_id":"00000000-0000-0000-0000-000000000001","title":"task 1","status":"todo","created_at":"2025-02-01T09:00:00Z","updated_at":"2025-03-01T12:00:00Z","version":1}`+"\n", rr.Body.String())
+
+	// Assert log
+	suite.Empty(lbuf.String())
+}
+
+func (suite *HandlerSuite) TestRestoreNotFound() {
+	// Prepare
+	id := uuid.New()
+	r := testutils.NewTaskRepository(testutils.TaskRepositoryWithTask(&aggregators.Task{ID: id, Title: "task 1", Status: "todo"}))
+	s := domain.NewService(r)
+	lbuf, log := testutils.NewLogger()
+	pr := testutils.NewProjectRepository(r)
+	wr := testutils.NewWebhookRepository()
+	h := application.APIHandler(application.Config{DefaultPageSize: 20, MaxPageSize: 100, DefaultWorkspace: true}, log, s, r, api.HandlerWithProjects(domain.NewProjectService(pr), pr), api.HandlerWithWebhooks(domain.NewWebhookService(wr), wr))
+
+	req := httptest.NewRequest(oghttp.MethodPost, "/api/tasks/"+id.String()+"/restore", nil)
+	rr := httptest.NewRecorder()
+
+	// Execute
+	h.ServeHTTP(rr, req)
+
+	// Assert result
+	suite.Equal(oghttp.StatusNotFound, rr.Code)
+	assertProblem(suite.T(), rr, "task_not_found", "task not found: "+id.String())
+
+	// Assert log
+	suite.Empty(lbuf.String())
+}
+
+func (suite *HandlerSuite) TestPurgeSuccess() {
+	// Prepare
+	id1, id2 := uuid.New(), uuid.New()
+	old := time.Now().Add(-48 * time.Hour)
+	recent := time.Now()
+	r := testutils.NewTaskRepository(
+		testutils.TaskRepositoryWithTask(&aggregators.Task{ID: id1, Title: "task 1", Status: "todo", DeletedAt: &old}),
+		testutils.TaskRepositoryWithTask(&aggregators.Task{ID: id2, Title: "task 2", Status: "todo", DeletedAt: &recent}),
+	)
+	s := domain.NewService(r, domain.ServiceWithTrashRetention(24*time.Hour))
+	lbuf, log := testutils.NewLogger()
+	pr := testutils.NewProjectRepository(r)
+	wr := testutils.NewWebhookRepository()
+	h := application.APIHandler(application.Config{DefaultPageSize: 20, MaxPageSize: 100, DefaultWorkspace: true}, log, s, r, api.HandlerWithProjects(domain.NewProjectService(pr), pr), api.HandlerWithWebhooks(domain.NewWebhookService(wr), wr))
+
+	req := httptest.NewRequest(oghttp.MethodDelete, "/api/tasks/trash", nil)
+	rr := httptest.NewRecorder()
+
+	// Execute
+	h.ServeHTTP(rr, req)
+
+	// Assert state
+	suite.Len(r.Records, 1)
+	suite.Contains(r.Records, id2)
+
+	// Assert result
+	suite.Equal(oghttp.StatusOK, rr.Code)
+	suite.Equal("application/json", rr.Header().Get("Content-Type"))
+	suite.Equal(`{"purged":1}`+"\n", rr.Body.String())
+
+	// Assert log
+	suite.Empty(lbuf.String())
+}
+
+func (suite *HandlerSuite) TestFindSuccess() {
+	// Prepare
+	id := uuid.New()
+	createdAt := time.Date(2025, 2, 1, 9, 0, 0, 0, time.UTC)
+	r := testutils.NewTaskRepository(testutils.TaskRepositoryWithTask(&aggregators.Task{ID: id, Title: "task 1", Status: "done", CompletedAt: &createdAt, CreatedAt: createdAt, UpdatedAt: createdAt, Version: 3}))
+	s := domain.NewService(r)
+	lbuf, log := testutils.NewLogger()
+	pr := testutils.NewProjectRepository(r)
+	wr := testutils.NewWebhookRepository()
+	h := application.APIHandler(application.Config{DefaultPageSize: 20, MaxPageSize: 100, DefaultWorkspace: true}, log, s, r, api.HandlerWithProjects(domain.NewProjectService(pr), pr), api.HandlerWithWebhooks(domain.NewWebhookService(wr), wr))
+
+	req := httptest.NewRequest(oghttp.MethodGet, "/api/tasks/"+id.String(), nil)
+	rr := httptest.NewRecorder()
+
+	// Execute
+	h.ServeHTTP(rr, req)
+
+	// Assert result
+	suite.Equal(oghttp.StatusOK, rr.Code)
+	suite.Equal("application/json", rr.Header().Get("Content-Type"))
+	suite.Equal(`"3"`, rr.Header().Get("ETag"))
+	suite.Equal(`{"id":"`+id.String()+`","project_id":"00000000-0000-0000-0000-000000000001","title":"task 1","status":"done","created_at":"2025-02-01T09:00:00Z","updated_at":"2025-02-01T09:00:00Z","completed_at":"2025-02-01T09:00:00Z","version":3}`+"\n", rr.Body.String())
+
+	// Assert log
+	suite.Empty(lbuf.String())
+}
+
+func (suite *HandlerSuite) TestUpdateIfMatchSuccess() {
+	tests := []struct {
+		name    string
+		ifMatch string
+	}{
+		{name: "single", ifMatch: `"3"`},
+		{name: "list", ifMatch: `"2", "3"`},
+		{name: "list with weak", ifMatch: `W/"3","3"`},
+		{name: "any", ifMatch: `*`},
+	}
+
+	for _, tt := range tests {
+		suite.Run(tt.name, func() {
+			// Prepare
+			id := uuid.New()
+			r := testutils.NewTaskRepository(testutils.TaskRepositoryWithTask(&aggregators.Task{ID: id, Title: "task 1", Status: "todo", Version: 3}))
+			s := domain.NewService(r)
+			lbuf, log := testutils.NewLogger()
+			pr := testutils.NewProjectRepository(r)
+			wr := testutils.NewWebhookRepository()
+			h := application.APIHandler(application.Config{DefaultPageSize: 20, MaxPageSize: 100, DefaultWorkspace: true}, log, s, r, api.HandlerWithProjects(domain.NewProjectService(pr), pr), api.HandlerWithWebhooks(domain.NewWebhookService(wr), wr))
+
+			req := httptest.NewRequest(oghttp.MethodPatch, "/api/tasks/"+id.String(), strings.NewReader(`{"title":"task 1 renamed"}`))
+			req.Header.Set("Content-Type", "application/merge-patch+json")
+			req.Header.Set("If-Match", tt.ifMatch)
+			rr := httptest.NewRecorder()
+
+			// Execute
+			h.ServeHTTP(rr, req)
+
+			// Assert state
+			suite.Equal("task 1 renamed", r.Records[id].Title)
+			suite.Equal(4, r.Records[id].Version)
+
+			// Assert result
+			suite.Equal(oghttp.StatusOK, rr.Code)
+			suite.Equal(`"4"`, rr.Header().Get("ETag"))
+
+			// Assert log
+			suite.Empty(lbuf.String())
+		})
+	}
+}
+
+func (suite *HandlerSuite) TestIfMatchPreconditionFailed() {
+	tests := []struct {
+		name    string
+		ifMatch string
+	}{
+		{name: "stale", ifMatch: `"2"`},
+		{name: "stale list", ifMatch: `"1", "2"`},
+		{name: "weak", ifMatch: `W/"3"`},
+		{name: "malformed", ifMatch: `3`},
+		{name: "malformed list", ifMatch: `"3" "4"`},
+	}
+
+	for _, tt := range tests {
+		suite.Run(tt.name, func() {
+			// Prepare
+			id := uuid.New()
+			r := testutils.NewTaskRepository(testutils.TaskRepositoryWithTask(&aggregators.Task{ID: id, Title: "task 1", Status: "todo", Version: 3}))
+			s := domain.NewService(r)
+			lbuf, log := testutils.NewLogger()
+			pr := testutils.NewProjectRepository(r)
+			wr := testutils.NewWebhookRepository()
+			h := application.APIHandler(application.Config{DefaultPageSize: 20, MaxPageSize: 100, DefaultWorkspace: true}, log, s, r, api.HandlerWithProjects(domain.NewProjectService(pr), pr), api.HandlerWithWebhooks(domain.NewWebhookService(wr), wr))
+
+			req := httptest.NewRequest(oghttp.MethodPut, "/api/tasks/"+id.String()+"/complete", nil)
+			req.Header.Set("If-Match", tt.ifMatch)
+			rr := httptest.NewRecorder()
+
+			// Execute
+			h.ServeHTTP(rr, req)
+
+			// Assert state
+			suite.Equal("todo", r.Records[id].Status)
+			suite.Equal(3, r.Records[id].Version)
+
+			// Assert result
+			suite.Equal(oghttp.StatusPreconditionFailed, rr.Code)
+			suite.Equal("application/problem+json", rr.Header().Get("Content-Type"))
+			suite.Contains(rr.Body.String(), `"code":"version_mismatch"`)
+			suite.Contains(rr.Body.String(), "task version mismatch")
+
+			// Assert log
+			suite.Empty(lbuf.String())
+		})
+	}
+}
+
+func (suite *HandlerSuite) TestIfMatchMissingTaskPreconditionFailed() {
+	tests := []struct {
+		name    string
+		ifMatch string
+	}{
+		{name: "version", ifMatch: `"1"`},
+		{name: "any", ifMatch: `*`},
+	}
+
+	for _, tt := range tests {
+		suite.Run(tt.name, func() {
+			// Prepare
+			r := testutils.NewTaskRepository()
+			s := domain.NewService(r)
+			lbuf, log := testutils.NewLogger()
+			h := application.APIHandler(application.Config{DefaultPageSize: 20, MaxPageSize: 100, DefaultWorkspace: true}, log, s, r)
+
+			req := httptest.NewRequest(oghttp.MethodPut, "/api/tasks/"+uuid.New().String()+"/complete", nil)
+			req.Header.Set("If-Match", tt.ifMatch)
+			rr := httptest.NewRecorder()
+
+			// Execute
+			h.ServeHTTP(rr, req)
+
+			// Assert result
+			suite.Equal(oghttp.StatusPreconditionFailed, rr.Code)
+			suite.Contains(rr.Body.String(), `"code":"version_mismatch"`)
+
+			// Assert log
+			suite.Empty(lbuf.String())
+		})
+	}
+}
+
+func (suite *HandlerSuite) TestDeleteConcurrentModification() {
+	// Prepare
+	id := uuid.New()
+	r := testutils.NewTaskRepository(
+		testutils.TaskRepositoryWithTask(&aggregators.Task{ID: id, Title: "task 1", Status: "todo", Version: 1}),
+		testutils.TaskRepositoryWithSaveError(infrastructure.ErrTaskConflict),
+	)
+	s := domain.NewService(r)
+	lbuf, log := testutils.NewLogger()
+	pr := testutils.NewProjectRepository(r)
+	wr := testutils.NewWebhookRepository()
+	h := application.APIHandler(application.Config{DefaultPageSize: 20, MaxPageSize: 100, DefaultWorkspace: true}, log, s, r, api.HandlerWithProjects(domain.NewProjectService(pr), pr), api.HandlerWithWebhooks(domain.NewWebhookService(wr), wr))
+
+	req := httptest.NewRequest(oghttp.MethodDelete, "/api/tasks/"+id.String(), nil)
+	rr := httptest.NewRecorder()
+
+	// Execute
+	h.ServeHTTP(rr, req)
+
+	// Assert result
+	suite.Equal(oghttp.StatusConflict, rr.Code)
+	assertProblem(suite.T(), rr, "task_modified", "task was modified concurrently: "+id.String())
+
+	// Assert log
+	suite.Empty(lbuf.String())
+}
+
+func (suite *HandlerSuite) TestAllPaginatedSuccess() {
+	// Prepare
+	id1, id2, id3 := uuid.New(), uuid.New(), uuid.New()
+	createdAt := time.Date(2025, 2, 1, 9, 0, 0, 0, time.UTC)
+	r := testutils.NewTaskRepository(
+		testutils.TaskRepositoryWithTask(&aggregators.Task{ID: id3, Title: "task 3", Status: "todo", CreatedAt: createdAt, UpdatedAt: createdAt, Version: 1}),
+		testutils.TaskRepositoryWithTask(&aggregators.Task{ID: id1, Title: "task 1", Status: "todo", Version: 1}),
+		testutils.TaskRepositoryWithTask(&aggregators.Task{ID: id2, Title: "task 2", Status: "todo", Version: 1}),
+	)
+	s := domain.NewService(r)
+	lbuf, log := testutils.NewLogger()
+	pr := testutils.NewProjectRepository(r)
+	wr := testutils.NewWebhookRepository()
+	h := application.APIHandler(application.Config{DefaultPageSize: 2, MaxPageSize: 100, DefaultWorkspace: true}, log, s, r, api.HandlerWithProjects(domain.NewProjectService(pr), pr), api.HandlerWithWebhooks(domain.NewWebhookService(wr), wr))
+
+	req1 := httptest.NewRequest(oghttp.MethodGet, "/api/tasks", nil)
+	rr1 := httptest.NewRecorder()
+
+	// Execute
+	h.ServeHTTP(rr1, req1)
+
+	var resp1 api.TaskListResponse
+	suite.NoError(json.Unmarshal(rr1.Body.Bytes(), &resp1))
+	req2 := httptest.NewRequest(oghttp.MethodGet, "/api/tasks?limit=2&cursor="+resp1.NextCursor, nil)
+	rr2 := httptest.NewRecorder()
+	h.ServeHTTP(rr2, req2)
+
+	// Assert result
+	suite.Equal(oghttp.StatusOK, rr1.Code)
+	suite.Equal("application/json", rr1.Header().Get("Content-Type"))
+	suite.Len(resp1.Tasks, 2)
+	suite.Equal(id1, resp1.Tasks[0].ID)
+	suite.Equal(id2, resp1.Tasks[1].ID)
+	suite.NotEmpty(resp1.NextCursor)
+
+	suite.Equal(oghttp.StatusOK, rr2.Code)
+	suite.Equal(`{"tasks":[{"id":"`+id3.String()+`","project_id":"00000000-0000-0000-0000-000000000001","title":"task 3","status":"todo","created_at":"2025-02-01T09:00:00Z","updated_at":"2025-02-01T09:00:00Z","version":1}]}`+"\n", rr2.Body.String())
+
+	// Assert log
+	suite.Empty(lbuf.String())
+}
+
+func (suite *HandlerSuite) TestAllInvalidPage() {
+	tests := []struct {
+		name    string
+		query   string
+		code    string
+		message string
+	}{
+		{name: "limit not a number", query: "limit=ten", code: "invalid_limit", message: "invalid limit: must be between 1 and 100"},
+		{name: "limit too small", query: "limit=0", code: "invalid_limit", message: "invalid limit: must be between 1 and 100"},
+		{name: "limit too large", query: "limit=101", code: "invalid_limit", message: "invalid limit: must be between 1 and 100"},
+		{name: "cursor", query: "cursor=not-a-cursor", code: "invalid_cursor", message: "invalid cursor"},
+	}
+
+	for _, tt := range tests {
+		suite.Run(tt.name, func() {
+			// Prepare
+			r := testutils.NewTaskRepository()
+			s := domain.NewService(r)
+			lbuf, log := testutils.NewLogger()
+			pr := testutils.NewProjectRepository(r)
+			wr := testutils.NewWebhookRepository()
+			h := application.APIHandler(application.Config{DefaultPageSize: 20, MaxPageSize: 100, DefaultWorkspace: true}, log, s, r, api.HandlerWithProjects(domain.NewProjectService(pr), pr), api.HandlerWithWebhooks(domain.NewWebhookService(wr), wr))
+
+			req := httptest.NewRequest(oghttp.MethodGet, "/api/tasks?"+tt.query, nil)
+			rr := httptest.NewRecorder()
+
+			// Execute
+			h.ServeHTTP(rr, req)
+
+			// Assert result
+			suite.Equal(oghttp.StatusBadRequest, rr.Code)
+			assertProblem(suite.T(), rr, tt.code, tt.message)
+
+			// Assert log
+			suite.Empty(lbuf.String())
+		})
+	}
+}
+
+func (suite *HandlerSuite) TestAllRepositoryFail() {
+	// Prepare
+	r := testutils.NewTaskRepository(testutils.TaskRepositoryWithError(errors.New("boom!")))
+	s := domain.NewService(r)
+	lbuf, log := testutils.NewLogger()
+	pr := testutils.NewProjectRepository(r)
+	wr := testutils.NewWebhookRepository()
+	h := application.APIHandler(application.Config{DefaultPageSize: 20, MaxPageSize: 100, DefaultWorkspace: true}, log, s, r, api.HandlerWithProjects(domain.NewProjectService(pr), pr), api.HandlerWithWebhooks(domain.NewWebhookService(wr), wr))
+
+	req := httptest.NewRequest(oghttp.MethodGet, "/api/tasks", nil)
+	rr := httptest.NewRecorder()
+
+	// Execute
+	h.ServeHTTP(rr, req)
+
+	// Assert result
+	suite.Equal(oghttp.StatusInternalServerError, rr.Code)
+	assertProblem(suite.T(), rr, "internal_error", "")
+
+	// Assert log
+	logs := testutils.LogLines(lbuf)
+	suite.Len(logs, 1)
+	suite.Contains(logs[0], `"msg":"boom!"`)
+}
+
+func (suite *HandlerSuite) TestAllFilteredSuccess() {
+	// Prepare
+	id1, id2 := uuid.New(), uuid.New()
+	createdAt := time.Date(2025, 2, 1, 9, 0, 0, 0, time.UTC)
+	r := testutils.NewTaskRepository(
+		testutils.TaskRepositoryWithTask(&aggregators.Task{ID: id1, Title: "buy milk", Status: "todo", CreatedAt: createdAt, UpdatedAt: createdAt, Version: 1}),
+		testutils.TaskRepositoryWithTask(&aggregators.Task{ID: id2, Title: "buy bread", Status: "in_progress", CreatedAt: createdAt, UpdatedAt: createdAt, Version: 1}),
+		testutils.TaskRepositoryWithTask(&aggregators.Task{ID: uuid.New(), Title: "buy eggs", Status: "done", Version: 1}),
+		testutils.TaskRepositoryWithTask(&aggregators.Task{ID: uuid.New(), Title: "walk dog", Status: "todo", Version: 1}),
+	)
+	s := domain.NewService(r)
+	lbuf, log := testutils.NewLogger()
+	pr := testutils.NewProjectRepository(r)
+	wr := testutils.NewWebhookRepository()
+	h := application.APIHandler(application.Config{DefaultPageSize: 20, MaxPageSize: 100, DefaultWorkspace: true}, log, s, r, api.HandlerWithProjects(domain.NewProjectService(pr), pr), api.HandlerWithWebhooks(domain.NewWebhookService(wr), wr))
+
+	req := httptest.NewRequest(oghttp.MethodGet, "/api/tasks?completed=false&title_contains=Buy&sort=-title", nil)
+	rr := httptest.NewRecorder()
+
+	// Execute
+	h.ServeHTTP(rr, req)
+
+	// Assert result
+	suite.Equal(oghttp.StatusOK, rr.Code)
+	suite.Equal(`{"tasks":[`+
+		`{"id":"`+id1.String()+`","project_id":"00000000-0000-0000-0000-000000000001","title":"buy milk","status":"todo","created_at":"2025-02-01T09:00:00Z","updated_at":"2025-02-01T09:00:00Z","version":1},`+
+		`{"id":"`+id2.String()+`","project_id":"00000000-0000-0000-0000-000000000001","title":"buy bread","status":"in_progress","created_at":"2025-02-01T09:00:00Z","updated_at":"2025-02-01T09:00:00Z","version":1}`+
+		`]}`+"\n", rr.Body.String())
+
+	// Assert log
+	suite.Empty(lbuf.String())
+}
+
+func (suite *HandlerSuite) TestAllInvalidFilter() {
+	tests := []struct {
+		name    string
+		query   string
+		code    string
+		message string
+	}{
+		{name: "completed", query: "completed=maybe", code: "invalid_completed", message: "completed must be true or false: maybe"},
+		{name: "sort", query: "sort=status", code: "invalid_sort", message: "sort must be one of title, -title, created_at, -created_at: status"},
+		{name: "cursor for other sort", query: "sort=-title&cursor=" + (&infrastructure.Cursor{Sort: infrastructure.SortTitle, Title: "task 1", ID: uuid.New()}).Encode(), code: "invalid_cursor", message: "invalid cursor"},
+	}
+
+	for _, tt := range tests {
+		suite.Run(tt.name, func() {
+			// Prepare
+			r := testutils.NewTaskRepository()
+			s := domain.NewService(r)
+			lbuf, log := testutils.NewLogger()
+			pr := testutils.NewProjectRepository(r)
+			wr := testutils.NewWebhookRepository()
+			h := application.APIHandler(application.Config{DefaultPageSize: 20, MaxPageSize: 100, DefaultWorkspace: true}, log, s, r, api.HandlerWithProjects(domain.NewProjectService(pr), pr), api.HandlerWithWebhooks(domain.NewWebhookService(wr), wr))
+
+			req := httptest.NewRequest(oghttp.MethodGet, "/api/tasks?"+tt.query, nil)
+			rr := httptest.NewRecorder()
+
+			// Execute
+			h.ServeHTTP(rr, req)
+
+			// Assert result
+			suite.Equal(oghttp.StatusBadRequest, rr.Code)
+			assertProblem(suite.T(), rr, tt.code, tt.message)
+
+			// Assert log
+			suite.Empty(lbuf.String())
+		})
+	}
+}
+
+func (suite *HandlerSuite) TestSearchSuccess() {
+	// Prepare
+	id1, id2 := uuid.New(), uuid.New()
+	r := testutils.NewTaskRepository(
+		testutils.TaskRepositoryWithTask(&aggregators.Task{ID: id1, Title: "Buy milk", Status: "todo", Version: 1}),
+		testutils.TaskRepositoryWithTask(&aggregators.Task{ID: id2, Title: "buy bread, buy eggs", Status: "todo", Version: 1}),
+		testutils.TaskRepositoryWithTask(&aggregators.Task{ID: uuid.New(), Title: "walk dog", Status: "todo", Version: 1}),
+	)
+	s := domain.NewService(r)
+	lbuf, log := testutils.NewLogger()
+	pr := testutils.NewProjectRepository(r)
+	wr := testutils.NewWebhookRepository()
+	h := application.APIHandler(application.Config{DefaultPageSize: 20, MaxPageSize: 100, DefaultWorkspace: true}, log, s, r, api.HandlerWithProjects(domain.NewProjectService(pr), pr), api.HandlerWithWebhooks(domain.NewWebhookService(wr), wr))
+
+	req := httptest.NewRequest(oghttp.MethodGet, "/api/tasks/search?q=buy", nil)
+	rr := httptest.NewRecorder()
+
+	// Execute
+	h.ServeHTTP(rr, req)
+
+	// Assert result
+	suite.Equal(oghttp.StatusOK, rr.Code)
+	suite.Equal("application/json", rr.Header().Get("Content-Type"))
+
+	var resp api.TaskSearchResponse
+	suite.NoError(json.Unmarshal(rr.Body.Bytes(), &resp))
+	suite.Len(resp.Tasks, 2)
+	suite.Equal(id2, resp.Tasks[0].ID)
+	suite.Equal("<b>buy</b> bread, <b>buy</b> eggs", resp.Tasks[0].Headline)
+	suite.Equal(id1, resp.Tasks[1].ID)
+	suite.Equal("Buy milk", resp.Tasks[1].Title)
+	suite.Equal("<b>Buy</b> milk", resp.Tasks[1].Headline)
+	suite.Greater(resp.Tasks[0].Rank, resp.Tasks[1].Rank)
+
+	// Assert log
+	suite.Empty(lbuf.String())
+}
+
+func (suite *HandlerSuite) TestSearchQueryIsRequired() {
+	// Prepare
+	r := testutils.NewTaskRepository()
+	s := domain.NewService(r)
+	lbuf, log := testutils.NewLogger()
+	pr := testutils.NewProjectRepository(r)
+	wr := testutils.NewWebhookRepository()
+	h := application.APIHandler(application.Config{DefaultPageSize: 20, MaxPageSize: 100, DefaultWorkspace: true}, log, s, r, api.HandlerWithProjects(domain.NewProjectService(pr), pr), api.HandlerWithWebhooks(domain.NewWebhookService(wr), wr))
+
+	req := httptest.NewRequest(oghttp.MethodGet, "/api/tasks/search", nil)
+	rr := httptest.NewRecorder()
+
+	// Execute
+	h.ServeHTTP(rr, req)
+
+	// Assert result
+	suite.Equal(oghttp.StatusBadRequest, rr.Code)
+	assertProblem(suite.T(), rr, "query_required", "q is required")
+
+	// Assert log
+	suite.Empty(lbuf.String())
+}
+
+func (suite *HandlerSuite) TestSearchRepositoryFail() {
+	// Prepare
+	r := testutils.NewTaskRepository(testutils.TaskRepositoryWithError(errors.New("boom!")))
+	s := domain.NewService(r)
+	lbuf, log := testutils.NewLogger()
+	pr := testutils.NewProjectRepository(r)
+	wr := testutils.NewWebhookRepository()
+	h := application.APIHandler(application.Config{DefaultPageSize: 20, MaxPageSize: 100, DefaultWorkspace: true}, log, s, r, api.HandlerWithProjects(domain.NewProjectService(pr), pr), api.HandlerWithWebhooks(domain.NewWebhookService(wr), wr))
+
+	req := httptest.NewRequest(oghttp.MethodGet, "/api/tasks/search?q=buy", nil)
+	rr := httptest.NewRecorder()
+
+	// Execute
+	h.ServeHTTP(rr, req)
+
+	// Assert result
+	suite.Equal(oghttp.StatusInternalServerError, rr.Code)
+	assertProblem(suite.T(), rr, "internal_error", "")
+
+	// Assert log
+	logs := testutils.LogLines(lbuf)
+	suite.Len(logs, 1)
+	suite.Contains(logs[0], `"msg":"boom!"`)
+}
+
+func (suite *HandlerSuite) TestCreateDueAtSuccess() {
+	// Prepare
+	r := testutils.NewTaskRepository()
+	now := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+	s := domain.NewService(r, domain.ServiceWithClock(func() time.Time { return now }))
+	lbuf, log := testutils.NewLogger()
+	pr := testutils.NewProjectRepository(r)
+	wr := testutils.NewWebhookRepository()
+	h := application.APIHandler(application.Config{DefaultPageSize: 20, MaxPageSize: 100, DefaultWorkspace: true}, log, s, r, api.HandlerWithProjects(domain.NewProjectService(pr), pr), api.HandlerWithWebhooks(domain.NewWebhookService(wr), wr))
+
+	req := httptest.NewRequest(oghttp.MethodPost, "/api/tasks", strings.NewReader(`{"title":"task 1","due_at":"2025-03-04T12:00:00Z"}`))
+	rr := httptest.NewRecorder()
+
+	// Execute
+	h.ServeHTTP(rr, req)
+
+	// Assert state
+	suite.Len(r.Records, 1)
+	var task *aggregators.Task
+	for _, t := range r.Records {
+		task = t
+	}
+
+	// Assert result
+	suite.Equal(oghttp.StatusCreated, rr.Code)
+	suite.Equal(`{"id":"`+task.ID.String()+`","project_id":"00000000-0000-0000-0000-000000000001","title":"task 1","status":"todo","due_at":"2025-03-04T12:00:00Z",`+
+		`"created_at":"2025-03-01T12:00:00Z","updated_at":"2025-03-01T12:00:00Z","version":1}`+"\n", rr.Body.String())
+
+	// Assert log
+	suite.Empty(lbuf.String())
+}
+
+func (suite *HandlerSuite) TestCreatePastDueAt() {
+	// Prepare
+	r := testutils.NewTaskRepository()
+	s := domain.NewService(r, domain.ServiceWithPastDueDatesRejected())
+	lbuf, log := testutils.NewLogger()
+	pr := testutils.NewProjectRepository(r)
+	wr := testutils.NewWebhookRepository()
+	h := application.APIHandler(application.Config{DefaultPageSize: 20, MaxPageSize: 100, DefaultWorkspace: true}, log, s, r, api.HandlerWithProjects(domain.NewProjectService(pr), pr), api.HandlerWithWebhooks(domain.NewWebhookService(wr), wr))
+
+	req := httptest.NewRequest(oghttp.MethodPost, "/api/tasks", strings.NewReader(`{"title":"task 1","due_at":"2020-01-01T00:00:00Z"}`))
+	rr := httptest.NewRecorder()
+
+	// Execute
+	h.ServeHTTP(rr, req)
+
+	// Assert state
+	suite.Empty(r.Records)
+
+	// Assert result
+	suite.Equal(oghttp.StatusBadRequest, rr.Code)
+	assertProblem(suite.T(), rr, "due_date_in_past", "due date is in the past: 2020-01-01T00:00:00Z")
+
+	// Assert log
+	suite.Empty(lbuf.String())
+}
+
+func (suite *HandlerSuite) TestUpdateClearDueAtSuccess() {
+	// Prepare
+	id := uuid.New()
+	dueAt := time.Date(2025, 3, 4, 12, 0, 0, 0, time.UTC)
+	r := testutils.NewTaskRepository(testutils.TaskRepositoryWithTask(&aggregators.Task{ID: id, Title: "task 1", Status: "todo", DueAt: &dueAt}))
+	s := domain.NewService(r)
+	lbuf, log := testutils.NewLogger()
+	pr := testutils.NewProjectRepository(r)
+	wr := testutils.NewWebhookRepository()
+	h := application.APIHandler(application.Config{DefaultPageSize: 20, MaxPageSize: 100, DefaultWorkspace: true}, log, s, r, api.HandlerWithProjects(domain.NewProjectService(pr), pr), api.HandlerWithWebhooks(domain.NewWebhookService(wr), wr))
+
+	req := httptest.NewRequest(oghttp.MethodPatch, "/api/tasks/"+id.String(), strings.NewReader(`{"due_at":null}`))
+	req.Header.Set("Content-Type", "application/merge-patch+json")
+	rr := httptest.NewRecorder()
+
+	// Execute
+	h.ServeHTTP(rr, req)
+
+	// Assert state
+	suite.Nil(r.Records[id].DueAt)
+
+	// Assert result
+	suite.Equal(oghttp.StatusOK, rr.Code)
+	suite.NotContains(rr.Body.String(), "due_at")
+
+	// Assert log
+	suite.Empty(lbuf.String())
+}
+
+func (suite *HandlerSuite) TestOverdueSuccess() {
+	// Prepare
+	now := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+	past := now.Add(-time.Hour)
+	future := now.Add(time.Hour)
+	id := uuid.New()
+	r := testutils.NewTaskRepository(
+		testutils.TaskRepositoryWithTask(&aggregators.Task{ID: id, Title: "task 1", Status: "todo", DueAt: &past, CreatedAt: past, UpdatedAt: past, Version: 1}),
+		testutils.TaskRepositoryWithTask(&aggregators.Task{ID: uuid.New(), Title: "task 2", Status: "todo", DueAt: &future, Version: 1}),
+	)
+	s := domain.NewService(r, domain.ServiceWithClock(func() time.Time { return now }))
+	lbuf, log := testutils.NewLogger()
+	pr := testutils.NewProjectRepository(r)
+	wr := testutils.NewWebhookRepository()
+	h := application.APIHandler(application.Config{DefaultPageSize: 20, MaxPageSize: 100, DefaultWorkspace: true}, log, s, r, api.HandlerWithProjects(domain.NewProjectService(pr), pr), api.HandlerWithWebhooks(domain.NewWebhookService(wr), wr))
+
+	req := httptest.NewRequest(oghttp.MethodGet, "/api/tasks/overdue", nil)
+	rr := httptest.NewRecorder()
+
+	// Execute
+	h.ServeHTTP(rr, req)
+
+	// Assert result
+	suite.Equal(oghttp.StatusOK, rr.Code)
+	suite.Equal("application/json", rr.Header().Get("Content-Type"))
+	suite.Equal(`{"tasks":[{"id":"`+id.String()+`","project_id":"00000000-0000-0000-0000-000000000001","title":"task 1","status":"todo","due_at":"2025-03-01T11:00:00Z",`+
+		`"created_at":"2025-03-01T11:00:00Z","updated_at":"2025-03-01T11:00:00Z","version":1}]}`+"\n", rr.Body.String())
+
+	// Assert log
+	suite.Empty(lbuf.String())
+}
+
+func (suite *HandlerSuite) TestUpcomingSuccess() {
+	// Prepare
+	now := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+	soon := now.Add(2 * time.Hour)
+	later := now.Add(4 * 24 * time.Hour)
+	id1, id2 := uuid.New(), uuid.New()
+	r := testutils.NewTaskRepository(
+		testutils.TaskRepositoryWithTask(&aggregators.Task{ID: id1, Title: "task 1", Status: "todo", DueAt: &soon, Version: 1}),
+		testutils.TaskRepositoryWithTask(&aggregators.Task{ID: id2, Title: "task 2", Status: "todo", DueAt: &later, Version: 1}),
+	)
+	s := domain.NewService(r, domain.ServiceWithClock(func() time.Time { return now }))
+	lbuf, log := testutils.NewLogger()
+	pr := testutils.NewProjectRepository(r)
+	wr := testutils.NewWebhookRepository()
+	h := application.APIHandler(application.Config{DefaultPageSize: 20, MaxPageSize: 100, DefaultWorkspace: true}, log, s, r, api.HandlerWithProjects(domain.NewProjectService(pr), pr), api.HandlerWithWebhooks(domain.NewWebhookService(wr), wr))
+
+	req1 := httptest.NewRequest(oghttp.MethodGet, "/api/tasks/upcoming", nil)
+	rr1 := httptest.NewRecorder()
+	req2 := httptest.NewRequest(oghttp.MethodGet, "/api/tasks/upcoming?within=168h", nil)
+	rr2 := httptest.NewRecorder()
+
+	// Execute
+	h.ServeHTTP(rr1, req1)
+	h.ServeHTTP(rr2, req2)
+
+	// Assert result
+	var resp1, resp2 api.TaskListResponse
+	suite.Equal(oghttp.StatusOK, rr1.Code)
+	suite.NoError(json.Unmarshal(rr1.Body.Bytes(), &resp1))
+	suite.Len(resp1.Tasks, 1)
+	suite.Equal(id1, resp1.Tasks[0].ID)
+
+	suite.Equal(oghttp.StatusOK, rr2.Code)
+	suite.NoError(json.Unmarshal(rr2.Body.Bytes(), &resp2))
+	suite.Len(resp2.Tasks, 2)
+	suite.Equal(id1, resp2.Tasks[0].ID)
+	suite.Equal(id2, resp2.Tasks[1].ID)
+
+	// Assert log
+	suite.Empty(lbuf.String())
+}
+
+func (suite *HandlerSuite) TestUpcomingInvalidWithin() {
+	// Prepare
+	r := testutils.NewTaskRepository()
+	s := domain.NewService(r)
+	lbuf, log := testutils.NewLogger()
+	pr := testutils.NewProjectRepository(r)
+	wr := testutils.NewWebhookRepository()
+	h := application.APIHandler(application.Config{DefaultPageSize: 20, MaxPageSize: 100, DefaultWorkspace: true}, log, s, r, api.HandlerWithProjects(domain.NewProjectService(pr), pr), api.HandlerWithWebhooks(domain.NewWebhookService(wr), wr))
+
+	req := httptest.NewRequest(oghttp.MethodGet, "/api/tasks/upcoming?within=-1h", nil)
+	rr := httptest.NewRecorder()
+
+	// Execute
+	h.ServeHTTP(rr, req)
+
+	// Assert result
+	suite.Equal(oghttp.StatusBadRequest, rr.Code)
+	assertProblem(suite.T(), rr, "invalid_within", "within must be a positive duration: -1h")
+
+	// Assert log
+	suite.Empty(lbuf.String())
+}
+
+func (suite *HandlerSuite) TestAllTagsSuccess() {
+	// Prepare
+	id1, id2 := uuid.New(), uuid.New()
+	r := testutils.NewTaskRepository(
+		testutils.TaskRepositoryWithTag(&aggregators.Tag{ID: id2, Name: "work"}),
+		testutils.TaskRepositoryWithTag(&aggregators.Tag{ID: id1, Name: "home"}),
+	)
+	s := domain.NewService(r)
+	lbuf, log := testutils.NewLogger()
+	pr := testutils.NewProjectRepository(r)
+	wr := testutils.NewWebhookRepository()
+	h := application.APIHandler(application.Config{DefaultPageSize: 20, MaxPageSize: 100, DefaultWorkspace: true}, log, s, r, api.HandlerWithProjects(domain.NewProjectService(pr), pr), api.HandlerWithWebhooks(domain.NewWebhookService(wr), wr))
+
+	req := httptest.NewRequest(oghttp.MethodGet, "/api/tags", nil)
+	rr := httptest.NewRecorder()
+
+	// Execute
+	h.ServeHTTP(rr, req)
+
+	// Assert result
+	suite.Equal(oghttp.StatusOK, rr.Code)
+	suite.Equal("application/json", rr.Header().Get("Content-Type"))
+	suite.Equal(`{"tags":[{"id":"`+id1.String()+`","name":"home"},{"id":"`+id2.String()+`","name":"work"}]}`+"\n", rr.Body.String())
+
+	// Assert log
+	suite.Empty(lbuf.String())
+}
+
+func (suite *HandlerSuite) TestCreateTagSuccess() {
+	// Prepare
+	r := testutils.NewTaskRepository()
+	s := domain.NewService(r)
+	lbuf, log := testutils.NewLogger()
+	pr := testutils.NewProjectRepository(r)
+	wr := testutils.NewWebhookRepository()
+	h := application.APIHandler(application.Config{DefaultPageSize: 20, MaxPageSize: 100, DefaultWorkspace: true}, log, s, r, api.HandlerWithProjects(domain.NewProjectService(pr), pr), api.HandlerWithWebhooks(domain.NewWebhookService(wr), wr))
+
+	req := httptest.NewRequest(oghttp.MethodPost, "/api/tags", strings.NewReader(`{"name":"work"}`))
+	rr := httptest.NewRecorder()
+
+	// Execute
+	h.ServeHTTP(rr, req)
+
+	// Assert state
+	suite.Len(r.TagRecords, 1)
+	var tag *aggregators.Tag
+	for _, t := range r.TagRecords {
+		tag = t
+	}
+	suite.Equal("work", tag.Name)
+
+	// Assert result
+	suite.Equal(oghttp.StatusCreated, rr.Code)
+	suite.Equal(`{"id":"`+tag.ID.String()+`","name":"work"}`+"\n", rr.Body.String())
+
+	// Assert log
+	suite.Empty(lbuf.String())
+}
+
+func (suite *HandlerSuite) TestCreateTagFail() {
+	tests := []struct {
+		name    string
+		body    string
+		status  int
+		code    string
+		message string
+	}{
+		{name: "invalid body", body: `{`, status: oghttp.StatusBadRequest, code: "invalid_request", message: "invalid request body"},
+		{name: "empty name", body: `{"name":""}`, status: oghttp.StatusBadRequest, code: "tag_name_required", message: "tag name is required"},
+		{name: "existing name", body: `{"name":"work"}`, status: oghttp.StatusConflict, code: "tag_exists", message: "tag already exists: work"},
+	}
+
+	for _, tt := range tests {
+		suite.Run(tt.name, func() {
+			// Prepare
+			r := testutils.NewTaskRepository(testutils.TaskRepositoryWithTag(&aggregators.Tag{ID: uuid.New(), Name: "work"}))
+			s := domain.NewService(r)
+			lbuf, log := testutils.NewLogger()
+			pr := testutils.NewProjectRepository(r)
+			wr := testutils.NewWebhookRepository()
+			h := application.APIHandler(application.Config{DefaultPageSize: 20, MaxPageSize: 100, DefaultWorkspace: true}, log, s, r, api.HandlerWithProjects(domain.NewProjectService(pr), pr), api.HandlerWithWebhooks(domain.NewWebhookService(wr), wr))
+
+			req := httptest.NewRequest(oghttp.MethodPost, "/api/tags", strings.NewReader(tt.body))
+			rr := httptest.NewRecorder()
+
+			// Execute
+			h.ServeHTTP(rr, req)
+
+			// Assert state
+			suite.Len(r.TagRecords, 1)
+
+			// Assert result
+			suite.Equal(tt.status, rr.Code)
+			assertProblem(suite.T(), rr, tt.code, tt.message)
+
+			// Assert log
+			suite.Empty(lbuf.String())
+		})
+	}
+}
+
+func (suite *HandlerSuite) TestDeleteTagSuccess() {
+	// Prepare
+	id := uuid.New()
+	r := testutils.NewTaskRepository(testutils.TaskRepositoryWithTag(&aggregators.Tag{ID: id, Name: "work"}))
+	s := domain.NewService(r)
+	lbuf, log := testutils.NewLogger()
+	pr := testutils.NewProjectRepository(r)
+	wr := testutils.NewWebhookRepository()
+	h := application.APIHandler(application.Config{DefaultPageSize: 20, MaxPageSize: 100, DefaultWorkspace: true}, log, s, r, api.HandlerWithProjects(domain.NewProjectService(pr), pr), api.HandlerWithWebhooks(domain.NewWebhookService(wr), wr))
+
+	req1 := httptest.NewRequest(oghttp.MethodDelete, "/api/tags/"+id.String(), nil)
+	rr1 := httptest.NewRecorder()
+	req2 := httptest.NewRequest(oghttp.MethodDelete, "/api/tags/"+id.String(), nil)
+	rr2 := httptest.NewRecorder()
+
+	// Execute
+	h.ServeHTTP(rr1, req1)
+	h.ServeHTTP(rr2, req2)
+
+	// Assert state
+	suite.Empty(r.TagRecords)
+
+	// Assert result
+	suite.Equal(oghttp.StatusNoContent, rr1.Code)
+	suite.Equal(oghttp.StatusNotFound, rr2.Code)
+	assertProblem(suite.T(), rr2, "tag_not_found", "tag not found: "+id.String())
+
+	// Assert log
+	suite.Empty(lbuf.String())
+}
+
+func (suite *HandlerSuite) TestAddTagSuccess() {
+	// Prepare
+	id := uuid.New()
+	r := testutils.NewTaskRepository(
+		testutils.TaskRepositoryWithTag(&aggregators.Tag{ID: uuid.New(), Name: "to do"}),
+		testutils.TaskRepositoryWithTask(&aggregators.Task{ID: id, Title: "task 1", Status: "todo", Version: 1}),
+	)
+	now := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+	s := domain.NewService(r, domain.ServiceWithClock(func() time.Time { return now }))
+	lbuf, log := testutils.NewLogger()
+	pr := testutils.NewProjectRepository(r)
+	wr := testutils.NewWebhookRepository()
+	h := application.APIHandler(application.Config{DefaultPageSize: 20, MaxPageSize: 100, DefaultWorkspace: true}, log, s, r, api.HandlerWithProjects(domain.NewProjectService(pr), pr), api.HandlerWithWebhooks(domain.NewWebhookService(wr), wr))
+
+	req := httptest.NewRequest(oghttp.MethodPut, "/api/tasks/"+id.String()+"/tags/to%20do", nil)
+	req.Header.Set("If-Match", `"1"`)
+	rr := httptest.NewRecorder()
+
+	// Execute
+	h.ServeHTTP(rr, req)
+
+	// Assert state
+	suite.Equal([]string{"to do"}, r.Records[id].Tags)
+
+	// Assert result
+	suite.Equal(oghttp.StatusOK, rr.Code)
+	suite.Equal(`"2"`, rr.Header().Get("ETag"))
+	suite.Equal(`{"id":"`+id.String()+`","project_id":"00000000-0000-0000-0000-000000000001","title":"task 1","status":"todo","created_at":"0001-01-01T00:00:00Z",`+
+		`"updated_at":"2025-03-01T12:00:00Z","version":2,"tags":["to do"]}`+"\n", rr.Body.String())
+
+	// Assert log
+	suite.Empty(lbuf.String())
+}
+
+func (suite *HandlerSuite) TestAddTagNotFound() {
+	// Prepare
+	id := uuid.New()
+	r := testutils.NewTaskRepository(testutils.TaskRepositoryWithTask(&aggregators.Task{ID: id, Title: "task 1", Status: "todo", Version: 1}))
+	s := domain.NewService(r)
+	lbuf, log := testutils.NewLogger()
+	pr := testutils.NewProjectRepository(r)
+	wr := testutils.NewWebhookRepository()
+	h := application.APIHandler(application.Config{DefaultPageSize: 20, MaxPageSize: 100, DefaultWorkspace: true}, log, s, r, api.HandlerWithProjects(domain.NewProjectService(pr), pr), api.HandlerWithWebhooks(domain.NewWebhookService(wr), wr))
+
+	req := httptest.NewRequest(oghttp.MethodPut, "/api/tasks/"+id.String()+"/tags/work", nil)
+	rr := httptest.NewRecorder()
+
+	// Execute
+	h.ServeHTTP(rr, req)
+
+	// Assert state
+	suite.Nil(r.Records[id].Tags)
+
+	// Assert result
+	suite.Equal(oghttp.StatusNotFound, rr.Code)
+	assertProblem(suite.T(), rr, "tag_not_found", "tag not found: work")
+
+	// Assert log
+	suite.Empty(lbuf.String())
+}
+
+func (suite *HandlerSuite) TestRemoveTagSuccess() {
+	// Prepare
+	id := uuid.New()
+	r := testutils.NewTaskRepository(
+		testutils.TaskRepositoryWithTag(&aggregators.Tag{ID: uuid.New(), Name: "work"}),
+		testutils.TaskRepositoryWithTask(&aggregators.Task{ID: id, Title: "task 1", Status: "todo", Version: 1, Tags: []string{"work"}}),
+	)
+	s := domain.NewService(r)
+	lbuf, log := testutils.NewLogger()
+	pr := testutils.NewProjectRepository(r)
+	wr := testutils.NewWebhookRepository()
+	h := application.APIHandler(application.Config{DefaultPageSize: 20, MaxPageSize: 100, DefaultWorkspace: true}, log, s, r, api.HandlerWithProjects(domain.NewProjectService(pr), pr), api.HandlerWithWebhooks(domain.NewWebhookService(wr), wr))
+
+	req := httptest.NewRequest(oghttp.MethodDelete, "/api/tasks/"+id.String()+"/tags/work", nil)
+	rr := httptest.NewRecorder()
+
+	// Execute
+	h.ServeHTTP(rr, req)
+
+	// Assert state
+	suite.Empty(r.Records[id].Tags)
+
+	// Assert result
+	suite.Equal(oghttp.StatusOK, rr.Code)
+	suite.Equal(`"2"`, rr.Header().Get("ETag"))
+	suite.NotContains(rr.Body.String(), "tags")
+
+	// Assert log
+	suite.Empty(lbuf.String())
+}
+
+func (suite *HandlerSuite) TestAllTaggedSuccess() {
+	// Prepare
+	id1, id2 := uuid.New(), uuid.New()
+	r := testutils.NewTaskRepository(
+		testutils.TaskRepositoryWithTask(&aggregators.Task{ID: id1, Title: "task 1", Status: "todo", Version: 1, Tags: []string{"home", "work"}}),
+		testutils.TaskRepositoryWithTask(&aggregators.Task{ID: id2, Title: "task 2", Status: "todo", Version: 1, Tags: []string{"work"}}),
+		testutils.TaskRepositoryWithTask(&aggregators.Task{ID: uuid.New(), Title: "task 3", Status: "todo", Version: 1}),
+	)
+	s := domain.NewService(r)
+	lbuf, log := testutils.NewLogger()
+	pr := testutils.NewProjectRepository(r)
+	wr := testutils.NewWebhookRepository()
+	h := application.APIHandler(application.Config{DefaultPageSize: 20, MaxPageSize: 100, DefaultWorkspace: true}, log, s, r, api.HandlerWithProjects(domain.NewProjectService(pr), pr), api.HandlerWithWebhooks(domain.NewWebhookService(wr), wr))
+
+	req1 := httptest.NewRequest(oghttp.MethodGet, "/api/tasks?tag=work", nil)
+	rr1 := httptest.NewRecorder()
+	req2 := httptest.NewRequest(oghttp.MethodGet, "/api/tasks?tag=work&tag=home", nil)
+	rr2 := httptest.NewRecorder()
+
+	// Execute
+	h.ServeHTTP(rr1, req1)
+	h.ServeHTTP(rr2, req2)
+
+	// Assert result
+	var resp1, resp2 api.TaskListResponse
+	suite.Equal(oghttp.StatusOK, rr1.Code)
+	suite.NoError(json.Unmarshal(rr1.Body.Bytes(), &resp1))
+	suite.Len(resp1.Tasks, 2)
+	suite.Equal(id1, resp1.Tasks[0].ID)
+	suite.Equal([]string{"home", "work"}, resp1.Tasks[0].Tags)
+	suite.Equal(id2, resp1.Tasks[1].ID)
+
+	suite.Equal(oghttp.StatusOK, rr2.Code)
+	suite.NoError(json.Unmarshal(rr2.Body.Bytes(), &resp2))
+	suite.Len(resp2.Tasks, 1)
+	suite.Equal(id1, resp2.Tasks[0].ID)
+
+	// Assert log
+	suite.Empty(lbuf.String())
+}
+
+func (suite *HandlerSuite) TestCreateSubtaskSuccess() {
+	// Prepare
+	parentID := uuid.New()
+	r := testutils.NewTaskRepository(testutils.TaskRepositoryWithTask(&aggregators.Task{ID: parentID, Title: "parent", Status: "todo", Version: 1}))
+	s := domain.NewService(r)
+	lbuf, log := testutils.NewLogger()
+	pr := testutils.NewProjectRepository(r)
+	wr := testutils.NewWebhookRepository()
+	h := application.APIHandler(application.Config{DefaultPageSize: 20, MaxPageSize: 100, DefaultWorkspace: true}, log, s, r, api.HandlerWithProjects(domain.NewProjectService(pr), pr), api.HandlerWithWebhooks(domain.NewWebhookService(wr), wr))
+
+	req := httptest.NewRequest(oghttp.MethodPost, "/api/tasks", strings.NewReader(`{"title":"task 1","parent_id":"`+parentID.String()+`"}`))
+	rr := httptest.NewRecorder()
+
+	// Execute
+	h.ServeHTTP(rr, req)
+
+	// Assert result
+	suite.Equal(oghttp.StatusCreated, rr.Code)
+	var task aggregators.Task
+	suite.NoError(json.Unmarshal(rr.Body.Bytes(), &task))
+	suite.Equal(&parentID, task.ParentID)
+
+	// Assert state
+	suite.Equal(&parentID, r.Records[task.ID].ParentID)
+
+	// Assert log
+	suite.Empty(lbuf.String())
+}
+
+func (suite *HandlerSuite) TestCreateSubtaskParentNotFound() {
+	// Prepare
+	r := testutils.NewTaskRepository()
+	s := domain.NewService(r)
+	lbuf, log := testutils.NewLogger()
+	pr := testutils.NewProjectRepository(r)
+	wr := testutils.NewWebhookRepository()
+	h := application.APIHandler(application.Config{DefaultPageSize: 20, MaxPageSize: 100, DefaultWorkspace: true}, log, s, r, api.HandlerWithProjects(domain.NewProjectService(pr), pr), api.HandlerWithWebhooks(domain.NewWebhookService(wr), wr))
+	parentID := uuid.New()
+
+	req := httptest.NewRequest(oghttp.MethodPost, "/api/tasks", strings.NewReader(`{"title":"task 1","parent_id":"`+parentID.String()+`"}`))
+	rr := httptest.NewRecorder()
+
+	// Execute
+	h.ServeHTTP(rr, req)
+
+	// Assert state
+	suite.Empty(r.Records)
+
+	// Assert result
+	suite.Equal(oghttp.StatusBadRequest, rr.Code)
+	assertProblem(suite.T(), rr, "parent_not_found", "parent task not found: "+parentID.String())
+
+	// Assert log
+	suite.Empty(lbuf.String())
+}
+
+func (suite *HandlerSuite) TestUpdateParentCycle() {
+	// Prepare
+	parentID, childID := uuid.New(), uuid.New()
+	r := testutils.NewTaskRepository(
+		testutils.TaskRepositoryWithTask(&aggregators.Task{ID: parentID, Title: "parent", Status: "todo", Version: 1}),
+		testutils.TaskRepositoryWithTask(&aggregators.Task{ID: childID, ParentID: &parentID, Title: "child", Status: "todo", Version: 1}),
+	)
+	s := domain.NewService(r)
+	lbuf, log := testutils.NewLogger()
+	pr := testutils.NewProjectRepository(r)
+	wr := testutils.NewWebhookRepository()
+	h := application.APIHandler(application.Config{DefaultPageSize: 20, MaxPageSize: 100, DefaultWorkspace: true}, log, s, r, api.HandlerWithProjects(domain.NewProjectService(pr), pr), api.HandlerWithWebhooks(domain.NewWebhookService(wr), wr))
+
+	req := httptest.NewRequest(oghttp.MethodPatch, "/api/tasks/"+parentID.String(), strings.NewReader(`{"parent_id":"`+childID.String()+`"}`))
+	req.Header.Set("Content-Type", "application/merge-patch+json")
+	rr := httptest.NewRecorder()
+
+	// Execute
+	h.ServeHTTP(rr, req)
+
+	// Assert state
+	suite.Nil(r.Records[parentID].ParentID)
+
+	// Assert result
+	suite.Equal(oghttp.StatusBadRequest, rr.Code)
+	assertProblem(suite.T(), rr, "parent_cycle", "task cannot be nested under itself or its subtasks: "+childID.String())
+
+	// Assert log
+	suite.Empty(lbuf.String())
+}
+
+func (suite *HandlerSuite) TestMarkCompletedOpenSubtasks() {
+	// Prepare
+	parentID := uuid.New()
+	r := testutils.NewTaskRepository(
+		testutils.TaskRepositoryWithTask(&aggregators.Task{ID: parentID, Title: "parent", Status: "todo", Version: 1}),
+		testutils.TaskRepositoryWithTask(&aggregators.Task{ID: uuid.New(), ParentID: &parentID, Title: "child", Status: "todo", Version: 1}),
+	)
+	s := domain.NewService(r)
+	lbuf, log := testutils.NewLogger()
+	pr := testutils.NewProjectRepository(r)
+	wr := testutils.NewWebhookRepository()
+	h := application.APIHandler(application.Config{DefaultPageSize: 20, MaxPageSize: 100, DefaultWorkspace: true}, log, s, r, api.HandlerWithProjects(domain.NewProjectService(pr), pr), api.HandlerWithWebhooks(domain.NewWebhookService(wr), wr))
+
+	req := httptest.NewRequest(oghttp.MethodPut, "/api/tasks/"+parentID.String()+"/complete", nil)
+	rr := httptest.NewRecorder()
+
+	// Execute
+	h.ServeHTTP(rr, req)
+
+	// Assert state
+	suite.Equal("todo", r.Records[parentID].Status)
+
+	// Assert result
+	suite.Equal(oghttp.StatusConflict, rr.Code)
+	assertProblem(suite.T(), rr, "open_subtasks", "task has open subtasks: "+parentID.String())
+
+	// Assert log
+	suite.Empty(lbuf.String())
+}
+
+func (suite *HandlerSuite) TestChildrenSuccess() {
+	// Prepare
+	parentID, id1, id2 := uuid.New(), uuid.New(), uuid.New()
+	r := testutils.NewTaskRepository(
+		testutils.TaskRepositoryWithTask(&aggregators.Task{ID: parentID, Title: "parent", Status: "todo", Version: 1}),
+		testutils.TaskRepositoryWithTask(&aggregators.Task{ID: id2, ParentID: &parentID, Title: "child 2", Status: "todo", Version: 1}),
+		testutils.TaskRepositoryWithTask(&aggregators.Task{ID: id1, ParentID: &parentID, Title: "child 1", Status: "todo", Version: 1}),
+		testutils.TaskRepositoryWithTask(&aggregators.Task{ID: uuid.New(), ParentID: &id1, Title: "grandchild", Status: "todo", Version: 1}),
+		testutils.TaskRepositoryWithTask(&aggregators.Task{ID: uuid.New(), Title: "other", Status: "todo", Version: 1}),
+	)
+	s := domain.NewService(r)
+	lbuf, log := testutils.NewLogger()
+	pr := testutils.NewProjectRepository(r)
+	wr := testutils.NewWebhookRepository()
+	h := application.APIHandler(application.Config{DefaultPageSize: 20, MaxPageSize: 100, DefaultWorkspace: true}, log, s, r, api.HandlerWithProjects(domain.NewProjectService(pr), pr), api.HandlerWithWebhooks(domain.NewWebhookService(wr), wr))
+
+	req := httptest.NewRequest(oghttp.MethodGet, "/api/tasks/"+parentID.String()+"/children", nil)
+	rr := httptest.NewRecorder()
+
+	// Execute
+	h.ServeHTTP(rr, req)
+
+	// Assert result
+	suite.Equal(oghttp.StatusOK, rr.Code)
+	var resp api.TaskListResponse
+	suite.NoError(json.Unmarshal(rr.Body.Bytes(), &resp))
+	suite.Len(resp.Tasks, 2)
+	suite.Equal(id1, resp.Tasks[0].ID)
+	suite.Equal(id2, resp.Tasks[1].ID)
+
+	// Assert log
+	suite.Empty(lbuf.String())
+}
+
+func (suite *HandlerSuite) TestChildrenNotFound() {
+	// Prepare
+	r := testutils.NewTaskRepository()
+	s := domain.NewService(r)
+	lbuf, log := testutils.NewLogger()
+	pr := testutils.NewProjectRepository(r)
+	wr := testutils.NewWebhookRepository()
+	h := application.APIHandler(application.Config{DefaultPageSize: 20, MaxPageSize: 100, DefaultWorkspace: true}, log, s, r, api.HandlerWithProjects(domain.NewProjectService(pr), pr), api.HandlerWithWebhooks(domain.NewWebhookService(wr), wr))
+
+	req := httptest.NewRequest(oghttp.MethodGet, "/api/tasks/"+uuid.New().String()+"/children", nil)
+	rr := httptest.NewRecorder()
+
+	// Execute
+	h.ServeHTTP(rr, req)
+
+	// Assert result
+	suite.Equal(oghttp.StatusNotFound, rr.Code)
+	assertProblem(suite.T(), rr, "task_not_found", "task not found")
+
+	// Assert log
+	suite.Empty(lbuf.String())
+}
+
+func (suite *HandlerSuite) TestTreeSuccess() {
+	// Prepare
+	rootID := uuid.MustParse("00000000-0000-0000-0000-000000000001")
+	childID := uuid.MustParse("00000000-0000-0000-0000-000000000002")
+	grandchildID := uuid.MustParse("00000000-0000-0000-0000-000000000003")
+	r := testutils.NewTaskRepository(
+		testutils.TaskRepositoryWithTask(&aggregators.Task{ID: rootID, Title: "root", Status: "todo", Version: 1}),
+		testutils.TaskRepositoryWithTask(&aggregators.Task{ID: childID, ParentID: &rootID, Title: "child", Status: "todo", Version: 1}),
+		testutils.TaskRepositoryWithTask(&aggregators.Task{ID: grandchildID, ParentID: &childID, Title: "grandchild", Status: "done", Version: 1}),
+	)
+	s := domain.NewService(r)
+	lbuf, log := testutils.NewLogger()
+	pr := testutils.NewProjectRepository(r)
+	wr := testutils.NewWebhookRepository()
+	h := application.APIHandler(application.Config{DefaultPageSize: 20, MaxPageSize: 100, DefaultWorkspace: true}, log, s, r, api.HandlerWithProjects(domain.NewProjectService(pr), pr), api.HandlerWithWebhooks(domain.NewWebhookService(wr), wr))
+
+	req := httptest.NewRequest(oghttp.MethodGet, "/api/tasks/"+rootID.String()+"/tree", nil)
+	rr := httptest.NewRecorder()
+
+	// Execute
+	h.ServeHTTP(rr, req)
+
+	// Assert result
+	suite.Equal(oghttp.StatusOK, rr.Code)
+	suite.Equal("application/json", rr.Header().Get("Content-Type"))
+	suite.Equal(`{"id":"`+rootID.String()+`","project_id":"00000000-0000-0000-0000-000000000001","title":"root","status":"todo","created_at":"0001-01-01T00:00:00Z","updated_at":"0001-01-01T00:00:00Z","version":1,"children":[`+
+		`{"id":"`+childID.String()+`","project_id":"00000000-0000-0000-0000-000000000001","parent_id":"`+rootID.String()+`","title":"child","status":"todo","created_at":"0001-01-01T00:00:00Z","updated_at":"0001-01-01T00:00:00Z","version":1,"children":[`+
+		`{"id":"`+grandchildID.String()+`","project_id":"00000000-0000-0000-0000-000000000001","parent_id":"`+childID.String()+`","title":"grandchild","status":"done","created_at":"0001-01-01T00:00:00Z","updated_at":"0001-01-01T00:00:00Z","version":1,"children":[]}]}]}`+"\n", rr.Body.String())
+
+	// Assert log
+	suite.Empty(lbuf.String())
+}
+
+func (suite *HandlerSuite) TestAddDependencySuccess() {
+	// Prepare
+	id, dependsOn := uuid.New(), uuid.New()
+	r := testutils.NewTaskRepository(
+		testutils.TaskRepositoryWithTask(&aggregators.Task{ID: id, Title: "task 1", Status: "todo", Version: 1}),
+		testutils.TaskRepositoryWithTask(&aggregators.Task{ID: dependsOn, Title: "task 2", Status: "todo", Version: 1}),
+	)
+	now := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+	s := domain.NewService(r, domain.ServiceWithClock(func() time.Time { return now }))
+	lbuf, log := testutils.NewLogger()
+	pr := testutils.NewProjectRepository(r)
+	wr := testutils.NewWebhookRepository()
+	h := application.APIHandler(application.Config{DefaultPageSize: 20, MaxPageSize: 100, DefaultWorkspace: true}, log, s, r, api.HandlerWithProjects(domain.NewProjectService(pr), pr), api.HandlerWithWebhooks(domain.NewWebhookService(wr), wr))
+
+	req := httptest.NewRequest(oghttp.MethodPost, "/api/tasks/"+id.String()+"/dependencies", strings.NewReader(`{"depends_on":"`+dependsOn.String()+`"}`))
+	req.Header.Set("If-Match", `"1"`)
+	rr := httptest.NewRecorder()
+
+	// Execute
+	h.ServeHTTP(rr, req)
+
+	// Assert state
+	suite.Equal([]uuid.UUID{dependsOn}, r.Records[id].BlockedBy)
+
+	// Assert result
+	suite.Equal(oghttp.StatusOK, rr.Code)
+	suite.Equal(`"2"`, rr.Header().Get("ETag"))
+	suite.Equal(`{"id":"`+id.String()+`","project_id":"00000000-0000-0000-0000-000000000001","title":"task 1","status":"todo","created_at":"0001-01-01T00:00:00Z",`+
+		`"updated_at":"2025-03-01T12:00:00Z","version":2,"blocked_by":["`+dependsOn.String()+`"]}`+"\n", rr.Body.String())
+
+	// Assert log
+	suite.Empty(lbuf.String())
+}
+
+func (suite *HandlerSuite) TestAddDependencyCycle() {
+	// Prepare
+	id, dependsOn := uuid.New(), uuid.New()
+	r := testutils.NewTaskRepository(
+		testutils.TaskRepositoryWithTask(&aggregators.Task{ID: id, Title: "task 1", Status: "todo", Version: 1}),
+		testutils.TaskRepositoryWithTask(&aggregators.Task{ID: dependsOn, Title: "task 2", Status: "todo", Version: 1, BlockedBy: []uuid.UUID{id}}),
+	)
+	s := domain.NewService(r)
+	lbuf, log := testutils.NewLogger()
+	pr := testutils.NewProjectRepository(r)
+	wr := testutils.NewWebhookRepository()
+	h := application.APIHandler(application.Config{DefaultPageSize: 20, MaxPageSize: 100, DefaultWorkspace: true}, log, s, r, api.HandlerWithProjects(domain.NewProjectService(pr), pr), api.HandlerWithWebhooks(domain.NewWebhookService(wr), wr))
+
+	req := httptest.NewRequest(oghttp.MethodPost, "/api/tasks/"+id.String()+"/dependencies", strings.NewReader(`{"depends_on":"`+dependsOn.String()+`"}`))
+	rr := httptest.NewRecorder()
+
+	// Execute
+	h.ServeHTTP(rr, req)
+
+	// Assert state
+	suite.Empty(r.Records[id].BlockedBy)
+
+	// Assert result
+	suite.Equal(oghttp.StatusBadRequest, rr.Code)
+	assertProblem(suite.T(), rr, "dependency_cycle", "dependency would create a cycle: "+dependsOn.String())
+
+	// Assert log
+	suite.Empty(lbuf.String())
+}
+
+func (suite *HandlerSuite) TestRemoveDependencySuccess() {
+	// Prepare
+	id, dependsOn := uuid.New(), uuid.New()
+	r := testutils.NewTaskRepository(
+		testutils.TaskRepositoryWithTask(&aggregators.Task{ID: id, Title: "task 1", Status: "todo", Version: 1, BlockedBy: []uuid.UUID{dependsOn}}),
+		testutils.TaskRepositoryWithTask(&aggregators.Task{ID: dependsOn, Title: "task 2", Status: "todo", Version: 1}),
+	)
+	s := domain.NewService(r)
+	lbuf, log := testutils.NewLogger()
+	pr := testutils.NewProjectRepository(r)
+	wr := testutils.NewWebhookRepository()
+	h := application.APIHandler(application.Config{DefaultPageSize: 20, MaxPageSize: 100, DefaultWorkspace: true}, log, s, r, api.HandlerWithProjects(domain.NewProjectService(pr), pr), api.HandlerWithWebhooks(domain.NewWebhookService(wr), wr))
+
+	req := httptest.NewRequest(oghttp.MethodDelete, "/api/tasks/"+id.String()+"/dependencies/"+dependsOn.String(), nil)
+	rr := httptest.NewRecorder()
+
+	// Execute
+	h.ServeHTTP(rr, req)
+
+	// Assert state
+	suite.Empty(r.Records[id].BlockedBy)
+
+	// Assert result
+	suite.Equal(oghttp.StatusOK, rr.Code)
+	suite.NotContains(rr.Body.String(), "blocked_by")
+
+	// Assert log
+	suite.Empty(lbuf.String())
+}
+
+func (suite *HandlerSuite) TestMarkCompletedBlocked() {
+	// Prepare
+	id, dependsOn := uuid.New(), uuid.New()
+	r := testutils.NewTaskRepository(
+		testutils.TaskRepositoryWithTask(&aggregators.Task{ID: id, Title: "task 1", Status: "todo", Version: 1, BlockedBy: []uuid.UUID{dependsOn}}),
+		testutils.TaskRepositoryWithTask(&aggregators.Task{ID: dependsOn, Title: "task 2", Status: "todo", Version: 1}),
+	)
+	s := domain.NewService(r)
+	lbuf, log := testutils.NewLogger()
+	pr := testutils.NewProjectRepository(r)
+	wr := testutils.NewWebhookRepository()
+	h := application.APIHandler(application.Config{DefaultPageSize: 20, MaxPageSize: 100, DefaultWorkspace: true}, log, s, r, api.HandlerWithProjects(domain.NewProjectService(pr), pr), api.HandlerWithWebhooks(domain.NewWebhookService(wr), wr))
+
+	req := httptest.NewRequest(oghttp.MethodPut, "/api/tasks/"+id.String()+"/complete", nil)
+	rr := httptest.NewRecorder()
+
+	// Execute
+	h.ServeHTTP(rr, req)
+
+	// Assert state
+	suite.Equal("todo", r.Records[id].Status)
+
+	// Assert result
+	suite.Equal(oghttp.StatusBadRequest, rr.Code)
+	assertProblem(suite.T(), rr, "task_blocked", "task is blocked by open tasks: "+dependsOn.String())
+
+	// Assert log
+	suite.Empty(lbuf.String())
+}
+
+func (suite *HandlerSuite) TestReadySuccess() {
+	// Prepare
+	id1, id2 := uuid.New(), uuid.New()
+	r := testutils.NewTaskRepository(
+		testutils.TaskRepositoryWithTask(&aggregators.Task{ID: id1, Title: "task 1", Status: "todo", Version: 1, BlockedBy: []uuid.UUID{id2}}),
+		testutils.TaskRepositoryWithTask(&aggregators.Task{ID: id2, Title: "task 2", Status: "todo", Version: 1}),
+		testutils.TaskRepositoryWithTask(&aggregators.Task{ID: uuid.New(), Title: "task 3", Status: "done", Version: 1}),
+	)
+	s := domain.NewService(r)
+	lbuf, log := testutils.NewLogger()
+	pr := testutils.NewProjectRepository(r)
+	wr := testutils.NewWebhookRepository()
+	h := application.APIHandler(application.Config{DefaultPageSize: 20, MaxPageSize: 100, DefaultWorkspace: true}, log, s, r, api.HandlerWithProjects(domain.NewProjectService(pr), pr), api.HandlerWithWebhooks(domain.NewWebhookService(wr), wr))
+
+	req := httptest.NewRequest(oghttp.MethodGet, "/api/tasks/ready", nil)
+	rr := httptest.NewRecorder()
+
+	// Execute
+	h.ServeHTTP(rr, req)
+
+	// Assert result
+	suite.Equal(oghttp.StatusOK, rr.Code)
+	var resp api.TaskListResponse
+	suite.NoError(json.Unmarshal(rr.Body.Bytes(), &resp))
+	suite.Len(resp.Tasks, 2)
+	suite.Equal(id2, resp.Tasks[0].ID)
+	suite.Equal(id1, resp.Tasks[1].ID)
+
+	// Assert log
+	suite.Empty(lbuf.String())
+}
+
+func (suite *HandlerSuite) TestCreateRecurringSuccess() {
+	// Prepare
+	r := testutils.NewTaskRepository()
+	now := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+	s := domain.NewService(r, domain.ServiceWithClock(func() time.Time { return now }))
+	lbuf, log := testutils.NewLogger()
+	pr := testutils.NewProjectRepository(r)
+	wr := testutils.NewWebhookRepository()
+	h := application.APIHandler(application.Config{DefaultPageSize: 20, MaxPageSize: 100, DefaultWorkspace: true}, log, s, r, api.HandlerWithProjects(domain.NewProjectService(pr), pr), api.HandlerWithWebhooks(domain.NewWebhookService(wr), wr))
+
+	req := httptest.NewRequest(oghttp.MethodPost, "/api/tasks", strings.NewReader(`{"title":"task 1","due_at":"2025-03-03T09:00:00Z","recurrence":"FREQ=WEEKLY;BYDAY=MO"}`))
+	rr := httptest.NewRecorder()
+
+	// Execute
+	h.ServeHTTP(rr, req)
+
+	// Assert state
+	suite.Len(r.Records, 1)
+	var task *aggregators.Task
+	for _, t := range r.Records {
+		task = t
+	}
+
+	// Assert result
+	suite.Equal(oghttp.StatusCreated, rr.Code)
+	suite.Equal(`{"id":"`+task.ID.String()+`","project_id":"00000000-0000-0000-0000-000000000001","title":"task 1","status":"todo","due_at":"2025-03-03T09:00:00Z","recurrence":"FREQ=WEEKLY;BYDAY=MO",`+
+		`"series_id":"`+task.ID.String()+`","occurrence":1,"created_at":"2025-03-01T12:00:00Z","updated_at":"2025-03-01T12:00:00Z","version":1}`+"\n", rr.Body.String())
+
+	// Assert log
+	suite.Empty(lbuf.String())
+}
+
+func (suite *HandlerSuite) TestCreateRecurringInvalid() {
+	// Prepare
+	r := testutils.NewTaskRepository()
+	s := domain.NewService(r)
+	lbuf, log := testutils.NewLogger()
+	pr := testutils.NewProjectRepository(r)
+	wr := testutils.NewWebhookRepository()
+	h := application.APIHandler(application.Config{DefaultPageSize: 20, MaxPageSize: 100, DefaultWorkspace: true}, log, s, r, api.HandlerWithProjects(domain.NewProjectService(pr), pr), api.HandlerWithWebhooks(domain.NewWebhookService(wr), wr))
+
+	req := httptest.NewRequest(oghttp.MethodPost, "/api/tasks", strings.NewReader(`{"title":"task 1","due_at":"2025-03-03T09:00:00Z","recurrence":"FREQ=FORTNIGHTLY"}`))
+	rr := httptest.NewRecorder()
+
+	// Execute
+	h.ServeHTTP(rr, req)
+
+	// Assert state
+	suite.Empty(r.Records)
+
+	// Assert result
+	suite.Equal(oghttp.StatusBadRequest, rr.Code)
+	assertProblem(suite.T(), rr, "invalid_recurrence", "invalid recurrence rule: unsupported FREQ FORTNIGHTLY")
+
+	// Assert log
+	suite.Empty(lbuf.String())
+}
+
+func (suite *HandlerSuite) TestMarkCompletedRecurringSuccess() {
+	// Prepare
+	id := uuid.New()
+	dueAt := time.Date(2025, 3, 3, 9, 0, 0, 0, time.UTC)
+	recurrence := "FREQ=WEEKLY;BYDAY=MO"
+	r := testutils.NewTaskRepository(testutils.TaskRepositoryWithTask(&aggregators.Task{
+		ID: id, Title: "task 1", Status: "todo", DueAt: &dueAt, Recurrence: &recurrence, SeriesID: &id, Occurrence: 1, Version: 1,
+	}))
+	s := domain.NewService(r)
+	lbuf, log := testutils.NewLogger()
+	pr := testutils.NewProjectRepository(r)
+	wr := testutils.NewWebhookRepository()
+	h := application.APIHandler(application.Config{DefaultPageSize: 20, MaxPageSize: 100, DefaultWorkspace: true}, log, s, r, api.HandlerWithProjects(domain.NewProjectService(pr), pr), api.HandlerWithWebhooks(domain.NewWebhookService(wr), wr))
+
+	req := httptest.NewRequest(oghttp.MethodPut, "/api/tasks/"+id.String()+"/complete", nil)
+	rr := httptest.NewRecorder()
+
+	// Execute
+	h.ServeHTTP(rr, req)
+
+	// Assert result
+	suite.Equal(oghttp.StatusNoContent, rr.Code)
+
+	// Assert state
+	suite.Len(r.Records, 2)
+	suite.Equal("done", r.Records[id].Status)
+	for _, task := range r.Records {
+		if task.ID != id {
+			suite.Equal(time.Date(2025, 3, 10, 9, 0, 0, 0, time.UTC), *task.DueAt)
+			suite.Equal(&id, task.SeriesID)
+			suite.Equal(2, task.Occurrence)
+		}
+	}
+
+	// Assert log
+	suite.Empty(lbuf.String())
+}
+
+func (suite *HandlerSuite) TestUpdateRecurrenceSuccess() {
+	// Prepare
+	id := uuid.New()
+	dueAt := time.Date(2025, 3, 3, 9, 0, 0, 0, time.UTC)
+	recurrence := "FREQ=DAILY"
+	r := testutils.NewTaskRepository(testutils.TaskRepositoryWithTask(&aggregators.Task{
+		ID: id, Title: "task 1", Status: "todo", DueAt: &dueAt, Recurrence: &recurrence, SeriesID: &id, Occurrence: 1, Version: 1,
+	}))
+	s := domain.NewService(r)
+	lbuf, log := testutils.NewLogger()
+	pr := testutils.NewProjectRepository(r)
+	wr := testutils.NewWebhookRepository()
+	h := application.APIHandler(application.Config{DefaultPageSize: 20, MaxPageSize: 100, DefaultWorkspace: true}, log, s, r, api.HandlerWithProjects(domain.NewProjectService(pr), pr), api.HandlerWithWebhooks(domain.NewWebhookService(wr), wr))
+
+	changed := httptest.NewRequest(oghttp.MethodPatch, "/api/tasks/"+id.String(), strings.NewReader(`{"recurrence":"FREQ=WEEKLY"}`))
+	changed.Header.Set("Content-Type", "application/merge-patch+json")
+	crr := httptest.NewRecorder()
+	removed := httptest.NewRequest(oghttp.MethodPatch, "/api/tasks/"+id.String(), strings.NewReader(`{"recurrence":null}`))
+	removed.Header.Set("Content-Type", "application/merge-patch+json")
+	rrr := httptest.NewRecorder()
+
+	// Execute
+	h.ServeHTTP(crr, changed)
+	h.ServeHTTP(rrr, removed)
+
+	// Assert result
+	suite.Equal(oghttp.StatusOK, crr.Code)
+	suite.Contains(crr.Body.String(), `"recurrence":"FREQ=WEEKLY"`)
+	suite.Equal(oghttp.StatusOK, rrr.Code)
+	suite.NotContains(rrr.Body.String(), `"recurrence"`)
+
+	// Assert state
+	suite.Nil(r.Records[id].Recurrence)
+
+	// Assert log
+	suite.Empty(lbuf.String())
+}
+
+func (suite *HandlerSuite) TestUpdateRecurringDueAtRemovedFail() {
+	// Prepare
+	id := uuid.New()
+	dueAt := time.Date(2025, 3, 3, 9, 0, 0, 0, time.UTC)
+	recurrence := "FREQ=DAILY"
+	r := testutils.NewTaskRepository(testutils.TaskRepositoryWithTask(&aggregators.Task{
+		ID: id, Title: "task 1", Status: "todo", DueAt: &dueAt, Recurrence: &recurrence, SeriesID: &id, Occurrence: 1, Version: 1,
+	}))
+	s := domain.NewService(r)
+	lbuf, log := testutils.NewLogger()
+	pr := testutils.NewProjectRepository(r)
+	wr := testutils.NewWebhookRepository()
+	h := application.APIHandler(application.Config{DefaultPageSize: 20, MaxPageSize: 100, DefaultWorkspace: true}, log, s, r, api.HandlerWithProjects(domain.NewProjectService(pr), pr), api.HandlerWithWebhooks(domain.NewWebhookService(wr), wr))
+
+	req := httptest.NewRequest(oghttp.MethodPatch, "/api/tasks/"+id.String(), strings.NewReader(`{"due_at":null}`))
+	req.Header.Set("Content-Type", "application/merge-patch+json")
+	rr := httptest.NewRecorder()
+
+	// Execute
+	h.ServeHTTP(rr, req)
+
+	// Assert state
+	suite.Equal(&dueAt, r.Records[id].DueAt)
+
+	// Assert result
+	suite.Equal(oghttp.StatusBadRequest, rr.Code)
+	assertProblem(suite.T(), rr, "recurrence_without_due_date", "a recurring task needs a due date")
+
+	// Assert log
+	suite.Empty(lbuf.String())
+}
+
+func (suite *HandlerSuite) TestAllProjectsSuccess() {
+	// Prepare
+	id := uuid.New()
+	createdAt := time.Date(2025, 2, 1, 9, 0, 0, 0, time.UTC)
+	r := testutils.NewTaskRepository(testutils.TaskRepositoryWithProject(&aggregators.Project{ID: id, Name: "work", CreatedAt: createdAt, UpdatedAt: createdAt, Version: 1}))
+	s := domain.NewService(r)
+	lbuf, log := testutils.NewLogger()
+	pr := testutils.NewProjectRepository(r)
+	wr := testutils.NewWebhookRepository()
+	h := application.APIHandler(application.Config{DefaultPageSize: 20, MaxPageSize: 100, DefaultWorkspace: true}, log, s, r, api.HandlerWithProjects(domain.NewProjectService(pr), pr), api.HandlerWithWebhooks(domain.NewWebhookService(wr), wr))
+
+	req := httptest.NewRequest(oghttp.MethodGet, "/api/projects", nil)
+	rr := httptest.NewRecorder()
+
+	// Execute
+	h.ServeHTTP(rr, req)
+
+	// Assert result
+	suite.Equal(oghttp.StatusOK, rr.Code)
+	suite.Equal("application/json", rr.Header().Get("Content-Type"))
+	suite.Equal(`{"projects":[`+
+		`{"id":"00000000-0000-0000-0000-000000000001","name":"Inbox","inbox":true,"created_at":"0001-01-01T00:00:00Z","updated_at":"0001-01-01T00:00:00Z","version":1},`+
+		`{"id":"`+id.String()+`","name":"work","inbox":false,"created_at":"2025-02-01T09:00:00Z","updated_at":"2025-02-01T09:00:00Z","version":1}]}`+"\n", rr.Body.String())
+
+	// Assert log
+	suite.Empty(lbuf.String())
+}
+
+func (suite *HandlerSuite) TestCreateProjectSuccess() {
+	// Prepare
+	now := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+	r := testutils.NewTaskRepository()
+	s := domain.NewService(r)
+	lbuf, log := testutils.NewLogger()
+	pr := testutils.NewProjectRepository(r)
+	wr := testutils.NewWebhookRepository()
+	ps := domain.NewProjectService(pr, domain.ProjectServiceWithClock(func() time.Time { return now }))
+	h := application.APIHandler(application.Config{DefaultPageSize: 20, MaxPageSize: 100, DefaultWorkspace: true}, log, s, r, api.HandlerWithProjects(ps, pr), api.HandlerWithWebhooks(domain.NewWebhookService(wr), wr))
+
+	req := httptest.NewRequest(oghttp.MethodPost, "/api/projects", strings.NewReader(`{"name":"work"}`))
+	rr := httptest.NewRecorder()
+
+	// Execute
+	h.ServeHTTP(rr, req)
+
+	// Assert state
+	suite.Len(r.ProjectRecords, 2)
+	var project *aggregators.Project
+	for _, p := range r.ProjectRecords {
+		if !p.Inbox {
+			project = p
+		}
+	}
+	suite.Equal("work", project.Name)
+
+	// Assert result
+	suite.Equal(oghttp.StatusCreated, rr.Code)
+	suite.Equal(`{"id":"`+project.ID.String()+`","name":"work","inbox":false,"created_at":"2025-03-01T12:00:00Z","updated_at":"2025-03-01T12:00:00Z","version":1}`+"\n", rr.Body.String())
+
+	// Assert log
+	suite.Empty(lbuf.String())
+}
+
+func (suite *HandlerSuite) TestCreateProjectFail() {
+	tests := []struct {
+		name    string
+		body    string
+		code    string
+		message string
+	}{
+		{name: "invalid body", body: `{`, code: "invalid_request", message: "invalid request body"},
+		{name: "empty name", body: `{"name":""}`, code: "project_name_required", message: "project name is required"},
+	}
+
+	for _, tt := range tests {
+		suite.Run(tt.name, func() {
+			// Prepare
+			r := testutils.NewTaskRepository()
+			s := domain.NewService(r)
+			lbuf, log := testutils.NewLogger()
+			pr := testutils.NewProjectRepository(r)
+			wr := testutils.NewWebhookRepository()
+			h := application.APIHandler(application.Config{DefaultPageSize: 20, MaxPageSize: 100, DefaultWorkspace: true}, log, s, r, api.HandlerWithProjects(domain.NewProjectService(pr), pr), api.HandlerWithWebhooks(domain.NewWebhookService(wr), wr))
+
+			req := httptest.NewRequest(oghttp.MethodPost, "/api/projects", strings.NewReader(tt.body))
+			rr := httptest.NewRecorder()
+
+			// Execute
+			h.ServeHTTP(rr, req)
+
+			// Assert state
+			suite.Len(r.ProjectRecords, 1)
+
+			// Assert result
+			suite.Equal(oghttp.StatusBadRequest, rr.Code)
+			assertProblem(suite.T(), rr, tt.code, tt.message)
+
+			// Assert log
+			suite.Empty(lbuf.String())
+		})
+	}
+}
+
+func (suite *HandlerSuite) TestFindProjectNotFound() {
+	// Prepare
+	r := testutils.NewTaskRepository()
+	s := domain.NewService(r)
+	lbuf, log := testutils.NewLogger()
+	pr := testutils.NewProjectRepository(r)
+	wr := testutils.NewWebhookRepository()
+	h := application.APIHandler(application.Config{DefaultPageSize: 20, MaxPageSize: 100, DefaultWorkspace: true}, log, s, r, api.HandlerWithProjects(domain.NewProjectService(pr), pr), api.HandlerWithWebhooks(domain.NewWebhookService(wr), wr))
+
+	req := httptest.NewRequest(oghttp.MethodGet, "/api/projects/"+uuid.New().String(), nil)
+	rr := httptest.NewRecorder()
+
+	// Execute
+	h.ServeHTTP(rr, req)
+
+	// Assert result
+	suite.Equal(oghttp.StatusNotFound, rr.Code)
+	assertProblem(suite.T(), rr, "project_not_found", "project not found")
+
+	// Assert log
+	suite.Empty(lbuf.String())
+}
+
+func (suite *HandlerSuite) TestUpdateProjectSuccess() {
+	// Prepare
+	now := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+	createdAt := time.Date(2025, 2, 1, 9, 0, 0, 0, time.UTC)
+	id := uuid.New()
+	r := testutils.NewTaskRepository(testutils.TaskRepositoryWithProject(&aggregators.Project{ID: id, Name: "work", CreatedAt: createdAt, UpdatedAt: createdAt, Version: 1}))
+	s := domain.NewService(r)
+	lbuf, log := testutils.NewLogger()
+	pr := testutils.NewProjectRepository(r)
+	wr := testutils.NewWebhookRepository()
+	ps := domain.NewProjectService(pr, domain.ProjectServiceWithClock(func() time.Time { return now }))
+	h := application.APIHandler(application.Config{DefaultPageSize: 20, MaxPageSize: 100, DefaultWorkspace: true}, log, s, r, api.HandlerWithProjects(ps, pr), api.HandlerWithWebhooks(domain.NewWebhookService(wr), wr))
+
+	req := httptest.NewRequest(oghttp.MethodPatch, "/api/projects/"+id.String(), strings.NewReader(`{"name":"office"}`))
+	rr := httptest.NewRecorder()
+
+	// Execute
+	h.ServeHTTP(rr, req)
+
+	// Assert state
+	suite.Equal("office", r.ProjectRecords[id].Name)
+
+	// Assert result
+	suite.Equal(oghttp.StatusOK, rr.Code)
+	suite.Equal(`{"id":"`+id.String()+`","name":"office","inbox":false,"created_at":"2025-02-01T09:00:00Z","updated_at":"2025-03-01T12:00:00Z","version":2}`+"\n", rr.Body.String())
+
+	// Assert log
+	suite.Empty(lbuf.String())
+}
+
+func (suite *HandlerSuite) TestDeleteProjectSuccess() {
+	// Prepare
+	id, taskID := uuid.New(), uuid.New()
+	r := testutils.NewTaskRepository(
+		testutils.TaskRepositoryWithProject(&aggregators.Project{ID: id, Name: "work", Version: 1}),
+		testutils.TaskRepositoryWithTask(&aggregators.Task{ID: taskID, ProjectID: id, Title: "task 1", Status: "todo", Version: 1}),
+	)
+	s := domain.NewService(r)
+	lbuf, log := testutils.NewLogger()
+	pr := testutils.NewProjectRepository(r)
+	wr := testutils.NewWebhookRepository()
+	h := application.APIHandler(application.Config{DefaultPageSize: 20, MaxPageSize: 100, DefaultWorkspace: true}, log, s, r, api.HandlerWithProjects(domain.NewProjectService(pr), pr), api.HandlerWithWebhooks(domain.NewWebhookService(wr), wr))
+
+	req := httptest.NewRequest(oghttp.MethodDelete, "/api/projects/"+id.String(), nil)
+	rr := httptest.NewRecorder()
+
+	// Execute
+	h.ServeHTTP(rr, req)
+
+	// Assert state
+	suite.NotContains(r.ProjectRecords, id)
+	suite.Equal(aggregators.InboxProjectID, r.Records[taskID].ProjectID)
+
+	// Assert result
+	suite.Equal(oghttp.StatusNoContent, rr.Code)
+	suite.Empty(rr.Body.String())
+
+	// Assert log
+	suite.Empty(lbuf.String())
+}
+
+func (suite *HandlerSuite) TestDeleteProjectFail() {
+	tests := []struct {
+		name    string
+		id      string
+		status  int
+		code    string
+		message string
+	}{
+		{name: "invalid id", id: "abc", status: oghttp.StatusBadRequest, code: "invalid_project_id", message: "invalid project ID"},
+		{name: "unknown project", id: "3b153945-36fb-43c2-9bc7-c893add07d38", status: oghttp.StatusNotFound, code: "project_not_found", message: "project not found: 3b153945-36fb-43c2-9bc7-c893add07d38"},
+		{name: "inbox", id: "00000000-0000-0000-0000-000000000001", status: oghttp.StatusConflict, code: "inbox_project", message: "inbox project cannot be changed: 00000000-0000-0000-0000-000000000001"},
+	}
+
+	for _, tt := range tests {
+		suite.Run(tt.name, func() {
+			// Prepare
+			r := testutils.NewTaskRepository()
+			s := domain.NewService(r)
+			lbuf, log := testutils.NewLogger()
+			pr := testutils.NewProjectRepository(r)
+			wr := testutils.NewWebhookRepository()
+			h := application.APIHandler(application.Config{DefaultPageSize: 20, MaxPageSize: 100, DefaultWorkspace: true}, log, s, r, api.HandlerWithProjects(domain.NewProjectService(pr), pr), api.HandlerWithWebhooks(domain.NewWebhookService(wr), wr))
+
+			req := httptest.NewRequest(oghttp.MethodDelete, "/api/projects/"+tt.id, nil)
+			rr := httptest.NewRecorder()
+
+			// Execute
+			h.ServeHTTP(rr, req)
+
+			// Assert state
+			suite.Len(r.ProjectRecords, 1)
+
+			// Assert result
+			suite.Equal(tt.status, rr.Code)
+			assertProblem(suite.T(), rr, tt.code, tt.message)
+
+			// Assert log
+			suite.Empty(lbuf.String())
+		})
+	}
+}
+
+func (suite *HandlerSuite) TestProjectTasksSuccess() {
+	// Prepare
+	projectID, id1, id2 := uuid.New(), uuid.New(), uuid.New()
+	r := testutils.NewTaskRepository(
+		testutils.TaskRepositoryWithProject(&aggregators.Project{ID: projectID, Name: "work", Version: 1}),
+		testutils.TaskRepositoryWithTask(&aggregators.Task{ID: id1, ProjectID: projectID, Title: "task 1", Status: "todo", Version: 1}),
+		testutils.TaskRepositoryWithTask(&aggregators.Task{ID: id2, Title: "task 2", Status: "todo", Version: 1}),
+	)
+	s := domain.NewService(r)
+	lbuf, log := testutils.NewLogger()
+	pr := testutils.NewProjectRepository(r)
+	wr := testutils.NewWebhookRepository()
+	h := application.APIHandler(application.Config{DefaultPageSize: 20, MaxPageSize: 100, DefaultWorkspace: true}, log, s, r, api.HandlerWithProjects(domain.NewProjectService(pr), pr), api.HandlerWithWebhooks(domain.NewWebhookService(wr), wr))
+
+	req := httptest.NewRequest(oghttp.MethodGet, "/api/projects/"+projectID.String()+"/tasks", nil)
+	rr := httptest.NewRecorder()
+
+	// Execute
+	h.ServeHTTP(rr, req)
+
+	// Assert result
+	suite.Equal(oghttp.StatusOK, rr.Code)
+	suite.Equal(`{"tasks":[{"id":"`+id1.String()+`","project_id":"`+projectID.String()+`","title":"task 1","status":"todo",`+
+		`"created_at":"0001-01-01T00:00:00Z","updated_at":"0001-01-01T00:00:00Z","version":1}]}`+"\n", rr.Body.String())
+
+	// Assert log
+	suite.Empty(lbuf.String())
+}
+
+func (suite *HandlerSuite) TestProjectTasksNotFound() {
+	// Prepare
+	r := testutils.NewTaskRepository()
+	s := domain.NewService(r)
+	lbuf, log := testutils.NewLogger()
+	pr := testutils.NewProjectRepository(r)
+	wr := testutils.NewWebhookRepository()
+	h := application.APIHandler(application.Config{DefaultPageSize: 20, MaxPageSize: 100, DefaultWorkspace: true}, log, s, r, api.HandlerWithProjects(domain.NewProjectService(pr), pr), api.HandlerWithWebhooks(domain.NewWebhookService(wr), wr))
+
+	req := httptest.NewRequest(oghttp.MethodGet, "/api/projects/"+uuid.New().String()+"/tasks", nil)
+	rr := httptest.NewRecorder()
+
+	// Execute
+	h.ServeHTTP(rr, req)
+
+	// Assert result
+	suite.Equal(oghttp.StatusNotFound, rr.Code)
+	assertProblem(suite.T(), rr, "project_not_found", "project not found")
+
+	// Assert log
+	suite.Empty(lbuf.String())
+}
+
+func (suite *HandlerSuite) TestCreateInUnknownProjectFail() {
+	// Prepare
+	r := testutils.NewTaskRepository()
+	s := domain.NewService(r)
+	lbuf, log := testutils.NewLogger()
+	pr := testutils.NewProjectRepository(r)
+	wr := testutils.NewWebhookRepository()
+	h := application.APIHandler(application.Config{DefaultPageSize: 20, MaxPageSize: 100, DefaultWorkspace: true}, log, s, r, api.HandlerWithProjects(domain.NewProjectService(pr), pr), api.HandlerWithWebhooks(domain.NewWebhookService(wr), wr))
+
+	req := httptest.NewRequest(oghttp.MethodPost, "/api/tasks", strings.NewReader(`{"title":"task 1","project_id":"3b153945-36fb-43c2-9bc7-c893add07d38"}`))
+	rr := httptest.NewRecorder()
+
+	// Execute
+	h.ServeHTTP(rr, req)
+
+	// Assert state
+	suite.Empty(r.Records)
+
+	// Assert result
+	suite.Equal(oghttp.StatusNotFound, rr.Code)
+	assertProblem(suite.T(), rr, "project_not_found", "project not found: 3b153945-36fb-43c2-9bc7-c893add07d38")
+
+	// Assert log
+	suite.Empty(lbuf.String())
+}
+
+func (suite *HandlerSuite) TestWorkspaceIsolation() {
+	// Prepare
+	id := uuid.New()
+	other := uuid.New()
+	r := testutils.NewTaskRepository(testutils.TaskRepositoryWithWorkspaceTask(uuid.MustParse("3b153945-36fb-43c2-9bc7-c893add07d38"), &aggregators.Task{ID: id, Title: "task 1", Status: "todo", Version: 1}))
+	s := domain.NewService(r)
+	lbuf, log := testutils.NewLogger()
+	pr := testutils.NewProjectRepository(r)
+	wr := testutils.NewWebhookRepository()
+	h := application.APIHandler(application.Config{DefaultPageSize: 20, MaxPageSize: 100, DefaultWorkspace: true}, log, s, r, api.HandlerWithProjects(domain.NewProjectService(pr), pr), api.HandlerWithWebhooks(domain.NewWebhookService(wr), wr))
+
+	find := httptest.NewRequest(oghttp.MethodGet, "/api/tasks/"+id.String(), nil)
+	find.Header.Set("X-Workspace-ID", other.String())
+	frr := httptest.NewRecorder()
+	list := httptest.NewRequest(oghttp.MethodGet, "/api/tasks", nil)
+	list.Header.Set("X-Workspace-ID", other.String())
+	lrr := httptest.NewRecorder()
+	own := httptest.NewRequest(oghttp.MethodGet, "/api/tasks/"+id.String(), nil)
+	own.Header.Set("X-Workspace-ID", "3b153945-36fb-43c2-9bc7-c893add07d38")
+	orr := httptest.NewRecorder()
+
+	// Execute
+	h.ServeHTTP(frr, find)
+	h.ServeHTTP(lrr, list)
+	h.ServeHTTP(orr, own)
+
+	// Assert result
+	suite.Equal(oghttp.StatusNotFound, frr.Code)
+	assertProblem(suite.T(), frr, "task_not_found", "task not found")
+	suite.Equal(oghttp.StatusOK, lrr.Code)
+	suite.Equal(`{"tasks":[]}`+"\n", lrr.Body.String())
+	suite.Equal(oghttp.StatusOK, orr.Code)
+
+	// Assert log
+	suite.Empty(lbuf.String())
+}
+
+func (suite *HandlerSuite) TestCreateInWorkspaceSuccess() {
+	// Prepare
+	workspaceID := uuid.New()
+	r := testutils.NewTaskRepository()
+	s := domain.NewService(r)
+	lbuf, log := testutils.NewLogger()
+	pr := testutils.NewProjectRepository(r)
+	wr := testutils.NewWebhookRepository()
+	h := application.APIHandler(application.Config{DefaultPageSize: 20, MaxPageSize: 100, DefaultWorkspace: true}, log, s, r, api.HandlerWithProjects(domain.NewProjectService(pr), pr), api.HandlerWithWebhooks(domain.NewWebhookService(wr), wr))
+
+	req := httptest.NewRequest(oghttp.MethodPost, "/api/tasks", strings.NewReader(`{"title":"task 1"}`))
+	req.Header.Set("X-Workspace-ID", workspaceID.String())
+	rr := httptest.NewRecorder()
+
+	// Execute
+	h.ServeHTTP(rr, req)
+
+	// Assert state
+	suite.Len(r.Records, 1)
+	var task *aggregators.Task
+	for _, t := range r.Records {
+		task = t
+	}
+	suite.NotNil(task)
+	_, err := r.Find(infrastructure.WithWorkspace(context.Background(), workspaceID), task.ID)
+	suite.NoError(err)
+	_, err = r.Find(context.Background(), task.ID)
+	suite.ErrorIs(err, infrastructure.ErrTaskNotFound)
+
+	// Assert result
+	suite.Equal(oghttp.StatusCreated, rr.Code)
+
+	// Assert log
+	suite.Empty(lbuf.String())
+}
+
+func (suite *HandlerSuite) TestInvalidWorkspace() {
+	// Prepare
+	r := testutils.NewTaskRepository()
+	s := domain.NewService(r)
+	lbuf, log := testutils.NewLogger()
+	pr := testutils.NewProjectRepository(r)
+	wr := testutils.NewWebhookRepository()
+	h := application.APIHandler(application.Config{DefaultPageSize: 20, MaxPageSize: 100}, log, s, r, api.HandlerWithProjects(domain.NewProjectService(pr), pr), api.HandlerWithWebhooks(domain.NewWebhookService(wr), wr))
+
+	req := httptest.NewRequest(oghttp.MethodGet, "/api/tasks", nil)
+	req.Header.Set("X-Workspace-ID", "not-a-uuid")
+	rr := httptest.NewRecorder()
+
+	// Execute
+	h.ServeHTTP(rr, req)
+
+	// Assert result
+	suite.Equal(oghttp.StatusBadRequest, rr.Code)
+	suite.Equal("application/problem+json", rr.Header().Get("Content-Type"))
+	assertProblem(suite.T(), rr, "invalid_workspace", "invalid workspace ID")
+
+	// Assert log
+	suite.Empty(lbuf.String())
+}
+
+func (suite *HandlerSuite) TestMissingWorkspace() {
+	// Prepare
+	r := testutils.NewTaskRepository()
+	s := domain.NewService(r)
+	lbuf, log := testutils.NewLogger()
+	h := application.APIHandler(application.Config{DefaultPageSize: 20, MaxPageSize: 100}, log, s, r)
+
+	req := httptest.NewRequest(oghttp.MethodPost, "/api/tasks", strings.NewReader(`{"title":"task 1"}`))
+	rr := httptest.NewRecorder()
+
+	// Execute
+	h.ServeHTTP(rr, req)
+
+	// Assert state
+	suite.Empty(r.Records)
+
+	// Assert result
+	suite.Equal(oghttp.StatusBadRequest, rr.Code)
+	assertProblem(suite.T(), rr, "workspace_required", "X-Workspace-ID is required")
+
+	// Assert log
+	suite.Empty(lbuf.String())
+}
+
+func (suite *HandlerSuite) TestHistorySuccess() {
+	// Prepare
+	r := testutils.NewTaskRepository()
+	now := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+	s := domain.NewService(r, domain.ServiceWithClock(func() time.Time { return now }))
+	lbuf, log := testutils.NewLogger()
+	pr := testutils.NewProjectRepository(r)
+	wr := testutils.NewWebhookRepository()
+	h := application.APIHandler(application.Config{DefaultPageSize: 20, MaxPageSize: 100, DefaultWorkspace: true}, log, s, r, api.HandlerWithProjects(domain.NewProjectService(pr), pr), api.HandlerWithWebhooks(domain.NewWebhookService(wr), wr))
+
+	task, err := s.Create(context.Background(), domain.TaskCreate{Title: "task 1"})
+	suite.Require().NoError(err)
+	now = now.Add(time.Hour)
+	complete := httptest.NewRequest(oghttp.MethodPut, "/api/tasks/"+task.ID.String()+"/complete", nil)
+	complete.Header.Set("X-Actor", "jane")
+	h.ServeHTTP(httptest.NewRecorder(), complete)
+
+	req := httptest.NewRequest(oghttp.MethodGet, "/api/tasks/"+task.ID.String()+"/history?limit=1", nil)
+	rr := httptest.NewRecorder()
+
+	// Execute
+	h.ServeHTTP(rr, req)
+
+	// Assert result
+	suite.Equal(oghttp.StatusOK, rr.Code)
+	suite.Equal("application/json", rr.Header().Get("Content-Type"))
+	var resp api.TaskHistoryResponse
+	suite.NoError(json.Unmarshal(rr.Body.Bytes(), &resp))
+	suite.Require().Len(resp.Changes, 1)
+	suite.Equal(task.ID, resp.Changes[0].TaskID)
+	suite.Equal("created", resp.Changes[0].Action)
+	suite.Equal("anonymous", resp.Changes[0].Actor)
+	suite.JSONEq("null", string(resp.Changes[0].Before))
+	suite.NotEmpty(resp.NextCursor)
+
+	// Execute next page
+	req = httptest.NewRequest(oghttp.MethodGet, "/api/tasks/"+task.ID.String()+"/history?limit=1&cursor="+resp.NextCursor, nil)
+	rr = httptest.NewRecorder()
+	h.ServeHTTP(rr, req)
+
+	// Assert next page
+	suite.Equal(oghttp.StatusOK, rr.Code)
+	resp = api.TaskHistoryResponse{}
+	suite.NoError(json.Unmarshal(rr.Body.Bytes(), &resp))
+	suite.Require().Len(resp.Changes, 1)
+	suite.Equal("completed", resp.Changes[0].Action)
+	suite.Equal("jane", resp.Changes[0].Actor)
+	suite.Equal(time.Date(2025, 3, 1, 13, 0, 0, 0, time.UTC), resp.Changes[0].CreatedAt)
+	suite.Contains(string(resp.Changes[0].Before), `"status":"todo"`)
+	suite.Contains(string(resp.Changes[0].After), `"status":"done"`)
+	suite.Empty(resp.NextCursor)
+
+	// Assert log
+	suite.Empty(lbuf.String())
+}
+
+func (suite *HandlerSuite) TestHistoryNotFound() {
+	// Prepare
+	r := testutils.NewTaskRepository()
+	s := domain.NewService(r)
+	lbuf, log := testutils.NewLogger()
+	pr := testutils.NewProjectRepository(r)
+	wr := testutils.NewWebhookRepository()
+	h := application.APIHandler(application.Config{DefaultPageSize: 20, MaxPageSize: 100, DefaultWorkspace: true}, log, s, r, api.HandlerWithProjects(domain.NewProjectService(pr), pr), api.HandlerWithWebhooks(domain.NewWebhookService(wr), wr))
+
+	req := httptest.NewRequest(oghttp.MethodGet, "/api/tasks/"+uuid.New().String()+"/history", nil)
+	rr := httptest.NewRecorder()
+
+	// Execute
+	h.ServeHTTP(rr, req)
+
+	// Assert result
+	suite.Equal(oghttp.StatusNotFound, rr.Code)
+	assertProblem(suite.T(), rr, "task_not_found", "task not found")
+
+	// Assert log
+	suite.Empty(lbuf.String())
+}
+
+func (suite *HandlerSuite) TestHistoryRepositoryFail() {
+	// Prepare
+	r := testutils.NewTaskRepository(testutils.TaskRepositoryWithError(errors.New("boom!")))
+	s := domain.NewService(r)
+	lbuf, log := testutils.NewLogger()
+	pr := testutils.NewProjectRepository(r)
+	wr := testutils.NewWebhookRepository()
+	h := application.APIHandler(application.Config{DefaultPageSize: 20, MaxPageSize: 100, DefaultWorkspace: true}, log, s, r, api.HandlerWithProjects(domain.NewProjectService(pr), pr), api.HandlerWithWebhooks(domain.NewWebhookService(wr), wr))
+
+	req := httptest.NewRequest(oghttp.MethodGet, "/api/tasks/"+uuid.New().String()+"/history", nil)
+	rr := httptest.NewRecorder()
+
+	// Execute
+	h.ServeHTTP(rr, req)
+
+	// Assert result
+	suite.Equal(oghttp.StatusInternalServerError, rr.Code)
+	assertProblem(suite.T(), rr, "internal_error", "")
+
+	// Assert log
+	suite.Contains(lbuf.String(), "boom!")
+}
+
+func (suite *HandlerSuite) TestCreateWebhookSuccess() {
+	// Prepare
+	r := testutils.NewTaskRepository()
+	s := domain.NewService(r)
+	lbuf, log := testutils.NewLogger()
+	pr := testutils.NewProjectRepository(r)
+	wr := testutils.NewWebhookRepository()
+	now := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+	ws := domain.NewWebhookService(wr, domain.WebhookServiceWithClock(func() time.Time { return now }))
+	h := application.APIHandler(application.Config{DefaultPageSize: 20, MaxPageSize: 100, DefaultWorkspace: true}, log, s, r, api.HandlerWithProjects(domain.NewProjectService(pr), pr), api.HandlerWithWebhooks(ws, wr))
+
+	req := httptest.NewRequest(oghttp.MethodPost, "/api/webhooks", strings.NewReader(`{"url":"https://example.com/hooks","events":["task.created"],"secret":"s3cret"}`))
+	rr := httptest.NewRecorder()
+
+	// Execute
+	h.ServeHTTP(rr, req)
+
+	// Assert state
+	suite.Require().Len(wr.Records, 1)
+	var webhook *aggregators.Webhook
+	for _, w := range wr.Records {
+		webhook = w
+	}
+	suite.Equal("https://example.com/hooks", webhook.URL)
+	suite.Equal("s3cret", webhook.Secret)
+
+	// Assert result
+	suite.Equal(oghttp.StatusCreated, rr.Code)
+	suite.Equal(`{"id":"`+webhook.ID.String()+`","url":"https://example.com/hooks","events":["task.created"],"created_at":"2025-03-01T12:00:00Z","secret":"s3cret"}`+"\n", rr.Body.String())
+
+	// Assert log
+	suite.Empty(lbuf.String())
+}
+
+func (suite *HandlerSuite) TestCreateWebhookFail() {
+	tests := []struct {
+		name    string
+		body    string
+		code    string
+		message string
+	}{
+		{name: "invalid body", body: `{`, code: "invalid_request", message: "invalid request body"},
+		{name: "invalid url", body: `{"url":"example.com"}`, code: "invalid_webhook_url", message: "webhook URL must be an absolute http or https URL"},
+		{name: "unknown event", body: `{"url":"https://example.com","events":["task.exploded"]}`, code: "invalid_event_type", message: "unknown event type: task.exploded"},
+	}
+
+	for _, tt := range tests {
+		suite.Run(tt.name, func() {
+			// Prepare
+			r := testutils.NewTaskRepository()
+			s := domain.NewService(r)
+			lbuf, log := testutils.NewLogger()
+			pr := testutils.NewProjectRepository(r)
+			wr := testutils.NewWebhookRepository()
+			h := application.APIHandler(application.Config{DefaultPageSize: 20, MaxPageSize: 100, DefaultWorkspace: true}, log, s, r, api.HandlerWithProjects(domain.NewProjectService(pr), pr), api.HandlerWithWebhooks(domain.NewWebhookService(wr), wr))
+
+			req := httptest.NewRequest(oghttp.MethodPost, "/api/webhooks", strings.NewReader(tt.body))
+			rr := httptest.NewRecorder()
+
+			// Execute
+			h.ServeHTTP(rr, req)
+
+			// Assert state
+			suite.Empty(wr.Records)
+
+			// Assert result
+			suite.Equal(oghttp.StatusBadRequest, rr.Code)
+			assertProblem(suite.T(), rr, tt.code, tt.message)
+
+			// Assert log
+			suite.Empty(lbuf.String())
+		})
+	}
+}
+
+func (suite *HandlerSuite) TestAllWebhooksSuccess() {
+	// Prepare
+	id := uuid.New()
+	r := testutils.NewTaskRepository()
+	s := domain.NewService(r)
+	lbuf, log := testutils.NewLogger()
+	pr := testutils.NewProjectRepository(r)
+	wr := testutils.NewWebhookRepository(testutils.WebhookRepositoryWithWebhook(&aggregators.Webhook{
+		ID: id, URL: "https://example.com/hooks", Events: []string{}, Secret: "s3cret", CreatedAt: time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC),
+	}))
+	suite.NoError(wr.Save(infrastructure.WithWorkspace(context.Background(), uuid.New()), &aggregators.Webhook{ID: uuid.New(), URL: "https://example.org"}))
+	h := application.APIHandler(application.Config{DefaultPageSize: 20, MaxPageSize: 100, DefaultWorkspace: true}, log, s, r, api.HandlerWithProjects(domain.NewProjectService(pr), pr), api.HandlerWithWebhooks(domain.NewWebhookService(wr), wr))
+
+	req := httptest.NewRequest(oghttp.MethodGet, "/api/webhooks", nil)
+	rr := httptest.NewRecorder()
+
+	// Execute
+	h.ServeHTTP(rr, req)
+
+	// Assert result
+	suite.Equal(oghttp.StatusOK, rr.Code)
+	suite.Equal(`{"webhooks":[{"id":"`+id.String()+`","url":"https://example.com/hooks","events":[],"created_at":"2025-03-01T12:00:00Z"}]}`+"\n", rr.Body.String())
+
+	// Assert log
+	suite.Empty(lbuf.String())
+}
+
+func (suite *HandlerSuite) TestFindWebhookNotFound() {
+	// Prepare
+	id := uuid.New()
+	r := testutils.NewTaskRepository()
+	s := domain.NewService(r)
+	lbuf, log := testutils.NewLogger()
+	pr := testutils.NewProjectRepository(r)
+	wr := testutils.NewWebhookRepository(testutils.WebhookRepositoryWithWebhook(&aggregators.Webhook{ID: id, URL: "https://example.com"}))
+	h := application.APIHandler(application.Config{DefaultPageSize: 20, MaxPageSize: 100, DefaultWorkspace: true}, log, s, r, api.HandlerWithProjects(domain.NewProjectService(pr), pr), api.HandlerWithWebhooks(domain.NewWebhookService(wr), wr))
+
+	req := httptest.NewRequest(oghttp.MethodGet, "/api/webhooks/"+id.String(), nil)
+	req.Header.Set("X-Workspace-ID", uuid.New().String())
+	rr := httptest.NewRecorder()
+
+	// Execute
+	h.ServeHTTP(rr, req)
+
+	// Assert result
+	suite.Equal(oghttp.StatusNotFound, rr.Code)
+	assertProblem(suite.T(), rr, "webhook_not_found", "webhook not found")
+
+	// Assert log
+	suite.Empty(lbuf.String())
+}
+
+func (suite *HandlerSuite) TestDeleteWebhookSuccess() {
+	// Prepare
+	id := uuid.New()
+	r := testutils.NewTaskRepository()
+	s := domain.NewService(r)
+	lbuf, log := testutils.NewLogger()
+	pr := testutils.NewProjectRepository(r)
+	wr := testutils.NewWebhookRepository(testutils.WebhookRepositoryWithWebhook(&aggregators.Webhook{ID: id, URL: "https://example.com"}))
+	h := application.APIHandler(application.Config{DefaultPageSize: 20, MaxPageSize: 100, DefaultWorkspace: true}, log, s, r, api.HandlerWithProjects(domain.NewProjectService(pr), pr), api.HandlerWithWebhooks(domain.NewWebhookService(wr), wr))
+
+	req := httptest.NewRequest(oghttp.MethodDelete, "/api/webhooks/"+id.String(), nil)
+	rr := httptest.NewRecorder()
+
+	// Execute
+	h.ServeHTTP(rr, req)
+
+	// Assert state
+	suite.Empty(wr.Records)
+
+	// Assert result
+	suite.Equal(oghttp.StatusNoContent, rr.Code)
+	suite.Empty(rr.Body.String())
+
+	// Assert log
+	suite.Empty(lbuf.String())
+}
+
+func (suite *HandlerSuite) TestWebhookDeliveriesSuccess() {
+	// Prepare
+	id, deliveryID, eventID := uuid.New(), uuid.New(), uuid.New()
+	code, attemptedAt := 500, time.Date(2025, 3, 1, 12, 0, 1, 0, time.UTC)
+	reason := "unexpected response status 500"
+	r := testutils.NewTaskRepository()
+	s := domain.NewService(r)
+	lbuf, log := testutils.NewLogger()
+	pr := testutils.NewProjectRepository(r)
+	wr := testutils.NewWebhookRepository(
+		testutils.WebhookRepositoryWithWebhook(&aggregators.Webhook{ID: id, URL: "https://example.com"}),
+		testutils.WebhookRepositoryWithDelivery(&aggregators.WebhookDelivery{
+			ID: deliveryID, WebhookID: id, EventID: eventID, EventType: "task.created", Payload: []byte(`{}`), Failures: 1,
+			CreatedAt: time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC),
+			Attempts:  []*aggregators.WebhookAttempt{{ID: uuid.Nil, Status: "failed", ResponseCode: &code, Error: &reason, AttemptedAt: attemptedAt}},
+		}),
+	)
+	h := application.APIHandler(application.Config{DefaultPageSize: 20, MaxPageSize: 100, DefaultWorkspace: true}, log, s, r, api.HandlerWithProjects(domain.NewProjectService(pr), pr), api.HandlerWithWebhooks(domain.NewWebhookService(wr), wr))
+
+	req := httptest.NewRequest(oghttp.MethodGet, "/api/webhooks/"+id.String()+"/deliveries", nil)
+	rr := httptest.NewRecorder()
+
+	// Execute
+	h.ServeHTTP(rr, req)
+
+	// Assert result
+	suite.Equal(oghttp.StatusOK, rr.Code)
+	suite.Equal(`{"deliveries":[{"id":"`+deliveryID.String()+`","webhook_id":"`+id.String()+`","event_id":"`+eventID.String()+`","event_type":"task.created","payload":{},"status":"pending","failures":1,"created_at":"2025-03-01T12:00:00Z",`+
+		`"attempts":[{"id":"00000000-0000-0000-0000-000000000000","status":"failed","response_code":500,"error":"unexpected response status 500","attempted_at":"2025-03-01T12:00:01Z"}]}]}`+"\n", rr.Body.String())
+
+	// Assert log
+	suite.Empty(lbuf.String())
+}
+
+func (suite *HandlerSuite) TestWebhookDeliveriesNotFound() {
+	// Prepare
+	r := testutils.NewTaskRepository()
+	s := domain.NewService(r)
+	lbuf, log := testutils.NewLogger()
+	pr := testutils.NewProjectRepository(r)
+	wr := testutils.NewWebhookRepository()
+	h := application.APIHandler(application.Config{DefaultPageSize: 20, MaxPageSize: 100, DefaultWorkspace: true}, log, s, r, api.HandlerWithProjects(domain.NewProjectService(pr), pr), api.HandlerWithWebhooks(domain.NewWebhookService(wr), wr))
+
+	req := httptest.NewRequest(oghttp.MethodGet, "/api/webhooks/"+uuid.New().String()+"/deliveries", nil)
+	rr := httptest.NewRecorder()
+
+	// Execute
+	h.ServeHTTP(rr, req)
+
+	// Assert result
+	suite.Equal(oghttp.StatusNotFound, rr.Code)
+	assertProblem(suite.T(), rr, "webhook_not_found", "webhook not found")
+
+	// Assert log
+	suite.Empty(lbuf.String())
+}
+
+func (suite *HandlerSuite) TestRedeliverSuccess() {
+	// Prepare
+	id, deliveryID := uuid.New(), uuid.New()
+	r := testutils.NewTaskRepository()
+	s := domain.NewService(r)
+	lbuf, log := testutils.NewLogger()
+	pr := testutils.NewProjectRepository(r)
+	wr := testutils.NewWebhookRepository(
+		testutils.WebhookRepositoryWithWebhook(&aggregators.Webhook{ID: id, URL: "https://example.com"}),
+		testutils.WebhookRepositoryWithDelivery(&aggregators.WebhookDelivery{ID: deliveryID, WebhookID: id, Status: "dead", Failures: 8}),
+	)
+	h := application.APIHandler(application.Config{DefaultPageSize: 20, MaxPageSize: 100, DefaultWorkspace: true}, log, s, r, api.HandlerWithProjects(domain.NewProjectService(pr), pr), api.HandlerWithWebhooks(domain.NewWebhookService(wr), wr))
+
+	req := httptest.NewRequest(oghttp.MethodPost, "/api/webhooks/"+id.String()+"/deliveries/"+deliveryID.String()+"/redeliver", nil)
+	rr := httptest.NewRecorder()
+
+	// Execute
+	h.ServeHTTP(rr, req)
+
+	// Assert state
+	suite.Equal("pending", wr.DeliveryRecords[0].Status)
+	suite.Equal(0, wr.DeliveryRecords[0].Failures)
+
+	// Assert result
+	suite.Equal(oghttp.StatusAccepted, rr.Code)
+	suite.Empty(rr.Body.String())
+
+	// Assert log
+	suite.Empty(lbuf.String())
+}
+
+func (suite *HandlerSuite) TestRedeliverNotFound() {
+	// Prepare
+	id := uuid.New()
+	r := testutils.NewTaskRepository()
+	s := domain.NewService(r)
+	lbuf, log := testutils.NewLogger()
+	pr := testutils.NewProjectRepository(r)
+	wr := testutils.NewWebhookRepository(testutils.WebhookRepositoryWithWebhook(&aggregators.Webhook{ID: id, URL: "https://example.com"}))
+	h := application.APIHandler(application.Config{DefaultPageSize: 20, MaxPageSize: 100, DefaultWorkspace: true}, log, s, r, api.HandlerWithProjects(domain.NewProjectService(pr), pr), api.HandlerWithWebhooks(domain.NewWebhookService(wr), wr))
+
+	deliveryID := uuid.New()
+	req := httptest.NewRequest(oghttp.MethodPost, "/api/webhooks/"+id.String()+"/deliveries/"+deliveryID.String()+"/redeliver", nil)
+	rr := httptest.NewRecorder()
+
+	// Execute
+	h.ServeHTTP(rr, req)
+
+	// Assert result
+	suite.Equal(oghttp.StatusNotFound, rr.Code)
+	assertProblem(suite.T(), rr, "delivery_not_found", "webhook delivery not found: "+deliveryID.String())
+
+	// Assert log
+	suite.Empty(lbuf.String())
+}
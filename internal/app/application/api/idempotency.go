@@ -0,0 +1,125 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"github.com/aviseu/go-sample/internal/app/infrastructure"
+	"github.com/aviseu/go-sample/internal/app/infrastructure/aggregators"
+	"github.com/google/uuid"
+	"io"
+	"net/http"
+	"time"
+)
+
+type IdempotencyRepository interface {
+	Claim(ctx context.Context, key, fingerprint string, lease, ttl time.Duration) (uuid.UUID, *aggregators.IdempotentResponse, error)
+	Complete(ctx context.Context, key string, token uuid.UUID, resp *aggregators.IdempotentResponse) error
+	Release(ctx context.Context, key string, token uuid.UUID) error
+}
+
+// idempotencyLease is how long a request holds on to its idempotency key before
+// a repeat may assume it died and take over.
+const idempotencyLease = time.Minute
+
+const maxIdempotencyKeyLength = 255
+
+// maxIdempotentBodySize is the largest request body that is read into memory to
+// fingerprint a request with an idempotency key.
+const maxIdempotentBodySize = 1 << 20
+
+// idempotent handles a request with an Idempotency-Key header only once and
+// replays the response to its repeats. A repeat that arrives while the request
+// is still being handled is turned away with a conflict, and reusing a key for
+// a different request fails, as does a body larger than maxIdempotentBodySize.
+// Server errors are not kept, so the request can be retried with the same key.
+func (h *Handler) idempotent(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		key := r.Header.Get("Idempotency-Key")
+		if key == "" || h.ir == nil {
+			next.ServeHTTP(w, r)
+			return
+		}
+		if len(key) > maxIdempotencyKeyLength {
+			h.handleFail(w, r, ErrInvalidIdempotencyKey)
+			return
+		}
+
+		body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxIdempotentBodySize))
+		if err != nil {
+			var tooLarge *http.MaxBytesError
+			if errors.As(err, &tooLarge) {
+				h.handleFail(w, r, ErrRequestTooLarge)
+				return
+			}
+
+			h.handleFail(w, r, ErrInvalidRequest)
+			return
+		}
+		r.Body = io.NopCloser(bytes.NewReader(body))
+
+		token, resp, err := h.ir.Claim(r.Context(), key, fingerprint(r, body), idempotencyLease, h.idempotencyTTL)
+		if err != nil {
+			if errors.Is(err, infrastructure.ErrIdempotencyKeyInFlight) {
+				w.Header().Set("Retry-After", "1")
+			}
+
+			h.handleFail(w, r, err)
+			return
+		}
+		if resp != nil {
+			for k, v := range resp.Header {
+				w.Header()[k] = v
+			}
+			w.Header().Set("Idempotent-Replayed", "true")
+			w.WriteHeader(resp.StatusCode)
+			_, _ = w.Write(resp.Body)
+			return
+		}
+
+		rec := &recorder{ResponseWriter: w, status: http.StatusOK}
+		next.ServeHTTP(rec, r)
+
+		// the client may be gone by now, which is exactly when a retry follows
+		ctx := context.WithoutCancel(r.Context())
+		if rec.status >= http.StatusInternalServerError {
+			if err := h.ir.Release(ctx, key, token); err != nil {
+				h.log.Error(err.Error())
+			}
+			return
+		}
+
+		resp = &aggregators.IdempotentResponse{StatusCode: rec.status, Header: w.Header().Clone(), Body: rec.body.Bytes()}
+		if err := h.ir.Complete(ctx, key, token, resp); err != nil {
+			h.log.Error(err.Error())
+		}
+	})
+}
+
+// fingerprint identifies a request by its method, path and body.
+func fingerprint(r *http.Request, body []byte) string {
+	hash := sha256.New()
+	_, _ = io.WriteString(hash, r.Method+" "+r.URL.Path+"\n")
+	_, _ = hash.Write(body)
+
+	return hex.EncodeToString(hash.Sum(nil))
+}
+
+// recorder keeps a copy of the response it passes on.
+type recorder struct {
+	http.ResponseWriter
+	status int
+	body   bytes.Buffer
+}
+
+func (r *recorder) WriteHeader(status int) {
+	r.status = status
+	r.ResponseWriter.WriteHeader(status)
+}
+
+func (r *recorder) Write(b []byte) (int, error) {
+	r.body.Write(b)
+	return r.ResponseWriter.Write(b)
+}
@@ -0,0 +1,222 @@
+package api_test
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"github.com/aviseu/go-sample/internal/app/application"
+	"github.com/aviseu/go-sample/internal/app/application/api"
+	"github.com/aviseu/go-sample/internal/app/domain"
+	"github.com/aviseu/go-sample/internal/app/infrastructure"
+	"github.com/aviseu/go-sample/internal/testutils"
+	"github.com/stretchr/testify/suite"
+	oghttp "net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestIdempotency(t *testing.T) {
+	t.Parallel()
+	suite.Run(t, new(IdempotencySuite))
+}
+
+type IdempotencySuite struct {
+	suite.Suite
+}
+
+func (suite *IdempotencySuite) handler(r *testutils.TaskRepository, ir *testutils.IdempotencyRepository) oghttp.Handler {
+	_, log := testutils.NewLogger()
+	pr := testutils.NewProjectRepository(r)
+	wr := testutils.NewWebhookRepository()
+
+	return application.APIHandler(application.Config{DefaultPageSize: 20, MaxPageSize: 100, DefaultWorkspace: true, IdempotencyTTL: time.Hour}, log, domain.NewService(r), r, api.HandlerWithProjects(domain.NewProjectService(pr), pr), api.HandlerWithWebhooks(domain.NewWebhookService(wr), wr), api.HandlerWithIdempotency(ir))
+}
+
+func (suite *IdempotencySuite) create(h oghttp.Handler, key, body string, header ...string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(oghttp.MethodPost, "/api/tasks", strings.NewReader(body))
+	if key != "" {
+		req.Header.Set("Idempotency-Key", key)
+	}
+	for i := 0; i+1 < len(header); i += 2 {
+		req.Header.Set(header[i], header[i+1])
+	}
+	rr := httptest.NewRecorder()
+	h.ServeHTTP(rr, req)
+
+	return rr
+}
+
+func (suite *IdempotencySuite) TestRepeatReplaysResponse() {
+	// Prepare
+	r := testutils.NewTaskRepository()
+	h := suite.handler(r, testutils.NewIdempotencyRepository())
+	first := suite.create(h, "key-1", `{"title":"task 1"}`)
+
+	// Execute
+	repeat := suite.create(h, "key-1", `{"title":"task 1"}`)
+
+	// Assert
+	suite.Len(r.Records, 1)
+	suite.Equal(oghttp.StatusCreated, first.Code)
+	suite.Equal(oghttp.StatusCreated, repeat.Code)
+	suite.Equal(first.Body.String(), repeat.Body.String())
+	suite.Equal("application/json", repeat.Header().Get("Content-Type"))
+	suite.Equal(first.Header().Get("ETag"), repeat.Header().Get("ETag"))
+	suite.Empty(first.Header().Get("Idempotent-Replayed"))
+	suite.Equal("true", repeat.Header().Get("Idempotent-Replayed"))
+}
+
+func (suite *IdempotencySuite) TestWithoutRepositoryKeyIsIgnored() {
+	// Prepare
+	r := testutils.NewTaskRepository()
+	_, log := testutils.NewLogger()
+	h := application.APIHandler(application.Config{DefaultPageSize: 20, MaxPageSize: 100, DefaultWorkspace: true}, log, domain.NewService(r), r)
+
+	// Execute
+	first := suite.create(h, "key-1", `{"title":"task 1"}`)
+	second := suite.create(h, "key-1", `{"title":"task 1"}`)
+
+	// Assert
+	suite.Len(r.Records, 2)
+	suite.Equal(oghttp.StatusCreated, first.Code)
+	suite.Equal(oghttp.StatusCreated, second.Code)
+	suite.Empty(second.Header().Get("Idempotent-Replayed"))
+}
+
+func (suite *IdempotencySuite) TestWithoutKeyCreatesEveryTime() {
+	// Prepare
+	r := testutils.NewTaskRepository()
+	h := suite.handler(r, testutils.NewIdempotencyRepository())
+
+	// Execute
+	first := suite.create(h, "", `{"title":"task 1"}`)
+	second := suite.create(h, "", `{"title":"task 1"}`)
+
+	// Assert
+	suite.Equal(oghttp.StatusCreated, first.Code)
+	suite.Equal(oghttp.StatusCreated, second.Code)
+	suite.Len(r.Records, 2)
+}
+
+func (suite *IdempotencySuite) TestKeysAreScopedToWorkspace() {
+	// Prepare
+	r := testutils.NewTaskRepository()
+	h := suite.handler(r, testutils.NewIdempotencyRepository())
+
+	// Execute
+	first := suite.create(h, "key-1", `{"title":"task 1"}`)
+	other := suite.create(h, "key-1", `{"title":"task 1"}`, "X-Workspace-ID", "3b153945-36fb-43c2-9bc7-c893add07d38")
+
+	// Assert
+	suite.Equal(oghttp.StatusCreated, first.Code)
+	suite.Equal(oghttp.StatusCreated, other.Code)
+	suite.Empty(other.Header().Get("Idempotent-Replayed"))
+	suite.Len(r.Records, 2)
+}
+
+func (suite *IdempotencySuite) TestKeyReusedForDifferentRequest() {
+	// Prepare
+	r := testutils.NewTaskRepository()
+	h := suite.handler(r, testutils.NewIdempotencyRepository())
+	suite.create(h, "key-1", `{"title":"task 1"}`)
+
+	// Execute
+	rr := suite.create(h, "key-1", `{"title":"task 2"}`)
+
+	// Assert
+	suite.Len(r.Records, 1)
+	suite.Equal(oghttp.StatusUnprocessableEntity, rr.Code)
+	assertProblem(suite.T(), rr, "idempotency_key_reused", "idempotency key was already used for a different request")
+}
+
+func (suite *IdempotencySuite) TestRepeatWhileInFlight() {
+	// Prepare
+	body := `{"title":"task 1"}`
+	sum := sha256.Sum256([]byte("POST /api/tasks\n" + body))
+	r := testutils.NewTaskRepository()
+	h := suite.handler(r, testutils.NewIdempotencyRepository(testutils.IdempotencyRepositoryWithKey("key-1", hex.EncodeToString(sum[:]), nil)))
+
+	// Execute
+	rr := suite.create(h, "key-1", body)
+
+	// Assert
+	suite.Empty(r.Records)
+	suite.Equal(oghttp.StatusConflict, rr.Code)
+	suite.Equal("1", rr.Header().Get("Retry-After"))
+	assertProblem(suite.T(), rr, "idempotency_key_in_flight", "a request with this idempotency key is still being processed")
+}
+
+func (suite *IdempotencySuite) TestClientErrorIsReplayed() {
+	// Prepare
+	r := testutils.NewTaskRepository()
+	h := suite.handler(r, testutils.NewIdempotencyRepository())
+	first := suite.create(h, "key-1", `{"title":""}`)
+
+	// Execute
+	repeat := suite.create(h, "key-1", `{"title":""}`)
+
+	// Assert
+	suite.Equal(oghttp.StatusBadRequest, first.Code)
+	suite.Equal(oghttp.StatusBadRequest, repeat.Code)
+	suite.Equal(first.Body.String(), repeat.Body.String())
+	suite.Equal("true", repeat.Header().Get("Idempotent-Replayed"))
+}
+
+func (suite *IdempotencySuite) TestServerErrorReleasesKey() {
+	// Prepare
+	ir := testutils.NewIdempotencyRepository()
+	h := suite.handler(testutils.NewTaskRepository(testutils.TaskRepositoryWithSaveError(errors.New("boom"))), ir)
+
+	// Execute
+	rr := suite.create(h, "key-1", `{"title":"task 1"}`)
+
+	// Assert
+	suite.Equal(oghttp.StatusInternalServerError, rr.Code)
+	suite.Nil(ir.Record(infrastructure.WithWorkspace(context.Background(), infrastructure.DefaultWorkspaceID), "key-1"))
+}
+
+func (suite *IdempotencySuite) TestKeyTooLong() {
+	// Prepare
+	r := testutils.NewTaskRepository()
+	h := suite.handler(r, testutils.NewIdempotencyRepository())
+
+	// Execute
+	rr := suite.create(h, strings.Repeat("k", 256), `{"title":"task 1"}`)
+
+	// Assert
+	suite.Empty(r.Records)
+	suite.Equal(oghttp.StatusBadRequest, rr.Code)
+	assertProblem(suite.T(), rr, "invalid_idempotency_key", "Idempotency-Key must be at most 255 characters")
+}
+
+func (suite *IdempotencySuite) TestBodyTooLarge() {
+	// Prepare
+	r := testutils.NewTaskRepository()
+	ir := testutils.NewIdempotencyRepository()
+	h := suite.handler(r, ir)
+
+	// Execute
+	rr := suite.create(h, "key-1", `{"title":"`+strings.Repeat("t", 1<<20)+`"}`)
+
+	// Assert
+	suite.Empty(r.Records)
+	suite.Nil(ir.Record(context.Background(), "key-1"))
+	suite.Equal(oghttp.StatusRequestEntityTooLarge, rr.Code)
+	assertProblem(suite.T(), rr, "request_too_large", "request body too large")
+}
+
+func (suite *IdempotencySuite) TestRepositoryFail() {
+	// Prepare
+	r := testutils.NewTaskRepository()
+	h := suite.handler(r, testutils.NewIdempotencyRepository(testutils.IdempotencyRepositoryWithError(errors.New("boom"))))
+
+	// Execute
+	rr := suite.create(h, "key-1", `{"title":"task 1"}`)
+
+	// Assert
+	suite.Empty(r.Records)
+	suite.Equal(oghttp.StatusInternalServerError, rr.Code)
+}
@@ -0,0 +1,267 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/aviseu/go-sample/internal/app/application/stream"
+	"github.com/aviseu/go-sample/internal/app/infrastructure"
+	"github.com/coder/websocket"
+	"github.com/coder/websocket/wsjson"
+	"github.com/google/uuid"
+	"net/http"
+	"slices"
+	"time"
+)
+
+// Commands a client sends over a live connection.
+const (
+	LiveSubscribe = "subscribe"
+	LiveCreate    = "create"
+	LiveComplete  = "complete"
+	LiveAck       = "ack"
+)
+
+// Messages the server sends over a live connection.
+const (
+	LiveResult = "result"
+	LiveError  = "error"
+	LiveEvent  = "event"
+)
+
+// liveWriteTimeout bounds how long a client may take to accept a message
+// before it is considered gone.
+const liveWriteTimeout = 10 * time.Second
+
+// Live upgrades the request to a WebSocket over which the client sends
+// commands and receives the changes to the tasks it subscribed to, its own
+// included. Every command with an ID is answered with a result or an error,
+// except for acknowledgements, which are only answered when they fail.
+//
+// Changes have to be acknowledged: at most window of them are sent ahead of
+// the acknowledgements. A client that falls so far behind that the broker
+// drops it is disconnected, after which it can subscribe again from the last
+// change it acknowledged. Pings keep idle connections open and detect the
+// ones that died.
+func (h *Handler) Live(w http.ResponseWriter, r *http.Request) {
+	conn, err := websocket.Accept(w, r, nil)
+	if err != nil {
+		return // Accept already responded
+	}
+	defer func() { _ = conn.CloseNow() }()
+
+	ctx, cancel := context.WithCancel(r.Context())
+	defer cancel()
+
+	l := &live{h: h, conn: conn}
+	status, reason := l.serve(ctx)
+	_ = conn.Close(status, reason)
+}
+
+type live struct {
+	h        *Handler
+	conn     *websocket.Conn
+	sub      *stream.Subscription
+	filter   *liveFilter
+	backlog  []*stream.Change
+	inflight []uuid.UUID
+}
+
+// liveFilter selects the changes a client subscribed to. Without a project or
+// event types it selects all changes of the workspace.
+type liveFilter struct {
+	projectID *uuid.UUID
+	events    []string
+}
+
+func (f *liveFilter) match(c *stream.Change) bool {
+	if f == nil {
+		return false
+	}
+	if f.projectID != nil && c.Task.ProjectID != *f.projectID {
+		return false
+	}
+	if len(f.events) > 0 && !slices.Contains(f.events, c.Event.Type) {
+		return false
+	}
+
+	return true
+}
+
+func (l *live) serve(ctx context.Context) (websocket.StatusCode, string) {
+	// Subscribe right away, even though nothing is sent before the client
+	// subscribes, so that the connection ends when the broker closes.
+	l.sub, _ = l.h.b.Subscribe(infrastructure.Workspace(ctx), nil)
+	defer func() { l.sub.Close() }()
+
+	commands := make(chan []byte)
+	go l.read(ctx, commands)
+	if l.h.ping > 0 {
+		go l.keepalive(ctx)
+	}
+
+	for {
+		if err := l.flush(ctx); err != nil {
+			return websocket.StatusInternalError, "failed to send change"
+		}
+
+		// Stop taking changes while the window is full so that they pile up
+		// in the subscription instead, until the broker drops it and the
+		// client is disconnected.
+		var changes <-chan *stream.Change
+		if len(l.backlog) == 0 && len(l.inflight) < l.h.window {
+			changes = l.sub.Changes()
+		}
+
+		select {
+		case data, ok := <-commands:
+			if !ok {
+				return websocket.StatusNormalClosure, ""
+			}
+			if err := l.handle(ctx, data); err != nil {
+				return websocket.StatusInternalError, "failed to answer command"
+			}
+		case <-l.sub.Done():
+			return websocket.StatusTryAgainLater, "subscription ended, subscribe again from the last acknowledged event"
+		case c, ok := <-changes:
+			if !ok {
+				return websocket.StatusTryAgainLater, "subscription ended, subscribe again from the last acknowledged event"
+			}
+			if l.filter.match(c) {
+				l.backlog = append(l.backlog, c)
+			}
+		}
+	}
+}
+
+// read passes the messages of the client on until the connection ends. It
+// also has to run for pongs to be noticed.
+func (l *live) read(ctx context.Context, commands chan<- []byte) {
+	defer close(commands)
+
+	for {
+		_, data, err := l.conn.Read(ctx)
+		if err != nil {
+			return
+		}
+
+		select {
+		case commands <- data:
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// keepalive pings the client and drops the connection when a pong does not
+// come back before the next ping is due.
+func (l *live) keepalive(ctx context.Context) {
+	ticker := time.NewTicker(l.h.ping)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			pingCtx, cancel := context.WithTimeout(ctx, l.h.ping)
+			err := l.conn.Ping(pingCtx)
+			cancel()
+			if err != nil {
+				if ctx.Err() == nil {
+					_ = l.conn.CloseNow()
+				}
+				return
+			}
+		}
+	}
+}
+
+func (l *live) handle(ctx context.Context, data []byte) error {
+	var req RequestLive
+	if err := json.Unmarshal(data, &req); err != nil {
+		return l.fail(ctx, "", ErrInvalidRequest)
+	}
+
+	switch req.Type {
+	case LiveSubscribe:
+		return l.subscribe(ctx, req)
+	case LiveCreate:
+		if req.Task == nil {
+			return l.fail(ctx, req.ID, ErrInvalidRequest)
+		}
+
+		task, err := l.h.s.Create(ctx, req.Task.toDomain())
+		if err != nil {
+			return l.fail(ctx, req.ID, err)
+		}
+
+		return l.send(ctx, &LiveResponse{Type: LiveResult, ID: req.ID, Task: task})
+	case LiveComplete:
+		if err := l.h.s.MarkCompleted(ctx, req.TaskID); err != nil {
+			return l.fail(ctx, req.ID, err)
+		}
+
+		return l.send(ctx, &LiveResponse{Type: LiveResult, ID: req.ID})
+	case LiveAck:
+		// Acknowledging a change acknowledges the ones sent before it too.
+		i := slices.Index(l.inflight, req.EventID)
+		if i < 0 {
+			return l.fail(ctx, req.ID, ErrUnknownEvent)
+		}
+		l.inflight = l.inflight[i+1:]
+
+		return nil
+	default:
+		return l.fail(ctx, req.ID, ErrUnknownCommand)
+	}
+}
+
+// subscribe replaces the subscription of the client, first replaying the
+// changes it missed since the given event.
+func (l *live) subscribe(ctx context.Context, req RequestLive) error {
+	l.sub.Close()
+	sub, missed := l.h.b.Subscribe(infrastructure.Workspace(ctx), req.LastEventID)
+	l.sub = sub
+	l.filter = &liveFilter{projectID: req.ProjectID, events: req.Events}
+	l.inflight = nil
+	l.backlog = nil
+	for _, c := range missed {
+		if l.filter.match(c) {
+			l.backlog = append(l.backlog, c)
+		}
+	}
+
+	return l.send(ctx, &LiveResponse{Type: LiveResult, ID: req.ID})
+}
+
+// flush sends the changes waiting in the backlog as far as the window allows.
+func (l *live) flush(ctx context.Context) error {
+	for len(l.backlog) > 0 && len(l.inflight) < l.h.window {
+		c := l.backlog[0]
+		if err := l.send(ctx, &LiveResponse{Type: LiveEvent, Event: c.Event, Task: c.Task}); err != nil {
+			return err
+		}
+		l.backlog = l.backlog[1:]
+		l.inflight = append(l.inflight, c.Event.ID)
+	}
+
+	return nil
+}
+
+func (l *live) fail(ctx context.Context, id string, err error) error {
+	msg := err.Error()
+	status, code, _ := classify(err)
+	if status == http.StatusInternalServerError {
+		l.h.log.Error(err.Error())
+		msg = http.StatusText(http.StatusInternalServerError)
+	}
+
+	return l.send(ctx, &LiveResponse{Type: LiveError, ID: id, Code: code, Message: msg})
+}
+
+func (l *live) send(ctx context.Context, resp *LiveResponse) error {
+	ctx, cancel := context.WithTimeout(ctx, liveWriteTimeout)
+	defer cancel()
+
+	return wsjson.Write(ctx, l.conn, resp)
+}
@@ -0,0 +1,320 @@
+package api_test
+
+import (
+	"context"
+	"errors"
+	"github.com/aviseu/go-sample/internal/app/application"
+	"github.com/aviseu/go-sample/internal/app/application/api"
+	"github.com/aviseu/go-sample/internal/app/application/stream"
+	"github.com/aviseu/go-sample/internal/app/domain"
+	"github.com/aviseu/go-sample/internal/app/infrastructure/aggregators"
+	"github.com/aviseu/go-sample/internal/testutils"
+	"github.com/coder/websocket"
+	"github.com/coder/websocket/wsjson"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/suite"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestLive(t *testing.T) {
+	t.Parallel()
+	suite.Run(t, new(LiveSuite))
+}
+
+type LiveSuite struct {
+	suite.Suite
+}
+
+func liveConfig() application.Config {
+	return application.Config{DefaultPageSize: 20, MaxPageSize: 100, DefaultWorkspace: true, LiveWindow: 10}
+}
+
+// serve starts a server for the API and returns the URL of its live endpoint.
+func (suite *LiveSuite) serve(cfg application.Config, s *domain.Service, b *stream.Broker) string {
+	r := testutils.NewTaskRepository()
+	_, log := testutils.NewLogger()
+	pr := testutils.NewProjectRepository(r)
+	wr := testutils.NewWebhookRepository()
+	server := httptest.NewServer(application.APIHandler(cfg, log, s, r, api.HandlerWithProjects(domain.NewProjectService(pr), pr), api.HandlerWithWebhooks(domain.NewWebhookService(wr), wr), api.HandlerWithBroker(b)))
+	suite.T().Cleanup(server.Close)
+
+	return "ws" + strings.TrimPrefix(server.URL, "http") + "/api/tasks/live"
+}
+
+// dial opens a live connection, which is closed with the test.
+func (suite *LiveSuite) dial(url string) *websocket.Conn {
+	conn, _, err := websocket.Dial(context.Background(), url, nil)
+	suite.Require().NoError(err)
+	suite.T().Cleanup(func() { _ = conn.CloseNow() })
+
+	return conn
+}
+
+func (suite *LiveSuite) send(conn *websocket.Conn, req api.RequestLive) {
+	suite.Require().NoError(wsjson.Write(context.Background(), conn, req))
+}
+
+func (suite *LiveSuite) receive(conn *websocket.Conn) *api.LiveResponse {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	var resp api.LiveResponse
+	suite.Require().NoError(wsjson.Read(ctx, conn, &resp))
+
+	return &resp
+}
+
+// subscribe subscribes the connection and waits for the subscription to be
+// confirmed.
+func (suite *LiveSuite) subscribe(conn *websocket.Conn, req api.RequestLive) {
+	req.Type = api.LiveSubscribe
+	req.ID = "subscribe"
+	suite.send(conn, req)
+	suite.Equal(&api.LiveResponse{Type: api.LiveResult, ID: "subscribe"}, suite.receive(conn))
+}
+
+func changed(projectID uuid.UUID, typ string) *aggregators.Task {
+	id := uuid.New()
+	return &aggregators.Task{
+		ID:        id,
+		Title:     "task",
+		ProjectID: projectID,
+		Events:    []*aggregators.Event{{ID: uuid.New(), TaskID: id, Type: typ, Payload: []byte(`{}`)}},
+	}
+}
+
+func (suite *LiveSuite) TestCreateIsBroadcastToSubscribers() {
+	// Prepare
+	b := stream.NewBroker()
+	url := suite.serve(liveConfig(), domain.NewService(testutils.NewTaskRepository(testutils.TaskRepositoryWithBus(b))), b)
+	author, watcher := suite.dial(url), suite.dial(url)
+	suite.subscribe(author, api.RequestLive{})
+	suite.subscribe(watcher, api.RequestLive{})
+
+	// Execute
+	suite.send(author, api.RequestLive{ID: "1", Type: api.LiveCreate, Task: &api.RequestTaskCreate{Title: "task 1"}})
+
+	// Assert
+	result, event := suite.receive(author), suite.receive(author)
+	if result.Type == api.LiveEvent {
+		result, event = event, result
+	}
+	suite.Equal(api.LiveResult, result.Type)
+	suite.Equal("1", result.ID)
+	suite.Require().NotNil(result.Task)
+	suite.Equal("task 1", result.Task.Title)
+	for _, e := range []*api.LiveResponse{event, suite.receive(watcher)} {
+		suite.Equal(api.LiveEvent, e.Type)
+		suite.Require().NotNil(e.Event)
+		suite.Equal("task.created", e.Event.Type)
+		suite.Equal(result.Task.ID, e.Event.TaskID)
+		suite.Require().NotNil(e.Task)
+		suite.Equal(result.Task.ID, e.Task.ID)
+	}
+}
+
+func (suite *LiveSuite) TestComplete() {
+	// Prepare
+	b := stream.NewBroker()
+	s := domain.NewService(testutils.NewTaskRepository(testutils.TaskRepositoryWithBus(b)))
+	task, err := s.Create(context.Background(), domain.TaskCreate{Title: "task 1"})
+	suite.Require().NoError(err)
+	conn := suite.dial(suite.serve(liveConfig(), s, b))
+	suite.subscribe(conn, api.RequestLive{Events: []string{"task.completed"}})
+
+	// Execute
+	suite.send(conn, api.RequestLive{ID: "1", Type: api.LiveComplete, TaskID: task.ID})
+
+	// Assert
+	result, event := suite.receive(conn), suite.receive(conn)
+	if result.Type == api.LiveEvent {
+		result, event = event, result
+	}
+	suite.Equal(&api.LiveResponse{Type: api.LiveResult, ID: "1"}, result)
+	suite.Equal(api.LiveEvent, event.Type)
+	suite.Equal("task.completed", event.Event.Type)
+	suite.Equal(task.ID, event.Task.ID)
+	suite.NotNil(event.Task.CompletedAt)
+}
+
+func (suite *LiveSuite) TestSubscribeFiltersByProject() {
+	// Prepare
+	b := stream.NewBroker()
+	conn := suite.dial(suite.serve(liveConfig(), domain.NewService(testutils.NewTaskRepository()), b))
+	projectID := uuid.New()
+	suite.subscribe(conn, api.RequestLive{ProjectID: &projectID})
+	other, mine := changed(uuid.New(), "task.created"), changed(projectID, "task.created")
+
+	// Execute
+	b.Notify(other, mine)
+
+	// Assert
+	resp := suite.receive(conn)
+	suite.Equal(api.LiveEvent, resp.Type)
+	suite.Equal(mine.Events[0].ID, resp.Event.ID)
+}
+
+func (suite *LiveSuite) TestSubscribeReplaysMissedChanges() {
+	// Prepare
+	b := stream.NewBroker()
+	t1, t2 := changed(uuid.New(), "task.created"), changed(uuid.New(), "task.created")
+	b.Notify(t1, t2)
+	conn := suite.dial(suite.serve(liveConfig(), domain.NewService(testutils.NewTaskRepository()), b))
+
+	// Execute
+	suite.subscribe(conn, api.RequestLive{LastEventID: &t1.Events[0].ID})
+
+	// Assert
+	resp := suite.receive(conn)
+	suite.Equal(api.LiveEvent, resp.Type)
+	suite.Equal(t2.Events[0].ID, resp.Event.ID)
+}
+
+func (suite *LiveSuite) TestWindowWaitsForAcknowledgement() {
+	// Prepare
+	b := stream.NewBroker()
+	cfg := liveConfig()
+	cfg.LiveWindow = 1
+	conn := suite.dial(suite.serve(cfg, domain.NewService(testutils.NewTaskRepository()), b))
+	suite.subscribe(conn, api.RequestLive{})
+	t1, t2 := changed(uuid.New(), "task.created"), changed(uuid.New(), "task.created")
+	b.Notify(t1, t2)
+	suite.Equal(t1.Events[0].ID, suite.receive(conn).Event.ID)
+
+	// Execute
+	suite.send(conn, api.RequestLive{ID: "1", Type: "unknown"})
+	held := suite.receive(conn)
+	suite.send(conn, api.RequestLive{Type: api.LiveAck, EventID: t1.Events[0].ID})
+
+	// Assert
+	suite.Equal(&api.LiveResponse{Type: api.LiveError, ID: "1", Code: "unknown_command", Message: "unknown command"}, held)
+	suite.Equal(t2.Events[0].ID, suite.receive(conn).Event.ID)
+}
+
+func (suite *LiveSuite) TestSlowClientIsDisconnected() {
+	// Prepare
+	b := stream.NewBroker(stream.BrokerWithBuffer(1))
+	cfg := liveConfig()
+	cfg.LiveWindow = 1
+	conn := suite.dial(suite.serve(cfg, domain.NewService(testutils.NewTaskRepository()), b))
+	suite.subscribe(conn, api.RequestLive{})
+	b.Notify(changed(uuid.New(), "task.created"))
+	suite.Equal(api.LiveEvent, suite.receive(conn).Type)
+
+	// Execute
+	b.Notify(changed(uuid.New(), "task.created"), changed(uuid.New(), "task.created"))
+
+	// Assert
+	_, _, err := conn.Read(context.Background())
+	suite.Equal(websocket.StatusTryAgainLater, websocket.CloseStatus(err))
+}
+
+func (suite *LiveSuite) TestCommandFailures() {
+	// Prepare
+	conn := suite.dial(suite.serve(liveConfig(), domain.NewService(testutils.NewTaskRepository()), stream.NewBroker()))
+	unknown := uuid.New()
+
+	cases := map[string]struct {
+		req     api.RequestLive
+		code    string
+		message string
+	}{
+		"unknown command": {
+			req:     api.RequestLive{ID: "1", Type: "delete"},
+			code:    "unknown_command",
+			message: "unknown command",
+		},
+		"create without task": {
+			req:     api.RequestLive{ID: "2", Type: api.LiveCreate},
+			code:    "invalid_request",
+			message: "invalid request body",
+		},
+		"create without title": {
+			req:     api.RequestLive{ID: "3", Type: api.LiveCreate, Task: &api.RequestTaskCreate{}},
+			code:    "title_required",
+			message: domain.ErrTitleIsRequired.Error(),
+		},
+		"complete unknown task": {
+			req:     api.RequestLive{ID: "4", Type: api.LiveComplete, TaskID: unknown},
+			code:    "task_not_found",
+			message: domain.ErrTaskNotFound.Error() + ": " + unknown.String(),
+		},
+		"ack unknown event": {
+			req:     api.RequestLive{ID: "5", Type: api.LiveAck, EventID: uuid.New()},
+			code:    "unknown_event",
+			message: "event is not awaiting acknowledgement",
+		},
+	}
+
+	for name, tc := range cases {
+		suite.Run(name, func() {
+			// Execute
+			suite.send(conn, tc.req)
+
+			// Assert
+			suite.Equal(&api.LiveResponse{Type: api.LiveError, ID: tc.req.ID, Code: tc.code, Message: tc.message}, suite.receive(conn))
+		})
+	}
+}
+
+func (suite *LiveSuite) TestInvalidMessage() {
+	// Prepare
+	conn := suite.dial(suite.serve(liveConfig(), domain.NewService(testutils.NewTaskRepository()), stream.NewBroker()))
+
+	// Execute
+	suite.Require().NoError(conn.Write(context.Background(), websocket.MessageText, []byte("{")))
+
+	// Assert
+	suite.Equal(&api.LiveResponse{Type: api.LiveError, Code: "invalid_request", Message: "invalid request body"}, suite.receive(conn))
+}
+
+func (suite *LiveSuite) TestConnectionEndsWhenBrokerCloses() {
+	// Prepare
+	b := stream.NewBroker()
+	conn := suite.dial(suite.serve(liveConfig(), domain.NewService(testutils.NewTaskRepository()), b))
+
+	// Execute
+	b.Close()
+
+	// Assert
+	_, _, err := conn.Read(context.Background())
+	suite.Equal(websocket.StatusTryAgainLater, websocket.CloseStatus(err))
+}
+
+func (suite *LiveSuite) TestUnresponsiveClientIsDropped() {
+	// Prepare
+	cfg := liveConfig()
+	cfg.LivePing = 10 * time.Millisecond
+	conn := suite.dial(suite.serve(cfg, domain.NewService(testutils.NewTaskRepository()), stream.NewBroker()))
+
+	// Execute
+	time.Sleep(100 * time.Millisecond)
+
+	// Assert
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	var err error
+	for err == nil {
+		_, _, err = conn.Read(ctx)
+	}
+	suite.False(errors.Is(err, context.DeadlineExceeded))
+	suite.Equal(websocket.StatusCode(-1), websocket.CloseStatus(err))
+}
+
+func (suite *LiveSuite) TestResponsiveClientIsKeptAlive() {
+	// Prepare
+	cfg := liveConfig()
+	cfg.LivePing = 10 * time.Millisecond
+	conn := suite.dial(suite.serve(cfg, domain.NewService(testutils.NewTaskRepository()), stream.NewBroker()))
+	ctx := conn.CloseRead(context.Background())
+
+	// Execute
+	time.Sleep(100 * time.Millisecond)
+
+	// Assert
+	suite.NoError(ctx.Err())
+	suite.NoError(conn.Ping(context.Background()))
+}
@@ -0,0 +1,173 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"github.com/aviseu/go-sample/internal/app/domain"
+	"github.com/aviseu/go-sample/internal/app/infrastructure"
+	"github.com/aviseu/go-sample/internal/errs"
+	"net/http"
+)
+
+const problemContentType = "application/problem+json"
+
+// problemTypePrefix is prefixed to the code of a problem to make up its type.
+const problemTypePrefix = "urn:go-sample:problem:"
+
+// Problem is an RFC 9457 problem details object. Code identifies the kind of
+// problem for clients that should not parse the detail.
+type Problem struct {
+	Type     string        `json:"type"`
+	Title    string        `json:"title"`
+	Status   int           `json:"status"`
+	Detail   string        `json:"detail,omitempty"`
+	Instance string        `json:"instance,omitempty"`
+	Code     string        `json:"code"`
+	Errors   []*FieldError `json:"errors,omitempty"`
+}
+
+// FieldError points at the part of the request that caused the problem.
+type FieldError struct {
+	Field  string `json:"field"`
+	Code   string `json:"code"`
+	Detail string `json:"detail"`
+}
+
+type problemKind struct {
+	err    error
+	status int
+	code   string
+	field  string
+}
+
+// problemKinds maps the errors a client can cause to their status and code.
+// The first one the error matches wins.
+var problemKinds = []problemKind{
+	{err: ErrInvalidTaskID, status: http.StatusBadRequest, code: "invalid_task_id"},
+	{err: ErrInvalidProjectID, status: http.StatusBadRequest, code: "invalid_project_id"},
+	{err: ErrInvalidTagID, status: http.StatusBadRequest, code: "invalid_tag_id"},
+	{err: ErrInvalidWebhookID, status: http.StatusBadRequest, code: "invalid_webhook_id"},
+	{err: ErrInvalidDependencyID, status: http.StatusBadRequest, code: "invalid_dependency_id"},
+	{err: ErrInvalidDeliveryID, status: http.StatusBadRequest, code: "invalid_delivery_id"},
+	{err: ErrInvalidRequest, status: http.StatusBadRequest, code: "invalid_request"},
+	{err: ErrInvalidLimit, status: http.StatusBadRequest, code: "invalid_limit", field: "limit"},
+	{err: ErrInvalidCompleted, status: http.StatusBadRequest, code: "invalid_completed", field: "completed"},
+	{err: ErrInvalidSort, status: http.StatusBadRequest, code: "invalid_sort", field: "sort"},
+	{err: ErrQueryIsRequired, status: http.StatusBadRequest, code: "query_required", field: "q"},
+	{err: ErrInvalidWithin, status: http.StatusBadRequest, code: "invalid_within", field: "within"},
+	{err: ErrInvalidTag, status: http.StatusBadRequest, code: "invalid_tag", field: "tag"},
+	{err: ErrInvalidWorkspace, status: http.StatusBadRequest, code: "invalid_workspace"},
+	{err: ErrWorkspaceRequired, status: http.StatusBadRequest, code: "workspace_required"},
+	{err: ErrInvalidEventID, status: http.StatusBadRequest, code: "invalid_last_event_id"},
+	{err: ErrUnknownCommand, status: http.StatusBadRequest, code: "unknown_command", field: "type"},
+	{err: ErrUnknownEvent, status: http.StatusBadRequest, code: "unknown_event", field: "event_id"},
+	{err: ErrInvalidIdempotencyKey, status: http.StatusBadRequest, code: "invalid_idempotency_key"},
+	{err: ErrUnsupportedMediaType, status: http.StatusUnsupportedMediaType, code: "unsupported_media_type"},
+	{err: ErrRequestTooLarge, status: http.StatusRequestEntityTooLarge, code: "request_too_large"},
+
+	{err: domain.ErrTaskNotFound, status: http.StatusNotFound, code: "task_not_found"},
+	{err: domain.ErrProjectNotFound, status: http.StatusNotFound, code: "project_not_found"},
+	{err: domain.ErrTagNotFound, status: http.StatusNotFound, code: "tag_not_found"},
+	{err: domain.ErrWebhookNotFound, status: http.StatusNotFound, code: "webhook_not_found"},
+	{err: domain.ErrDeliveryNotFound, status: http.StatusNotFound, code: "delivery_not_found"},
+	{err: domain.ErrTitleIsRequired, status: http.StatusBadRequest, code: "title_required", field: "title"},
+	{err: domain.ErrInvalidStatus, status: http.StatusBadRequest, code: "invalid_status", field: "status"},
+	{err: domain.ErrInvalidDueDate, status: http.StatusBadRequest, code: "invalid_due_date", field: "due_at"},
+	{err: domain.ErrDueDateInPast, status: http.StatusBadRequest, code: "due_date_in_past", field: "due_at"},
+	{err: domain.ErrInvalidRecurrence, status: http.StatusBadRequest, code: "invalid_recurrence", field: "recurrence"},
+	{err: domain.ErrRecurrenceNoDueAt, status: http.StatusBadRequest, code: "recurrence_without_due_date", field: "due_at"},
+	{err: domain.ErrParentNotFound, status: http.StatusBadRequest, code: "parent_not_found", field: "parent_id"},
+	{err: domain.ErrParentCycle, status: http.StatusBadRequest, code: "parent_cycle", field: "parent_id"},
+	{err: domain.ErrMaxDepthExceeded, status: http.StatusBadRequest, code: "max_depth_exceeded", field: "parent_id"},
+	{err: domain.ErrDependencyNotFound, status: http.StatusBadRequest, code: "dependency_not_found", field: "depends_on"},
+	{err: domain.ErrDependencyCycle, status: http.StatusBadRequest, code: "dependency_cycle", field: "depends_on"},
+	{err: domain.ErrBlocked, status: http.StatusBadRequest, code: "task_blocked"},
+	{err: domain.ErrProjectNameIsRequired, status: http.StatusBadRequest, code: "project_name_required", field: "name"},
+	{err: domain.ErrProjectMismatch, status: http.StatusBadRequest, code: "project_mismatch", field: "project_id"},
+	{err: domain.ErrTagNameIsRequired, status: http.StatusBadRequest, code: "tag_name_required", field: "name"},
+	{err: domain.ErrInvalidWebhookURL, status: http.StatusBadRequest, code: "invalid_webhook_url", field: "url"},
+	{err: domain.ErrInvalidEventType, status: http.StatusBadRequest, code: "invalid_event_type", field: "events"},
+	{err: domain.ErrTagExists, status: http.StatusConflict, code: "tag_exists"},
+	{err: domain.ErrInvalidTransition, status: http.StatusConflict, code: "invalid_transition"},
+	{err: domain.ErrOpenSubtasks, status: http.StatusConflict, code: "open_subtasks"},
+	{err: domain.ErrInboxProject, status: http.StatusConflict, code: "inbox_project"},
+	{err: domain.ErrProjectModified, status: http.StatusConflict, code: "project_modified"},
+	{err: domain.ErrTaskModified, status: http.StatusConflict, code: "task_modified"},
+	{err: domain.ErrVersionMismatch, status: http.StatusPreconditionFailed, code: "version_mismatch"},
+
+	{err: infrastructure.ErrTaskNotFound, status: http.StatusNotFound, code: "task_not_found"},
+	{err: infrastructure.ErrProjectNotFound, status: http.StatusNotFound, code: "project_not_found"},
+	{err: infrastructure.ErrTagNotFound, status: http.StatusNotFound, code: "tag_not_found"},
+	{err: infrastructure.ErrWebhookNotFound, status: http.StatusNotFound, code: "webhook_not_found"},
+	{err: infrastructure.ErrDeliveryNotFound, status: http.StatusNotFound, code: "delivery_not_found"},
+	{err: infrastructure.ErrInvalidCursor, status: http.StatusBadRequest, code: "invalid_cursor", field: "cursor"},
+	{err: infrastructure.ErrTaskConflict, status: http.StatusConflict, code: "task_modified"},
+	{err: infrastructure.ErrProjectConflict, status: http.StatusConflict, code: "project_modified"},
+	{err: infrastructure.ErrTagExists, status: http.StatusConflict, code: "tag_exists"},
+	{err: infrastructure.ErrIdempotencyKeyReused, status: http.StatusUnprocessableEntity, code: "idempotency_key_reused"},
+	{err: infrastructure.ErrIdempotencyKeyInFlight, status: http.StatusConflict, code: "idempotency_key_in_flight"},
+}
+
+// classify returns the status, code and field for err. Errors that are not
+// listed fall back on their kind, anything else is an internal error.
+func classify(err error) (int, string, string) {
+	for _, k := range problemKinds {
+		if errors.Is(err, k.err) {
+			return k.status, k.code, k.field
+		}
+	}
+
+	switch {
+	case errs.IsValidationError(err):
+		return http.StatusBadRequest, "validation_failed", ""
+	case errs.IsConflictError(err):
+		return http.StatusConflict, "conflict", ""
+	case errs.IsPreconditionError(err):
+		return http.StatusPreconditionFailed, "precondition_failed", ""
+	}
+
+	return http.StatusInternalServerError, "internal_error", ""
+}
+
+func newProblem(status int, code, detail, field, instance string) *Problem {
+	p := &Problem{
+		Type:     problemTypePrefix + code,
+		Title:    http.StatusText(status),
+		Status:   status,
+		Detail:   detail,
+		Instance: instance,
+		Code:     code,
+	}
+	if field != "" {
+		p.Errors = []*FieldError{{Field: field, Code: code, Detail: detail}}
+	}
+
+	return p
+}
+
+// handleFail responds to err with the problem it maps to. Errors that do not
+// map to one are handled as internal errors.
+func (h *Handler) handleFail(w http.ResponseWriter, r *http.Request, err error) {
+	status, code, field := classify(err)
+	if status == http.StatusInternalServerError {
+		h.handleError(w, r, err)
+		return
+	}
+
+	writeProblem(w, newProblem(status, code, err.Error(), field, r.URL.Path))
+}
+
+// handleError logs err and responds with an internal error, without telling
+// the client what went wrong.
+func (h *Handler) handleError(w http.ResponseWriter, r *http.Request, err error) {
+	h.log.Error(err.Error())
+	writeProblem(w, newProblem(http.StatusInternalServerError, "internal_error", "", "", r.URL.Path))
+}
+
+func writeProblem(w http.ResponseWriter, p *Problem) {
+	w.Header().Set("Content-Type", problemContentType)
+	w.WriteHeader(p.Status)
+	if err := json.NewEncoder(w).Encode(p); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
@@ -0,0 +1,94 @@
+package api_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"github.com/aviseu/go-sample/internal/app/application"
+	"github.com/aviseu/go-sample/internal/app/application/api"
+	"github.com/aviseu/go-sample/internal/app/domain"
+	"github.com/aviseu/go-sample/internal/testutils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+	oghttp "net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// assertProblem asserts that rr holds a problem with the code and detail, and
+// with the status it was sent with.
+func assertProblem(t *testing.T, rr *httptest.ResponseRecorder, code, detail string) {
+	t.Helper()
+
+	var p api.Problem
+	if !assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &p)) {
+		return
+	}
+	assert.Equal(t, rr.Code, p.Status)
+	assert.Equal(t, code, p.Code)
+	assert.Equal(t, detail, p.Detail)
+}
+
+func TestProblem(t *testing.T) {
+	t.Parallel()
+	suite.Run(t, new(ProblemSuite))
+}
+
+type ProblemSuite struct {
+	suite.Suite
+}
+
+func (suite *ProblemSuite) handler(r *testutils.TaskRepository) (*bytes.Buffer, oghttp.Handler) {
+	lbuf, log := testutils.NewLogger()
+	pr := testutils.NewProjectRepository(r)
+	wr := testutils.NewWebhookRepository()
+
+	return lbuf, application.APIHandler(application.Config{DefaultPageSize: 20, MaxPageSize: 100, DefaultWorkspace: true}, log, domain.NewService(r), r, api.HandlerWithProjects(domain.NewProjectService(pr), pr), api.HandlerWithWebhooks(domain.NewWebhookService(wr), wr))
+}
+
+func (suite *ProblemSuite) TestFieldError() {
+	// Prepare
+	_, h := suite.handler(testutils.NewTaskRepository())
+	req := httptest.NewRequest(oghttp.MethodPost, "/api/tasks", strings.NewReader(`{"title":""}`))
+	rr := httptest.NewRecorder()
+
+	// Execute
+	h.ServeHTTP(rr, req)
+
+	// Assert
+	suite.Equal(oghttp.StatusBadRequest, rr.Code)
+	suite.Equal("application/problem+json", rr.Header().Get("Content-Type"))
+	suite.Equal(`{"type":"urn:go-sample:problem:title_required","title":"Bad Request","status":400,"detail":"title is required","instance":"/api/tasks","code":"title_required","errors":[{"field":"title","code":"title_required","detail":"title is required"}]}`+"\n", rr.Body.String())
+}
+
+func (suite *ProblemSuite) TestNotFound() {
+	// Prepare
+	_, h := suite.handler(testutils.NewTaskRepository())
+	req := httptest.NewRequest(oghttp.MethodGet, "/api/projects/3b153945-36fb-43c2-9bc7-c893add07d38", nil)
+	rr := httptest.NewRecorder()
+
+	// Execute
+	h.ServeHTTP(rr, req)
+
+	// Assert
+	suite.Equal(oghttp.StatusNotFound, rr.Code)
+	suite.Equal("application/problem+json", rr.Header().Get("Content-Type"))
+	suite.Equal(`{"type":"urn:go-sample:problem:project_not_found","title":"Not Found","status":404,"detail":"project not found","instance":"/api/projects/3b153945-36fb-43c2-9bc7-c893add07d38","code":"project_not_found"}`+"\n", rr.Body.String())
+}
+
+func (suite *ProblemSuite) TestInternalErrorHidesDetail() {
+	// Prepare
+	lbuf, h := suite.handler(testutils.NewTaskRepository(testutils.TaskRepositoryWithError(errors.New("boom!"))))
+	req := httptest.NewRequest(oghttp.MethodPost, "/api/tasks", strings.NewReader(`{"title":"task 1"}`))
+	rr := httptest.NewRecorder()
+
+	// Execute
+	h.ServeHTTP(rr, req)
+
+	// Assert
+	suite.Equal(oghttp.StatusInternalServerError, rr.Code)
+	suite.Equal("application/problem+json", rr.Header().Get("Content-Type"))
+	suite.Equal(`{"type":"urn:go-sample:problem:internal_error","title":"Internal Server Error","status":500,"instance":"/api/tasks","code":"internal_error"}`+"\n", rr.Body.String())
+	suite.Contains(lbuf.String(), "boom!")
+}
@@ -0,0 +1,165 @@
+package api
+
+import (
+	"encoding/json"
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"net/http"
+)
+
+func (h *Handler) ProjectRoutes() http.Handler {
+	r := chi.NewRouter()
+
+	r.Get("/", h.AllProjects)
+	r.Post("/", h.CreateProject)
+	r.Get("/{id}", h.FindProject)
+	r.Patch("/{id}", h.UpdateProject)
+	r.Delete("/{id}", h.DeleteProject)
+	r.Get("/{id}/tasks", h.ProjectTasks)
+
+	return r
+}
+
+func (h *Handler) AllProjects(w http.ResponseWriter, r *http.Request) {
+	projects, err := h.pr.All(r.Context())
+	if err != nil {
+		h.handleError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	resp := NewProjectListResponse(projects)
+	if err := json.NewEncoder(w).Encode(resp); err != nil {
+		h.handleError(w, r, err)
+		return
+	}
+}
+
+func (h *Handler) CreateProject(w http.ResponseWriter, r *http.Request) {
+	var req RequestProjectCreate
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.handleFail(w, r, ErrInvalidRequest)
+		return
+	}
+
+	project, err := h.ps.Create(r.Context(), req.Name)
+	if err != nil {
+		h.handleFail(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	if err := json.NewEncoder(w).Encode(project); err != nil {
+		h.handleError(w, r, err)
+		return
+	}
+}
+
+func (h *Handler) FindProject(w http.ResponseWriter, r *http.Request) {
+	idStr := chi.URLParam(r, "id")
+	id, err := uuid.Parse(idStr)
+	if err != nil {
+		h.handleFail(w, r, ErrInvalidProjectID)
+		return
+	}
+
+	project, err := h.pr.Find(r.Context(), id)
+	if err != nil {
+		h.handleFail(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	if err := json.NewEncoder(w).Encode(project); err != nil {
+		h.handleError(w, r, err)
+		return
+	}
+}
+
+func (h *Handler) UpdateProject(w http.ResponseWriter, r *http.Request) {
+	idStr := chi.URLParam(r, "id")
+	id, err := uuid.Parse(idStr)
+	if err != nil {
+		h.handleFail(w, r, ErrInvalidProjectID)
+		return
+	}
+
+	var req RequestProjectUpdate
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.handleFail(w, r, ErrInvalidRequest)
+		return
+	}
+
+	project, err := h.ps.Rename(r.Context(), id, req.Name)
+	if err != nil {
+		h.handleFail(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	if err := json.NewEncoder(w).Encode(project); err != nil {
+		h.handleError(w, r, err)
+		return
+	}
+}
+
+func (h *Handler) DeleteProject(w http.ResponseWriter, r *http.Request) {
+	idStr := chi.URLParam(r, "id")
+	id, err := uuid.Parse(idStr)
+	if err != nil {
+		h.handleFail(w, r, ErrInvalidProjectID)
+		return
+	}
+
+	if err := h.ps.Delete(r.Context(), id); err != nil {
+		h.handleFail(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *Handler) ProjectTasks(w http.ResponseWriter, r *http.Request) {
+	idStr := chi.URLParam(r, "id")
+	id, err := uuid.Parse(idStr)
+	if err != nil {
+		h.handleFail(w, r, ErrInvalidProjectID)
+		return
+	}
+
+	f, err := filter(r)
+	if err != nil {
+		h.handleFail(w, r, err)
+		return
+	}
+	f.ProjectID = &id
+
+	p, err := h.page(r, f.Sort)
+	if err != nil {
+		h.handleFail(w, r, err)
+		return
+	}
+
+	if _, err := h.pr.Find(r.Context(), id); err != nil {
+		h.handleFail(w, r, err)
+		return
+	}
+
+	tasks, next, err := h.r.List(r.Context(), f, p)
+	if err != nil {
+		h.handleError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	resp := NewTaskListResponse(tasks).WithNextCursor(next)
+	if err := json.NewEncoder(w).Encode(resp); err != nil {
+		h.handleError(w, r, err)
+		return
+	}
+}
@@ -1,5 +1,126 @@
 package api
 
+import (
+	"encoding/json"
+	"github.com/aviseu/go-sample/internal/app/domain"
+	"github.com/google/uuid"
+)
+
 type RequestTaskCreate struct {
-	Title string `json:"title"`
+	Title      string     `json:"title"`
+	DueAt      string     `json:"due_at"`
+	Recurrence string     `json:"recurrence"`
+	ProjectID  *uuid.UUID `json:"project_id"`
+	ParentID   *uuid.UUID `json:"parent_id"`
+}
+
+func (r RequestTaskCreate) toDomain() domain.TaskCreate {
+	return domain.TaskCreate{
+		Title:      r.Title,
+		DueAt:      r.DueAt,
+		Recurrence: r.Recurrence,
+		ProjectID:  r.ProjectID,
+		ParentID:   r.ParentID,
+	}
+}
+
+type RequestTagCreate struct {
+	Name string `json:"name"`
+}
+
+type RequestProjectCreate struct {
+	Name string `json:"name"`
+}
+
+type RequestProjectUpdate struct {
+	Name string `json:"name"`
+}
+
+type RequestWebhookCreate struct {
+	URL    string   `json:"url"`
+	Events []string `json:"events"`
+	Secret string   `json:"secret"`
+}
+
+func (r RequestWebhookCreate) toDomain() domain.WebhookCreate {
+	return domain.WebhookCreate{
+		URL:    r.URL,
+		Events: r.Events,
+		Secret: r.Secret,
+	}
+}
+
+type RequestTaskDependency struct {
+	DependsOn uuid.UUID `json:"depends_on"`
+}
+
+type RequestTaskStatus struct {
+	Status string `json:"status"`
+}
+
+// Optional tells a JSON Merge Patch member that is absent apart from one that
+// is present, which may be null.
+type Optional[T any] struct {
+	Set   bool
+	Null  bool
+	Value T
+}
+
+func (o *Optional[T]) UnmarshalJSON(data []byte) error {
+	o.Set = true
+	if string(data) == "null" {
+		o.Null = true
+		return nil
+	}
+
+	return json.Unmarshal(data, &o.Value)
+}
+
+// ptr returns nil when the member was absent and the zero value when it was
+// null, so that removing a required member fails its validation.
+func (o Optional[T]) ptr() *T {
+	if !o.Set {
+		return nil
+	}
+
+	v := o.Value
+	return &v
+}
+
+type RequestTaskUpdate struct {
+	Title      Optional[string]    `json:"title"`
+	Status     Optional[string]    `json:"status"`
+	DueAt      Optional[string]    `json:"due_at"`
+	Recurrence Optional[string]    `json:"recurrence"`
+	ProjectID  Optional[uuid.UUID] `json:"project_id"`
+	ParentID   Optional[uuid.UUID] `json:"parent_id"`
+}
+
+func (r RequestTaskUpdate) toDomain() domain.TaskUpdate {
+	u := domain.TaskUpdate{
+		Title:      r.Title.ptr(),
+		DueAt:      r.DueAt.ptr(),
+		Recurrence: r.Recurrence.ptr(),
+		ProjectID:  r.ProjectID.ptr(),
+		ParentID:   r.ParentID.ptr(),
+	}
+	if r.Status.Set {
+		status := domain.Status(r.Status.Value)
+		u.Status = &status
+	}
+
+	return u
+}
+
+// RequestLive is a command sent over a live connection. Which of the other
+// fields apply depends on its type.
+type RequestLive struct {
+	ID          string             `json:"id"`
+	Type        string             `json:"type"`
+	ProjectID   *uuid.UUID         `json:"project_id"`
+	Events      []string           `json:"events"`
+	LastEventID *uuid.UUID         `json:"last_event_id"`
+	Task        *RequestTaskCreate `json:"task"`
+	TaskID      uuid.UUID          `json:"task_id"`
+	EventID     uuid.UUID          `json:"event_id"`
 }
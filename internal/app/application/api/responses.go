@@ -1,19 +1,14 @@
 package api
 
 import (
+	"github.com/aviseu/go-sample/internal/app/infrastructure"
 	"github.com/aviseu/go-sample/internal/app/infrastructure/aggregators"
+	"github.com/google/uuid"
 )
 
-type ErrorResponse struct {
-	Message string `json:"message"`
-}
-
-func NewErrorResponse(err error) *ErrorResponse {
-	return &ErrorResponse{Message: err.Error()}
-}
-
 type TaskListResponse struct {
-	Tasks []*aggregators.Task `json:"tasks"`
+	Tasks      []*aggregators.Task `json:"tasks"`
+	NextCursor string              `json:"next_cursor,omitempty"`
 }
 
 func NewTaskListResponse(tasks []*aggregators.Task) *TaskListResponse {
@@ -24,3 +19,153 @@ func NewTaskListResponse(tasks []*aggregators.Task) *TaskListResponse {
 		Tasks: tasks,
 	}
 }
+
+func (r *TaskListResponse) WithNextCursor(c *infrastructure.Cursor) *TaskListResponse {
+	if c != nil {
+		r.NextCursor = c.Encode()
+	}
+
+	return r
+}
+
+type TaskHistoryResponse struct {
+	Changes    []*aggregators.TaskChange `json:"changes"`
+	NextCursor string                    `json:"next_cursor,omitempty"`
+}
+
+func NewTaskHistoryResponse(changes []*aggregators.TaskChange) *TaskHistoryResponse {
+	if changes == nil {
+		changes = []*aggregators.TaskChange{}
+	}
+	return &TaskHistoryResponse{
+		Changes: changes,
+	}
+}
+
+func (r *TaskHistoryResponse) WithNextCursor(c *infrastructure.Cursor) *TaskHistoryResponse {
+	if c != nil {
+		r.NextCursor = c.Encode()
+	}
+
+	return r
+}
+
+// TaskTreeResponse is a task with its subtasks nested below it.
+type TaskTreeResponse struct {
+	*aggregators.Task
+	Children []*TaskTreeResponse `json:"children"`
+}
+
+// NewTaskTreeResponse nests the descendants, which have to be ordered parents
+// first, below the root task.
+func NewTaskTreeResponse(root *aggregators.Task, descendants []*aggregators.Task) *TaskTreeResponse {
+	tree := &TaskTreeResponse{Task: root, Children: []*TaskTreeResponse{}}
+	nodes := map[uuid.UUID]*TaskTreeResponse{root.ID: tree}
+	for _, task := range descendants {
+		node := &TaskTreeResponse{Task: task, Children: []*TaskTreeResponse{}}
+		nodes[task.ID] = node
+		if parent, ok := nodes[*task.ParentID]; ok {
+			parent.Children = append(parent.Children, node)
+		}
+	}
+
+	return tree
+}
+
+type PurgeResponse struct {
+	Purged int64 `json:"purged"`
+}
+
+func NewPurgeResponse(n int64) *PurgeResponse {
+	return &PurgeResponse{Purged: n}
+}
+
+type TaskSearchResponse struct {
+	Tasks []*aggregators.TaskMatch `json:"tasks"`
+}
+
+func NewTaskSearchResponse(matches []*aggregators.TaskMatch) *TaskSearchResponse {
+	if matches == nil {
+		matches = []*aggregators.TaskMatch{}
+	}
+	return &TaskSearchResponse{
+		Tasks: matches,
+	}
+}
+
+type TagListResponse struct {
+	Tags []*aggregators.Tag `json:"tags"`
+}
+
+func NewTagListResponse(tags []*aggregators.Tag) *TagListResponse {
+	if tags == nil {
+		tags = []*aggregators.Tag{}
+	}
+	return &TagListResponse{
+		Tags: tags,
+	}
+}
+
+type ProjectListResponse struct {
+	Projects []*aggregators.Project `json:"projects"`
+}
+
+func NewProjectListResponse(projects []*aggregators.Project) *ProjectListResponse {
+	if projects == nil {
+		projects = []*aggregators.Project{}
+	}
+	return &ProjectListResponse{
+		Projects: projects,
+	}
+}
+
+type WebhookListResponse struct {
+	Webhooks []*aggregators.Webhook `json:"webhooks"`
+}
+
+func NewWebhookListResponse(webhooks []*aggregators.Webhook) *WebhookListResponse {
+	if webhooks == nil {
+		webhooks = []*aggregators.Webhook{}
+	}
+	return &WebhookListResponse{
+		Webhooks: webhooks,
+	}
+}
+
+// WebhookCreatedResponse is the only response that reveals the secret of a
+// webhook.
+type WebhookCreatedResponse struct {
+	*aggregators.Webhook
+	Secret string `json:"secret"`
+}
+
+func NewWebhookCreatedResponse(webhook *aggregators.Webhook) *WebhookCreatedResponse {
+	return &WebhookCreatedResponse{
+		Webhook: webhook,
+		Secret:  webhook.Secret,
+	}
+}
+
+type WebhookDeliveryListResponse struct {
+	Deliveries []*aggregators.WebhookDelivery `json:"deliveries"`
+}
+
+func NewWebhookDeliveryListResponse(deliveries []*aggregators.WebhookDelivery) *WebhookDeliveryListResponse {
+	if deliveries == nil {
+		deliveries = []*aggregators.WebhookDelivery{}
+	}
+	return &WebhookDeliveryListResponse{
+		Deliveries: deliveries,
+	}
+}
+
+// LiveResponse is a message sent over a live connection: the result of a
+// command, the error it failed with or a change to a task.
+type LiveResponse struct {
+	Type    string             `json:"type"`
+	ID      string             `json:"id,omitempty"`
+	Task    *aggregators.Task  `json:"task,omitempty"`
+	Event   *aggregators.Event `json:"event,omitempty"`
+	Code    string             `json:"code,omitempty"`
+	Message string             `json:"message,omitempty"`
+}
@@ -0,0 +1,111 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/aviseu/go-sample/internal/app/infrastructure/aggregators"
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"net/http"
+	"net/url"
+)
+
+func (h *Handler) TagRoutes() http.Handler {
+	r := chi.NewRouter()
+
+	r.Get("/", h.AllTags)
+	r.Post("/", h.CreateTag)
+	r.Delete("/{id}", h.DeleteTag)
+
+	return r
+}
+
+func (h *Handler) AllTags(w http.ResponseWriter, r *http.Request) {
+	tags, err := h.r.Tags(r.Context())
+	if err != nil {
+		h.handleError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	resp := NewTagListResponse(tags)
+	if err := json.NewEncoder(w).Encode(resp); err != nil {
+		h.handleError(w, r, err)
+		return
+	}
+}
+
+func (h *Handler) CreateTag(w http.ResponseWriter, r *http.Request) {
+	var req RequestTagCreate
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.handleFail(w, r, ErrInvalidRequest)
+		return
+	}
+
+	tag, err := h.s.CreateTag(r.Context(), req.Name)
+	if err != nil {
+		h.handleFail(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	if err := json.NewEncoder(w).Encode(tag); err != nil {
+		h.handleError(w, r, err)
+		return
+	}
+}
+
+func (h *Handler) DeleteTag(w http.ResponseWriter, r *http.Request) {
+	idStr := chi.URLParam(r, "id")
+	id, err := uuid.Parse(idStr)
+	if err != nil {
+		h.handleFail(w, r, ErrInvalidTagID)
+		return
+	}
+
+	if err := h.s.DeleteTag(r.Context(), id); err != nil {
+		h.handleFail(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *Handler) AddTag(w http.ResponseWriter, r *http.Request) {
+	h.tagTask(w, r, h.s.Tag)
+}
+
+func (h *Handler) RemoveTag(w http.ResponseWriter, r *http.Request) {
+	h.tagTask(w, r, h.s.Untag)
+}
+
+func (h *Handler) tagTask(w http.ResponseWriter, r *http.Request, apply func(ctx context.Context, id uuid.UUID, name string) (*aggregators.Task, error)) {
+	idStr := chi.URLParam(r, "id")
+	id, err := uuid.Parse(idStr)
+	if err != nil {
+		h.handleFail(w, r, ErrInvalidTaskID)
+		return
+	}
+
+	name, err := url.PathUnescape(chi.URLParam(r, "tag"))
+	if err != nil {
+		h.handleFail(w, r, ErrInvalidTag)
+		return
+	}
+
+	task, err := apply(r.Context(), id, name)
+	if err != nil {
+		h.handleFail(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("ETag", etag(task.Version))
+	w.WriteHeader(http.StatusOK)
+	if err := json.NewEncoder(w).Encode(task); err != nil {
+		h.handleError(w, r, err)
+		return
+	}
+}
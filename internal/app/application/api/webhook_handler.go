@@ -0,0 +1,155 @@
+package api
+
+import (
+	"encoding/json"
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"net/http"
+)
+
+func (h *Handler) WebhookRoutes() http.Handler {
+	r := chi.NewRouter()
+
+	r.Get("/", h.AllWebhooks)
+	r.Post("/", h.CreateWebhook)
+	r.Get("/{id}", h.FindWebhook)
+	r.Delete("/{id}", h.DeleteWebhook)
+	r.Get("/{id}/deliveries", h.WebhookDeliveries)
+	r.Post("/{id}/deliveries/{deliveryID}/redeliver", h.Redeliver)
+
+	return r
+}
+
+func (h *Handler) AllWebhooks(w http.ResponseWriter, r *http.Request) {
+	webhooks, err := h.wr.All(r.Context())
+	if err != nil {
+		h.handleError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	resp := NewWebhookListResponse(webhooks)
+	if err := json.NewEncoder(w).Encode(resp); err != nil {
+		h.handleError(w, r, err)
+		return
+	}
+}
+
+func (h *Handler) CreateWebhook(w http.ResponseWriter, r *http.Request) {
+	var req RequestWebhookCreate
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.handleFail(w, r, ErrInvalidRequest)
+		return
+	}
+
+	webhook, err := h.ws.Register(r.Context(), req.toDomain())
+	if err != nil {
+		h.handleFail(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	resp := NewWebhookCreatedResponse(webhook)
+	if err := json.NewEncoder(w).Encode(resp); err != nil {
+		h.handleError(w, r, err)
+		return
+	}
+}
+
+func (h *Handler) FindWebhook(w http.ResponseWriter, r *http.Request) {
+	idStr := chi.URLParam(r, "id")
+	id, err := uuid.Parse(idStr)
+	if err != nil {
+		h.handleFail(w, r, ErrInvalidWebhookID)
+		return
+	}
+
+	webhook, err := h.wr.Find(r.Context(), id)
+	if err != nil {
+		h.handleFail(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	if err := json.NewEncoder(w).Encode(webhook); err != nil {
+		h.handleError(w, r, err)
+		return
+	}
+}
+
+func (h *Handler) DeleteWebhook(w http.ResponseWriter, r *http.Request) {
+	idStr := chi.URLParam(r, "id")
+	id, err := uuid.Parse(idStr)
+	if err != nil {
+		h.handleFail(w, r, ErrInvalidWebhookID)
+		return
+	}
+
+	if err := h.ws.Delete(r.Context(), id); err != nil {
+		h.handleFail(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *Handler) WebhookDeliveries(w http.ResponseWriter, r *http.Request) {
+	idStr := chi.URLParam(r, "id")
+	id, err := uuid.Parse(idStr)
+	if err != nil {
+		h.handleFail(w, r, ErrInvalidWebhookID)
+		return
+	}
+
+	limit, err := h.limit(r)
+	if err != nil {
+		h.handleFail(w, r, err)
+		return
+	}
+
+	if _, err := h.wr.Find(r.Context(), id); err != nil {
+		h.handleFail(w, r, err)
+		return
+	}
+
+	deliveries, err := h.wr.Deliveries(r.Context(), id, limit)
+	if err != nil {
+		h.handleError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	resp := NewWebhookDeliveryListResponse(deliveries)
+	if err := json.NewEncoder(w).Encode(resp); err != nil {
+		h.handleError(w, r, err)
+		return
+	}
+}
+
+// Redeliver queues a delivery again. It is sent by the dispatcher, hence the
+// response only acknowledges the request.
+func (h *Handler) Redeliver(w http.ResponseWriter, r *http.Request) {
+	idStr := chi.URLParam(r, "id")
+	id, err := uuid.Parse(idStr)
+	if err != nil {
+		h.handleFail(w, r, ErrInvalidWebhookID)
+		return
+	}
+	deliveryIDStr := chi.URLParam(r, "deliveryID")
+	deliveryID, err := uuid.Parse(deliveryIDStr)
+	if err != nil {
+		h.handleFail(w, r, ErrInvalidDeliveryID)
+		return
+	}
+
+	if err := h.ws.Redeliver(r.Context(), id, deliveryID); err != nil {
+		h.handleFail(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusAccepted)
+}
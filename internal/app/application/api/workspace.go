@@ -0,0 +1,33 @@
+package api
+
+import (
+	"github.com/aviseu/go-sample/internal/app/infrastructure"
+	"github.com/google/uuid"
+	"net/http"
+)
+
+// Workspace scopes the request to the workspace named in the X-Workspace-ID
+// header. Requests without the header are rejected, unless the handler falls
+// back to the default workspace, see HandlerWithDefaultWorkspace.
+func (h *Handler) Workspace(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		header := r.Header.Get("X-Workspace-ID")
+		if header == "" {
+			if !h.defaultWorkspace {
+				h.handleFail(w, r, ErrWorkspaceRequired)
+				return
+			}
+
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		id, err := uuid.Parse(header)
+		if err != nil {
+			h.handleFail(w, r, ErrInvalidWorkspace)
+			return
+		}
+
+		next.ServeHTTP(w, r.WithContext(infrastructure.WithWorkspace(r.Context(), id)))
+	})
+}
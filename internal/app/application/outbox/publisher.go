@@ -0,0 +1,44 @@
+package outbox
+
+import (
+	"context"
+	"fmt"
+	"github.com/aviseu/go-sample/internal/app/infrastructure/aggregators"
+	"log/slog"
+)
+
+// Publisher hands an event to another system. Events are delivered at least
+// once, so publishers and whatever is behind them must cope with duplicates.
+type Publisher interface {
+	Publish(ctx context.Context, e *aggregators.Event) error
+}
+
+// Publishers hands an event to every publisher in turn. A failing publisher
+// fails the delivery, so the publishers before it see the event again when it
+// is retried.
+type Publishers []Publisher
+
+func (ps Publishers) Publish(ctx context.Context, e *aggregators.Event) error {
+	for _, p := range ps {
+		if err := p.Publish(ctx, e); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// LogPublisher logs every event it is handed.
+type LogPublisher struct {
+	log *slog.Logger
+}
+
+func NewLogPublisher(log *slog.Logger) *LogPublisher {
+	return &LogPublisher{log: log}
+}
+
+func (p *LogPublisher) Publish(_ context.Context, e *aggregators.Event) error {
+	p.log.Info(fmt.Sprintf("event %s: %s of task %s", e.ID, e.Type, e.TaskID))
+
+	return nil
+}
@@ -0,0 +1,154 @@
+package outbox
+
+import (
+	"context"
+	"fmt"
+	"github.com/aviseu/go-sample/internal/app/infrastructure/aggregators"
+	"github.com/google/uuid"
+	"log/slog"
+	"time"
+)
+
+type Store interface {
+	Claim(ctx context.Context, limit int, lease time.Duration) ([]*aggregators.Event, error)
+	Delivered(ctx context.Context, id uuid.UUID) error
+	Failed(ctx context.Context, id uuid.UUID, reason string, retryAt time.Time, dead bool) error
+}
+
+type RelayOptional func(*Relay)
+
+func RelayWithClock(now func() time.Time) RelayOptional {
+	return func(r *Relay) {
+		r.now = now
+	}
+}
+
+func RelayWithInterval(d time.Duration) RelayOptional {
+	return func(r *Relay) {
+		r.interval = d
+	}
+}
+
+func RelayWithBatchSize(n int) RelayOptional {
+	return func(r *Relay) {
+		r.batchSize = n
+	}
+}
+
+func RelayWithLease(d time.Duration) RelayOptional {
+	return func(r *Relay) {
+		r.lease = d
+	}
+}
+
+// RelayWithMaxAttempts sets after how many failed deliveries an event is given
+// up on and left dead in the outbox.
+func RelayWithMaxAttempts(n int) RelayOptional {
+	return func(r *Relay) {
+		r.maxAttempts = n
+	}
+}
+
+// RelayWithBackoff sets the wait before the first retry, which doubles with
+// every further failure.
+func RelayWithBackoff(d time.Duration) RelayOptional {
+	return func(r *Relay) {
+		r.backoff = d
+	}
+}
+
+// Relay moves the events from the outbox to the publisher. An event is only
+// marked as delivered once the publisher accepted it, and the events of a task
+// are delivered one after the other in the order they were recorded.
+type Relay struct {
+	log         *slog.Logger
+	s           Store
+	p           Publisher
+	now         func() time.Time
+	interval    time.Duration
+	batchSize   int
+	lease       time.Duration
+	maxAttempts int
+	backoff     time.Duration
+}
+
+func NewRelay(log *slog.Logger, s Store, p Publisher, opts ...RelayOptional) *Relay {
+	r := &Relay{
+		log:         log,
+		s:           s,
+		p:           p,
+		now:         time.Now,
+		interval:    time.Second,
+		batchSize:   100,
+		lease:       30 * time.Second,
+		maxAttempts: 10,
+		backoff:     time.Second,
+	}
+	for _, opt := range opts {
+		opt(r)
+	}
+
+	return r
+}
+
+// Run delivers events until ctx is done. It only waits for the interval when
+// there was nothing to deliver, so a backlog is worked off right away.
+func (r *Relay) Run(ctx context.Context) {
+	for {
+		n, err := r.Deliver(ctx)
+		if err != nil && ctx.Err() == nil {
+			r.log.Error(err.Error())
+		}
+		if n > 0 && err == nil {
+			continue
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(r.interval):
+		}
+	}
+}
+
+// Deliver hands one batch of due events to the publisher and returns how many
+// it claimed. A failed delivery is retried with exponential backoff until the
+// event runs out of attempts and is marked as dead.
+func (r *Relay) Deliver(ctx context.Context) (int, error) {
+	events, err := r.s.Claim(ctx, r.batchSize, r.lease)
+	if err != nil {
+		return 0, fmt.Errorf("failed to claim events: %w", err)
+	}
+
+	for _, e := range events {
+		if err := r.p.Publish(ctx, e); err != nil {
+			if err := r.failed(ctx, e, err); err != nil {
+				return len(events), err
+			}
+			continue
+		}
+
+		if err := r.s.Delivered(ctx, e.ID); err != nil {
+			return len(events), fmt.Errorf("failed to mark event %s as delivered: %w", e.ID, err)
+		}
+	}
+
+	return len(events), nil
+}
+
+func (r *Relay) failed(ctx context.Context, e *aggregators.Event, reason error) error {
+	attempts := e.Attempts + 1
+	dead := attempts >= r.maxAttempts
+	if dead {
+		r.log.Error(fmt.Sprintf("giving up on event %s after %d attempts: %s", e.ID, attempts, reason))
+	} else {
+		r.log.Warn(fmt.Sprintf("failed to deliver event %s, attempt %d: %s", e.ID, attempts, reason))
+	}
+
+	retryAt := r.now().Add(r.backoff << min(attempts-1, 20))
+	if err := r.s.Failed(ctx, e.ID, reason.Error(), retryAt, dead); err != nil {
+		return fmt.Errorf("failed to mark event %s as failed: %w", e.ID, err)
+	}
+
+	return nil
+}
@@ -0,0 +1,214 @@
+package outbox_test
+
+import (
+	"context"
+	"errors"
+	"github.com/aviseu/go-sample/internal/app/application/outbox"
+	"github.com/aviseu/go-sample/internal/app/infrastructure/aggregators"
+	"github.com/aviseu/go-sample/internal/testutils"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/suite"
+	"testing"
+	"time"
+)
+
+func TestRelay(t *testing.T) {
+	t.Parallel()
+	suite.Run(t, new(RelaySuite))
+}
+
+type RelaySuite struct {
+	suite.Suite
+}
+
+func event(taskID uuid.UUID, typ string) *aggregators.Event {
+	return &aggregators.Event{ID: uuid.New(), TaskID: taskID, Type: typ, Payload: []byte(`{}`)}
+}
+
+func (suite *RelaySuite) TestDeliverSuccess() {
+	// Prepare
+	task1, task2 := uuid.New(), uuid.New()
+	tr := testutils.NewTaskRepository()
+	tr.EventRecords = []*aggregators.Event{event(task1, "task.created"), event(task2, "task.created")}
+	s := testutils.NewOutboxRepository(tr)
+	p := testutils.NewPublisher()
+	_, log := testutils.NewLogger()
+	r := outbox.NewRelay(log, s, p)
+
+	// Execute
+	n, err := r.Deliver(context.Background())
+
+	// Assert
+	suite.NoError(err)
+	suite.Equal(2, n)
+	suite.Equal(tr.EventRecords, p.Published)
+	suite.Equal(testutils.OutboxDelivered, s.Entries[tr.EventRecords[0].ID].Status)
+	suite.Equal(testutils.OutboxDelivered, s.Entries[tr.EventRecords[1].ID].Status)
+}
+
+func (suite *RelaySuite) TestDeliverInOrderPerTask() {
+	// Prepare
+	task1 := uuid.New()
+	tr := testutils.NewTaskRepository()
+	tr.EventRecords = []*aggregators.Event{event(task1, "task.created"), event(task1, "task.completed")}
+	s := testutils.NewOutboxRepository(tr)
+	p := testutils.NewPublisher()
+	_, log := testutils.NewLogger()
+	r := outbox.NewRelay(log, s, p)
+
+	// Execute
+	n1, err1 := r.Deliver(context.Background())
+	n2, err2 := r.Deliver(context.Background())
+	n3, err3 := r.Deliver(context.Background())
+
+	// Assert
+	suite.NoError(err1)
+	suite.NoError(err2)
+	suite.NoError(err3)
+	suite.Equal(1, n1)
+	suite.Equal(1, n2)
+	suite.Equal(0, n3)
+	suite.Require().Len(p.Published, 2)
+	suite.Equal("task.created", p.Published[0].Type)
+	suite.Equal("task.completed", p.Published[1].Type)
+}
+
+func (suite *RelaySuite) TestDeliverRetriesWithBackoff() {
+	// Prepare
+	task1 := uuid.New()
+	tr := testutils.NewTaskRepository()
+	tr.EventRecords = []*aggregators.Event{event(task1, "task.created"), event(task1, "task.completed")}
+	now := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+	clock := func() time.Time { return now }
+	s := testutils.NewOutboxRepository(tr, testutils.OutboxRepositoryWithClock(clock))
+	p := testutils.NewPublisher(errors.New("unavailable"), errors.New("unavailable"))
+	buf, log := testutils.NewLogger()
+	r := outbox.NewRelay(log, s, p, outbox.RelayWithClock(clock), outbox.RelayWithBackoff(time.Minute))
+
+	// Execute
+	_, err1 := r.Deliver(context.Background())
+	now = now.Add(time.Minute)
+	_, err2 := r.Deliver(context.Background())
+	now = now.Add(time.Minute)
+	n3, err3 := r.Deliver(context.Background())
+	now = now.Add(time.Minute)
+	n4, err4 := r.Deliver(context.Background())
+
+	// Assert
+	suite.NoError(err1)
+	suite.NoError(err2)
+	suite.NoError(err3)
+	suite.NoError(err4)
+	suite.Equal(0, n3)
+	suite.Equal(1, n4)
+	suite.Require().Len(p.Published, 1)
+	suite.Equal("task.created", p.Published[0].Type)
+	entry := s.Entries[tr.EventRecords[0].ID]
+	suite.Equal(testutils.OutboxDelivered, entry.Status)
+	suite.Equal("unavailable", entry.LastError)
+	suite.Equal(2, tr.EventRecords[0].Attempts)
+	suite.Equal(testutils.OutboxPending, s.Entries[tr.EventRecords[1].ID].Status)
+	lines := testutils.LogLines(buf)
+	suite.Len(lines, 2)
+	suite.Contains(lines[0], `"level":"WARN"`)
+	suite.Contains(lines[0], "attempt 1: unavailable")
+}
+
+func (suite *RelaySuite) TestDeliverDeadAfterMaxAttempts() {
+	// Prepare
+	task1 := uuid.New()
+	tr := testutils.NewTaskRepository()
+	tr.EventRecords = []*aggregators.Event{event(task1, "task.created"), event(task1, "task.completed")}
+	s := testutils.NewOutboxRepository(tr)
+	p := testutils.NewPublisher(errors.New("rejected"), errors.New("rejected"))
+	buf, log := testutils.NewLogger()
+	r := outbox.NewRelay(log, s, p, outbox.RelayWithMaxAttempts(2), outbox.RelayWithBackoff(0))
+
+	// Execute
+	_, err1 := r.Deliver(context.Background())
+	_, err2 := r.Deliver(context.Background())
+	_, err3 := r.Deliver(context.Background())
+
+	// Assert
+	suite.NoError(err1)
+	suite.NoError(err2)
+	suite.NoError(err3)
+	suite.Equal(testutils.OutboxDead, s.Entries[tr.EventRecords[0].ID].Status)
+	suite.Equal(testutils.OutboxDelivered, s.Entries[tr.EventRecords[1].ID].Status)
+	suite.Require().Len(p.Published, 1)
+	suite.Equal("task.completed", p.Published[0].Type)
+	lines := testutils.LogLines(buf)
+	suite.Len(lines, 2)
+	suite.Contains(lines[1], `"level":"ERROR"`)
+	suite.Contains(lines[1], "after 2 attempts: rejected")
+}
+
+func (suite *RelaySuite) TestDeliverStoreFail() {
+	// Prepare
+	tr := testutils.NewTaskRepository()
+	s := testutils.NewOutboxRepository(tr, testutils.OutboxRepositoryWithError(errors.New("error")))
+	_, log := testutils.NewLogger()
+	r := outbox.NewRelay(log, s, testutils.NewPublisher())
+
+	// Execute
+	n, err := r.Deliver(context.Background())
+
+	// Assert
+	suite.Error(err)
+	suite.Equal("failed to claim events: error", err.Error())
+	suite.Equal(0, n)
+}
+
+// cancelPublisher stops the relay once it published an event.
+type cancelPublisher struct {
+	cancel context.CancelFunc
+}
+
+func (p cancelPublisher) Publish(context.Context, *aggregators.Event) error {
+	p.cancel()
+	return nil
+}
+
+func (suite *RelaySuite) TestRunStopsWhenContextIsDone() {
+	// Prepare
+	tr := testutils.NewTaskRepository()
+	tr.EventRecords = []*aggregators.Event{event(uuid.New(), "task.created")}
+	s := testutils.NewOutboxRepository(tr)
+	p := testutils.NewPublisher()
+	ctx, cancel := context.WithCancel(context.Background())
+	_, log := testutils.NewLogger()
+	r := outbox.NewRelay(log, s, outbox.Publishers{p, cancelPublisher{cancel}}, outbox.RelayWithInterval(time.Hour))
+	done := make(chan struct{})
+
+	// Execute
+	go func() {
+		defer close(done)
+		r.Run(ctx)
+	}()
+
+	// Assert
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		suite.Fail("relay did not stop")
+	}
+	suite.Len(p.Published, 1)
+	suite.Equal(testutils.OutboxDelivered, s.Entries[tr.EventRecords[0].ID].Status)
+}
+
+func (suite *RelaySuite) TestPublishersStopAtFirstFailure() {
+	// Prepare
+	p1 := testutils.NewPublisher()
+	p2 := testutils.NewPublisher(errors.New("error"))
+	p3 := testutils.NewPublisher()
+	e := event(uuid.New(), "task.created")
+
+	// Execute
+	err := outbox.Publishers{p1, p2, p3}.Publish(context.Background(), e)
+
+	// Assert
+	suite.Error(err)
+	suite.Len(p1.Published, 1)
+	suite.Empty(p2.Published)
+	suite.Empty(p3.Published)
+}
@@ -12,6 +12,15 @@ import (
 type Config struct {
 	Host            string        `default:"0.0.0.0:8080"`
 	ShutdownTimeout time.Duration `default:"30s"`
+	DefaultPageSize int           `default:"20"`
+	MaxPageSize     int           `default:"100"`
+	EventsHeartbeat time.Duration `default:"15s"`
+	LiveWindow      int           `default:"32"`
+	LivePing        time.Duration `default:"30s"`
+	IdempotencyTTL  time.Duration `default:"24h"`
+	// DefaultWorkspace lets requests without an X-Workspace-ID header use the
+	// default workspace, which they are refused otherwise.
+	DefaultWorkspace bool `default:"false"`
 }
 
 func SetupServer(cfg Config, h http.Handler) http.Server {
@@ -21,11 +30,26 @@ func SetupServer(cfg Config, h http.Handler) http.Server {
 	}
 }
 
-func APIHandler(log *slog.Logger, s *domain.Service, r api.Repository) http.Handler {
+// APIHandler serves the API with the settings of cfg, which come before the
+// given options.
+func APIHandler(cfg Config, log *slog.Logger, s *domain.Service, r api.Repository, opts ...api.HandlerOptional) http.Handler {
 	router := chi.NewRouter()
 
-	h := api.NewHandler(log, s, r)
+	opts = append([]api.HandlerOptional{
+		api.HandlerWithPageSize(cfg.DefaultPageSize, cfg.MaxPageSize),
+		api.HandlerWithHeartbeat(cfg.EventsHeartbeat),
+		api.HandlerWithLive(cfg.LiveWindow, cfg.LivePing),
+		api.HandlerWithIdempotencyTTL(cfg.IdempotencyTTL),
+	}, opts...)
+	if cfg.DefaultWorkspace {
+		opts = append([]api.HandlerOptional{api.HandlerWithDefaultWorkspace()}, opts...)
+	}
+	h := api.NewHandler(log, s, r, opts...)
+	router.Use(h.Workspace, h.Actor)
 	router.Mount("/api/tasks", h.Routes())
+	router.Mount("/api/tags", h.TagRoutes())
+	router.Mount("/api/projects", h.ProjectRoutes())
+	router.Mount("/api/webhooks", h.WebhookRoutes())
 
 	return router
 }
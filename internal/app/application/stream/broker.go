@@ -0,0 +1,185 @@
+package stream
+
+import (
+	"github.com/aviseu/go-sample/internal/app/infrastructure/aggregators"
+	"github.com/google/uuid"
+	"slices"
+	"sync"
+)
+
+type BrokerOptional func(*Broker)
+
+// BrokerWithReplay sets how many of the latest changes are kept to replay to
+// subscribers that reconnect.
+func BrokerWithReplay(n int) BrokerOptional {
+	return func(b *Broker) {
+		b.replaySize = n
+	}
+}
+
+// BrokerWithBuffer sets how many changes a subscriber may fall behind before it
+// is dropped.
+func BrokerWithBuffer(n int) BrokerOptional {
+	return func(b *Broker) {
+		b.buffer = n
+	}
+}
+
+// Change is an event together with the task as it was saved with it.
+type Change struct {
+	Event *aggregators.Event
+	Task  *aggregators.Task
+}
+
+// Broker fans the changes of saved tasks out to the subscribers of their
+// workspace as they happen. It is fed by a postgres.ChangeListener, so its
+// subscribers see the changes saved by every instance. Its replay is kept in
+// memory, though, and only holds the latest changes this instance passed on.
+type Broker struct {
+	mu          sync.Mutex
+	replay      []*Change
+	replaySize  int
+	buffer      int
+	subscribers map[*Subscription]struct{}
+	closed      bool
+}
+
+func NewBroker(opts ...BrokerOptional) *Broker {
+	b := &Broker{
+		replaySize:  1000,
+		buffer:      100,
+		subscribers: make(map[*Subscription]struct{}),
+	}
+	for _, opt := range opts {
+		opt(b)
+	}
+
+	return b
+}
+
+// Subscription receives the changes of a single workspace until it is closed,
+// either by the subscriber or by the broker.
+type Subscription struct {
+	b           *Broker
+	workspaceID uuid.UUID
+	changes     chan *Change
+	done        chan struct{}
+}
+
+// Changes is closed when the subscription ends. That happens when the broker
+// shuts down or when the subscriber fell too far behind, which it can recover
+// from by subscribing again from the last event it saw.
+func (s *Subscription) Changes() <-chan *Change {
+	return s.changes
+}
+
+// Done is closed when the subscription ends, which can be noticed without
+// taking the changes that are still waiting.
+func (s *Subscription) Done() <-chan struct{} {
+	return s.done
+}
+
+func (s *Subscription) Close() {
+	s.b.mu.Lock()
+	defer s.b.mu.Unlock()
+
+	s.b.drop(s)
+}
+
+// Subscribe starts a subscription to the changes of the workspace. Given the ID
+// of the last event a subscriber saw, it also returns the changes that followed
+// it. When that event is no longer kept, all kept changes are returned as some
+// of the missed changes are better than none.
+func (b *Broker) Subscribe(workspaceID uuid.UUID, lastEventID *uuid.UUID) (*Subscription, []*Change) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	s := &Subscription{
+		b:           b,
+		workspaceID: workspaceID,
+		changes:     make(chan *Change, b.buffer),
+		done:        make(chan struct{}),
+	}
+	if b.closed {
+		close(s.changes)
+		close(s.done)
+		return s, nil
+	}
+	b.subscribers[s] = struct{}{}
+
+	if lastEventID == nil {
+		return s, nil
+	}
+
+	replay := b.replay
+	if i := slices.IndexFunc(replay, func(c *Change) bool { return c.Event.ID == *lastEventID }); i >= 0 {
+		replay = replay[i+1:]
+	}
+
+	var missed []*Change
+	for _, c := range replay {
+		if c.Event.WorkspaceID == workspaceID {
+			missed = append(missed, c)
+		}
+	}
+
+	return s, missed
+}
+
+// Notify hands the events of the tasks to the subscribers of their workspace
+// without ever blocking on them.
+func (b *Broker) Notify(tasks ...*aggregators.Task) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.closed {
+		return
+	}
+
+	for _, task := range tasks {
+		for _, e := range task.Events {
+			b.publish(&Change{Event: e, Task: task})
+		}
+	}
+}
+
+func (b *Broker) publish(c *Change) {
+	b.replay = append(b.replay, c)
+	if len(b.replay) > b.replaySize {
+		b.replay = slices.Delete(b.replay, 0, len(b.replay)-b.replaySize)
+	}
+
+	for s := range b.subscribers {
+		if s.workspaceID != c.Event.WorkspaceID {
+			continue
+		}
+
+		select {
+		case s.changes <- c:
+		default:
+			b.drop(s)
+		}
+	}
+}
+
+// Close ends all subscriptions, and every later one right away, so that the
+// streams that serve them finish and the server can shut down.
+func (b *Broker) Close() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.closed = true
+	for s := range b.subscribers {
+		b.drop(s)
+	}
+}
+
+func (b *Broker) drop(s *Subscription) {
+	if _, ok := b.subscribers[s]; !ok {
+		return
+	}
+
+	delete(b.subscribers, s)
+	close(s.changes)
+	close(s.done)
+}
@@ -0,0 +1,147 @@
+package stream_test
+
+import (
+	"github.com/aviseu/go-sample/internal/app/application/stream"
+	"github.com/aviseu/go-sample/internal/app/infrastructure/aggregators"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/suite"
+	"testing"
+)
+
+func TestBroker(t *testing.T) {
+	t.Parallel()
+	suite.Run(t, new(BrokerSuite))
+}
+
+type BrokerSuite struct {
+	suite.Suite
+}
+
+// changed returns a task of the workspace that recorded a single event.
+func changed(workspaceID uuid.UUID) *aggregators.Task {
+	id := uuid.New()
+	return &aggregators.Task{
+		ID:     id,
+		Title:  "task",
+		Events: []*aggregators.Event{{ID: uuid.New(), WorkspaceID: workspaceID, TaskID: id, Type: "task.created"}},
+	}
+}
+
+// change returns the change the broker makes of a task changed with a single
+// event.
+func change(task *aggregators.Task) *stream.Change {
+	return &stream.Change{Event: task.Events[0], Task: task}
+}
+
+func (suite *BrokerSuite) TestNotifySubscribersOfWorkspace() {
+	// Prepare
+	ws1, ws2 := uuid.New(), uuid.New()
+	b := stream.NewBroker()
+	sub1, _ := b.Subscribe(ws1, nil)
+	sub2, _ := b.Subscribe(ws2, nil)
+	t := changed(ws1)
+
+	// Execute
+	b.Notify(t)
+
+	// Assert
+	suite.Equal(change(t), <-sub1.Changes())
+	suite.Empty(sub2.Changes())
+}
+
+func (suite *BrokerSuite) TestSubscribeReplaysMissedChanges() {
+	// Prepare
+	ws := uuid.New()
+	b := stream.NewBroker()
+	t1, t2, other, t3 := changed(ws), changed(ws), changed(uuid.New()), changed(ws)
+	b.Notify(t1, t2, other, t3)
+
+	// Execute
+	_, missed := b.Subscribe(ws, &t1.Events[0].ID)
+
+	// Assert
+	suite.Equal([]*stream.Change{change(t2), change(t3)}, missed)
+}
+
+func (suite *BrokerSuite) TestSubscribeReplaysAllWhenLastEventIsGone() {
+	// Prepare
+	ws := uuid.New()
+	b := stream.NewBroker(stream.BrokerWithReplay(2))
+	t1, t2, t3 := changed(ws), changed(ws), changed(ws)
+	b.Notify(t1, t2, t3)
+
+	// Execute
+	_, missed := b.Subscribe(ws, &t1.Events[0].ID)
+
+	// Assert
+	suite.Equal([]*stream.Change{change(t2), change(t3)}, missed)
+}
+
+func (suite *BrokerSuite) TestSubscribeWithoutLastEventReplaysNothing() {
+	// Prepare
+	ws := uuid.New()
+	b := stream.NewBroker()
+	b.Notify(changed(ws))
+
+	// Execute
+	sub, missed := b.Subscribe(ws, nil)
+
+	// Assert
+	suite.Empty(missed)
+	suite.Empty(sub.Changes())
+}
+
+func (suite *BrokerSuite) TestSlowSubscriberIsDropped() {
+	// Prepare
+	ws := uuid.New()
+	b := stream.NewBroker(stream.BrokerWithBuffer(1))
+	slow, _ := b.Subscribe(ws, nil)
+	t1, t2 := changed(ws), changed(ws)
+
+	// Execute
+	b.Notify(t1, t2)
+
+	// Assert
+	c, ok := <-slow.Changes()
+	suite.True(ok)
+	suite.Equal(change(t1), c)
+	_, ok = <-slow.Changes()
+	suite.False(ok)
+	<-slow.Done()
+	_, missed := b.Subscribe(ws, &t1.Events[0].ID)
+	suite.Equal([]*stream.Change{change(t2)}, missed)
+}
+
+func (suite *BrokerSuite) TestCloseEndsSubscriptions() {
+	// Prepare
+	ws := uuid.New()
+	b := stream.NewBroker()
+	before, _ := b.Subscribe(ws, nil)
+
+	// Execute
+	b.Close()
+
+	// Assert
+	_, ok := <-before.Changes()
+	suite.False(ok)
+	after, _ := b.Subscribe(ws, nil)
+	_, ok = <-after.Changes()
+	suite.False(ok)
+	b.Notify(changed(ws))
+	before.Close()
+}
+
+func (suite *BrokerSuite) TestCloseSubscription() {
+	// Prepare
+	ws := uuid.New()
+	b := stream.NewBroker()
+	sub, _ := b.Subscribe(ws, nil)
+
+	// Execute
+	sub.Close()
+	b.Notify(changed(ws))
+
+	// Assert
+	_, ok := <-sub.Changes()
+	suite.False(ok)
+}
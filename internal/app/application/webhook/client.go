@@ -0,0 +1,59 @@
+package webhook
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/netip"
+	"syscall"
+	"time"
+)
+
+var ErrForbiddenAddress = errors.New("webhook address is not public")
+
+// sharedAddresses is the carrier-grade NAT range, which is not private by name
+// but no more reachable from the internet.
+var sharedAddresses = netip.MustParsePrefix("100.64.0.0/10")
+
+// NewClient returns the client webhooks are sent with. It only connects to
+// public addresses, whatever the host of a webhook resolves to at the time, so
+// that a webhook cannot reach the services next to this one. It does not
+// follow redirects either, since those could point anywhere.
+func NewClient(timeout time.Duration) *http.Client {
+	dialer := &net.Dialer{Timeout: 30 * time.Second, KeepAlive: 30 * time.Second, Control: dialPublic}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = nil
+	transport.DialContext = dialer.DialContext
+
+	return &http.Client{
+		Timeout:   timeout,
+		Transport: transport,
+		CheckRedirect: func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+}
+
+// dialPublic refuses to connect to an address that is not public. It runs
+// after the host was resolved, so it also sees through names that resolve to
+// such an address.
+func dialPublic(_, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return fmt.Errorf("%w: %s", ErrForbiddenAddress, address)
+	}
+	ip, err := netip.ParseAddr(host)
+	if err != nil || !public(ip) {
+		return fmt.Errorf("%w: %s", ErrForbiddenAddress, address)
+	}
+
+	return nil
+}
+
+// public reports whether ip can be reached from the internet, which rules out
+// loopback, link-local, private and unspecified addresses among others.
+func public(ip netip.Addr) bool {
+	ip = ip.Unmap()
+	return ip.IsGlobalUnicast() && !ip.IsPrivate() && !sharedAddresses.Contains(ip)
+}
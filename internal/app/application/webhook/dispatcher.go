@@ -0,0 +1,216 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"github.com/aviseu/go-sample/internal/app/infrastructure/aggregators"
+	"github.com/google/uuid"
+	"io"
+	"log/slog"
+	"math/rand/v2"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+const (
+	DeliveryPending   = "pending"
+	DeliveryDelivered = "delivered"
+	DeliveryDead      = "dead"
+
+	AttemptSucceeded = "succeeded"
+	AttemptFailed    = "failed"
+)
+
+type Store interface {
+	Claim(ctx context.Context, limit int, lease time.Duration) ([]*aggregators.WebhookDelivery, error)
+	Record(ctx context.Context, a *aggregators.WebhookAttempt, status string, retryAt time.Time) error
+}
+
+type DispatcherOptional func(*Dispatcher)
+
+func DispatcherWithClock(now func() time.Time) DispatcherOptional {
+	return func(d *Dispatcher) {
+		d.now = now
+	}
+}
+
+// DispatcherWithClient replaces the client of NewClient, and with it the
+// restriction to public addresses.
+func DispatcherWithClient(c *http.Client) DispatcherOptional {
+	return func(d *Dispatcher) {
+		d.client = c
+	}
+}
+
+func DispatcherWithInterval(i time.Duration) DispatcherOptional {
+	return func(d *Dispatcher) {
+		d.interval = i
+	}
+}
+
+func DispatcherWithBatchSize(n int) DispatcherOptional {
+	return func(d *Dispatcher) {
+		d.batchSize = n
+	}
+}
+
+func DispatcherWithMaxAttempts(n int) DispatcherOptional {
+	return func(d *Dispatcher) {
+		d.maxAttempts = n
+	}
+}
+
+// DispatcherWithBackoff sets the wait before the first retry, which doubles with
+// every further failure.
+func DispatcherWithBackoff(b time.Duration) DispatcherOptional {
+	return func(d *Dispatcher) {
+		d.backoff = b
+	}
+}
+
+// DispatcherWithJitter replaces the random extra wait of up to the given
+// duration that spreads out the retries of deliveries which failed together.
+func DispatcherWithJitter(jitter func(max time.Duration) time.Duration) DispatcherOptional {
+	return func(d *Dispatcher) {
+		d.jitter = jitter
+	}
+}
+
+// Dispatcher sends the queued deliveries to their webhooks. Every request is
+// recorded as an attempt; any 2xx response delivers the event, anything else is
+// retried with exponential backoff and jitter until the delivery is dead.
+type Dispatcher struct {
+	log         *slog.Logger
+	s           Store
+	client      *http.Client
+	now         func() time.Time
+	jitter      func(max time.Duration) time.Duration
+	interval    time.Duration
+	batchSize   int
+	lease       time.Duration
+	maxAttempts int
+	backoff     time.Duration
+}
+
+func NewDispatcher(log *slog.Logger, s Store, opts ...DispatcherOptional) *Dispatcher {
+	d := &Dispatcher{
+		log:         log,
+		s:           s,
+		client:      NewClient(10 * time.Second),
+		now:         time.Now,
+		jitter:      func(max time.Duration) time.Duration { return rand.N(max + 1) },
+		interval:    time.Second,
+		batchSize:   20,
+		maxAttempts: 8,
+		backoff:     10 * time.Second,
+	}
+	for _, opt := range opts {
+		opt(d)
+	}
+	// a delivery must not be claimed again while its request may still be running
+	d.lease = 2 * d.client.Timeout
+	if d.lease == 0 {
+		d.lease = time.Minute
+	}
+
+	return d
+}
+
+// Run dispatches deliveries until ctx is done, waiting for the interval only
+// when there was nothing to send.
+func (d *Dispatcher) Run(ctx context.Context) {
+	for {
+		n, err := d.Dispatch(ctx)
+		if err != nil && ctx.Err() == nil {
+			d.log.Error(err.Error())
+		}
+		if n > 0 && err == nil {
+			continue
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(d.interval):
+		}
+	}
+}
+
+// Dispatch sends one batch of due deliveries and returns how many it claimed.
+func (d *Dispatcher) Dispatch(ctx context.Context) (int, error) {
+	deliveries, err := d.s.Claim(ctx, d.batchSize, d.lease)
+	if err != nil {
+		return 0, fmt.Errorf("failed to claim webhook deliveries: %w", err)
+	}
+
+	for _, delivery := range deliveries {
+		a := d.send(ctx, delivery)
+
+		status, retryAt := DeliveryDelivered, a.AttemptedAt
+		if a.Status == AttemptFailed {
+			status, retryAt = d.retry(delivery, a)
+		}
+
+		if err := d.s.Record(ctx, a, status, retryAt); err != nil {
+			return len(deliveries), fmt.Errorf("failed to record attempt of webhook delivery %s: %w", delivery.ID, err)
+		}
+	}
+
+	return len(deliveries), nil
+}
+
+func (d *Dispatcher) send(ctx context.Context, delivery *aggregators.WebhookDelivery) *aggregators.WebhookAttempt {
+	now := d.now()
+	a := &aggregators.WebhookAttempt{
+		ID:          uuid.New(),
+		DeliveryID:  delivery.ID,
+		Status:      AttemptFailed,
+		AttemptedAt: now,
+	}
+	fail := func(err error) *aggregators.WebhookAttempt {
+		reason := err.Error()
+		a.Error = &reason
+		return a
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, delivery.URL, bytes.NewReader(delivery.Payload))
+	if err != nil {
+		return fail(err)
+	}
+	timestamp := now.Unix()
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(HeaderDelivery, delivery.ID.String())
+	req.Header.Set(HeaderEvent, delivery.EventType)
+	req.Header.Set(HeaderTimestamp, strconv.FormatInt(timestamp, 10))
+	req.Header.Set(HeaderSignature, Sign(delivery.Secret, timestamp, delivery.Payload))
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return fail(err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+
+	a.ResponseCode = &resp.StatusCode
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fail(fmt.Errorf("unexpected response status %d", resp.StatusCode))
+	}
+	a.Status = AttemptSucceeded
+
+	return a
+}
+
+func (d *Dispatcher) retry(delivery *aggregators.WebhookDelivery, a *aggregators.WebhookAttempt) (string, time.Time) {
+	failures := delivery.Failures + 1
+	if failures >= d.maxAttempts {
+		d.log.Error(fmt.Sprintf("giving up on webhook delivery %s after %d attempts: %s", delivery.ID, failures, *a.Error))
+		return DeliveryDead, a.AttemptedAt
+	}
+
+	d.log.Warn(fmt.Sprintf("failed to deliver webhook delivery %s, attempt %d: %s", delivery.ID, failures, *a.Error))
+	wait := d.backoff << min(failures-1, 20)
+
+	return DeliveryPending, a.AttemptedAt.Add(wait + d.jitter(wait/2))
+}
@@ -0,0 +1,353 @@
+package webhook_test
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"github.com/aviseu/go-sample/internal/app/application/outbox"
+	"github.com/aviseu/go-sample/internal/app/application/webhook"
+	"github.com/aviseu/go-sample/internal/app/domain"
+	"github.com/aviseu/go-sample/internal/app/infrastructure"
+	"github.com/aviseu/go-sample/internal/app/infrastructure/aggregators"
+	"github.com/aviseu/go-sample/internal/testutils"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/suite"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestDispatcher(t *testing.T) {
+	t.Parallel()
+	suite.Run(t, new(DispatcherSuite))
+}
+
+type DispatcherSuite struct {
+	suite.Suite
+}
+
+// received is a request as the receiver saw it.
+type received struct {
+	header http.Header
+	body   []byte
+}
+
+// receiver answers with the given status codes in turn and then with 200.
+func receiver(codes ...int) (*httptest.Server, *[]received) {
+	var requests []received
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		requests = append(requests, received{header: r.Header.Clone(), body: body})
+
+		code := http.StatusOK
+		if len(codes) > 0 {
+			code, codes = codes[0], codes[1:]
+		}
+		w.WriteHeader(code)
+	})), &requests
+}
+
+func (suite *DispatcherSuite) TestDispatchSuccess() {
+	// Prepare
+	server, requests := receiver()
+	defer server.Close()
+	id, deliveryID := uuid.New(), uuid.New()
+	now := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+	r := testutils.NewWebhookRepository(
+		testutils.WebhookRepositoryWithClock(func() time.Time { return now }),
+		testutils.WebhookRepositoryWithWebhook(&aggregators.Webhook{ID: id, URL: server.URL, Secret: "s3cret"}),
+		testutils.WebhookRepositoryWithDelivery(&aggregators.WebhookDelivery{ID: deliveryID, WebhookID: id, EventType: "task.created", Payload: []byte(`{"type":"task.created"}`)}),
+	)
+	_, log := testutils.NewLogger()
+	d := webhook.NewDispatcher(log, r, webhook.DispatcherWithClient(server.Client()), webhook.DispatcherWithClock(func() time.Time { return now }))
+
+	// Execute
+	n, err := d.Dispatch(context.Background())
+
+	// Assert result
+	suite.NoError(err)
+	suite.Equal(1, n)
+
+	// Assert request
+	suite.Require().Len(*requests, 1)
+	req := (*requests)[0]
+	suite.JSONEq(`{"type":"task.created"}`, string(req.body))
+	suite.Equal("application/json", req.header.Get("Content-Type"))
+	suite.Equal(deliveryID.String(), req.header.Get(webhook.HeaderDelivery))
+	suite.Equal("task.created", req.header.Get(webhook.HeaderEvent))
+	suite.Equal(strconv.FormatInt(now.Unix(), 10), req.header.Get(webhook.HeaderTimestamp))
+	mac := hmac.New(sha256.New, []byte("s3cret"))
+	mac.Write([]byte(req.header.Get(webhook.HeaderTimestamp) + "." + string(req.body)))
+	suite.Equal("sha256="+hex.EncodeToString(mac.Sum(nil)), req.header.Get(webhook.HeaderSignature))
+
+	// Assert state
+	delivery := r.DeliveryRecords[0]
+	suite.Equal(webhook.DeliveryDelivered, delivery.Status)
+	suite.Require().Len(delivery.Attempts, 1)
+	suite.Equal(webhook.AttemptSucceeded, delivery.Attempts[0].Status)
+	suite.Equal(http.StatusOK, *delivery.Attempts[0].ResponseCode)
+	suite.Nil(delivery.Attempts[0].Error)
+	suite.Equal(now, delivery.Attempts[0].AttemptedAt)
+}
+
+func (suite *DispatcherSuite) TestDispatchRetriesWithBackoffAndJitter() {
+	// Prepare
+	server, requests := receiver(http.StatusInternalServerError, http.StatusServiceUnavailable)
+	defer server.Close()
+	id := uuid.New()
+	now := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+	clock := func() time.Time { return now }
+	r := testutils.NewWebhookRepository(
+		testutils.WebhookRepositoryWithClock(clock),
+		testutils.WebhookRepositoryWithWebhook(&aggregators.Webhook{ID: id, URL: server.URL}),
+		testutils.WebhookRepositoryWithDelivery(&aggregators.WebhookDelivery{ID: uuid.New(), WebhookID: id, Payload: []byte(`{}`)}),
+	)
+	buf, log := testutils.NewLogger()
+	d := webhook.NewDispatcher(log, r,
+		webhook.DispatcherWithClient(server.Client()),
+		webhook.DispatcherWithClock(clock),
+		webhook.DispatcherWithBackoff(time.Minute),
+		webhook.DispatcherWithJitter(func(max time.Duration) time.Duration { return max }),
+	)
+
+	// Execute
+	_, err1 := d.Dispatch(context.Background())
+	now = now.Add(89 * time.Second)
+	n2, err2 := d.Dispatch(context.Background())
+	now = now.Add(time.Second)
+	n3, err3 := d.Dispatch(context.Background())
+	now = now.Add(179 * time.Second)
+	n4, err4 := d.Dispatch(context.Background())
+	now = now.Add(time.Second)
+	n5, err5 := d.Dispatch(context.Background())
+
+	// Assert result
+	suite.NoError(err1)
+	suite.NoError(err2)
+	suite.NoError(err3)
+	suite.NoError(err4)
+	suite.NoError(err5)
+	suite.Equal(0, n2)
+	suite.Equal(1, n3)
+	suite.Equal(0, n4)
+	suite.Equal(1, n5)
+	suite.Len(*requests, 3)
+
+	// Assert state
+	delivery := r.DeliveryRecords[0]
+	suite.Equal(webhook.DeliveryDelivered, delivery.Status)
+	suite.Equal(2, delivery.Failures)
+	suite.Require().Len(delivery.Attempts, 3)
+	suite.Equal(webhook.AttemptFailed, delivery.Attempts[0].Status)
+	suite.Equal(http.StatusInternalServerError, *delivery.Attempts[0].ResponseCode)
+	suite.Equal("unexpected response status 500", *delivery.Attempts[0].Error)
+	suite.Equal(http.StatusServiceUnavailable, *delivery.Attempts[1].ResponseCode)
+	suite.Equal(webhook.AttemptSucceeded, delivery.Attempts[2].Status)
+
+	// Assert log
+	lines := testutils.LogLines(buf)
+	suite.Len(lines, 2)
+	suite.Contains(lines[0], `"level":"WARN"`)
+	suite.Contains(lines[0], "attempt 1: unexpected response status 500")
+}
+
+func (suite *DispatcherSuite) TestDispatchDeadAfterMaxAttempts() {
+	// Prepare
+	server, requests := receiver(http.StatusGone, http.StatusGone)
+	defer server.Close()
+	id := uuid.New()
+	r := testutils.NewWebhookRepository(
+		testutils.WebhookRepositoryWithWebhook(&aggregators.Webhook{ID: id, URL: server.URL}),
+		testutils.WebhookRepositoryWithDelivery(&aggregators.WebhookDelivery{ID: uuid.New(), WebhookID: id, Payload: []byte(`{}`)}),
+	)
+	buf, log := testutils.NewLogger()
+	d := webhook.NewDispatcher(log, r, webhook.DispatcherWithClient(server.Client()), webhook.DispatcherWithMaxAttempts(2), webhook.DispatcherWithBackoff(0))
+
+	// Execute
+	_, err1 := d.Dispatch(context.Background())
+	_, err2 := d.Dispatch(context.Background())
+	n3, err3 := d.Dispatch(context.Background())
+
+	// Assert
+	suite.NoError(err1)
+	suite.NoError(err2)
+	suite.NoError(err3)
+	suite.Equal(0, n3)
+	suite.Len(*requests, 2)
+	suite.Equal(webhook.DeliveryDead, r.DeliveryRecords[0].Status)
+	suite.Len(r.DeliveryRecords[0].Attempts, 2)
+	lines := testutils.LogLines(buf)
+	suite.Len(lines, 2)
+	suite.Contains(lines[1], `"level":"ERROR"`)
+	suite.Contains(lines[1], "after 2 attempts: unexpected response status 410")
+}
+
+func (suite *DispatcherSuite) TestDispatchUnreachable() {
+	// Prepare
+	server, _ := receiver()
+	server.Close()
+	id := uuid.New()
+	r := testutils.NewWebhookRepository(
+		testutils.WebhookRepositoryWithWebhook(&aggregators.Webhook{ID: id, URL: server.URL}),
+		testutils.WebhookRepositoryWithDelivery(&aggregators.WebhookDelivery{ID: uuid.New(), WebhookID: id, Payload: []byte(`{}`)}),
+	)
+	_, log := testutils.NewLogger()
+	d := webhook.NewDispatcher(log, r, webhook.DispatcherWithClient(server.Client()))
+
+	// Execute
+	_, err := d.Dispatch(context.Background())
+
+	// Assert
+	suite.NoError(err)
+	delivery := r.DeliveryRecords[0]
+	suite.Equal(webhook.DeliveryPending, delivery.Status)
+	suite.Require().Len(delivery.Attempts, 1)
+	suite.Nil(delivery.Attempts[0].ResponseCode)
+	suite.Contains(*delivery.Attempts[0].Error, "connection refused")
+}
+
+func (suite *DispatcherSuite) TestDispatchPrivateAddressFail() {
+	// Prepare
+	server, requests := receiver()
+	defer server.Close()
+	loopback, metadata, private := uuid.New(), uuid.New(), uuid.New()
+	r := testutils.NewWebhookRepository(
+		testutils.WebhookRepositoryWithWebhook(&aggregators.Webhook{ID: loopback, URL: server.URL}),
+		testutils.WebhookRepositoryWithWebhook(&aggregators.Webhook{ID: metadata, URL: "http://169.254.169.254/latest/meta-data"}),
+		testutils.WebhookRepositoryWithWebhook(&aggregators.Webhook{ID: private, URL: "http://10.0.0.1"}),
+		testutils.WebhookRepositoryWithDelivery(&aggregators.WebhookDelivery{ID: uuid.New(), WebhookID: loopback, Payload: []byte(`{}`)}),
+		testutils.WebhookRepositoryWithDelivery(&aggregators.WebhookDelivery{ID: uuid.New(), WebhookID: metadata, Payload: []byte(`{}`)}),
+		testutils.WebhookRepositoryWithDelivery(&aggregators.WebhookDelivery{ID: uuid.New(), WebhookID: private, Payload: []byte(`{}`)}),
+	)
+	_, log := testutils.NewLogger()
+	d := webhook.NewDispatcher(log, r)
+
+	// Execute
+	n, err := d.Dispatch(context.Background())
+
+	// Assert
+	suite.NoError(err)
+	suite.Equal(3, n)
+	suite.Empty(*requests)
+	for _, delivery := range r.DeliveryRecords {
+		suite.Equal(webhook.DeliveryPending, delivery.Status)
+		suite.Require().Len(delivery.Attempts, 1)
+		suite.Contains(*delivery.Attempts[0].Error, webhook.ErrForbiddenAddress.Error())
+	}
+}
+
+func (suite *DispatcherSuite) TestDispatchDoesNotFollowRedirects() {
+	// Prepare
+	target, requests := receiver()
+	defer target.Close()
+	server := httptest.NewServer(http.RedirectHandler(target.URL, http.StatusFound))
+	defer server.Close()
+	id := uuid.New()
+	r := testutils.NewWebhookRepository(
+		testutils.WebhookRepositoryWithWebhook(&aggregators.Webhook{ID: id, URL: server.URL}),
+		testutils.WebhookRepositoryWithDelivery(&aggregators.WebhookDelivery{ID: uuid.New(), WebhookID: id, Payload: []byte(`{}`)}),
+	)
+	// the receivers listen on loopback, which only the transport refuses
+	client := webhook.NewClient(time.Second)
+	client.Transport = server.Client().Transport
+	_, log := testutils.NewLogger()
+	d := webhook.NewDispatcher(log, r, webhook.DispatcherWithClient(client))
+
+	// Execute
+	_, err := d.Dispatch(context.Background())
+
+	// Assert
+	suite.NoError(err)
+	suite.Empty(*requests)
+	delivery := r.DeliveryRecords[0]
+	suite.Equal(webhook.DeliveryPending, delivery.Status)
+	suite.Require().Len(delivery.Attempts, 1)
+	suite.Equal(http.StatusFound, *delivery.Attempts[0].ResponseCode)
+}
+
+func (suite *DispatcherSuite) TestDispatchStoreFail() {
+	// Prepare
+	r := testutils.NewWebhookRepository(testutils.WebhookRepositoryWithError(errors.New("boom!")))
+	_, log := testutils.NewLogger()
+	d := webhook.NewDispatcher(log, r)
+
+	// Execute
+	n, err := d.Dispatch(context.Background())
+
+	// Assert
+	suite.Error(err)
+	suite.Equal("failed to claim webhook deliveries: boom!", err.Error())
+	suite.Equal(0, n)
+}
+
+func (suite *DispatcherSuite) TestPublishEnqueuesSubscribedWebhooks() {
+	// Prepare
+	all, created, deleted, other := uuid.New(), uuid.New(), uuid.New(), uuid.New()
+	r := testutils.NewWebhookRepository(
+		testutils.WebhookRepositoryWithWebhook(&aggregators.Webhook{ID: all, URL: "https://example.com/all"}),
+		testutils.WebhookRepositoryWithWebhook(&aggregators.Webhook{ID: created, URL: "https://example.com/created", Events: []string{"task.created"}}),
+		testutils.WebhookRepositoryWithWebhook(&aggregators.Webhook{ID: deleted, URL: "https://example.com/deleted", Events: []string{"task.deleted"}}),
+	)
+	suite.NoError(r.Save(infrastructure.WithWorkspace(context.Background(), uuid.New()), &aggregators.Webhook{ID: other, URL: "https://example.com/other"}))
+	p := webhook.NewPublisher(r)
+	e := &aggregators.Event{ID: uuid.New(), TaskID: uuid.New(), Type: "task.created", Payload: []byte(`{}`)}
+
+	// Execute
+	err1 := p.Publish(context.Background(), e)
+	err2 := p.Publish(context.Background(), e)
+
+	// Assert
+	suite.NoError(err1)
+	suite.NoError(err2)
+	webhooks := make([]uuid.UUID, 0, len(r.DeliveryRecords))
+	for _, d := range r.DeliveryRecords {
+		webhooks = append(webhooks, d.WebhookID)
+		suite.Equal(e.ID, d.EventID)
+		suite.Equal("task.created", d.EventType)
+		suite.Contains(string(d.Payload), `"id":"`+e.ID.String()+`"`)
+	}
+	suite.ElementsMatch([]uuid.UUID{all, created}, webhooks)
+}
+
+func (suite *DispatcherSuite) TestTaskChangesReachWebhook() {
+	// Prepare
+	server, requests := receiver()
+	defer server.Close()
+	tr := testutils.NewTaskRepository()
+	wr := testutils.NewWebhookRepository()
+	_, log := testutils.NewLogger()
+	s := domain.NewService(tr)
+	ws := domain.NewWebhookService(wr)
+	_, err := ws.Register(context.Background(), domain.WebhookCreate{URL: server.URL, Events: []string{"task.completed"}, Secret: "s3cret"})
+	suite.Require().NoError(err)
+	task, err := s.Create(context.Background(), domain.TaskCreate{Title: "task 1"})
+	suite.Require().NoError(err)
+	suite.Require().NoError(s.MarkCompleted(context.Background(), task.ID))
+	relay := outbox.NewRelay(log, testutils.NewOutboxRepository(tr), webhook.NewPublisher(wr))
+	d := webhook.NewDispatcher(log, wr, webhook.DispatcherWithClient(server.Client()))
+
+	// Execute
+	for {
+		n, err := relay.Deliver(context.Background())
+		suite.Require().NoError(err)
+		if n == 0 {
+			break
+		}
+	}
+	_, err = d.Dispatch(context.Background())
+
+	// Assert
+	suite.NoError(err)
+	suite.Require().Len(*requests, 1)
+	req := (*requests)[0]
+	suite.Equal("task.completed", req.header.Get(webhook.HeaderEvent))
+	suite.Contains(string(req.body), `"task_id":"`+task.ID.String()+`"`)
+	timestamp, err := strconv.ParseInt(req.header.Get(webhook.HeaderTimestamp), 10, 64)
+	suite.NoError(err)
+	suite.Equal(webhook.Sign("s3cret", timestamp, req.body), req.header.Get(webhook.HeaderSignature))
+}
@@ -0,0 +1,26 @@
+package webhook
+
+import (
+	"context"
+	"github.com/aviseu/go-sample/internal/app/infrastructure/aggregators"
+)
+
+type Queue interface {
+	Enqueue(ctx context.Context, e *aggregators.Event) error
+}
+
+// Publisher hands the events of the outbox to the webhooks by queueing a
+// delivery for every webhook that subscribed to them. The dispatcher then
+// delivers each of them on its own, so a failing webhook does not hold up the
+// others.
+type Publisher struct {
+	q Queue
+}
+
+func NewPublisher(q Queue) *Publisher {
+	return &Publisher{q: q}
+}
+
+func (p *Publisher) Publish(ctx context.Context, e *aggregators.Event) error {
+	return p.q.Enqueue(ctx, e)
+}
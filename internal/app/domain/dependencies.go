@@ -0,0 +1,82 @@
+package domain
+
+import (
+	"cmp"
+	"github.com/aviseu/go-sample/internal/app/infrastructure/aggregators"
+	"github.com/google/uuid"
+	"slices"
+)
+
+// workable leaves out the tasks that are blocked, together with the tasks that
+// depend on them, directly or not, since none of those can be worked on yet.
+func workable(tasks []*aggregators.Task) []*aggregators.Task {
+	blocked := make(map[uuid.UUID]bool)
+	isBlocked := func(id uuid.UUID) bool { return blocked[id] }
+	for changed := true; changed; {
+		changed = false
+		for _, t := range tasks {
+			if !blocked[t.ID] && (Status(t.Status) == StatusBlocked || slices.ContainsFunc(t.BlockedBy, isBlocked)) {
+				blocked[t.ID] = true
+				changed = true
+			}
+		}
+	}
+
+	return slices.DeleteFunc(tasks, func(t *aggregators.Task) bool { return blocked[t.ID] })
+}
+
+// topologicalSort orders the tasks so that every task comes after the tasks
+// it depends on. Dependencies on tasks outside the given set are ignored and
+// tasks that are free to go are picked by due date, then title.
+func topologicalSort(tasks []*aggregators.Task) []*aggregators.Task {
+	pending := make(map[uuid.UUID]int, len(tasks))
+	dependents := make(map[uuid.UUID][]*aggregators.Task, len(tasks))
+	for _, t := range tasks {
+		pending[t.ID] = 0
+	}
+	for _, t := range tasks {
+		for _, id := range t.BlockedBy {
+			if _, ok := pending[id]; ok {
+				pending[t.ID]++
+				dependents[id] = append(dependents[id], t)
+			}
+		}
+	}
+
+	var free []*aggregators.Task
+	for _, t := range tasks {
+		if pending[t.ID] == 0 {
+			free = append(free, t)
+		}
+	}
+
+	sorted := make([]*aggregators.Task, 0, len(tasks))
+	for len(free) > 0 {
+		slices.SortFunc(free, comparePriority)
+		t := free[0]
+		free = free[1:]
+		sorted = append(sorted, t)
+
+		for _, dependent := range dependents[t.ID] {
+			pending[dependent.ID]--
+			if pending[dependent.ID] == 0 {
+				free = append(free, dependent)
+			}
+		}
+	}
+
+	return sorted
+}
+
+func comparePriority(a, b *aggregators.Task) int {
+	switch {
+	case a.DueAt != nil && b.DueAt == nil:
+		return -1
+	case a.DueAt == nil && b.DueAt != nil:
+		return 1
+	case a.DueAt != nil && !a.DueAt.Equal(*b.DueAt):
+		return a.DueAt.Compare(*b.DueAt)
+	}
+
+	return cmp.Or(cmp.Compare(a.Title, b.Title), compareIDs(a.ID, b.ID))
+}
@@ -6,6 +6,33 @@ import (
 )
 
 var (
-	ErrTaskNotFound    = errs.NewValidationError(errors.New("task not found"))
-	ErrTitleIsRequired = errs.NewValidationError(errors.New("title is required"))
+	ErrTaskNotFound          = errs.NewValidationError(errors.New("task not found"))
+	ErrTitleIsRequired       = errs.NewValidationError(errors.New("title is required"))
+	ErrInvalidStatus         = errs.NewValidationError(errors.New("invalid status"))
+	ErrInvalidDueDate        = errs.NewValidationError(errors.New("due date must be an RFC 3339 timestamp"))
+	ErrDueDateInPast         = errs.NewValidationError(errors.New("due date is in the past"))
+	ErrInvalidRecurrence     = errs.NewValidationError(errors.New("invalid recurrence rule"))
+	ErrRecurrenceNoDueAt     = errs.NewValidationError(errors.New("a recurring task needs a due date"))
+	ErrParentNotFound        = errs.NewValidationError(errors.New("parent task not found"))
+	ErrParentCycle           = errs.NewValidationError(errors.New("task cannot be nested under itself or its subtasks"))
+	ErrMaxDepthExceeded      = errs.NewValidationError(errors.New("task hierarchy is too deep"))
+	ErrDependencyNotFound    = errs.NewValidationError(errors.New("dependency not found"))
+	ErrDependencyCycle       = errs.NewValidationError(errors.New("dependency would create a cycle"))
+	ErrBlocked               = errs.NewValidationError(errors.New("task is blocked by open tasks"))
+	ErrProjectNotFound       = errs.NewValidationError(errors.New("project not found"))
+	ErrProjectNameIsRequired = errs.NewValidationError(errors.New("project name is required"))
+	ErrProjectMismatch       = errs.NewValidationError(errors.New("subtask must be in the same project as its parent"))
+	ErrTagNotFound           = errs.NewValidationError(errors.New("tag not found"))
+	ErrTagNameIsRequired     = errs.NewValidationError(errors.New("tag name is required"))
+	ErrWebhookNotFound       = errs.NewValidationError(errors.New("webhook not found"))
+	ErrInvalidWebhookURL     = errs.NewValidationError(errors.New("webhook URL must be an absolute http or https URL"))
+	ErrInvalidEventType      = errs.NewValidationError(errors.New("unknown event type"))
+	ErrDeliveryNotFound      = errs.NewValidationError(errors.New("webhook delivery not found"))
+	ErrTagExists             = errs.NewConflictError(errors.New("tag already exists"))
+	ErrInvalidTransition     = errs.NewConflictError(errors.New("invalid status transition"))
+	ErrOpenSubtasks          = errs.NewConflictError(errors.New("task has open subtasks"))
+	ErrInboxProject          = errs.NewConflictError(errors.New("inbox project cannot be changed"))
+	ErrProjectModified       = errs.NewConflictError(errors.New("project was modified concurrently"))
+	ErrTaskModified          = errs.NewConflictError(errors.New("task was modified concurrently"))
+	ErrVersionMismatch       = errs.NewPreconditionError(errors.New("task version mismatch"))
 )
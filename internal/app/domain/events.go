@@ -0,0 +1,90 @@
+package domain
+
+import (
+	"encoding/json"
+	"github.com/aviseu/go-sample/internal/app/infrastructure/aggregators"
+	"github.com/google/uuid"
+	"time"
+)
+
+// Event is a fact about a task that other systems learn about. Tasks record
+// their events as they change and the repository stores them in the outbox
+// together with the task.
+type Event interface {
+	EventType() string
+}
+
+type TaskCreated struct {
+	TaskID    uuid.UUID  `json:"task_id"`
+	ProjectID uuid.UUID  `json:"project_id"`
+	ParentID  *uuid.UUID `json:"parent_id,omitempty"`
+	Title     string     `json:"title"`
+	DueAt     *time.Time `json:"due_at,omitempty"`
+	CreatedAt time.Time  `json:"created_at"`
+}
+
+func (TaskCreated) EventType() string { return "task.created" }
+
+// TaskUpdated is recorded once per save for any change that has no event of
+// its own, such as a new title, due date, project, parent, tag or dependency.
+type TaskUpdated struct {
+	TaskID    uuid.UUID `json:"task_id"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+func (TaskUpdated) EventType() string { return "task.updated" }
+
+type TaskCompleted struct {
+	TaskID      uuid.UUID `json:"task_id"`
+	CompletedAt time.Time `json:"completed_at"`
+}
+
+func (TaskCompleted) EventType() string { return "task.completed" }
+
+type TaskStatusChanged struct {
+	TaskID    uuid.UUID `json:"task_id"`
+	From      Status    `json:"from"`
+	To        Status    `json:"to"`
+	ChangedAt time.Time `json:"changed_at"`
+}
+
+func (TaskStatusChanged) EventType() string { return "task.status_changed" }
+
+type TaskDeleted struct {
+	TaskID    uuid.UUID `json:"task_id"`
+	DeletedAt time.Time `json:"deleted_at"`
+}
+
+func (TaskDeleted) EventType() string { return "task.deleted" }
+
+type TaskRestored struct {
+	TaskID     uuid.UUID `json:"task_id"`
+	RestoredAt time.Time `json:"restored_at"`
+}
+
+func (TaskRestored) EventType() string { return "task.restored" }
+
+// eventTypes are the types of all events, which webhooks can subscribe to.
+var eventTypes = []string{
+	TaskCreated{}.EventType(),
+	TaskUpdated{}.EventType(),
+	TaskCompleted{}.EventType(),
+	TaskStatusChanged{}.EventType(),
+	TaskDeleted{}.EventType(),
+	TaskRestored{}.EventType(),
+}
+
+// newEventAggregator encodes the event for the outbox. Version 7 ids keep the
+// events in the order they were recorded.
+func newEventAggregator(taskID uuid.UUID, e Event, now time.Time) *aggregators.Event {
+	// events are plain structs, which always encode
+	payload, _ := json.Marshal(e)
+
+	return &aggregators.Event{
+		ID:         uuid.Must(uuid.NewV7()),
+		TaskID:     taskID,
+		Type:       e.EventType(),
+		Payload:    payload,
+		OccurredAt: now,
+	}
+}
@@ -0,0 +1,52 @@
+package domain
+
+import (
+	"github.com/aviseu/go-sample/internal/app/infrastructure/aggregators"
+	"github.com/google/uuid"
+	"time"
+)
+
+type project struct {
+	id        uuid.UUID
+	name      string
+	inbox     bool
+	createdAt time.Time
+	updatedAt time.Time
+	version   int
+}
+
+func newProject(id uuid.UUID, name string, now time.Time) *project {
+	return &project{
+		id:        id,
+		name:      name,
+		createdAt: now,
+		updatedAt: now,
+	}
+}
+
+func (p *project) rename(name string, now time.Time) {
+	p.name = name
+	p.updatedAt = now
+}
+
+func newProjectFromAggregator(p *aggregators.Project) *project {
+	return &project{
+		id:        p.ID,
+		name:      p.Name,
+		inbox:     p.Inbox,
+		createdAt: p.CreatedAt,
+		updatedAt: p.UpdatedAt,
+		version:   p.Version,
+	}
+}
+
+func (p *project) toAggregator() *aggregators.Project {
+	return &aggregators.Project{
+		ID:        p.id,
+		Name:      p.name,
+		Inbox:     p.inbox,
+		CreatedAt: p.createdAt,
+		UpdatedAt: p.updatedAt,
+		Version:   p.version,
+	}
+}
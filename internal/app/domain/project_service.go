@@ -0,0 +1,146 @@
+package domain
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/aviseu/go-sample/internal/app/infrastructure"
+	"github.com/aviseu/go-sample/internal/app/infrastructure/aggregators"
+	"github.com/google/uuid"
+	"strings"
+	"time"
+)
+
+type ProjectRepository interface {
+	Find(ctx context.Context, id uuid.UUID) (*aggregators.Project, error)
+	Save(ctx context.Context, project *aggregators.Project) error
+	Tasks(ctx context.Context, id uuid.UUID) ([]*aggregators.Task, error)
+	Delete(ctx context.Context, id uuid.UUID, tasks ...*aggregators.Task) error
+}
+
+type ProjectServiceOptional func(*ProjectService)
+
+func ProjectServiceWithClock(now func() time.Time) ProjectServiceOptional {
+	return func(s *ProjectService) {
+		s.now = now
+	}
+}
+
+type ProjectService struct {
+	r   ProjectRepository
+	now func() time.Time
+}
+
+func NewProjectService(r ProjectRepository, opts ...ProjectServiceOptional) *ProjectService {
+	s := &ProjectService{
+		r:   r,
+		now: time.Now,
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+
+	return s
+}
+
+func (s *ProjectService) Create(ctx context.Context, name string) (*aggregators.Project, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return nil, ErrProjectNameIsRequired
+	}
+
+	project := newProject(uuid.New(), name, s.now()).toAggregator()
+	if err := s.save(ctx, project); err != nil {
+		return nil, err
+	}
+
+	return project, nil
+}
+
+// Rename changes the name of a project other than the inbox, which is shared
+// by all workspaces.
+func (s *ProjectService) Rename(ctx context.Context, id uuid.UUID, name string) (*aggregators.Project, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return nil, ErrProjectNameIsRequired
+	}
+
+	d, err := s.find(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if d.inbox {
+		return nil, fmt.Errorf("%w: %s", ErrInboxProject, id)
+	}
+
+	d.rename(name, s.now())
+
+	project := d.toAggregator()
+	if err := s.save(ctx, project); err != nil {
+		return nil, err
+	}
+
+	return project, nil
+}
+
+// Delete removes the project and moves its tasks, trashed ones included, into
+// the inbox, which itself cannot be deleted. The tasks are saved along with the
+// deletion, so each of them gets an entry in its history and a TaskUpdated
+// event.
+func (s *ProjectService) Delete(ctx context.Context, id uuid.UUID) error {
+	d, err := s.find(ctx, id)
+	if err != nil {
+		return err
+	}
+	if d.inbox {
+		return fmt.Errorf("%w: %s", ErrInboxProject, id)
+	}
+
+	filed, err := s.r.Tasks(ctx, id)
+	if err != nil {
+		return fmt.Errorf("failed to find project tasks: %w", err)
+	}
+
+	now := s.now()
+	tasks := make([]*aggregators.Task, 0, len(filed))
+	for _, task := range filed {
+		t := newFromAggregator(task)
+		t.moveToProject(aggregators.InboxProjectID, now)
+		tasks = append(tasks, t.toAggregator())
+	}
+
+	if err := s.r.Delete(ctx, id, tasks...); err != nil {
+		if errors.Is(err, infrastructure.ErrProjectNotFound) {
+			return fmt.Errorf("%w: %s", ErrProjectNotFound, id)
+		}
+		if errors.Is(err, infrastructure.ErrProjectConflict) || errors.Is(err, infrastructure.ErrTaskConflict) {
+			return fmt.Errorf("%w: %s", ErrProjectModified, id)
+		}
+		return fmt.Errorf("failed to delete project: %w", err)
+	}
+
+	return nil
+}
+
+func (s *ProjectService) find(ctx context.Context, id uuid.UUID) (*project, error) {
+	project, err := s.r.Find(ctx, id)
+	if err != nil {
+		if errors.Is(err, infrastructure.ErrProjectNotFound) {
+			return nil, fmt.Errorf("%w: %s", ErrProjectNotFound, id)
+		}
+		return nil, fmt.Errorf("failed to find project: %w", err)
+	}
+
+	return newProjectFromAggregator(project), nil
+}
+
+func (s *ProjectService) save(ctx context.Context, project *aggregators.Project) error {
+	if err := s.r.Save(ctx, project); err != nil {
+		if errors.Is(err, infrastructure.ErrProjectConflict) {
+			return fmt.Errorf("%w: %s", ErrProjectModified, project.ID)
+		}
+		return fmt.Errorf("failed to save project: %w", err)
+	}
+
+	return nil
+}
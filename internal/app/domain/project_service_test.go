@@ -0,0 +1,188 @@
+package domain_test
+
+import (
+	"context"
+	"errors"
+	"github.com/aviseu/go-sample/internal/app/domain"
+	"github.com/aviseu/go-sample/internal/app/infrastructure"
+	"github.com/aviseu/go-sample/internal/app/infrastructure/aggregators"
+	"github.com/aviseu/go-sample/internal/errs"
+	"github.com/aviseu/go-sample/internal/testutils"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/suite"
+	"testing"
+	"time"
+)
+
+func TestProjectService(t *testing.T) {
+	t.Parallel()
+	suite.Run(t, new(ProjectServiceSuite))
+}
+
+type ProjectServiceSuite struct {
+	suite.Suite
+}
+
+func (suite *ProjectServiceSuite) TestCreateSuccess() {
+	// Prepare
+	now := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+	r := testutils.NewProjectRepository(testutils.NewTaskRepository())
+	s := domain.NewProjectService(r, domain.ProjectServiceWithClock(func() time.Time { return now }))
+
+	// Execute
+	project, err := s.Create(context.Background(), "  work ")
+
+	// Assert result
+	suite.NoError(err)
+	suite.NotEmpty(project.ID)
+	suite.Equal("work", project.Name)
+	suite.False(project.Inbox)
+	suite.Equal(now, project.CreatedAt)
+	suite.Equal(now, project.UpdatedAt)
+	suite.Equal(1, project.Version)
+
+	// Assert state
+	found, err := r.Find(context.Background(), project.ID)
+	suite.NoError(err)
+	suite.Equal(project, found)
+}
+
+func (suite *ProjectServiceSuite) TestCreateFail() {
+	tests := []struct {
+		name        string
+		projectName string
+		saveErr     error
+		err         error
+		validation  bool
+	}{
+		{name: "name is required", projectName: " ", err: domain.ErrProjectNameIsRequired, validation: true},
+		{name: "repository fails", projectName: "work", saveErr: errors.New("boom!")},
+	}
+
+	for _, tt := range tests {
+		suite.Run(tt.name, func() {
+			// Prepare
+			tr := testutils.NewTaskRepository()
+			r := testutils.NewProjectRepository(tr, testutils.ProjectRepositoryWithSaveError(tt.saveErr))
+			s := domain.NewProjectService(r)
+
+			// Execute
+			project, err := s.Create(context.Background(), tt.projectName)
+
+			// Assert
+			suite.Nil(project)
+			suite.Error(err)
+			if tt.err != nil {
+				suite.ErrorIs(err, tt.err)
+			} else {
+				suite.ErrorContains(err, "failed to save project: boom!")
+			}
+			suite.Equal(tt.validation, errs.IsValidationError(err))
+			suite.Len(tr.ProjectRecords, 1)
+		})
+	}
+}
+
+func (suite *ProjectServiceSuite) TestRenameSuccess() {
+	// Prepare
+	now := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+	id := uuid.New()
+	tr := testutils.NewTaskRepository(testutils.TaskRepositoryWithProject(&aggregators.Project{ID: id, Name: "work", Version: 1}))
+	s := domain.NewProjectService(testutils.NewProjectRepository(tr), domain.ProjectServiceWithClock(func() time.Time { return now }))
+
+	// Execute
+	project, err := s.Rename(context.Background(), id, "office")
+
+	// Assert
+	suite.NoError(err)
+	suite.Equal("office", project.Name)
+	suite.Equal(now, project.UpdatedAt)
+	suite.Equal(2, project.Version)
+	suite.Equal("office", tr.ProjectRecords[id].Name)
+}
+
+func (suite *ProjectServiceSuite) TestRenameFail() {
+	// Prepare
+	id := uuid.New()
+	tr := testutils.NewTaskRepository(testutils.TaskRepositoryWithProject(&aggregators.Project{ID: id, Name: "work", Version: 1}))
+	s := domain.NewProjectService(testutils.NewProjectRepository(tr))
+
+	// Execute
+	project1, err1 := s.Rename(context.Background(), id, " ")
+	project2, err2 := s.Rename(context.Background(), uuid.New(), "office")
+	project3, err3 := s.Rename(context.Background(), aggregators.InboxProjectID, "office")
+
+	// Assert
+	suite.Nil(project1)
+	suite.ErrorIs(err1, domain.ErrProjectNameIsRequired)
+	suite.Nil(project2)
+	suite.ErrorIs(err2, domain.ErrProjectNotFound)
+	suite.True(errs.IsValidationError(err2))
+	suite.Nil(project3)
+	suite.ErrorIs(err3, domain.ErrInboxProject)
+	suite.True(errs.IsConflictError(err3))
+	suite.Equal("work", tr.ProjectRecords[id].Name)
+	suite.Equal("Inbox", tr.ProjectRecords[aggregators.InboxProjectID].Name)
+}
+
+func (suite *ProjectServiceSuite) TestDeleteSuccess() {
+	// Prepare
+	id, taskID, trashedID := uuid.New(), uuid.New(), uuid.New()
+	now := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+	tr := testutils.NewTaskRepository(
+		testutils.TaskRepositoryWithProject(&aggregators.Project{ID: id, Name: "work", Version: 1}),
+		testutils.TaskRepositoryWithTask(&aggregators.Task{ID: taskID, ProjectID: id, Title: "task 1", Status: "todo", Version: 1}),
+		testutils.TaskRepositoryWithTask(&aggregators.Task{ID: trashedID, ProjectID: id, Title: "task 2", Status: "todo", Version: 1, DeletedAt: &now}),
+	)
+	s := domain.NewProjectService(testutils.NewProjectRepository(tr), domain.ProjectServiceWithClock(func() time.Time { return now }))
+
+	// Execute
+	err := s.Delete(context.Background(), id)
+
+	// Assert
+	suite.NoError(err)
+	suite.NotContains(tr.ProjectRecords, id)
+	suite.Equal(aggregators.InboxProjectID, tr.Records[taskID].ProjectID)
+	suite.Equal(2, tr.Records[taskID].Version)
+	suite.Equal(now, tr.Records[taskID].UpdatedAt)
+	suite.Equal(aggregators.InboxProjectID, tr.Records[trashedID].ProjectID)
+	suite.Len(tr.HistoryRecords[taskID], 1)
+	suite.Equal(infrastructure.ActionUpdated, tr.HistoryRecords[taskID][0].Action)
+	suite.Len(tr.HistoryRecords[trashedID], 1)
+}
+
+func (suite *ProjectServiceSuite) TestDeleteRecordsEvents() {
+	// Prepare
+	id, taskID, ws := uuid.New(), uuid.New(), uuid.New()
+	ctx := infrastructure.WithWorkspace(context.Background(), ws)
+	tr := testutils.NewTaskRepository(testutils.TaskRepositoryWithWorkspaceTask(ws, &aggregators.Task{ID: taskID, ProjectID: id, Title: "task 1", Status: "todo", Version: 1}))
+	pr := testutils.NewProjectRepository(tr)
+	suite.Require().NoError(pr.Save(ctx, &aggregators.Project{ID: id, Name: "work"}))
+	s := domain.NewProjectService(pr)
+
+	// Execute
+	err := s.Delete(ctx, id)
+
+	// Assert
+	suite.NoError(err)
+	suite.Require().Len(tr.EventRecords, 1)
+	suite.Equal("task.updated", tr.EventRecords[0].Type)
+	suite.Equal(taskID, tr.EventRecords[0].TaskID)
+	suite.Equal(ws, tr.EventRecords[0].WorkspaceID)
+}
+
+func (suite *ProjectServiceSuite) TestDeleteFail() {
+	// Prepare
+	tr := testutils.NewTaskRepository()
+	s := domain.NewProjectService(testutils.NewProjectRepository(tr))
+
+	// Execute
+	err1 := s.Delete(context.Background(), aggregators.InboxProjectID)
+	err2 := s.Delete(context.Background(), uuid.New())
+
+	// Assert
+	suite.ErrorIs(err1, domain.ErrInboxProject)
+	suite.True(errs.IsConflictError(err1))
+	suite.ErrorIs(err2, domain.ErrProjectNotFound)
+	suite.Contains(tr.ProjectRecords, aggregators.InboxProjectID)
+}
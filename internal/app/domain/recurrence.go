@@ -0,0 +1,245 @@
+package domain
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type frequency string
+
+const (
+	frequencyDaily   frequency = "DAILY"
+	frequencyWeekly  frequency = "WEEKLY"
+	frequencyMonthly frequency = "MONTHLY"
+	frequencyYearly  frequency = "YEARLY"
+)
+
+var weekdays = map[string]time.Weekday{
+	"MO": time.Monday,
+	"TU": time.Tuesday,
+	"WE": time.Wednesday,
+	"TH": time.Thursday,
+	"FR": time.Friday,
+	"SA": time.Saturday,
+	"SU": time.Sunday,
+}
+
+// maxPeriods bounds the search for the next occurrence of rules that rarely or
+// never match, such as the 31st of every other month starting in February.
+const maxPeriods = 1000
+
+// recurrence is the subset of RFC 5545 recurrence rules that tasks support:
+// FREQ, INTERVAL, BYDAY without ordinals, BYMONTHDAY, COUNT and UNTIL.
+type recurrence struct {
+	freq       frequency
+	interval   int
+	byDay      []time.Weekday
+	byMonthDay []int
+	count      int
+	until      *time.Time
+}
+
+func parseRecurrence(v string) (*recurrence, error) {
+	r := &recurrence{interval: 1}
+	seen := make(map[string]bool)
+	for _, part := range strings.Split(strings.TrimPrefix(strings.ToUpper(v), "RRULE:"), ";") {
+		name, value, ok := strings.Cut(part, "=")
+		if !ok || value == "" {
+			return nil, fmt.Errorf("%w: malformed part %q", ErrInvalidRecurrence, part)
+		}
+		if seen[name] {
+			return nil, fmt.Errorf("%w: %s given more than once", ErrInvalidRecurrence, name)
+		}
+		seen[name] = true
+
+		switch name {
+		case "FREQ":
+			r.freq = frequency(value)
+			if !slices.Contains([]frequency{frequencyDaily, frequencyWeekly, frequencyMonthly, frequencyYearly}, r.freq) {
+				return nil, fmt.Errorf("%w: unsupported FREQ %s", ErrInvalidRecurrence, value)
+			}
+		case "INTERVAL":
+			n, err := strconv.Atoi(value)
+			if err != nil || n < 1 {
+				return nil, fmt.Errorf("%w: INTERVAL must be a positive number", ErrInvalidRecurrence)
+			}
+			r.interval = n
+		case "BYDAY":
+			for _, day := range strings.Split(value, ",") {
+				wd, ok := weekdays[day]
+				if !ok {
+					return nil, fmt.Errorf("%w: unsupported BYDAY %s", ErrInvalidRecurrence, day)
+				}
+				r.byDay = append(r.byDay, wd)
+			}
+		case "BYMONTHDAY":
+			for _, day := range strings.Split(value, ",") {
+				n, err := strconv.Atoi(day)
+				if err != nil || n == 0 || n < -31 || n > 31 {
+					return nil, fmt.Errorf("%w: BYMONTHDAY must be between 1 and 31 or -31 and -1", ErrInvalidRecurrence)
+				}
+				r.byMonthDay = append(r.byMonthDay, n)
+			}
+		case "COUNT":
+			n, err := strconv.Atoi(value)
+			if err != nil || n < 1 {
+				return nil, fmt.Errorf("%w: COUNT must be a positive number", ErrInvalidRecurrence)
+			}
+			r.count = n
+		case "UNTIL":
+			until, err := parseUntil(value)
+			if err != nil {
+				return nil, fmt.Errorf("%w: UNTIL must be a date or a UTC date-time", ErrInvalidRecurrence)
+			}
+			r.until = &until
+		default:
+			return nil, fmt.Errorf("%w: unsupported part %s", ErrInvalidRecurrence, name)
+		}
+	}
+
+	switch {
+	case r.freq == "":
+		return nil, fmt.Errorf("%w: FREQ is required", ErrInvalidRecurrence)
+	case r.count > 0 && r.until != nil:
+		return nil, fmt.Errorf("%w: COUNT and UNTIL cannot be combined", ErrInvalidRecurrence)
+	case r.freq == frequencyWeekly && len(r.byMonthDay) > 0:
+		return nil, fmt.Errorf("%w: BYMONTHDAY cannot be used with FREQ=WEEKLY", ErrInvalidRecurrence)
+	case r.freq == frequencyYearly && (len(r.byDay) > 0 || len(r.byMonthDay) > 0):
+		return nil, fmt.Errorf("%w: BYDAY and BYMONTHDAY cannot be used with FREQ=YEARLY", ErrInvalidRecurrence)
+	}
+
+	return r, nil
+}
+
+func parseUntil(v string) (time.Time, error) {
+	if t, err := time.Parse("20060102T150405Z", v); err == nil {
+		return t, nil
+	}
+
+	// a plain date includes the whole day
+	t, err := time.Parse("20060102", v)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	return t.AddDate(0, 0, 1).Add(-time.Nanosecond), nil
+}
+
+// String returns the rule in a canonical form.
+func (r *recurrence) String() string {
+	parts := []string{"FREQ=" + string(r.freq)}
+	if r.interval > 1 {
+		parts = append(parts, "INTERVAL="+strconv.Itoa(r.interval))
+	}
+	if len(r.byDay) > 0 {
+		days := make([]string, len(r.byDay))
+		for i, wd := range r.byDay {
+			days[i] = strings.ToUpper(wd.String()[:2])
+		}
+		parts = append(parts, "BYDAY="+strings.Join(days, ","))
+	}
+	if len(r.byMonthDay) > 0 {
+		days := make([]string, len(r.byMonthDay))
+		for i, d := range r.byMonthDay {
+			days[i] = strconv.Itoa(d)
+		}
+		parts = append(parts, "BYMONTHDAY="+strings.Join(days, ","))
+	}
+	if r.count > 0 {
+		parts = append(parts, "COUNT="+strconv.Itoa(r.count))
+	}
+	if r.until != nil {
+		parts = append(parts, "UNTIL="+r.until.UTC().Format("20060102T150405Z"))
+	}
+
+	return strings.Join(parts, ";")
+}
+
+// next returns the occurrence that follows the given one, which has to be an
+// occurrence of the rule itself, and false once the series has ended.
+func (r *recurrence) next(current time.Time, occurrence int) (time.Time, bool) {
+	if r.count > 0 && occurrence >= r.count {
+		return time.Time{}, false
+	}
+
+	for p := 0; p < maxPeriods; p++ {
+		for _, candidate := range r.candidates(current, p*r.interval) {
+			if !candidate.After(current) {
+				continue
+			}
+			if r.until != nil && candidate.After(*r.until) {
+				return time.Time{}, false
+			}
+			return candidate, true
+		}
+	}
+
+	return time.Time{}, false
+}
+
+// candidates returns, in order, the days of the period that lies the given
+// number of periods after the one holding current, at current's time of day.
+func (r *recurrence) candidates(current time.Time, offset int) []time.Time {
+	y, m, d := current.Date()
+	at := func(y int, m time.Month, d int) time.Time {
+		return time.Date(y, m, d, current.Hour(), current.Minute(), current.Second(), current.Nanosecond(), current.Location())
+	}
+
+	var days []time.Time
+	switch r.freq {
+	case frequencyDaily:
+		days = []time.Time{at(y, m, d+offset)}
+	case frequencyWeekly:
+		monday := d - (int(current.Weekday())+6)%7 + 7*offset
+		for i := 0; i < 7; i++ {
+			days = append(days, at(y, m, monday+i))
+		}
+		if len(r.byDay) == 0 {
+			return filter(days, func(t time.Time) bool { return t.Weekday() == current.Weekday() })
+		}
+	case frequencyMonthly:
+		first := at(y, m+time.Month(offset), 1)
+		for day := first; day.Month() == first.Month(); day = day.AddDate(0, 0, 1) {
+			days = append(days, day)
+		}
+		if len(r.byDay) == 0 && len(r.byMonthDay) == 0 {
+			return filter(days, func(t time.Time) bool { return t.Day() == d })
+		}
+	case frequencyYearly:
+		day := at(y+offset, m, d)
+		if day.Day() != d {
+			return nil
+		}
+		return []time.Time{day}
+	}
+
+	return filter(days, r.matches)
+}
+
+func (r *recurrence) matches(t time.Time) bool {
+	if len(r.byDay) > 0 && !slices.Contains(r.byDay, t.Weekday()) {
+		return false
+	}
+	if len(r.byMonthDay) > 0 {
+		last := time.Date(t.Year(), t.Month()+1, 0, 0, 0, 0, 0, t.Location()).Day()
+		if !slices.Contains(r.byMonthDay, t.Day()) && !slices.Contains(r.byMonthDay, t.Day()-last-1) {
+			return false
+		}
+	}
+
+	return true
+}
+
+func filter(days []time.Time, keep func(time.Time) bool) []time.Time {
+	var kept []time.Time
+	for _, day := range days {
+		if keep(day) {
+			kept = append(kept, day)
+		}
+	}
+
+	return kept
+}
@@ -7,48 +7,851 @@ import (
 	"github.com/aviseu/go-sample/internal/app/infrastructure"
 	"github.com/aviseu/go-sample/internal/app/infrastructure/aggregators"
 	"github.com/google/uuid"
+	"slices"
+	"strings"
+	"time"
 )
 
 type Repository interface {
 	Find(ctx context.Context, id uuid.UUID) (*aggregators.Task, error)
-	Save(ctx context.Context, task *aggregators.Task) error
+	FindTrashed(ctx context.Context, id uuid.UUID) (*aggregators.Task, error)
+	Due(ctx context.Context, from, to time.Time) ([]*aggregators.Task, error)
+	Ancestors(ctx context.Context, id uuid.UUID) ([]*aggregators.Task, error)
+	Descendants(ctx context.Context, id uuid.UUID) ([]*aggregators.Task, error)
+	Blockers(ctx context.Context, id uuid.UUID) ([]*aggregators.Task, error)
+	Open(ctx context.Context) ([]*aggregators.Task, error)
+	HasOccurrence(ctx context.Context, seriesID uuid.UUID, occurrence int) (bool, error)
+	Save(ctx context.Context, tasks ...*aggregators.Task) error
+	Expired(ctx context.Context, before time.Time) ([]*aggregators.Task, error)
+	Referencing(ctx context.Context, ids ...uuid.UUID) ([]*aggregators.Task, error)
+	Purge(ctx context.Context, ids []uuid.UUID, tasks ...*aggregators.Task) (int64, error)
+	FindTag(ctx context.Context, id uuid.UUID) (*aggregators.Tag, error)
+	Tagged(ctx context.Context, id uuid.UUID) ([]*aggregators.Task, error)
+	SaveTag(ctx context.Context, tag *aggregators.Tag) error
+	DeleteTag(ctx context.Context, id uuid.UUID, tasks ...*aggregators.Task) error
+}
+
+// ParentCompletion decides what happens to a parent task when its subtasks
+// are closed. Either way a parent cannot be completed while a subtask is open.
+type ParentCompletion string
+
+const (
+	// ParentCompletionBlock leaves completing the parent to the user.
+	ParentCompletionBlock ParentCompletion = "block"
+	// ParentCompletionAuto completes the parent once its last open subtask is closed.
+	ParentCompletionAuto ParentCompletion = "auto"
+)
+
+func (p ParentCompletion) Valid() bool {
+	return p == ParentCompletionBlock || p == ParentCompletionAuto
+}
+
+type ServiceOptional func(*Service)
+
+func ServiceWithClock(now func() time.Time) ServiceOptional {
+	return func(s *Service) {
+		s.now = now
+	}
+}
+
+func ServiceWithMaxDepth(n int) ServiceOptional {
+	return func(s *Service) {
+		s.maxDepth = n
+	}
+}
+
+func ServiceWithParentCompletion(p ParentCompletion) ServiceOptional {
+	return func(s *Service) {
+		s.parentCompletion = p
+	}
+}
+
+func ServiceWithPastDueDatesRejected() ServiceOptional {
+	return func(s *Service) {
+		s.rejectPastDueDates = true
+	}
+}
+
+func ServiceWithTrashRetention(d time.Duration) ServiceOptional {
+	return func(s *Service) {
+		s.trashRetention = d
+	}
 }
 
 type Service struct {
-	r Repository
+	r                  Repository
+	now                func() time.Time
+	maxDepth           int
+	parentCompletion   ParentCompletion
+	rejectPastDueDates bool
+	trashRetention     time.Duration
 }
 
-func NewService(r Repository) *Service {
-	return &Service{r: r}
+func NewService(r Repository, opts ...ServiceOptional) *Service {
+	s := &Service{
+		r:                r,
+		now:              time.Now,
+		maxDepth:         5,
+		parentCompletion: ParentCompletionBlock,
+		trashRetention:   30 * 24 * time.Hour,
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+
+	return s
+}
+
+// TaskCreate files the task in the inbox unless a project is given, or in the
+// project of its parent when it is a subtask.
+type TaskCreate struct {
+	Title      string
+	DueAt      string
+	Recurrence string
+	ProjectID  *uuid.UUID
+	ParentID   *uuid.UUID
 }
 
-func (s *Service) Create(ctx context.Context, title string) (*aggregators.Task, error) {
-	if title == "" {
+func (s *Service) Create(ctx context.Context, c TaskCreate) (*aggregators.Task, error) {
+	if c.Title == "" {
 		return nil, ErrTitleIsRequired
 	}
 
-	task := newTask(uuid.New(), title, false).toAggregator()
+	now := s.now()
+	dueAt, err := s.parseDueAt(c.DueAt, now)
+	if err != nil {
+		return nil, err
+	}
+
+	var r *recurrence
+	if c.Recurrence != "" {
+		if r, err = parseRecurrence(c.Recurrence); err != nil {
+			return nil, err
+		}
+		if dueAt == nil {
+			return nil, ErrRecurrenceNoDueAt
+		}
+	}
+
+	d := newTask(uuid.New(), c.Title, StatusTodo, now)
+	d.reschedule(dueAt, now)
+	if r != nil {
+		d.recur(r)
+	}
+	if c.ProjectID != nil {
+		d.moveToProject(*c.ProjectID, now)
+	}
+	if c.ParentID != nil {
+		parent, err := s.checkParent(ctx, d, *c.ParentID)
+		if err != nil {
+			return nil, err
+		}
+		if err := s.followParent(d, parent, c.ProjectID != nil, now); err != nil {
+			return nil, err
+		}
+		d.move(c.ParentID, now)
+	}
+	d.created()
+
+	task := d.toAggregator()
 
-	if err := s.r.Save(ctx, task); err != nil {
-		return nil, fmt.Errorf("failed to save task: %w", err)
+	if err := s.save(ctx, task); err != nil {
+		return nil, err
 	}
 
 	return task, nil
 }
 
 func (s *Service) MarkCompleted(ctx context.Context, id uuid.UUID) error {
-	task, err := s.r.Find(ctx, id)
+	return s.Transition(ctx, id, StatusDone)
+}
+
+func (s *Service) Transition(ctx context.Context, id uuid.UUID, status Status) error {
+	if !status.valid() {
+		return fmt.Errorf("%w: %s", ErrInvalidStatus, status)
+	}
+
+	d, err := s.find(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	now := s.now()
+	if err := d.transition(status, now); err != nil {
+		return err
+	}
+	if err := s.checkSubtasks(ctx, d); err != nil {
+		return err
+	}
+	if err := s.checkBlockers(ctx, d); err != nil {
+		return err
+	}
+
+	tasks, err := s.withNextOccurrence(ctx, d, now)
+	if err != nil {
+		return err
+	}
+	parents, err := s.rollUp(ctx, d, now)
+	if err != nil {
+		return err
+	}
+
+	return s.save(ctx, append(tasks, parents...)...)
+}
+
+// TaskUpdate holds the fields to change; nil fields are left untouched, an
+// empty DueAt removes the due date, an empty Recurrence stops the task from
+// repeating, a nil ProjectID moves it to the inbox and a nil ParentID makes it
+// a top-level task.
+type TaskUpdate struct {
+	Title      *string
+	Status     *Status
+	DueAt      *string
+	Recurrence *string
+	ProjectID  *uuid.UUID
+	ParentID   *uuid.UUID
+}
+
+func (s *Service) Update(ctx context.Context, id uuid.UUID, u TaskUpdate) (*aggregators.Task, error) {
+	if u.Title != nil && *u.Title == "" {
+		return nil, ErrTitleIsRequired
+	}
+	if u.Status != nil && !u.Status.valid() {
+		return nil, fmt.Errorf("%w: %s", ErrInvalidStatus, *u.Status)
+	}
+
+	now := s.now()
+	var dueAt *time.Time
+	if u.DueAt != nil {
+		var err error
+		if dueAt, err = s.parseDueAt(*u.DueAt, now); err != nil {
+			return nil, err
+		}
+	}
+	var r *recurrence
+	if u.Recurrence != nil && *u.Recurrence != "" {
+		var err error
+		if r, err = parseRecurrence(*u.Recurrence); err != nil {
+			return nil, err
+		}
+	}
+
+	d, err := s.find(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if u.Title != nil {
+		d.rename(*u.Title, now)
+	}
+	if u.DueAt != nil {
+		d.reschedule(dueAt, now)
+	}
+	if u.Recurrence != nil {
+		d.repeat(r, now)
+	}
+	if d.recurrence != nil && d.dueAt == nil {
+		return nil, ErrRecurrenceNoDueAt
+	}
+	projectID := d.projectID
+	if u.ProjectID != nil {
+		to := *u.ProjectID
+		if to == uuid.Nil {
+			to = aggregators.InboxProjectID
+		}
+		d.moveToProject(to, now)
+	}
+	if u.ParentID != nil {
+		parentID := u.ParentID
+		if *parentID == uuid.Nil {
+			parentID = nil
+		} else {
+			parent, err := s.checkParent(ctx, d, *parentID)
+			if err != nil {
+				return nil, err
+			}
+			if err := s.followParent(d, parent, u.ProjectID != nil, now); err != nil {
+				return nil, err
+			}
+		}
+		d.move(parentID, now)
+	} else if d.parentID != nil && d.projectID != projectID {
+		return nil, fmt.Errorf("%w: %s", ErrProjectMismatch, *d.parentID)
+	}
+	transitioned := u.Status != nil && *u.Status != d.status
+	if transitioned {
+		if err := d.transition(*u.Status, now); err != nil {
+			return nil, err
+		}
+		if err := s.checkSubtasks(ctx, d); err != nil {
+			return nil, err
+		}
+		if err := s.checkBlockers(ctx, d); err != nil {
+			return nil, err
+		}
+	}
+
+	tasks := []*aggregators.Task{d.toAggregator()}
+	if transitioned {
+		if tasks, err = s.withNextOccurrence(ctx, d, now); err != nil {
+			return nil, err
+		}
+	}
+	task := tasks[0]
+	if d.projectID != projectID {
+		subtasks, err := s.moveSubtasks(ctx, d, now)
+		if err != nil {
+			return nil, err
+		}
+		tasks = append(tasks, subtasks...)
+	}
+	if transitioned {
+		parents, err := s.rollUp(ctx, d, now)
+		if err != nil {
+			return nil, err
+		}
+		tasks = append(tasks, parents...)
+	}
+	if err := s.save(ctx, tasks...); err != nil {
+		return nil, err
+	}
+
+	return task, nil
+}
+
+func (s *Service) Delete(ctx context.Context, id uuid.UUID) error {
+	d, err := s.find(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	d.trash(s.now())
+
+	if err := s.save(ctx, d.toAggregator()); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (s *Service) Restore(ctx context.Context, id uuid.UUID) (*aggregators.Task, error) {
+	d, err := s.load(ctx, id, s.r.FindTrashed)
+	if err != nil {
+		return nil, err
+	}
+
+	d.restore(s.now())
+
+	task := d.toAggregator()
+	if err := s.save(ctx, task); err != nil {
+		return nil, err
+	}
+
+	return task, nil
+}
+
+// AddDependency makes the task wait for another one to be done. Dependencies
+// that would make a task transitively wait for itself are rejected.
+func (s *Service) AddDependency(ctx context.Context, id, dependsOn uuid.UUID) (*aggregators.Task, error) {
+	d, err := s.find(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := s.r.Find(ctx, dependsOn); err != nil {
+		if errors.Is(err, infrastructure.ErrTaskNotFound) {
+			return nil, fmt.Errorf("%w: %s", ErrDependencyNotFound, dependsOn)
+		}
+		return nil, fmt.Errorf("failed to find task: %w", err)
+	}
+
+	if dependsOn == id {
+		return nil, fmt.Errorf("%w: %s", ErrDependencyCycle, dependsOn)
+	}
+	blockers, err := s.r.Blockers(ctx, dependsOn)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get blockers: %w", err)
+	}
+	for _, b := range blockers {
+		if b.ID == id {
+			return nil, fmt.Errorf("%w: %s", ErrDependencyCycle, dependsOn)
+		}
+	}
+
+	task := d.toAggregator()
+	if !d.block(dependsOn, s.now()) {
+		return task, nil
+	}
+
+	task = d.toAggregator()
+	if err := s.save(ctx, task); err != nil {
+		return nil, err
+	}
+
+	return task, nil
+}
+
+// RemoveDependency stops the task from waiting for another one; removing a
+// dependency it does not have is a no-op.
+func (s *Service) RemoveDependency(ctx context.Context, id, dependsOn uuid.UUID) (*aggregators.Task, error) {
+	d, err := s.find(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	task := d.toAggregator()
+	if !d.unblock(dependsOn, s.now()) {
+		return task, nil
+	}
+
+	task = d.toAggregator()
+	if err := s.save(ctx, task); err != nil {
+		return nil, err
+	}
+
+	return task, nil
+}
+
+// Ready returns the open tasks in an order they can be worked on: every task
+// comes after the open tasks it depends on and otherwise the task due first,
+// then alphabetically. Blocked tasks are left out, and so are the tasks that
+// wait for them.
+func (s *Service) Ready(ctx context.Context) ([]*aggregators.Task, error) {
+	tasks, err := s.r.Open(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get open tasks: %w", err)
+	}
+
+	return topologicalSort(workable(tasks)), nil
+}
+
+func (s *Service) CreateTag(ctx context.Context, name string) (*aggregators.Tag, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return nil, ErrTagNameIsRequired
+	}
+
+	tag := &aggregators.Tag{ID: uuid.New(), Name: name}
+	if err := s.r.SaveTag(ctx, tag); err != nil {
+		if errors.Is(err, infrastructure.ErrTagExists) {
+			return nil, fmt.Errorf("%w: %s", ErrTagExists, name)
+		}
+		return nil, fmt.Errorf("failed to save tag: %w", err)
+	}
+
+	return tag, nil
+}
+
+// DeleteTag removes the tag and detaches it from every task that has it,
+// trashed ones included. The tasks are saved along with the deletion, so each
+// of them gets an entry in its history and a TaskUpdated event.
+func (s *Service) DeleteTag(ctx context.Context, id uuid.UUID) error {
+	tag, err := s.r.FindTag(ctx, id)
+	if err != nil {
+		if errors.Is(err, infrastructure.ErrTagNotFound) {
+			return fmt.Errorf("%w: %s", ErrTagNotFound, id)
+		}
+		return fmt.Errorf("failed to find tag: %w", err)
+	}
+
+	tagged, err := s.r.Tagged(ctx, id)
+	if err != nil {
+		return fmt.Errorf("failed to find tagged tasks: %w", err)
+	}
+
+	now := s.now()
+	tasks := make([]*aggregators.Task, 0, len(tagged))
+	for _, task := range tagged {
+		d := newFromAggregator(task)
+		d.untag(tag.Name, now)
+		tasks = append(tasks, d.toAggregator())
+	}
+
+	if err := s.r.DeleteTag(ctx, id, tasks...); err != nil {
+		if errors.Is(err, infrastructure.ErrTagNotFound) {
+			return fmt.Errorf("%w: %s", ErrTagNotFound, id)
+		}
+		if errors.Is(err, infrastructure.ErrTaskConflict) {
+			return fmt.Errorf("%w: tagged %s", ErrTaskModified, tag.Name)
+		}
+		return fmt.Errorf("failed to delete tag: %w", err)
+	}
+
+	return nil
+}
+
+// Tag attaches an existing tag to the task; attaching it twice is a no-op.
+func (s *Service) Tag(ctx context.Context, id uuid.UUID, name string) (*aggregators.Task, error) {
+	d, err := s.find(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	task := d.toAggregator()
+	if !d.tag(name, s.now()) {
+		return task, nil
+	}
+
+	task = d.toAggregator()
+	if err := s.save(ctx, task); err != nil {
+		if errors.Is(err, infrastructure.ErrTagNotFound) {
+			return nil, fmt.Errorf("%w: %s", ErrTagNotFound, name)
+		}
+		return nil, err
+	}
+
+	return task, nil
+}
+
+// Untag detaches the tag from the task; detaching a tag it does not have is a no-op.
+func (s *Service) Untag(ctx context.Context, id uuid.UUID, name string) (*aggregators.Task, error) {
+	d, err := s.find(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	task := d.toAggregator()
+	if !d.untag(name, s.now()) {
+		return task, nil
+	}
+
+	task = d.toAggregator()
+	if err := s.save(ctx, task); err != nil {
+		return nil, err
+	}
+
+	return task, nil
+}
+
+// Purge permanently removes tasks that have been in the trash for longer than
+// the configured retention and returns how many were removed. The tasks that
+// depend on a removed task, or are subtasks of one, are saved without that
+// reference in the same change.
+func (s *Service) Purge(ctx context.Context) (int64, error) {
+	expired, err := s.r.Expired(ctx, s.now().Add(-s.trashRetention))
+	if err != nil {
+		return 0, fmt.Errorf("failed to purge tasks: %w", err)
+	}
+	if len(expired) == 0 {
+		return 0, nil
+	}
+
+	ids := make([]uuid.UUID, len(expired))
+	for i, task := range expired {
+		ids[i] = task.ID
+	}
+	referencing, err := s.r.Referencing(ctx, ids...)
+	if err != nil {
+		return 0, fmt.Errorf("failed to purge tasks: %w", err)
+	}
+
+	now := s.now()
+	tasks := make([]*aggregators.Task, 0, len(referencing))
+	for _, task := range referencing {
+		d := newFromAggregator(task)
+		for _, id := range ids {
+			d.unblock(id, now)
+		}
+		if d.parentID != nil && slices.Contains(ids, *d.parentID) {
+			d.move(nil, now)
+		}
+		tasks = append(tasks, d.toAggregator())
+	}
+
+	n, err := s.r.Purge(ctx, ids, tasks...)
+	if err != nil {
+		if errors.Is(err, infrastructure.ErrTaskConflict) {
+			return 0, fmt.Errorf("%w: trash", ErrTaskModified)
+		}
+		return 0, fmt.Errorf("failed to purge tasks: %w", err)
+	}
+
+	return n, nil
+}
+
+// Overdue returns the open tasks whose due date has passed.
+func (s *Service) Overdue(ctx context.Context) ([]*aggregators.Task, error) {
+	tasks, err := s.r.Due(ctx, time.Time{}, s.now())
+	if err != nil {
+		return nil, fmt.Errorf("failed to get due tasks: %w", err)
+	}
+
+	return tasks, nil
+}
+
+// Upcoming returns the open tasks that fall due within the given duration.
+func (s *Service) Upcoming(ctx context.Context, within time.Duration) ([]*aggregators.Task, error) {
+	now := s.now()
+	tasks, err := s.r.Due(ctx, now, now.Add(within))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get due tasks: %w", err)
+	}
+
+	return tasks, nil
+}
+
+// checkParent makes sure d can be nested under the parent without creating a
+// cycle or a hierarchy deeper than the configured maximum and returns the parent.
+func (s *Service) checkParent(ctx context.Context, d *task, parentID uuid.UUID) (*aggregators.Task, error) {
+	if parentID == d.id {
+		return nil, fmt.Errorf("%w: %s", ErrParentCycle, parentID)
+	}
+
+	parent, err := s.r.Find(ctx, parentID)
+	if err != nil {
+		if errors.Is(err, infrastructure.ErrTaskNotFound) {
+			return nil, fmt.Errorf("%w: %s", ErrParentNotFound, parentID)
+		}
+		return nil, fmt.Errorf("failed to find task: %w", err)
+	}
+
+	ancestors, err := s.r.Ancestors(ctx, parentID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get ancestors: %w", err)
+	}
+	for _, a := range ancestors {
+		if a.ID == d.id {
+			return nil, fmt.Errorf("%w: %s", ErrParentCycle, parentID)
+		}
+	}
+
+	descendants, err := s.r.Descendants(ctx, d.id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get subtasks: %w", err)
+	}
+	if len(ancestors)+1+height(d.id, descendants) > s.maxDepth {
+		return nil, fmt.Errorf("%w: at most %d levels", ErrMaxDepthExceeded, s.maxDepth)
+	}
+
+	return parent, nil
+}
+
+// followParent moves d into the project of the parent it is nested under. When
+// a project was asked for explicitly it has to be the parent's.
+func (s *Service) followParent(d *task, parent *aggregators.Task, explicit bool, now time.Time) error {
+	if !explicit {
+		d.moveToProject(parent.ProjectID, now)
+		return nil
+	}
+	if d.projectID != parent.ProjectID {
+		return fmt.Errorf("%w: %s", ErrProjectMismatch, parent.ID)
+	}
+
+	return nil
+}
+
+// moveSubtasks returns the subtasks of d moved along into its project.
+func (s *Service) moveSubtasks(ctx context.Context, d *task, now time.Time) ([]*aggregators.Task, error) {
+	descendants, err := s.r.Descendants(ctx, d.id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get subtasks: %w", err)
+	}
+
+	tasks := make([]*aggregators.Task, len(descendants))
+	for i, t := range descendants {
+		sub := newFromAggregator(t)
+		sub.moveToProject(d.projectID, now)
+		tasks[i] = sub.toAggregator()
+	}
+
+	return tasks, nil
+}
+
+// height returns how many levels of subtasks hang below the root, given its
+// descendants ordered parents first.
+func height(root uuid.UUID, descendants []*aggregators.Task) int {
+	depths := map[uuid.UUID]int{root: 0}
+	h := 0
+	for _, t := range descendants {
+		depths[t.ID] = depths[*t.ParentID] + 1
+		h = max(h, depths[t.ID])
+	}
+
+	return h
+}
+
+// checkSubtasks refuses to complete a task while any of its subtasks is open.
+func (s *Service) checkSubtasks(ctx context.Context, d *task) error {
+	if d.status != StatusDone {
+		return nil
+	}
+
+	open, err := s.openSubtasks(ctx, d.id)
+	if err != nil {
+		return err
+	}
+	if open {
+		return fmt.Errorf("%w: %s", ErrOpenSubtasks, d.id)
+	}
+
+	return nil
+}
+
+// rollUp completes the parent of a task that was just closed once none of the
+// parent's subtasks are open anymore, and so on up the hierarchy. It stops at
+// a parent that is still blocked by an open task. The completed parents and
+// their next occurrences are returned so that they are saved together with the
+// task.
+func (s *Service) rollUp(ctx context.Context, d *task, now time.Time) ([]*aggregators.Task, error) {
+	var tasks []*aggregators.Task
+	closed := []uuid.UUID{d.id}
+	for s.parentCompletion == ParentCompletionAuto && d.parentID != nil && d.status.closed() {
+		task, err := s.r.Find(ctx, *d.parentID)
+		if err != nil {
+			if errors.Is(err, infrastructure.ErrTaskNotFound) {
+				return tasks, nil
+			}
+			return nil, fmt.Errorf("failed to find task: %w", err)
+		}
+
+		parent := newFromAggregator(task)
+		if !parent.status.canTransitionTo(StatusDone) {
+			return tasks, nil
+		}
+
+		open, err := s.openSubtasks(ctx, parent.id, closed...)
+		if err != nil {
+			return nil, err
+		}
+		if open {
+			return tasks, nil
+		}
+
+		if err := parent.transition(StatusDone, now); err != nil {
+			return nil, err
+		}
+		if err := s.checkBlockers(ctx, parent, closed...); err != nil {
+			if errors.Is(err, ErrBlocked) {
+				return tasks, nil
+			}
+			return nil, err
+		}
+		next, err := s.withNextOccurrence(ctx, parent, now)
+		if err != nil {
+			return nil, err
+		}
+		tasks = append(tasks, next...)
+		closed = append(closed, parent.id)
+
+		d = parent
+	}
+
+	return tasks, nil
+}
+
+// checkBlockers refuses to complete a task while any task it depends on is
+// open. Tasks in closed are being closed in the same change and count as such.
+func (s *Service) checkBlockers(ctx context.Context, d *task, closed ...uuid.UUID) error {
+	if d.status != StatusDone || len(d.blockedBy) == 0 {
+		return nil
+	}
+
+	blockers, err := s.r.Blockers(ctx, d.id)
+	if err != nil {
+		return fmt.Errorf("failed to get blockers: %w", err)
+	}
+
+	var open []string
+	for _, b := range blockers {
+		if slices.Contains(d.blockedBy, b.ID) && b.DeletedAt == nil && !Status(b.Status).closed() && !slices.Contains(closed, b.ID) {
+			open = append(open, b.ID.String())
+		}
+	}
+	if len(open) > 0 {
+		return fmt.Errorf("%w: %s", ErrBlocked, strings.Join(open, ", "))
+	}
+
+	return nil
+}
+
+func (s *Service) openSubtasks(ctx context.Context, id uuid.UUID, closed ...uuid.UUID) (bool, error) {
+	descendants, err := s.r.Descendants(ctx, id)
+	if err != nil {
+		return false, fmt.Errorf("failed to get subtasks: %w", err)
+	}
+
+	for _, t := range descendants {
+		if !Status(t.Status).closed() && !slices.Contains(closed, t.ID) {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+func (s *Service) parseDueAt(v string, now time.Time) (*time.Time, error) {
+	if v == "" {
+		return nil, nil
+	}
+
+	dueAt, err := time.Parse(time.RFC3339, v)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrInvalidDueDate, v)
+	}
+	if s.rejectPastDueDates && dueAt.Before(now) {
+		return nil, fmt.Errorf("%w: %s", ErrDueDateInPast, v)
+	}
+
+	return &dueAt, nil
+}
+
+func (s *Service) find(ctx context.Context, id uuid.UUID) (*task, error) {
+	return s.load(ctx, id, s.r.Find)
+}
+
+func (s *Service) load(ctx context.Context, id uuid.UUID, find func(context.Context, uuid.UUID) (*aggregators.Task, error)) (*task, error) {
+	vs, expected := expectedVersion(ctx)
+	task, err := find(ctx, id)
 	if err != nil {
 		if errors.Is(err, infrastructure.ErrTaskNotFound) {
-			return fmt.Errorf("%w: %s", ErrTaskNotFound, id)
+			if expected {
+				return nil, fmt.Errorf("%w: %s", ErrVersionMismatch, id)
+			}
+			return nil, fmt.Errorf("%w: %s", ErrTaskNotFound, id)
 		}
-		return fmt.Errorf("failed to find task: %w", err)
+		return nil, fmt.Errorf("failed to find task: %w", err)
+	}
+
+	if expected && len(vs) > 0 && !slices.Contains(vs, task.Version) {
+		return nil, fmt.Errorf("%w: %s", ErrVersionMismatch, id)
+	}
+
+	return newFromAggregator(task), nil
+}
+
+// withNextOccurrence returns the task, followed by the next occurrence of its
+// series when it was just completed, so that both are saved together. A task
+// that is completed again after being reopened does not repeat its series.
+func (s *Service) withNextOccurrence(ctx context.Context, d *task, now time.Time) ([]*aggregators.Task, error) {
+	tasks := []*aggregators.Task{d.toAggregator()}
+	if d.status != StatusDone {
+		return tasks, nil
+	}
+
+	next := d.nextOccurrence(uuid.New(), now)
+	if next == nil {
+		return tasks, nil
+	}
+
+	exists, err := s.r.HasOccurrence(ctx, *next.seriesID, next.occurrence)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find occurrence: %w", err)
+	}
+	if !exists {
+		tasks = append(tasks, next.toAggregator())
 	}
 
-	d := newFromAggregator(task)
-	d.markCompleted()
+	return tasks, nil
+}
 
-	if err := s.r.Save(ctx, d.toAggregator()); err != nil {
+func (s *Service) save(ctx context.Context, tasks ...*aggregators.Task) error {
+	if err := s.r.Save(ctx, tasks...); err != nil {
+		if errors.Is(err, infrastructure.ErrTaskConflict) {
+			return fmt.Errorf("%w: %s", ErrTaskModified, tasks[0].ID)
+		}
+		if errors.Is(err, infrastructure.ErrProjectNotFound) {
+			return fmt.Errorf("%w: %s", ErrProjectNotFound, tasks[0].ProjectID)
+		}
 		return fmt.Errorf("failed to save task: %w", err)
 	}
 
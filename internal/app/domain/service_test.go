@@ -4,12 +4,15 @@ import (
 	"context"
 	"errors"
 	"github.com/aviseu/go-sample/internal/app/domain"
+	"github.com/aviseu/go-sample/internal/app/infrastructure"
 	"github.com/aviseu/go-sample/internal/app/infrastructure/aggregators"
 	"github.com/aviseu/go-sample/internal/errs"
 	"github.com/aviseu/go-sample/internal/testutils"
 	"github.com/google/uuid"
 	"github.com/stretchr/testify/suite"
+	"slices"
 	"testing"
+	"time"
 )
 
 func TestService(t *testing.T) {
@@ -27,14 +30,14 @@ func (suite *ServiceSuite) TestCreateSuccess() {
 	s := domain.NewService(r)
 
 	// Execute
-	task, err := s.Create(context.Background(), "task 1")
+	task, err := s.Create(context.Background(), domain.TaskCreate{Title: "task 1"})
 
 	// Assert result
 	suite.NoError(err)
 	suite.NotNil(task)
 	suite.NotEmpty(task.ID)
 	suite.Equal("task 1", task.Title)
-	suite.False(task.Completed)
+	suite.Equal("todo", task.Status)
 
 	// Assert state
 	suite.Len(r.Records, 1)
@@ -42,7 +45,7 @@ func (suite *ServiceSuite) TestCreateSuccess() {
 	suite.True(ok)
 	suite.Equal(task.ID, t.ID)
 	suite.Equal("task 1", t.Title)
-	suite.False(t.Completed)
+	suite.Equal("todo", t.Status)
 }
 
 func (suite *ServiceSuite) TestCreateTitleIsRequiredFail() {
@@ -51,7 +54,7 @@ func (suite *ServiceSuite) TestCreateTitleIsRequiredFail() {
 	s := domain.NewService(r)
 
 	// Execute
-	task, err := s.Create(context.Background(), "")
+	task, err := s.Create(context.Background(), domain.TaskCreate{})
 
 	// Assert
 	suite.Error(err)
@@ -66,7 +69,7 @@ func (suite *ServiceSuite) TestCreateRepositoryFail() {
 	s := domain.NewService(r)
 
 	// Execute
-	task, err := s.Create(context.Background(), "task 1")
+	task, err := s.Create(context.Background(), domain.TaskCreate{Title: "task 1"})
 
 	// Assert
 	suite.Error(err)
@@ -78,7 +81,7 @@ func (suite *ServiceSuite) TestCreateRepositoryFail() {
 func (suite *ServiceSuite) TestMarkCompletedSuccess() {
 	// Prepare
 	id := uuid.New()
-	r := testutils.NewTaskRepository(testutils.TaskRepositoryWithTask(&aggregators.Task{ID: id, Title: "task 1", Completed: false}))
+	r := testutils.NewTaskRepository(testutils.TaskRepositoryWithTask(&aggregators.Task{ID: id, Title: "task 1", Status: "todo"}))
 	s := domain.NewService(r)
 
 	// Execute
@@ -90,7 +93,7 @@ func (suite *ServiceSuite) TestMarkCompletedSuccess() {
 	// Assert state
 	t, ok := r.Records[id]
 	suite.True(ok)
-	suite.True(t.Completed)
+	suite.Equal("done", t.Status)
 }
 
 func (suite *ServiceSuite) TestMarkCompletedNotFoundFail() {
@@ -121,3 +124,1701 @@ func (suite *ServiceSuite) TestMarkCompletedRepositoryFail() {
 	suite.ErrorContains(err, "boom!")
 	suite.False(errs.IsValidationError(err))
 }
+
+func (suite *ServiceSuite) TestMarkCompletedCancelledFail() {
+	// Prepare
+	id := uuid.New()
+	r := testutils.NewTaskRepository(testutils.TaskRepositoryWithTask(&aggregators.Task{ID: id, Title: "task 1", Status: "cancelled"}))
+	s := domain.NewService(r)
+
+	// Execute
+	err := s.MarkCompleted(context.Background(), id)
+
+	// Assert
+	suite.Error(err)
+	suite.ErrorIs(err, domain.ErrInvalidTransition)
+	suite.ErrorContains(err, "from cancelled to done")
+	suite.True(errs.IsConflictError(err))
+
+	// Assert state
+	suite.Equal("cancelled", r.Records[id].Status)
+}
+
+func (suite *ServiceSuite) TestTransitionSuccess() {
+	tests := []struct {
+		from domain.Status
+		to   domain.Status
+	}{
+		{from: domain.StatusTodo, to: domain.StatusInProgress},
+		{from: domain.StatusTodo, to: domain.StatusCancelled},
+		{from: domain.StatusInProgress, to: domain.StatusBlocked},
+		{from: domain.StatusInProgress, to: domain.StatusDone},
+		{from: domain.StatusBlocked, to: domain.StatusInProgress},
+		{from: domain.StatusDone, to: domain.StatusTodo},
+		{from: domain.StatusCancelled, to: domain.StatusTodo},
+	}
+
+	for _, tt := range tests {
+		suite.Run(string(tt.from)+" -> "+string(tt.to), func() {
+			// Prepare
+			id := uuid.New()
+			r := testutils.NewTaskRepository(testutils.TaskRepositoryWithTask(&aggregators.Task{ID: id, Title: "task 1", Status: string(tt.from)}))
+			s := domain.NewService(r)
+
+			// Execute
+			err := s.Transition(context.Background(), id, tt.to)
+
+			// Assert
+			suite.NoError(err)
+			suite.Equal(string(tt.to), r.Records[id].Status)
+		})
+	}
+}
+
+func (suite *ServiceSuite) TestTransitionIllegalFail() {
+	tests := []struct {
+		from domain.Status
+		to   domain.Status
+	}{
+		{from: domain.StatusTodo, to: domain.StatusTodo},
+		{from: domain.StatusBlocked, to: domain.StatusDone},
+		{from: domain.StatusDone, to: domain.StatusDone},
+		{from: domain.StatusDone, to: domain.StatusCancelled},
+		{from: domain.StatusCancelled, to: domain.StatusInProgress},
+	}
+
+	for _, tt := range tests {
+		suite.Run(string(tt.from)+" -> "+string(tt.to), func() {
+			// Prepare
+			id := uuid.New()
+			r := testutils.NewTaskRepository(testutils.TaskRepositoryWithTask(&aggregators.Task{ID: id, Title: "task 1", Status: string(tt.from)}))
+			s := domain.NewService(r)
+
+			// Execute
+			err := s.Transition(context.Background(), id, tt.to)
+
+			// Assert
+			suite.ErrorIs(err, domain.ErrInvalidTransition)
+			suite.True(errs.IsConflictError(err))
+			suite.Equal(string(tt.from), r.Records[id].Status)
+		})
+	}
+}
+
+func (suite *ServiceSuite) TestTransitionInvalidStatusFail() {
+	// Prepare
+	id := uuid.New()
+	r := testutils.NewTaskRepository(testutils.TaskRepositoryWithTask(&aggregators.Task{ID: id, Title: "task 1", Status: "todo"}))
+	s := domain.NewService(r)
+
+	// Execute
+	err := s.Transition(context.Background(), id, "finished")
+
+	// Assert
+	suite.ErrorIs(err, domain.ErrInvalidStatus)
+	suite.ErrorContains(err, "invalid status: finished")
+	suite.True(errs.IsValidationError(err))
+	suite.Equal("todo", r.Records[id].Status)
+}
+
+func (suite *ServiceSuite) TestUpdateTitleSuccess() {
+	// Prepare
+	id := uuid.New()
+	r := testutils.NewTaskRepository(testutils.TaskRepositoryWithTask(&aggregators.Task{ID: id, Title: "task 1", Status: "todo"}))
+	s := domain.NewService(r)
+	title := "task 1 renamed"
+
+	// Execute
+	task, err := s.Update(context.Background(), id, domain.TaskUpdate{Title: &title})
+
+	// Assert result
+	suite.NoError(err)
+	suite.Equal(id, task.ID)
+	suite.Equal("task 1 renamed", task.Title)
+	suite.Equal("todo", task.Status)
+
+	// Assert state
+	suite.Equal("task 1 renamed", r.Records[id].Title)
+	suite.Equal("todo", r.Records[id].Status)
+}
+
+func (suite *ServiceSuite) TestUpdateReopenSuccess() {
+	// Prepare
+	id := uuid.New()
+	r := testutils.NewTaskRepository(testutils.TaskRepositoryWithTask(&aggregators.Task{ID: id, Title: "task 1", Status: "done"}))
+	s := domain.NewService(r)
+	status := domain.StatusTodo
+
+	// Execute
+	task, err := s.Update(context.Background(), id, domain.TaskUpdate{Status: &status})
+
+	// Assert
+	suite.NoError(err)
+	suite.Equal("task 1", task.Title)
+	suite.Equal("todo", task.Status)
+	suite.Equal("todo", r.Records[id].Status)
+}
+
+func (suite *ServiceSuite) TestUpdateUnchangedStatusSuccess() {
+	// Prepare
+	id := uuid.New()
+	r := testutils.NewTaskRepository(testutils.TaskRepositoryWithTask(&aggregators.Task{ID: id, Title: "task 1", Status: "done"}))
+	s := domain.NewService(r)
+	title := "task 1 renamed"
+	status := domain.StatusDone
+
+	// Execute
+	task, err := s.Update(context.Background(), id, domain.TaskUpdate{Title: &title, Status: &status})
+
+	// Assert
+	suite.NoError(err)
+	suite.Equal("task 1 renamed", task.Title)
+	suite.Equal("done", task.Status)
+}
+
+func (suite *ServiceSuite) TestUpdateTitleIsRequiredFail() {
+	// Prepare
+	id := uuid.New()
+	r := testutils.NewTaskRepository(testutils.TaskRepositoryWithTask(&aggregators.Task{ID: id, Title: "task 1", Status: "todo"}))
+	s := domain.NewService(r)
+	title := ""
+
+	// Execute
+	task, err := s.Update(context.Background(), id, domain.TaskUpdate{Title: &title})
+
+	// Assert
+	suite.Nil(task)
+	suite.ErrorIs(err, domain.ErrTitleIsRequired)
+	suite.True(errs.IsValidationError(err))
+	suite.Equal("task 1", r.Records[id].Title)
+}
+
+func (suite *ServiceSuite) TestUpdateIllegalTransitionFail() {
+	// Prepare
+	id := uuid.New()
+	r := testutils.NewTaskRepository(testutils.TaskRepositoryWithTask(&aggregators.Task{ID: id, Title: "task 1", Status: "cancelled"}))
+	s := domain.NewService(r)
+	title := "task 1 renamed"
+	status := domain.StatusDone
+
+	// Execute
+	task, err := s.Update(context.Background(), id, domain.TaskUpdate{Title: &title, Status: &status})
+
+	// Assert
+	suite.Nil(task)
+	suite.ErrorIs(err, domain.ErrInvalidTransition)
+	suite.Equal("task 1", r.Records[id].Title)
+	suite.Equal("cancelled", r.Records[id].Status)
+}
+
+func (suite *ServiceSuite) TestUpdateNotFoundFail() {
+	// Prepare
+	r := testutils.NewTaskRepository()
+	s := domain.NewService(r)
+	title := "task 1"
+
+	// Execute
+	task, err := s.Update(context.Background(), uuid.New(), domain.TaskUpdate{Title: &title})
+
+	// Assert
+	suite.Nil(task)
+	suite.ErrorIs(err, domain.ErrTaskNotFound)
+}
+
+func (suite *ServiceSuite) TestUpdateRepositoryFail() {
+	// Prepare
+	r := testutils.NewTaskRepository(testutils.TaskRepositoryWithError(errors.New("boom!")))
+	s := domain.NewService(r)
+	title := "task 1"
+
+	// Execute
+	task, err := s.Update(context.Background(), uuid.New(), domain.TaskUpdate{Title: &title})
+
+	// Assert
+	suite.Nil(task)
+	suite.ErrorContains(err, "boom!")
+	suite.False(errs.IsValidationError(err))
+}
+
+func (suite *ServiceSuite) TestDeleteSuccess() {
+	// Prepare
+	id := uuid.New()
+	r := testutils.NewTaskRepository(testutils.TaskRepositoryWithTask(&aggregators.Task{ID: id, Title: "task 1", Status: "todo"}))
+	now := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+	s := domain.NewService(r, domain.ServiceWithClock(func() time.Time { return now }))
+
+	// Execute
+	err := s.Delete(context.Background(), id)
+
+	// Assert
+	suite.NoError(err)
+
+	// Assert state
+	suite.Len(r.Records, 1)
+	suite.Equal(&now, r.Records[id].DeletedAt)
+	suite.Equal(now, r.Records[id].UpdatedAt)
+}
+
+func (suite *ServiceSuite) TestDeleteNotFoundFail() {
+	// Prepare
+	id := uuid.New()
+	deletedAt := time.Now()
+	r := testutils.NewTaskRepository(testutils.TaskRepositoryWithTask(&aggregators.Task{ID: id, Title: "task 1", Status: "todo", DeletedAt: &deletedAt}))
+	s := domain.NewService(r)
+
+	// Execute
+	err := s.Delete(context.Background(), id)
+
+	// Assert
+	suite.ErrorIs(err, domain.ErrTaskNotFound)
+	suite.Equal(deletedAt, *r.Records[id].DeletedAt)
+}
+
+func (suite *ServiceSuite) TestRestoreSuccess() {
+	// Prepare
+	id := uuid.New()
+	deletedAt := time.Now()
+	r := testutils.NewTaskRepository(testutils.TaskRepositoryWithTask(&aggregators.Task{ID: id, Title: "task 1", Status: "todo", DeletedAt: &deletedAt}))
+	s := domain.NewService(r)
+
+	// Execute
+	task, err := s.Restore(context.Background(), id)
+
+	// Assert result
+	suite.NoError(err)
+	suite.Equal(id, task.ID)
+	suite.Nil(task.DeletedAt)
+
+	// Assert state
+	suite.Nil(r.Records[id].DeletedAt)
+}
+
+func (suite *ServiceSuite) TestRestoreNotTrashedFail() {
+	// Prepare
+	id := uuid.New()
+	r := testutils.NewTaskRepository(testutils.TaskRepositoryWithTask(&aggregators.Task{ID: id, Title: "task 1", Status: "todo"}))
+	s := domain.NewService(r)
+
+	// Execute
+	task, err := s.Restore(context.Background(), id)
+
+	// Assert
+	suite.Nil(task)
+	suite.ErrorIs(err, domain.ErrTaskNotFound)
+}
+
+func (suite *ServiceSuite) TestPurgeSuccess() {
+	// Prepare
+	id1, id2, id3 := uuid.New(), uuid.New(), uuid.New()
+	old := time.Now().Add(-48 * time.Hour)
+	recent := time.Now().Add(-time.Hour)
+	r := testutils.NewTaskRepository(
+		testutils.TaskRepositoryWithTask(&aggregators.Task{ID: id1, Title: "task 1", Status: "todo", DeletedAt: &old}),
+		testutils.TaskRepositoryWithTask(&aggregators.Task{ID: id2, Title: "task 2", Status: "todo", DeletedAt: &recent}),
+		testutils.TaskRepositoryWithTask(&aggregators.Task{ID: id3, Title: "task 3", Status: "todo"}),
+	)
+	s := domain.NewService(r, domain.ServiceWithTrashRetention(24*time.Hour))
+
+	// Execute
+	n, err := s.Purge(context.Background())
+
+	// Assert result
+	suite.NoError(err)
+	suite.Equal(int64(1), n)
+
+	// Assert state
+	suite.Len(r.Records, 2)
+	suite.NotContains(r.Records, id1)
+	suite.Contains(r.Records, id2)
+	suite.Contains(r.Records, id3)
+}
+
+func (suite *ServiceSuite) TestPurgeKeepsParentOfLiveChild() {
+	// Prepare
+	parentID, childID := uuid.New(), uuid.New()
+	old := time.Now().Add(-48 * time.Hour)
+	r := testutils.NewTaskRepository(
+		testutils.TaskRepositoryWithTask(&aggregators.Task{ID: parentID, Title: "parent", Status: "todo", DeletedAt: &old}),
+		testutils.TaskRepositoryWithTask(&aggregators.Task{ID: childID, ParentID: &parentID, Title: "child", Status: "todo"}),
+	)
+	s := domain.NewService(r, domain.ServiceWithTrashRetention(24*time.Hour))
+
+	// Execute
+	n, err := s.Purge(context.Background())
+
+	// Assert
+	suite.NoError(err)
+	suite.Zero(n)
+	suite.Contains(r.Records, parentID)
+	suite.Equal(&parentID, r.Records[childID].ParentID)
+}
+
+func (suite *ServiceSuite) TestPurgeReleasesReferences() {
+	// Prepare
+	id, childID, dependentID := uuid.New(), uuid.New(), uuid.New()
+	old := time.Now().Add(-48 * time.Hour)
+	recent := time.Now().Add(-time.Hour)
+	r := testutils.NewTaskRepository(
+		testutils.TaskRepositoryWithTask(&aggregators.Task{ID: id, Title: "parent", Status: "todo", Version: 1, DeletedAt: &old}),
+		testutils.TaskRepositoryWithTask(&aggregators.Task{ID: childID, ParentID: &id, Title: "child", Status: "todo", Version: 1, DeletedAt: &recent}),
+		testutils.TaskRepositoryWithTask(&aggregators.Task{ID: dependentID, Title: "dependent", Status: "todo", Version: 1, BlockedBy: []uuid.UUID{id}}),
+	)
+	s := domain.NewService(r, domain.ServiceWithTrashRetention(24*time.Hour))
+
+	// Execute
+	n, err := s.Purge(context.Background())
+
+	// Assert result
+	suite.NoError(err)
+	suite.Equal(int64(1), n)
+
+	// Assert state
+	suite.NotContains(r.Records, id)
+	suite.Nil(r.Records[childID].ParentID)
+	suite.Equal(2, r.Records[childID].Version)
+	suite.Empty(r.Records[dependentID].BlockedBy)
+	suite.Equal(2, r.Records[dependentID].Version)
+	suite.Len(r.HistoryRecords[childID], 1)
+	suite.Len(r.HistoryRecords[dependentID], 1)
+	suite.Len(r.EventRecords, 2)
+}
+
+func (suite *ServiceSuite) TestPurgeRepositoryFail() {
+	// Prepare
+	r := testutils.NewTaskRepository(testutils.TaskRepositoryWithError(errors.New("boom!")))
+	s := domain.NewService(r)
+
+	// Execute
+	n, err := s.Purge(context.Background())
+
+	// Assert
+	suite.Zero(n)
+	suite.ErrorContains(err, "failed to purge tasks: boom!")
+}
+
+func (suite *ServiceSuite) TestTransitionBumpsVersionSuccess() {
+	// Prepare
+	id := uuid.New()
+	r := testutils.NewTaskRepository(testutils.TaskRepositoryWithTask(&aggregators.Task{ID: id, Title: "task 1", Status: "todo", Version: 3}))
+	s := domain.NewService(r)
+
+	// Execute
+	err := s.Transition(context.Background(), id, domain.StatusInProgress)
+
+	// Assert
+	suite.NoError(err)
+	suite.Equal(4, r.Records[id].Version)
+}
+
+func (suite *ServiceSuite) TestUpdateExpectedVersionSuccess() {
+	// Prepare
+	id := uuid.New()
+	r := testutils.NewTaskRepository(testutils.TaskRepositoryWithTask(&aggregators.Task{ID: id, Title: "task 1", Status: "todo", Version: 3}))
+	s := domain.NewService(r)
+	title := "task 1 renamed"
+	ctx := domain.WithExpectedVersion(context.Background(), 3)
+
+	// Execute
+	task, err := s.Update(ctx, id, domain.TaskUpdate{Title: &title})
+
+	// Assert
+	suite.NoError(err)
+	suite.Equal(4, task.Version)
+	suite.Equal("task 1 renamed", r.Records[id].Title)
+}
+
+func (suite *ServiceSuite) TestUpdateVersionMismatchFail() {
+	// Prepare
+	id := uuid.New()
+	r := testutils.NewTaskRepository(testutils.TaskRepositoryWithTask(&aggregators.Task{ID: id, Title: "task 1", Status: "todo", Version: 3}))
+	s := domain.NewService(r)
+	title := "task 1 renamed"
+	ctx := domain.WithExpectedVersion(context.Background(), 2)
+
+	// Execute
+	task, err := s.Update(ctx, id, domain.TaskUpdate{Title: &title})
+
+	// Assert
+	suite.Nil(task)
+	suite.ErrorIs(err, domain.ErrVersionMismatch)
+	suite.True(errs.IsPreconditionError(err))
+	suite.Equal("task 1", r.Records[id].Title)
+	suite.Equal(3, r.Records[id].Version)
+}
+
+func (suite *ServiceSuite) TestMarkCompletedConcurrentModificationFail() {
+	// Prepare
+	id := uuid.New()
+	r := testutils.NewTaskRepository(
+		testutils.TaskRepositoryWithTask(&aggregators.Task{ID: id, Title: "task 1", Status: "todo", Version: 1}),
+		testutils.TaskRepositoryWithSaveError(infrastructure.ErrTaskConflict),
+	)
+	s := domain.NewService(r)
+
+	// Execute
+	err := s.MarkCompleted(context.Background(), id)
+
+	// Assert
+	suite.ErrorIs(err, domain.ErrTaskModified)
+	suite.ErrorContains(err, "task was modified concurrently: "+id.String())
+	suite.True(errs.IsConflictError(err))
+}
+
+func (suite *ServiceSuite) TestCreateTimestampsSuccess() {
+	// Prepare
+	r := testutils.NewTaskRepository()
+	now := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+	s := domain.NewService(r, domain.ServiceWithClock(func() time.Time { return now }))
+
+	// Execute
+	task, err := s.Create(context.Background(), domain.TaskCreate{Title: "task 1"})
+
+	// Assert
+	suite.NoError(err)
+	suite.Equal(now, task.CreatedAt)
+	suite.Equal(now, task.UpdatedAt)
+	suite.Nil(task.CompletedAt)
+}
+
+func (suite *ServiceSuite) TestMarkCompletedTimestampsSuccess() {
+	// Prepare
+	id := uuid.New()
+	createdAt := time.Date(2025, 2, 1, 9, 0, 0, 0, time.UTC)
+	r := testutils.NewTaskRepository(testutils.TaskRepositoryWithTask(&aggregators.Task{ID: id, Title: "task 1", Status: "todo", CreatedAt: createdAt, UpdatedAt: createdAt}))
+	now := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+	s := domain.NewService(r, domain.ServiceWithClock(func() time.Time { return now }))
+
+	// Execute
+	err := s.MarkCompleted(context.Background(), id)
+
+	// Assert
+	suite.NoError(err)
+	suite.Equal(createdAt, r.Records[id].CreatedAt)
+	suite.Equal(now, r.Records[id].UpdatedAt)
+	suite.Equal(&now, r.Records[id].CompletedAt)
+}
+
+func (suite *ServiceSuite) TestTransitionClearsCompletedAtSuccess() {
+	// Prepare
+	id := uuid.New()
+	createdAt := time.Date(2025, 2, 1, 9, 0, 0, 0, time.UTC)
+	r := testutils.NewTaskRepository(testutils.TaskRepositoryWithTask(&aggregators.Task{ID: id, Title: "task 1", Status: "done", CreatedAt: createdAt, UpdatedAt: createdAt, CompletedAt: &createdAt}))
+	now := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+	s := domain.NewService(r, domain.ServiceWithClock(func() time.Time { return now }))
+
+	// Execute
+	err := s.Transition(context.Background(), id, domain.StatusTodo)
+
+	// Assert
+	suite.NoError(err)
+	suite.Equal(now, r.Records[id].UpdatedAt)
+	suite.Nil(r.Records[id].CompletedAt)
+}
+
+func (suite *ServiceSuite) TestUpdateTitleKeepsCompletedAtSuccess() {
+	// Prepare
+	id := uuid.New()
+	createdAt := time.Date(2025, 2, 1, 9, 0, 0, 0, time.UTC)
+	r := testutils.NewTaskRepository(testutils.TaskRepositoryWithTask(&aggregators.Task{ID: id, Title: "task 1", Status: "done", CreatedAt: createdAt, UpdatedAt: createdAt, CompletedAt: &createdAt}))
+	now := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+	s := domain.NewService(r, domain.ServiceWithClock(func() time.Time { return now }))
+	title := "task 1 renamed"
+
+	// Execute
+	task, err := s.Update(context.Background(), id, domain.TaskUpdate{Title: &title})
+
+	// Assert
+	suite.NoError(err)
+	suite.Equal(now, task.UpdatedAt)
+	suite.Equal(&createdAt, task.CompletedAt)
+}
+
+func (suite *ServiceSuite) TestPurgeUsesClockSuccess() {
+	// Prepare
+	id := uuid.New()
+	deletedAt := time.Date(2025, 2, 1, 9, 0, 0, 0, time.UTC)
+	r := testutils.NewTaskRepository(testutils.TaskRepositoryWithTask(&aggregators.Task{ID: id, Title: "task 1", Status: "todo", DeletedAt: &deletedAt}))
+	now := time.Date(2025, 2, 2, 9, 0, 1, 0, time.UTC)
+	s := domain.NewService(r, domain.ServiceWithClock(func() time.Time { return now }), domain.ServiceWithTrashRetention(24*time.Hour))
+
+	// Execute
+	n, err := s.Purge(context.Background())
+
+	// Assert
+	suite.NoError(err)
+	suite.Equal(int64(1), n)
+	suite.Empty(r.Records)
+}
+
+func (suite *ServiceSuite) TestCreateDueAtSuccess() {
+	// Prepare
+	r := testutils.NewTaskRepository()
+	now := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+	s := domain.NewService(r, domain.ServiceWithClock(func() time.Time { return now }), domain.ServiceWithPastDueDatesRejected())
+
+	// Execute
+	task, err := s.Create(context.Background(), domain.TaskCreate{Title: "task 1", DueAt: "2025-03-04T12:00:00+01:00"})
+
+	// Assert
+	suite.NoError(err)
+	suite.NotNil(task.DueAt)
+	suite.True(time.Date(2025, 3, 4, 11, 0, 0, 0, time.UTC).Equal(*task.DueAt))
+	suite.Equal(task.DueAt, r.Records[task.ID].DueAt)
+}
+
+func (suite *ServiceSuite) TestCreateInvalidDueAtFail() {
+	// Prepare
+	r := testutils.NewTaskRepository()
+	s := domain.NewService(r)
+
+	// Execute
+	task, err := s.Create(context.Background(), domain.TaskCreate{Title: "task 1", DueAt: "next friday"})
+
+	// Assert
+	suite.Nil(task)
+	suite.ErrorIs(err, domain.ErrInvalidDueDate)
+	suite.ErrorContains(err, "due date must be an RFC 3339 timestamp: next friday")
+	suite.True(errs.IsValidationError(err))
+	suite.Empty(r.Records)
+}
+
+func (suite *ServiceSuite) TestCreatePastDueAt() {
+	tests := []struct {
+		name   string
+		opts   []domain.ServiceOptional
+		reject bool
+	}{
+		{name: "allowed", opts: nil, reject: false},
+		{name: "rejected", opts: []domain.ServiceOptional{domain.ServiceWithPastDueDatesRejected()}, reject: true},
+	}
+
+	for _, tt := range tests {
+		suite.Run(tt.name, func() {
+			// Prepare
+			r := testutils.NewTaskRepository()
+			now := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+			opts := append([]domain.ServiceOptional{domain.ServiceWithClock(func() time.Time { return now })}, tt.opts...)
+			s := domain.NewService(r, opts...)
+
+			// Execute
+			task, err := s.Create(context.Background(), domain.TaskCreate{Title: "task 1", DueAt: "2025-03-01T11:59:59Z"})
+
+			// Assert
+			if tt.reject {
+				suite.Nil(task)
+				suite.ErrorIs(err, domain.ErrDueDateInPast)
+				suite.True(errs.IsValidationError(err))
+				return
+			}
+			suite.NoError(err)
+			suite.NotNil(task.DueAt)
+		})
+	}
+}
+
+func (suite *ServiceSuite) TestUpdateDueAtSuccess() {
+	// Prepare
+	id := uuid.New()
+	dueAt := time.Date(2025, 3, 2, 9, 0, 0, 0, time.UTC)
+	r := testutils.NewTaskRepository(testutils.TaskRepositoryWithTask(&aggregators.Task{ID: id, Title: "task 1", Status: "todo", DueAt: &dueAt}))
+	s := domain.NewService(r)
+	newDueAt := "2025-03-05T09:00:00Z"
+	noDueAt := ""
+
+	// Execute
+	rescheduled, err1 := s.Update(context.Background(), id, domain.TaskUpdate{DueAt: &newDueAt})
+	cleared, err2 := s.Update(context.Background(), id, domain.TaskUpdate{DueAt: &noDueAt})
+
+	// Assert
+	suite.NoError(err1)
+	suite.True(time.Date(2025, 3, 5, 9, 0, 0, 0, time.UTC).Equal(*rescheduled.DueAt))
+	suite.NoError(err2)
+	suite.Nil(cleared.DueAt)
+	suite.Nil(r.Records[id].DueAt)
+}
+
+func (suite *ServiceSuite) TestUpdateRecurrenceSuccess() {
+	// Prepare
+	id := uuid.New()
+	dueAt := date(2025, 3, 1)
+	r := testutils.NewTaskRepository(testutils.TaskRepositoryWithTask(&aggregators.Task{ID: id, Title: "task 1", Status: "todo", DueAt: &dueAt}))
+	s := domain.NewService(r)
+
+	// Execute
+	started, err1 := s.Update(context.Background(), id, domain.TaskUpdate{Recurrence: ptr("FREQ=DAILY")})
+	changed, err2 := s.Update(context.Background(), id, domain.TaskUpdate{Recurrence: ptr("FREQ=WEEKLY")})
+	stopped, err3 := s.Update(context.Background(), id, domain.TaskUpdate{Recurrence: ptr("")})
+
+	// Assert
+	suite.NoError(err1)
+	suite.Equal(ptr("FREQ=DAILY"), started.Recurrence)
+	suite.Equal(&id, started.SeriesID)
+	suite.Equal(1, started.Occurrence)
+	suite.NoError(err2)
+	suite.Equal(ptr("FREQ=WEEKLY"), changed.Recurrence)
+	suite.Equal(&id, changed.SeriesID)
+	suite.NoError(err3)
+	suite.Nil(stopped.Recurrence)
+	suite.Nil(r.Records[id].Recurrence)
+}
+
+func (suite *ServiceSuite) TestUpdateRecurrenceFail() {
+	// Prepare
+	dueAt := date(2025, 3, 1)
+	recurrence := "FREQ=DAILY"
+
+	tests := []struct {
+		name   string
+		task   *aggregators.Task
+		update domain.TaskUpdate
+		err    error
+	}{
+		{name: "due date removed", task: &aggregators.Task{Title: "task 1", Status: "todo", DueAt: &dueAt, Recurrence: &recurrence}, update: domain.TaskUpdate{DueAt: ptr("")}, err: domain.ErrRecurrenceNoDueAt},
+		{name: "no due date", task: &aggregators.Task{Title: "task 1", Status: "todo"}, update: domain.TaskUpdate{Recurrence: ptr("FREQ=DAILY")}, err: domain.ErrRecurrenceNoDueAt},
+		{name: "invalid rule", task: &aggregators.Task{Title: "task 1", Status: "todo", DueAt: &dueAt}, update: domain.TaskUpdate{Recurrence: ptr("FREQ=HOURLY")}, err: domain.ErrInvalidRecurrence},
+	}
+
+	for _, tt := range tests {
+		suite.Run(tt.name, func() {
+			// Prepare
+			tt.task.ID = uuid.New()
+			tt.task.SeriesID = nil
+			if tt.task.Recurrence != nil {
+				tt.task.SeriesID = &tt.task.ID
+			}
+			r := testutils.NewTaskRepository(testutils.TaskRepositoryWithTask(tt.task))
+			s := domain.NewService(r)
+
+			// Execute
+			task, err := s.Update(context.Background(), tt.task.ID, tt.update)
+
+			// Assert
+			suite.Nil(task)
+			suite.ErrorIs(err, tt.err)
+			suite.Equal(tt.task.DueAt, r.Records[tt.task.ID].DueAt)
+			suite.Equal(tt.task.Recurrence, r.Records[tt.task.ID].Recurrence)
+		})
+	}
+}
+
+func (suite *ServiceSuite) TestOverdueSuccess() {
+	// Prepare
+	now := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+	past := now.Add(-time.Hour)
+	future := now.Add(time.Hour)
+	id := uuid.New()
+	r := testutils.NewTaskRepository(
+		testutils.TaskRepositoryWithTask(&aggregators.Task{ID: id, Title: "task 1", Status: "todo", DueAt: &past}),
+		testutils.TaskRepositoryWithTask(&aggregators.Task{ID: uuid.New(), Title: "task 2", Status: "done", DueAt: &past}),
+		testutils.TaskRepositoryWithTask(&aggregators.Task{ID: uuid.New(), Title: "task 3", Status: "todo", DueAt: &future}),
+		testutils.TaskRepositoryWithTask(&aggregators.Task{ID: uuid.New(), Title: "task 4", Status: "todo"}),
+	)
+	s := domain.NewService(r, domain.ServiceWithClock(func() time.Time { return now }))
+
+	// Execute
+	tasks, err := s.Overdue(context.Background())
+
+	// Assert
+	suite.NoError(err)
+	suite.Len(tasks, 1)
+	suite.Equal(id, tasks[0].ID)
+}
+
+func (suite *ServiceSuite) TestUpcomingSuccess() {
+	// Prepare
+	now := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+	past := now.Add(-time.Hour)
+	soon := now.Add(time.Hour)
+	later := now.Add(25 * time.Hour)
+	id := uuid.New()
+	r := testutils.NewTaskRepository(
+		testutils.TaskRepositoryWithTask(&aggregators.Task{ID: uuid.New(), Title: "task 1", Status: "todo", DueAt: &past}),
+		testutils.TaskRepositoryWithTask(&aggregators.Task{ID: id, Title: "task 2", Status: "in_progress", DueAt: &soon}),
+		testutils.TaskRepositoryWithTask(&aggregators.Task{ID: uuid.New(), Title: "task 3", Status: "todo", DueAt: &later}),
+	)
+	s := domain.NewService(r, domain.ServiceWithClock(func() time.Time { return now }))
+
+	// Execute
+	tasks, err := s.Upcoming(context.Background(), 24*time.Hour)
+
+	// Assert
+	suite.NoError(err)
+	suite.Len(tasks, 1)
+	suite.Equal(id, tasks[0].ID)
+}
+
+func (suite *ServiceSuite) TestOverdueRepositoryFail() {
+	// Prepare
+	r := testutils.NewTaskRepository(testutils.TaskRepositoryWithError(errors.New("boom!")))
+	s := domain.NewService(r)
+
+	// Execute
+	tasks, err := s.Overdue(context.Background())
+
+	// Assert
+	suite.Nil(tasks)
+	suite.ErrorContains(err, "failed to get due tasks: boom!")
+}
+
+func (suite *ServiceSuite) TestCreateTagSuccess() {
+	// Prepare
+	r := testutils.NewTaskRepository()
+	s := domain.NewService(r)
+
+	// Execute
+	tag, err := s.CreateTag(context.Background(), " work ")
+
+	// Assert
+	suite.NoError(err)
+	suite.Equal("work", tag.Name)
+	suite.Equal(tag, r.TagRecords[tag.ID])
+}
+
+func (suite *ServiceSuite) TestCreateTagFail() {
+	tests := []struct {
+		name string
+		tag  string
+		err  error
+	}{
+		{name: "empty", tag: " ", err: domain.ErrTagNameIsRequired},
+		{name: "exists", tag: "work", err: domain.ErrTagExists},
+	}
+
+	for _, tt := range tests {
+		suite.Run(tt.name, func() {
+			// Prepare
+			r := testutils.NewTaskRepository(testutils.TaskRepositoryWithTag(&aggregators.Tag{ID: uuid.New(), Name: "work"}))
+			s := domain.NewService(r)
+
+			// Execute
+			tag, err := s.CreateTag(context.Background(), tt.tag)
+
+			// Assert
+			suite.Nil(tag)
+			suite.ErrorIs(err, tt.err)
+			suite.Len(r.TagRecords, 1)
+		})
+	}
+}
+
+func (suite *ServiceSuite) TestDeleteTagSuccess() {
+	// Prepare
+	tagID, taskID, trashedID := uuid.New(), uuid.New(), uuid.New()
+	now := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+	r := testutils.NewTaskRepository(
+		testutils.TaskRepositoryWithTag(&aggregators.Tag{ID: uuid.New(), Name: "home"}),
+		testutils.TaskRepositoryWithTag(&aggregators.Tag{ID: tagID, Name: "work"}),
+		testutils.TaskRepositoryWithTask(&aggregators.Task{ID: taskID, Title: "task 1", Status: "todo", Version: 1, Tags: []string{"home", "work"}}),
+		testutils.TaskRepositoryWithTask(&aggregators.Task{ID: trashedID, Title: "task 2", Status: "todo", Version: 1, Tags: []string{"work"}, DeletedAt: &now}),
+	)
+	s := domain.NewService(r, domain.ServiceWithClock(func() time.Time { return now }))
+
+	// Execute
+	err := s.DeleteTag(context.Background(), tagID)
+
+	// Assert
+	suite.NoError(err)
+	suite.Len(r.TagRecords, 1)
+	suite.Equal([]string{"home"}, r.Records[taskID].Tags)
+	suite.Equal(2, r.Records[taskID].Version)
+	suite.Equal(now, r.Records[taskID].UpdatedAt)
+	suite.Empty(r.Records[trashedID].Tags)
+	suite.Equal(2, r.Records[trashedID].Version)
+	suite.Len(r.HistoryRecords[taskID], 1)
+	suite.Equal(infrastructure.ActionUpdated, r.HistoryRecords[taskID][0].Action)
+	suite.Len(r.HistoryRecords[trashedID], 1)
+}
+
+func (suite *ServiceSuite) TestDeleteTagNotFound() {
+	// Prepare
+	r := testutils.NewTaskRepository()
+	s := domain.NewService(r)
+	id := uuid.New()
+
+	// Execute
+	err := s.DeleteTag(context.Background(), id)
+
+	// Assert
+	suite.ErrorIs(err, domain.ErrTagNotFound)
+	suite.ErrorContains(err, "tag not found: "+id.String())
+}
+
+func (suite *ServiceSuite) TestTagSuccess() {
+	// Prepare
+	id := uuid.New()
+	now := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+	r := testutils.NewTaskRepository(
+		testutils.TaskRepositoryWithTag(&aggregators.Tag{ID: uuid.New(), Name: "home"}),
+		testutils.TaskRepositoryWithTag(&aggregators.Tag{ID: uuid.New(), Name: "work"}),
+		testutils.TaskRepositoryWithTask(&aggregators.Task{ID: id, Title: "task 1", Status: "todo", Version: 1, Tags: []string{"work"}}),
+	)
+	s := domain.NewService(r, domain.ServiceWithClock(func() time.Time { return now }))
+
+	// Execute
+	task1, err1 := s.Tag(context.Background(), id, "home")
+	task2, err2 := s.Tag(context.Background(), id, "home")
+
+	// Assert
+	suite.NoError(err1)
+	suite.Equal([]string{"home", "work"}, task1.Tags)
+	suite.Equal(now, task1.UpdatedAt)
+	suite.Equal(2, task1.Version)
+	suite.NoError(err2)
+	suite.Equal(2, task2.Version)
+	suite.Equal([]string{"home", "work"}, r.Records[id].Tags)
+}
+
+func (suite *ServiceSuite) TestTagUnknownTagFail() {
+	// Prepare
+	id := uuid.New()
+	r := testutils.NewTaskRepository(testutils.TaskRepositoryWithTask(&aggregators.Task{ID: id, Title: "task 1", Status: "todo", Version: 1}))
+	s := domain.NewService(r)
+
+	// Execute
+	task, err := s.Tag(context.Background(), id, "home")
+
+	// Assert
+	suite.Nil(task)
+	suite.ErrorIs(err, domain.ErrTagNotFound)
+	suite.ErrorContains(err, "tag not found: home")
+	suite.Nil(r.Records[id].Tags)
+	suite.Equal(1, r.Records[id].Version)
+}
+
+func (suite *ServiceSuite) TestUntagSuccess() {
+	// Prepare
+	id := uuid.New()
+	r := testutils.NewTaskRepository(
+		testutils.TaskRepositoryWithTag(&aggregators.Tag{ID: uuid.New(), Name: "work"}),
+		testutils.TaskRepositoryWithTask(&aggregators.Task{ID: id, Title: "task 1", Status: "todo", Version: 1, Tags: []string{"work"}}),
+	)
+	s := domain.NewService(r)
+
+	// Execute
+	task1, err1 := s.Untag(context.Background(), id, "work")
+	task2, err2 := s.Untag(context.Background(), id, "work")
+
+	// Assert
+	suite.NoError(err1)
+	suite.Empty(task1.Tags)
+	suite.Equal(2, task1.Version)
+	suite.NoError(err2)
+	suite.Equal(2, task2.Version)
+	suite.Empty(r.Records[id].Tags)
+}
+
+func (suite *ServiceSuite) TestCreateSubtaskSuccess() {
+	// Prepare
+	parentID := uuid.New()
+	r := testutils.NewTaskRepository(testutils.TaskRepositoryWithTask(&aggregators.Task{ID: parentID, Title: "parent", Status: "todo", Version: 1}))
+	s := domain.NewService(r)
+
+	// Execute
+	task, err := s.Create(context.Background(), domain.TaskCreate{Title: "task 1", ParentID: &parentID})
+
+	// Assert
+	suite.NoError(err)
+	suite.Equal(&parentID, task.ParentID)
+	suite.Equal(&parentID, r.Records[task.ID].ParentID)
+}
+
+func (suite *ServiceSuite) TestCreateSubtaskParentNotFound() {
+	// Prepare
+	r := testutils.NewTaskRepository()
+	s := domain.NewService(r)
+	parentID := uuid.New()
+
+	// Execute
+	task, err := s.Create(context.Background(), domain.TaskCreate{Title: "task 1", ParentID: &parentID})
+
+	// Assert
+	suite.Nil(task)
+	suite.ErrorIs(err, domain.ErrParentNotFound)
+	suite.NotErrorIs(err, domain.ErrTaskNotFound)
+	suite.True(errs.IsValidationError(err))
+	suite.Empty(r.Records)
+}
+
+func (suite *ServiceSuite) TestUpdateParentSuccess() {
+	// Prepare
+	parentID, id := uuid.New(), uuid.New()
+	r := testutils.NewTaskRepository(
+		testutils.TaskRepositoryWithTask(&aggregators.Task{ID: parentID, Title: "parent", Status: "todo", Version: 1}),
+		testutils.TaskRepositoryWithTask(&aggregators.Task{ID: id, Title: "task 1", Status: "todo", Version: 1}),
+	)
+	s := domain.NewService(r)
+	detach := uuid.Nil
+
+	// Execute
+	moved, err1 := s.Update(context.Background(), id, domain.TaskUpdate{ParentID: &parentID})
+	detached, err2 := s.Update(context.Background(), id, domain.TaskUpdate{ParentID: &detach})
+
+	// Assert
+	suite.NoError(err1)
+	suite.Equal(&parentID, moved.ParentID)
+	suite.NoError(err2)
+	suite.Nil(detached.ParentID)
+	suite.Nil(r.Records[id].ParentID)
+}
+
+func (suite *ServiceSuite) TestUpdateParentFail() {
+	// A chain root -> child -> grandchild, plus an unrelated chain of four levels.
+	rootID, childID, grandchildID := uuid.New(), uuid.New(), uuid.New()
+	deep := []uuid.UUID{uuid.New(), uuid.New(), uuid.New(), uuid.New()}
+
+	tests := []struct {
+		name     string
+		id       uuid.UUID
+		parentID uuid.UUID
+		err      error
+	}{
+		{name: "itself", id: rootID, parentID: rootID, err: domain.ErrParentCycle},
+		{name: "own child", id: rootID, parentID: childID, err: domain.ErrParentCycle},
+		{name: "own grandchild", id: rootID, parentID: grandchildID, err: domain.ErrParentCycle},
+		{name: "too deep", id: rootID, parentID: deep[3], err: domain.ErrMaxDepthExceeded},
+		{name: "unknown parent", id: rootID, parentID: uuid.New(), err: domain.ErrParentNotFound},
+	}
+
+	for _, tt := range tests {
+		suite.Run(tt.name, func() {
+			// Prepare
+			opts := []testutils.TaskRepositoryOptional{
+				testutils.TaskRepositoryWithTask(&aggregators.Task{ID: rootID, Title: "root", Status: "todo", Version: 1}),
+				testutils.TaskRepositoryWithTask(&aggregators.Task{ID: childID, ParentID: &rootID, Title: "child", Status: "todo", Version: 1}),
+				testutils.TaskRepositoryWithTask(&aggregators.Task{ID: grandchildID, ParentID: &childID, Title: "grandchild", Status: "todo", Version: 1}),
+			}
+			for i, id := range deep {
+				task := &aggregators.Task{ID: id, Title: "deep", Status: "todo", Version: 1}
+				if i > 0 {
+					task.ParentID = &deep[i-1]
+				}
+				opts = append(opts, testutils.TaskRepositoryWithTask(task))
+			}
+			r := testutils.NewTaskRepository(opts...)
+			s := domain.NewService(r, domain.ServiceWithMaxDepth(5))
+
+			// Execute
+			task, err := s.Update(context.Background(), tt.id, domain.TaskUpdate{ParentID: &tt.parentID})
+
+			// Assert
+			suite.Nil(task)
+			suite.ErrorIs(err, tt.err)
+			suite.True(errs.IsValidationError(err))
+			suite.Nil(r.Records[tt.id].ParentID)
+			suite.Equal(1, r.Records[tt.id].Version)
+		})
+	}
+}
+
+func (suite *ServiceSuite) TestTransitionOpenSubtasksFail() {
+	for _, completion := range []domain.ParentCompletion{domain.ParentCompletionBlock, domain.ParentCompletionAuto} {
+		suite.Run(string(completion), func() {
+			// Prepare
+			parentID, childID := uuid.New(), uuid.New()
+			r := testutils.NewTaskRepository(
+				testutils.TaskRepositoryWithTask(&aggregators.Task{ID: parentID, Title: "parent", Status: "todo", Version: 1}),
+				testutils.TaskRepositoryWithTask(&aggregators.Task{ID: uuid.New(), ParentID: &parentID, Title: "child 1", Status: "done", Version: 1}),
+				testutils.TaskRepositoryWithTask(&aggregators.Task{ID: childID, ParentID: &parentID, Title: "child 2", Status: "in_progress", Version: 1}),
+			)
+			s := domain.NewService(r, domain.ServiceWithParentCompletion(completion))
+
+			// Execute
+			err := s.MarkCompleted(context.Background(), parentID)
+
+			// Assert
+			suite.ErrorIs(err, domain.ErrOpenSubtasks)
+			suite.True(errs.IsConflictError(err))
+			suite.Equal("todo", r.Records[parentID].Status)
+		})
+	}
+}
+
+func (suite *ServiceSuite) TestTransitionClosedSubtasksSuccess() {
+	// Prepare
+	parentID := uuid.New()
+	r := testutils.NewTaskRepository(
+		testutils.TaskRepositoryWithTask(&aggregators.Task{ID: parentID, Title: "parent", Status: "todo", Version: 1}),
+		testutils.TaskRepositoryWithTask(&aggregators.Task{ID: uuid.New(), ParentID: &parentID, Title: "child 1", Status: "done", Version: 1}),
+		testutils.TaskRepositoryWithTask(&aggregators.Task{ID: uuid.New(), ParentID: &parentID, Title: "child 2", Status: "cancelled", Version: 1}),
+	)
+	s := domain.NewService(r)
+
+	// Execute
+	err := s.MarkCompleted(context.Background(), parentID)
+
+	// Assert
+	suite.NoError(err)
+	suite.Equal("done", r.Records[parentID].Status)
+}
+
+func (suite *ServiceSuite) TestTransitionRollUp() {
+	tests := []struct {
+		name       string
+		completion domain.ParentCompletion
+		parent     string
+		root       string
+	}{
+		{name: "block", completion: domain.ParentCompletionBlock, parent: "todo", root: "in_progress"},
+		{name: "auto", completion: domain.ParentCompletionAuto, parent: "done", root: "done"},
+	}
+
+	for _, tt := range tests {
+		suite.Run(tt.name, func() {
+			// Prepare
+			rootID, parentID, childID := uuid.New(), uuid.New(), uuid.New()
+			r := testutils.NewTaskRepository(
+				testutils.TaskRepositoryWithTask(&aggregators.Task{ID: rootID, Title: "root", Status: "in_progress", Version: 1}),
+				testutils.TaskRepositoryWithTask(&aggregators.Task{ID: parentID, ParentID: &rootID, Title: "parent", Status: "todo", Version: 1}),
+				testutils.TaskRepositoryWithTask(&aggregators.Task{ID: uuid.New(), ParentID: &parentID, Title: "child 1", Status: "cancelled", Version: 1}),
+				testutils.TaskRepositoryWithTask(&aggregators.Task{ID: childID, ParentID: &parentID, Title: "child 2", Status: "todo", Version: 1}),
+			)
+			s := domain.NewService(r, domain.ServiceWithParentCompletion(tt.completion))
+
+			// Execute
+			err := s.MarkCompleted(context.Background(), childID)
+
+			// Assert
+			suite.NoError(err)
+			suite.Equal("done", r.Records[childID].Status)
+			suite.Equal(tt.parent, r.Records[parentID].Status)
+			suite.Equal(tt.root, r.Records[rootID].Status)
+		})
+	}
+}
+
+func (suite *ServiceSuite) TestTransitionRollUpStopsAtOpenSibling() {
+	// Prepare
+	parentID, childID := uuid.New(), uuid.New()
+	r := testutils.NewTaskRepository(
+		testutils.TaskRepositoryWithTask(&aggregators.Task{ID: parentID, Title: "parent", Status: "todo", Version: 1}),
+		testutils.TaskRepositoryWithTask(&aggregators.Task{ID: uuid.New(), ParentID: &parentID, Title: "child 1", Status: "blocked", Version: 1}),
+		testutils.TaskRepositoryWithTask(&aggregators.Task{ID: childID, ParentID: &parentID, Title: "child 2", Status: "todo", Version: 1}),
+	)
+	s := domain.NewService(r, domain.ServiceWithParentCompletion(domain.ParentCompletionAuto))
+
+	// Execute
+	err := s.MarkCompleted(context.Background(), childID)
+
+	// Assert
+	suite.NoError(err)
+	suite.Equal("done", r.Records[childID].Status)
+	suite.Equal("todo", r.Records[parentID].Status)
+}
+
+func (suite *ServiceSuite) TestTransitionRollUpStopsAtBlockedParent() {
+	// Prepare
+	blockerID, parentID, childID := uuid.New(), uuid.New(), uuid.New()
+	r := testutils.NewTaskRepository(
+		testutils.TaskRepositoryWithTask(&aggregators.Task{ID: blockerID, Title: "blocker", Status: "todo", Version: 1}),
+		testutils.TaskRepositoryWithTask(&aggregators.Task{ID: parentID, Title: "parent", Status: "todo", Version: 1, BlockedBy: []uuid.UUID{blockerID}}),
+		testutils.TaskRepositoryWithTask(&aggregators.Task{ID: childID, ParentID: &parentID, Title: "child", Status: "todo", Version: 1}),
+	)
+	s := domain.NewService(r, domain.ServiceWithParentCompletion(domain.ParentCompletionAuto))
+
+	// Execute
+	err := s.MarkCompleted(context.Background(), childID)
+
+	// Assert
+	suite.NoError(err)
+	suite.Equal("done", r.Records[childID].Status)
+	suite.Equal("todo", r.Records[parentID].Status)
+	suite.Equal(1, r.Records[parentID].Version)
+}
+
+func (suite *ServiceSuite) TestTransitionRollUpSavesTogether() {
+	// Prepare
+	parentID, childID := uuid.New(), uuid.New()
+	r := testutils.NewTaskRepository(
+		testutils.TaskRepositoryWithTask(&aggregators.Task{ID: parentID, Title: "parent", Status: "todo", Version: 1, BlockedBy: []uuid.UUID{uuid.New()}}),
+		testutils.TaskRepositoryWithTask(&aggregators.Task{ID: childID, ParentID: &parentID, Title: "child", Status: "todo", Version: 1}),
+	)
+	s := domain.NewService(r, domain.ServiceWithParentCompletion(domain.ParentCompletionAuto))
+
+	// Execute
+	err := s.MarkCompleted(context.Background(), childID)
+
+	// Assert
+	suite.Error(err)
+	suite.Equal("todo", r.Records[childID].Status)
+	suite.Equal(1, r.Records[childID].Version)
+	suite.Equal("todo", r.Records[parentID].Status)
+}
+
+func (suite *ServiceSuite) TestAddDependencySuccess() {
+	// Prepare
+	id, dependsOn := uuid.New(), uuid.New()
+	r := testutils.NewTaskRepository(
+		testutils.TaskRepositoryWithTask(&aggregators.Task{ID: id, Title: "task 1", Status: "todo", Version: 1}),
+		testutils.TaskRepositoryWithTask(&aggregators.Task{ID: dependsOn, Title: "task 2", Status: "todo", Version: 1}),
+	)
+	s := domain.NewService(r)
+
+	// Execute
+	task1, err1 := s.AddDependency(context.Background(), id, dependsOn)
+	task2, err2 := s.AddDependency(context.Background(), id, dependsOn)
+
+	// Assert
+	suite.NoError(err1)
+	suite.Equal([]uuid.UUID{dependsOn}, task1.BlockedBy)
+	suite.Equal(2, task1.Version)
+	suite.NoError(err2)
+	suite.Equal(2, task2.Version)
+	suite.Equal([]uuid.UUID{dependsOn}, r.Records[id].BlockedBy)
+}
+
+func (suite *ServiceSuite) TestAddDependencyFail() {
+	// task 3 depends on task 2, which depends on task 1
+	id1, id2, id3 := uuid.New(), uuid.New(), uuid.New()
+
+	tests := []struct {
+		name      string
+		id        uuid.UUID
+		dependsOn uuid.UUID
+		err       error
+	}{
+		{name: "itself", id: id1, dependsOn: id1, err: domain.ErrDependencyCycle},
+		{name: "direct cycle", id: id2, dependsOn: id3, err: domain.ErrDependencyCycle},
+		{name: "transitive cycle", id: id1, dependsOn: id3, err: domain.ErrDependencyCycle},
+		{name: "unknown dependency", id: id1, dependsOn: uuid.New(), err: domain.ErrDependencyNotFound},
+	}
+
+	for _, tt := range tests {
+		suite.Run(tt.name, func() {
+			// Prepare
+			r := testutils.NewTaskRepository(
+				testutils.TaskRepositoryWithTask(&aggregators.Task{ID: id1, Title: "task 1", Status: "todo", Version: 1}),
+				testutils.TaskRepositoryWithTask(&aggregators.Task{ID: id2, Title: "task 2", Status: "todo", Version: 1, BlockedBy: []uuid.UUID{id1}}),
+				testutils.TaskRepositoryWithTask(&aggregators.Task{ID: id3, Title: "task 3", Status: "todo", Version: 1, BlockedBy: []uuid.UUID{id2}}),
+			)
+			s := domain.NewService(r)
+			before := slices.Clone(r.Records[tt.id].BlockedBy)
+
+			// Execute
+			task, err := s.AddDependency(context.Background(), tt.id, tt.dependsOn)
+
+			// Assert
+			suite.Nil(task)
+			suite.ErrorIs(err, tt.err)
+			suite.True(errs.IsValidationError(err))
+			suite.Equal(before, r.Records[tt.id].BlockedBy)
+			suite.Equal(1, r.Records[tt.id].Version)
+		})
+	}
+}
+
+func (suite *ServiceSuite) TestRemoveDependencySuccess() {
+	// Prepare
+	id, dependsOn := uuid.New(), uuid.New()
+	r := testutils.NewTaskRepository(
+		testutils.TaskRepositoryWithTask(&aggregators.Task{ID: id, Title: "task 1", Status: "todo", Version: 1, BlockedBy: []uuid.UUID{dependsOn}}),
+		testutils.TaskRepositoryWithTask(&aggregators.Task{ID: dependsOn, Title: "task 2", Status: "todo", Version: 1}),
+	)
+	s := domain.NewService(r)
+
+	// Execute
+	task, err := s.RemoveDependency(context.Background(), id, dependsOn)
+
+	// Assert
+	suite.NoError(err)
+	suite.Empty(task.BlockedBy)
+	suite.Empty(r.Records[id].BlockedBy)
+	suite.Equal(2, r.Records[id].Version)
+}
+
+func (suite *ServiceSuite) TestMarkCompletedBlockedFail() {
+	// Prepare
+	id, open, done, trashed := uuid.New(), uuid.New(), uuid.New(), uuid.New()
+	deletedAt := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+	r := testutils.NewTaskRepository(
+		testutils.TaskRepositoryWithTask(&aggregators.Task{ID: id, Title: "task 1", Status: "todo", Version: 1, BlockedBy: []uuid.UUID{open, done, trashed}}),
+		testutils.TaskRepositoryWithTask(&aggregators.Task{ID: open, Title: "task 2", Status: "in_progress", Version: 1}),
+		testutils.TaskRepositoryWithTask(&aggregators.Task{ID: done, Title: "task 3", Status: "done", Version: 1}),
+		testutils.TaskRepositoryWithTask(&aggregators.Task{ID: trashed, Title: "task 4", Status: "todo", Version: 1, DeletedAt: &deletedAt}),
+	)
+	s := domain.NewService(r)
+
+	// Execute
+	err := s.MarkCompleted(context.Background(), id)
+
+	// Assert
+	suite.ErrorIs(err, domain.ErrBlocked)
+	suite.EqualError(err, "task is blocked by open tasks: "+open.String())
+	suite.True(errs.IsValidationError(err))
+	suite.Equal("todo", r.Records[id].Status)
+}
+
+func (suite *ServiceSuite) TestMarkCompletedBlockersClosedSuccess() {
+	// Prepare
+	id, done, cancelled := uuid.New(), uuid.New(), uuid.New()
+	r := testutils.NewTaskRepository(
+		testutils.TaskRepositoryWithTask(&aggregators.Task{ID: id, Title: "task 1", Status: "todo", Version: 1, BlockedBy: []uuid.UUID{done, cancelled}}),
+		testutils.TaskRepositoryWithTask(&aggregators.Task{ID: done, Title: "task 2", Status: "done", Version: 1}),
+		testutils.TaskRepositoryWithTask(&aggregators.Task{ID: cancelled, Title: "task 3", Status: "cancelled", Version: 1}),
+	)
+	s := domain.NewService(r)
+
+	// Execute
+	err := s.MarkCompleted(context.Background(), id)
+
+	// Assert
+	suite.NoError(err)
+	suite.Equal("done", r.Records[id].Status)
+}
+
+func (suite *ServiceSuite) TestReadySuccess() {
+	// Prepare
+	dueAt := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+	write, review, deploy, docs, urgent, closed := uuid.New(), uuid.New(), uuid.New(), uuid.New(), uuid.New(), uuid.New()
+	r := testutils.NewTaskRepository(
+		testutils.TaskRepositoryWithTask(&aggregators.Task{ID: deploy, Title: "a deploy", Status: "todo", BlockedBy: []uuid.UUID{review, closed}}),
+		testutils.TaskRepositoryWithTask(&aggregators.Task{ID: review, Title: "b review", Status: "todo", BlockedBy: []uuid.UUID{write}}),
+		testutils.TaskRepositoryWithTask(&aggregators.Task{ID: write, Title: "c write", Status: "in_progress"}),
+		testutils.TaskRepositoryWithTask(&aggregators.Task{ID: docs, Title: "d docs", Status: "todo"}),
+		testutils.TaskRepositoryWithTask(&aggregators.Task{ID: urgent, Title: "e urgent", Status: "todo", DueAt: &dueAt}),
+		testutils.TaskRepositoryWithTask(&aggregators.Task{ID: closed, Title: "f closed", Status: "done"}),
+	)
+	s := domain.NewService(r)
+
+	// Execute
+	tasks, err := s.Ready(context.Background())
+
+	// Assert
+	suite.NoError(err)
+	ids := make([]uuid.UUID, len(tasks))
+	for i, t := range tasks {
+		ids[i] = t.ID
+	}
+	suite.Equal([]uuid.UUID{urgent, write, review, deploy, docs}, ids)
+}
+
+func (suite *ServiceSuite) TestReadyLeavesOutBlocked() {
+	// Prepare
+	blocked, waiting, free, done := uuid.New(), uuid.New(), uuid.New(), uuid.New()
+	r := testutils.NewTaskRepository(
+		testutils.TaskRepositoryWithTask(&aggregators.Task{ID: blocked, Title: "a blocked", Status: "blocked", BlockedBy: []uuid.UUID{done}}),
+		testutils.TaskRepositoryWithTask(&aggregators.Task{ID: waiting, Title: "b waiting", Status: "todo", BlockedBy: []uuid.UUID{blocked}}),
+		testutils.TaskRepositoryWithTask(&aggregators.Task{ID: free, Title: "c free", Status: "todo", BlockedBy: []uuid.UUID{done}}),
+		testutils.TaskRepositoryWithTask(&aggregators.Task{ID: done, Title: "d done", Status: "done"}),
+	)
+	s := domain.NewService(r)
+
+	// Execute
+	tasks, err := s.Ready(context.Background())
+
+	// Assert
+	suite.NoError(err)
+	suite.Require().Len(tasks, 1)
+	suite.Equal(free, tasks[0].ID)
+}
+
+func (suite *ServiceSuite) TestReadyRepositoryFail() {
+	// Prepare
+	r := testutils.NewTaskRepository(testutils.TaskRepositoryWithError(errors.New("boom!")))
+	s := domain.NewService(r)
+
+	// Execute
+	tasks, err := s.Ready(context.Background())
+
+	// Assert
+	suite.Nil(tasks)
+	suite.ErrorContains(err, "failed to get open tasks: boom!")
+}
+
+func (suite *ServiceSuite) TestCreateRecurringSuccess() {
+	// Prepare
+	r := testutils.NewTaskRepository()
+	s := domain.NewService(r)
+
+	// Execute
+	task, err := s.Create(context.Background(), domain.TaskCreate{
+		Title:      "task 1",
+		DueAt:      "2025-03-03T09:00:00Z",
+		Recurrence: "RRULE:freq=weekly;interval=1;byday=MO,WE;until=20251231",
+	})
+
+	// Assert
+	suite.NoError(err)
+	suite.Equal("FREQ=WEEKLY;BYDAY=MO,WE;UNTIL=20251231T235959Z", *task.Recurrence)
+	suite.Equal(&task.ID, task.SeriesID)
+	suite.Equal(1, task.Occurrence)
+	suite.Equal(task, r.Records[task.ID])
+}
+
+func (suite *ServiceSuite) TestCreateRecurringFail() {
+	tests := []struct {
+		name       string
+		dueAt      string
+		recurrence string
+		err        error
+		message    string
+	}{
+		{name: "no due date", recurrence: "FREQ=DAILY", err: domain.ErrRecurrenceNoDueAt, message: "a recurring task needs a due date"},
+		{name: "no frequency", dueAt: "2025-03-03T09:00:00Z", recurrence: "INTERVAL=2", err: domain.ErrInvalidRecurrence, message: "invalid recurrence rule: FREQ is required"},
+		{name: "unsupported frequency", dueAt: "2025-03-03T09:00:00Z", recurrence: "FREQ=HOURLY", err: domain.ErrInvalidRecurrence, message: "invalid recurrence rule: unsupported FREQ HOURLY"},
+		{name: "unsupported part", dueAt: "2025-03-03T09:00:00Z", recurrence: "FREQ=DAILY;BYHOUR=9", err: domain.ErrInvalidRecurrence, message: "invalid recurrence rule: unsupported part BYHOUR"},
+		{name: "malformed part", dueAt: "2025-03-03T09:00:00Z", recurrence: "FREQ=DAILY;COUNT", err: domain.ErrInvalidRecurrence, message: `invalid recurrence rule: malformed part "COUNT"`},
+		{name: "ordinal weekday", dueAt: "2025-03-03T09:00:00Z", recurrence: "FREQ=MONTHLY;BYDAY=1MO", err: domain.ErrInvalidRecurrence, message: "invalid recurrence rule: unsupported BYDAY 1MO"},
+		{name: "invalid month day", dueAt: "2025-03-03T09:00:00Z", recurrence: "FREQ=MONTHLY;BYMONTHDAY=32", err: domain.ErrInvalidRecurrence, message: "invalid recurrence rule: BYMONTHDAY must be between 1 and 31 or -31 and -1"},
+		{name: "count and until", dueAt: "2025-03-03T09:00:00Z", recurrence: "FREQ=DAILY;COUNT=2;UNTIL=20251231", err: domain.ErrInvalidRecurrence, message: "invalid recurrence rule: COUNT and UNTIL cannot be combined"},
+		{name: "weekly by month day", dueAt: "2025-03-03T09:00:00Z", recurrence: "FREQ=WEEKLY;BYMONTHDAY=1", err: domain.ErrInvalidRecurrence, message: "invalid recurrence rule: BYMONTHDAY cannot be used with FREQ=WEEKLY"},
+		{name: "repeated part", dueAt: "2025-03-03T09:00:00Z", recurrence: "FREQ=DAILY;FREQ=WEEKLY", err: domain.ErrInvalidRecurrence, message: "invalid recurrence rule: FREQ given more than once"},
+	}
+
+	for _, tt := range tests {
+		suite.Run(tt.name, func() {
+			// Prepare
+			r := testutils.NewTaskRepository()
+			s := domain.NewService(r)
+
+			// Execute
+			task, err := s.Create(context.Background(), domain.TaskCreate{Title: "task 1", DueAt: tt.dueAt, Recurrence: tt.recurrence})
+
+			// Assert
+			suite.Nil(task)
+			suite.ErrorIs(err, tt.err)
+			suite.EqualError(err, tt.message)
+			suite.True(errs.IsValidationError(err))
+			suite.Empty(r.Records)
+		})
+	}
+}
+
+func (suite *ServiceSuite) TestMarkCompletedRecurring() {
+	tests := []struct {
+		name       string
+		recurrence string
+		dueAt      time.Time
+		occurrence int
+		next       *time.Time
+	}{
+		{name: "daily", recurrence: "FREQ=DAILY", dueAt: date(2025, 3, 1), next: ptr(date(2025, 3, 2))},
+		{name: "every third day", recurrence: "FREQ=DAILY;INTERVAL=3", dueAt: date(2025, 3, 1), next: ptr(date(2025, 3, 4))},
+		{name: "weekdays", recurrence: "FREQ=DAILY;BYDAY=MO,TU,WE,TH,FR", dueAt: date(2025, 2, 28), next: ptr(date(2025, 3, 3))},
+		{name: "weekly", recurrence: "FREQ=WEEKLY", dueAt: date(2025, 3, 1), next: ptr(date(2025, 3, 8))},
+		{name: "weekly later this week", recurrence: "FREQ=WEEKLY;BYDAY=MO,WE", dueAt: date(2025, 3, 3), next: ptr(date(2025, 3, 5))},
+		{name: "weekly next week", recurrence: "FREQ=WEEKLY;BYDAY=MO,WE", dueAt: date(2025, 3, 5), next: ptr(date(2025, 3, 10))},
+		{name: "fortnightly", recurrence: "FREQ=WEEKLY;INTERVAL=2;BYDAY=MO", dueAt: date(2025, 3, 3), next: ptr(date(2025, 3, 17))},
+		{name: "monthly skips short months", recurrence: "FREQ=MONTHLY", dueAt: date(2025, 1, 31), next: ptr(date(2025, 3, 31))},
+		{name: "last day of the month", recurrence: "FREQ=MONTHLY;BYMONTHDAY=-1", dueAt: date(2025, 1, 31), next: ptr(date(2025, 2, 28))},
+		{name: "twice a month", recurrence: "FREQ=MONTHLY;BYMONTHDAY=1,15", dueAt: date(2025, 3, 1), next: ptr(date(2025, 3, 15))},
+		{name: "every friday of the month", recurrence: "FREQ=MONTHLY;BYDAY=FR", dueAt: date(2025, 3, 28), next: ptr(date(2025, 4, 4))},
+		{name: "friday the 13th", recurrence: "FREQ=MONTHLY;BYDAY=FR;BYMONTHDAY=13", dueAt: date(2024, 12, 13), next: ptr(date(2025, 6, 13))},
+		{name: "yearly leap day", recurrence: "FREQ=YEARLY", dueAt: date(2024, 2, 29), next: ptr(date(2028, 2, 29))},
+		{name: "count not reached", recurrence: "FREQ=DAILY;COUNT=3", dueAt: date(2025, 3, 1), occurrence: 2, next: ptr(date(2025, 3, 2))},
+		{name: "count reached", recurrence: "FREQ=DAILY;COUNT=3", dueAt: date(2025, 3, 1), occurrence: 3, next: nil},
+		{name: "until includes the day", recurrence: "FREQ=DAILY;UNTIL=20250302", dueAt: date(2025, 3, 1), next: ptr(date(2025, 3, 2))},
+		{name: "until passed", recurrence: "FREQ=DAILY;UNTIL=20250301T235959Z", dueAt: date(2025, 3, 1), next: nil},
+	}
+
+	for _, tt := range tests {
+		suite.Run(tt.name, func() {
+			// Prepare
+			id, parentID := uuid.New(), uuid.New()
+			occurrence := max(tt.occurrence, 1)
+			r := testutils.NewTaskRepository(
+				testutils.TaskRepositoryWithTag(&aggregators.Tag{ID: uuid.New(), Name: "chores"}),
+				testutils.TaskRepositoryWithTask(&aggregators.Task{ID: parentID, Title: "parent", Status: "todo", Version: 1}),
+				testutils.TaskRepositoryWithTask(&aggregators.Task{
+					ID: id, ParentID: &parentID, Title: "task 1", Status: "in_progress", DueAt: &tt.dueAt, Tags: []string{"chores"},
+					Recurrence: &tt.recurrence, SeriesID: &id, Occurrence: occurrence, Version: 1,
+				}),
+			)
+			s := domain.NewService(r)
+
+			// Execute
+			err := s.MarkCompleted(context.Background(), id)
+
+			// Assert
+			suite.NoError(err)
+			suite.Equal("done", r.Records[id].Status)
+			if tt.next == nil {
+				suite.Len(r.Records, 2)
+				return
+			}
+
+			suite.Len(r.Records, 3)
+			for _, task := range r.Records {
+				if task.ID == id || task.ID == parentID {
+					continue
+				}
+				suite.Equal("task 1", task.Title)
+				suite.Equal("todo", task.Status)
+				suite.Equal(tt.next, task.DueAt)
+				suite.Equal(&parentID, task.ParentID)
+				suite.Equal([]string{"chores"}, task.Tags)
+				suite.Equal(r.Records[id].Recurrence, task.Recurrence)
+				suite.Equal(&id, task.SeriesID)
+				suite.Equal(occurrence+1, task.Occurrence)
+				suite.Equal(1, task.Version)
+			}
+		})
+	}
+}
+
+func (suite *ServiceSuite) TestMarkCompletedRecurringAgain() {
+	// Prepare
+	id, nextID := uuid.New(), uuid.New()
+	dueAt := date(2025, 3, 1)
+	recurrence := "FREQ=DAILY"
+	r := testutils.NewTaskRepository(
+		testutils.TaskRepositoryWithTask(&aggregators.Task{ID: id, Title: "task 1", Status: "todo", DueAt: &dueAt, Recurrence: &recurrence, SeriesID: &id, Occurrence: 1, Version: 1}),
+		testutils.TaskRepositoryWithTask(&aggregators.Task{ID: nextID, Title: "task 1", Status: "todo", Recurrence: &recurrence, SeriesID: &id, Occurrence: 2, Version: 1}),
+	)
+	s := domain.NewService(r)
+
+	// Execute
+	err := s.MarkCompleted(context.Background(), id)
+
+	// Assert
+	suite.NoError(err)
+	suite.Equal("done", r.Records[id].Status)
+	suite.Len(r.Records, 2)
+	suite.Contains(r.Records, nextID)
+}
+
+func (suite *ServiceSuite) TestCreateProjectSuccess() {
+	// Prepare
+	project := &aggregators.Project{ID: uuid.New(), Name: "work", Version: 1}
+	parentID := uuid.New()
+	r := testutils.NewTaskRepository(
+		testutils.TaskRepositoryWithProject(project),
+		testutils.TaskRepositoryWithTask(&aggregators.Task{ID: parentID, ProjectID: project.ID, Title: "parent", Status: "todo", Version: 1}),
+	)
+	s := domain.NewService(r)
+
+	// Execute
+	inbox, err1 := s.Create(context.Background(), domain.TaskCreate{Title: "task 1"})
+	filed, err2 := s.Create(context.Background(), domain.TaskCreate{Title: "task 2", ProjectID: &project.ID})
+	subtask, err3 := s.Create(context.Background(), domain.TaskCreate{Title: "task 3", ParentID: &parentID})
+
+	// Assert
+	suite.NoError(err1)
+	suite.Equal(aggregators.InboxProjectID, inbox.ProjectID)
+	suite.NoError(err2)
+	suite.Equal(project.ID, filed.ProjectID)
+	suite.Equal(project.ID, r.Records[filed.ID].ProjectID)
+	suite.NoError(err3)
+	suite.Equal(project.ID, subtask.ProjectID)
+}
+
+func (suite *ServiceSuite) TestCreateProjectFail() {
+	// Prepare
+	parentID := uuid.New()
+	r := testutils.NewTaskRepository(testutils.TaskRepositoryWithTask(&aggregators.Task{ID: parentID, Title: "parent", Status: "todo", Version: 1}))
+	s := domain.NewService(r)
+	unknown := uuid.New()
+	project := &aggregators.Project{ID: uuid.New(), Name: "work", Version: 1}
+	r.ProjectRecords[project.ID] = project
+
+	// Execute
+	task1, err1 := s.Create(context.Background(), domain.TaskCreate{Title: "task 1", ProjectID: &unknown})
+	task2, err2 := s.Create(context.Background(), domain.TaskCreate{Title: "task 2", ProjectID: &project.ID, ParentID: &parentID})
+
+	// Assert
+	suite.Nil(task1)
+	suite.ErrorIs(err1, domain.ErrProjectNotFound)
+	suite.True(errs.IsValidationError(err1))
+	suite.Nil(task2)
+	suite.ErrorIs(err2, domain.ErrProjectMismatch)
+	suite.True(errs.IsValidationError(err2))
+	suite.Len(r.Records, 1)
+}
+
+func (suite *ServiceSuite) TestUpdateProjectMovesSubtasks() {
+	// Prepare
+	project := &aggregators.Project{ID: uuid.New(), Name: "work", Version: 1}
+	rootID, childID, grandchildID := uuid.New(), uuid.New(), uuid.New()
+	r := testutils.NewTaskRepository(
+		testutils.TaskRepositoryWithProject(project),
+		testutils.TaskRepositoryWithTask(&aggregators.Task{ID: rootID, Title: "root", Status: "todo", Version: 1}),
+		testutils.TaskRepositoryWithTask(&aggregators.Task{ID: childID, ParentID: &rootID, Title: "child", Status: "todo", Version: 1}),
+		testutils.TaskRepositoryWithTask(&aggregators.Task{ID: grandchildID, ParentID: &childID, Title: "grandchild", Status: "todo", Version: 1}),
+	)
+	s := domain.NewService(r)
+	inbox := uuid.Nil
+
+	// Execute
+	moved, err1 := s.Update(context.Background(), rootID, domain.TaskUpdate{ProjectID: &project.ID})
+
+	// Assert
+	suite.NoError(err1)
+	suite.Equal(project.ID, moved.ProjectID)
+	for _, id := range []uuid.UUID{rootID, childID, grandchildID} {
+		suite.Equal(project.ID, r.Records[id].ProjectID)
+		suite.Equal(2, r.Records[id].Version)
+	}
+
+	// Execute
+	back, err2 := s.Update(context.Background(), rootID, domain.TaskUpdate{ProjectID: &inbox})
+
+	// Assert
+	suite.NoError(err2)
+	suite.Equal(aggregators.InboxProjectID, back.ProjectID)
+	suite.Equal(aggregators.InboxProjectID, r.Records[grandchildID].ProjectID)
+}
+
+func (suite *ServiceSuite) TestUpdateProjectFail() {
+	// Prepare
+	project := &aggregators.Project{ID: uuid.New(), Name: "work", Version: 1}
+	parentID, childID, id := uuid.New(), uuid.New(), uuid.New()
+	r := testutils.NewTaskRepository(
+		testutils.TaskRepositoryWithProject(project),
+		testutils.TaskRepositoryWithTask(&aggregators.Task{ID: parentID, ProjectID: project.ID, Title: "parent", Status: "todo", Version: 1}),
+		testutils.TaskRepositoryWithTask(&aggregators.Task{ID: childID, ParentID: &parentID, ProjectID: project.ID, Title: "child", Status: "todo", Version: 1}),
+		testutils.TaskRepositoryWithTask(&aggregators.Task{ID: id, Title: "task 1", Status: "todo", Version: 1}),
+	)
+	s := domain.NewService(r)
+	inbox, unknown := uuid.Nil, uuid.New()
+
+	// Execute
+	task1, err1 := s.Update(context.Background(), childID, domain.TaskUpdate{ProjectID: &inbox})
+	task2, err2 := s.Update(context.Background(), id, domain.TaskUpdate{ProjectID: &inbox, ParentID: &parentID})
+	task3, err3 := s.Update(context.Background(), id, domain.TaskUpdate{ProjectID: &unknown})
+
+	// Assert
+	suite.Nil(task1)
+	suite.ErrorIs(err1, domain.ErrProjectMismatch)
+	suite.Nil(task2)
+	suite.ErrorIs(err2, domain.ErrProjectMismatch)
+	suite.Nil(task3)
+	suite.ErrorIs(err3, domain.ErrProjectNotFound)
+	suite.Equal(project.ID, r.Records[childID].ProjectID)
+	suite.Equal(aggregators.InboxProjectID, r.Records[id].ProjectID)
+	suite.Equal(1, r.Records[id].Version)
+}
+
+func (suite *ServiceSuite) TestUpdateParentFollowsProject() {
+	// Prepare
+	project := &aggregators.Project{ID: uuid.New(), Name: "work", Version: 1}
+	parentID, id := uuid.New(), uuid.New()
+	r := testutils.NewTaskRepository(
+		testutils.TaskRepositoryWithProject(project),
+		testutils.TaskRepositoryWithTask(&aggregators.Task{ID: parentID, ProjectID: project.ID, Title: "parent", Status: "todo", Version: 1}),
+		testutils.TaskRepositoryWithTask(&aggregators.Task{ID: id, Title: "task 1", Status: "todo", Version: 1}),
+	)
+	s := domain.NewService(r)
+
+	// Execute
+	task, err := s.Update(context.Background(), id, domain.TaskUpdate{ParentID: &parentID})
+
+	// Assert
+	suite.NoError(err)
+	suite.Equal(project.ID, task.ProjectID)
+	suite.Equal(project.ID, r.Records[id].ProjectID)
+}
+
+func date(year int, month time.Month, day int) time.Time {
+	return time.Date(year, month, day, 9, 0, 0, 0, time.UTC)
+}
+
+func ptr[T any](v T) *T {
+	return &v
+}
+
+func (suite *ServiceSuite) TestChangesAreRecordedInHistory() {
+	// Prepare
+	r := testutils.NewTaskRepository()
+	now := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+	s := domain.NewService(r, domain.ServiceWithClock(func() time.Time { return now }))
+	ctx := infrastructure.WithActor(context.Background(), "jane")
+
+	// Execute
+	task, err1 := s.Create(ctx, domain.TaskCreate{Title: "task 1"})
+	suite.Require().NoError(err1)
+	now = now.Add(time.Hour)
+	err2 := s.MarkCompleted(ctx, task.ID)
+	now = now.Add(time.Hour)
+	err3 := s.Delete(context.Background(), task.ID)
+
+	// Assert
+	suite.NoError(err2)
+	suite.NoError(err3)
+	history := r.HistoryRecords[task.ID]
+	suite.Require().Len(history, 3)
+	suite.Equal(infrastructure.ActionCreated, history[0].Action)
+	suite.Equal("jane", history[0].Actor)
+	suite.JSONEq("null", string(history[0].Before))
+	suite.Contains(string(history[0].After), `"status":"todo"`)
+	suite.Equal(time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC), history[0].CreatedAt)
+	suite.Equal(infrastructure.ActionCompleted, history[1].Action)
+	suite.Contains(string(history[1].Before), `"status":"todo"`)
+	suite.Contains(string(history[1].After), `"status":"done"`)
+	suite.Contains(string(history[1].After), `"version":2`)
+	suite.Equal(time.Date(2025, 3, 1, 13, 0, 0, 0, time.UTC), history[1].CreatedAt)
+	suite.Equal(infrastructure.ActionDeleted, history[2].Action)
+	suite.Equal(infrastructure.DefaultActor, history[2].Actor)
+}
+
+func (suite *ServiceSuite) TestEventsAreRecordedInOutbox() {
+	// Prepare
+	r := testutils.NewTaskRepository()
+	now := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+	s := domain.NewService(r, domain.ServiceWithClock(func() time.Time { return now }))
+	ws := uuid.New()
+	ctx := infrastructure.WithWorkspace(context.Background(), ws)
+
+	// Execute
+	task, err1 := s.Create(ctx, domain.TaskCreate{Title: "task 1"})
+	suite.Require().NoError(err1)
+	now = now.Add(time.Hour)
+	err2 := s.MarkCompleted(ctx, task.ID)
+	now = now.Add(time.Hour)
+	err3 := s.Delete(ctx, task.ID)
+
+	// Assert
+	suite.NoError(err2)
+	suite.NoError(err3)
+	suite.Require().Len(r.EventRecords, 3)
+	suite.Equal("task.created", r.EventRecords[0].Type)
+	suite.Equal(task.ID, r.EventRecords[0].TaskID)
+	suite.Equal(ws, r.EventRecords[0].WorkspaceID)
+	suite.Contains(string(r.EventRecords[0].Payload), `"title":"task 1"`)
+	suite.Equal(time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC), r.EventRecords[0].OccurredAt)
+	suite.Equal("task.completed", r.EventRecords[1].Type)
+	suite.JSONEq(`{"task_id":"`+task.ID.String()+`","completed_at":"2025-03-01T13:00:00Z"}`, string(r.EventRecords[1].Payload))
+	suite.Equal("task.deleted", r.EventRecords[2].Type)
+}
+
+func (suite *ServiceSuite) TestUpdateRecordsOneEventPerKind() {
+	// Prepare
+	id := uuid.New()
+	r := testutils.NewTaskRepository(testutils.TaskRepositoryWithTask(&aggregators.Task{ID: id, Title: "task 1", Status: "todo", Version: 1}))
+	s := domain.NewService(r)
+	title := "task 2"
+	status := domain.StatusInProgress
+
+	// Execute
+	_, err := s.Update(context.Background(), id, domain.TaskUpdate{Title: &title, Status: &status})
+
+	// Assert
+	suite.NoError(err)
+	types := make([]string, 0, len(r.EventRecords))
+	for _, e := range r.EventRecords {
+		types = append(types, e.Type)
+	}
+	suite.ElementsMatch([]string{"task.updated", "task.status_changed"}, types)
+	for _, e := range r.EventRecords {
+		if e.Type == "task.status_changed" {
+			suite.Contains(string(e.Payload), `"from":"todo","to":"in_progress"`)
+		}
+	}
+}
+
+func (suite *ServiceSuite) TestDeleteTagRecordsEvents() {
+	// Prepare
+	tagID, taskID, ws := uuid.New(), uuid.New(), uuid.New()
+	ctx := infrastructure.WithWorkspace(context.Background(), ws)
+	r := testutils.NewTaskRepository(testutils.TaskRepositoryWithWorkspaceTask(ws, &aggregators.Task{ID: taskID, Title: "task 1", Status: "todo", Version: 1, Tags: []string{"work"}}))
+	suite.Require().NoError(r.SaveTag(ctx, &aggregators.Tag{ID: tagID, Name: "work"}))
+	s := domain.NewService(r)
+
+	// Execute
+	err := s.DeleteTag(ctx, tagID)
+
+	// Assert
+	suite.NoError(err)
+	suite.Require().Len(r.EventRecords, 1)
+	suite.Equal("task.updated", r.EventRecords[0].Type)
+	suite.Equal(taskID, r.EventRecords[0].TaskID)
+	suite.Equal(ws, r.EventRecords[0].WorkspaceID)
+}
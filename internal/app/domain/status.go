@@ -0,0 +1,39 @@
+package domain
+
+type Status string
+
+const (
+	StatusTodo       Status = "todo"
+	StatusInProgress Status = "in_progress"
+	StatusBlocked    Status = "blocked"
+	StatusDone       Status = "done"
+	StatusCancelled  Status = "cancelled"
+)
+
+// transitions lists, per status, the statuses a task is allowed to move to.
+var transitions = map[Status][]Status{
+	StatusTodo:       {StatusInProgress, StatusBlocked, StatusDone, StatusCancelled},
+	StatusInProgress: {StatusTodo, StatusBlocked, StatusDone, StatusCancelled},
+	StatusBlocked:    {StatusTodo, StatusInProgress, StatusCancelled},
+	StatusDone:       {StatusTodo},
+	StatusCancelled:  {StatusTodo},
+}
+
+func (s Status) valid() bool {
+	_, ok := transitions[s]
+	return ok
+}
+
+func (s Status) closed() bool {
+	return s == StatusDone || s == StatusCancelled
+}
+
+func (s Status) canTransitionTo(to Status) bool {
+	for _, allowed := range transitions[s] {
+		if allowed == to {
+			return true
+		}
+	}
+
+	return false
+}
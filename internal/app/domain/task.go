@@ -1,40 +1,273 @@
 package domain
 
 import (
+	"bytes"
+	"fmt"
 	"github.com/aviseu/go-sample/internal/app/infrastructure/aggregators"
 	"github.com/google/uuid"
+	"slices"
+	"time"
 )
 
 type task struct {
-	id        uuid.UUID
-	title     string
-	completed bool
+	id          uuid.UUID
+	projectID   uuid.UUID
+	parentID    *uuid.UUID
+	title       string
+	status      Status
+	dueAt       *time.Time
+	recurrence  *recurrence
+	seriesID    *uuid.UUID
+	occurrence  int
+	createdAt   time.Time
+	updatedAt   time.Time
+	completedAt *time.Time
+	deletedAt   *time.Time
+	version     int
+	tags        []string
+	blockedBy   []uuid.UUID
+	events      []*aggregators.Event
+	edited      bool
 }
 
-func newTask(id uuid.UUID, title string, completed bool) *task {
+func newTask(id uuid.UUID, title string, status Status, now time.Time) *task {
 	return &task{
 		id:        id,
+		projectID: aggregators.InboxProjectID,
 		title:     title,
-		completed: completed,
+		status:    status,
+		createdAt: now,
+		updatedAt: now,
 	}
 }
 
-func (t *task) markCompleted() {
-	t.completed = true
+// created records the creation of a new task once it is fully set up.
+func (t *task) created() {
+	t.record(TaskCreated{
+		TaskID:    t.id,
+		ProjectID: t.projectID,
+		ParentID:  t.parentID,
+		Title:     t.title,
+		DueAt:     t.dueAt,
+		CreatedAt: t.createdAt,
+	}, t.createdAt)
+}
+
+func (t *task) record(e Event, now time.Time) {
+	t.events = append(t.events, newEventAggregator(t.id, e, now))
+}
+
+// touch marks a stored task as edited, which is recorded as a single
+// TaskUpdated event however many edits follow.
+func (t *task) touch(now time.Time) {
+	t.updatedAt = now
+	if t.version != 0 && !t.edited {
+		t.edited = true
+		t.record(TaskUpdated{TaskID: t.id, UpdatedAt: now}, now)
+	}
+}
+
+func (t *task) rename(title string, now time.Time) {
+	t.title = title
+	t.touch(now)
+}
+
+func (t *task) reschedule(dueAt *time.Time, now time.Time) {
+	t.dueAt = dueAt
+	t.touch(now)
+}
+
+// recur makes the task the first occurrence of a series repeating by the rule.
+func (t *task) recur(r *recurrence) {
+	t.recurrence = r
+	t.seriesID = &t.id
+	t.occurrence = 1
+}
+
+// repeat changes the rule the task repeats by, starting a series when it is
+// not part of one yet. A nil rule stops the series at this task.
+func (t *task) repeat(r *recurrence, now time.Time) {
+	t.recurrence = r
+	if r != nil && t.seriesID == nil {
+		t.seriesID = &t.id
+		t.occurrence = 1
+	}
+	t.touch(now)
+}
+
+// nextOccurrence returns the task that follows this one in its series, if any.
+func (t *task) nextOccurrence(id uuid.UUID, now time.Time) *task {
+	if t.recurrence == nil || t.dueAt == nil {
+		return nil
+	}
+
+	dueAt, ok := t.recurrence.next(*t.dueAt, t.occurrence)
+	if !ok {
+		return nil
+	}
+
+	next := newTask(id, t.title, StatusTodo, now)
+	next.dueAt = &dueAt
+	next.projectID = t.projectID
+	next.parentID = t.parentID
+	next.tags = slices.Clone(t.tags)
+	next.recurrence = t.recurrence
+	next.seriesID = t.seriesID
+	next.occurrence = t.occurrence + 1
+	next.created()
+
+	return next
+}
+
+func (t *task) moveToProject(projectID uuid.UUID, now time.Time) {
+	t.projectID = projectID
+	t.touch(now)
+}
+
+func (t *task) move(parentID *uuid.UUID, now time.Time) {
+	t.parentID = parentID
+	t.touch(now)
+}
+
+func (t *task) transition(to Status, now time.Time) error {
+	if !t.status.canTransitionTo(to) {
+		return fmt.Errorf("%w: from %s to %s", ErrInvalidTransition, t.status, to)
+	}
+
+	from := t.status
+	t.status = to
+	t.updatedAt = now
+	if to == StatusDone {
+		t.completedAt = &now
+		t.record(TaskCompleted{TaskID: t.id, CompletedAt: now}, now)
+	} else {
+		t.completedAt = nil
+		t.record(TaskStatusChanged{TaskID: t.id, From: from, To: to, ChangedAt: now}, now)
+	}
+
+	return nil
 }
 
+// tag adds the tag, keeping the tags sorted, and reports whether it was added.
+func (t *task) tag(name string, now time.Time) bool {
+	i, found := slices.BinarySearch(t.tags, name)
+	if found {
+		return false
+	}
+
+	t.tags = slices.Insert(t.tags, i, name)
+	t.touch(now)
+
+	return true
+}
+
+// untag removes the tag and reports whether the task had it.
+func (t *task) untag(name string, now time.Time) bool {
+	i, found := slices.BinarySearch(t.tags, name)
+	if !found {
+		return false
+	}
+
+	t.tags = slices.Delete(t.tags, i, i+1)
+	t.touch(now)
+
+	return true
+}
+
+// block makes the task depend on another one and reports whether it did not already.
+func (t *task) block(by uuid.UUID, now time.Time) bool {
+	i, found := slices.BinarySearchFunc(t.blockedBy, by, compareIDs)
+	if found {
+		return false
+	}
+
+	t.blockedBy = slices.Insert(t.blockedBy, i, by)
+	t.touch(now)
+
+	return true
+}
+
+// unblock removes the dependency on another task and reports whether it had it.
+func (t *task) unblock(by uuid.UUID, now time.Time) bool {
+	i, found := slices.BinarySearchFunc(t.blockedBy, by, compareIDs)
+	if !found {
+		return false
+	}
+
+	t.blockedBy = slices.Delete(t.blockedBy, i, i+1)
+	t.touch(now)
+
+	return true
+}
+
+func compareIDs(a, b uuid.UUID) int {
+	return bytes.Compare(a[:], b[:])
+}
+
+func (t *task) trash(now time.Time) {
+	t.deletedAt = &now
+	t.updatedAt = now
+	t.record(TaskDeleted{TaskID: t.id, DeletedAt: now}, now)
+}
+
+func (t *task) restore(now time.Time) {
+	t.deletedAt = nil
+	t.updatedAt = now
+	t.record(TaskRestored{TaskID: t.id, RestoredAt: now}, now)
+}
+
+// newFromAggregator trusts the stored recurrence rule, which was validated
+// when the task was created.
 func newFromAggregator(t *aggregators.Task) *task {
+	var r *recurrence
+	if t.Recurrence != nil {
+		r, _ = parseRecurrence(*t.Recurrence)
+	}
+
 	return &task{
-		id:        t.ID,
-		title:     t.Title,
-		completed: t.Completed,
+		id:          t.ID,
+		projectID:   t.ProjectID,
+		parentID:    t.ParentID,
+		title:       t.Title,
+		status:      Status(t.Status),
+		dueAt:       t.DueAt,
+		recurrence:  r,
+		seriesID:    t.SeriesID,
+		occurrence:  t.Occurrence,
+		createdAt:   t.CreatedAt,
+		updatedAt:   t.UpdatedAt,
+		completedAt: t.CompletedAt,
+		deletedAt:   t.DeletedAt,
+		version:     t.Version,
+		tags:        slices.Clone(t.Tags),
+		blockedBy:   slices.Clone(t.BlockedBy),
 	}
 }
 
 func (t *task) toAggregator() *aggregators.Task {
+	var r *string
+	if t.recurrence != nil {
+		rule := t.recurrence.String()
+		r = &rule
+	}
+
 	return &aggregators.Task{
-		ID:        t.id,
-		Title:     t.title,
-		Completed: t.completed,
+		ID:          t.id,
+		ProjectID:   t.projectID,
+		ParentID:    t.parentID,
+		Title:       t.title,
+		Status:      string(t.status),
+		DueAt:       t.dueAt,
+		Recurrence:  r,
+		SeriesID:    t.seriesID,
+		Occurrence:  t.occurrence,
+		CreatedAt:   t.createdAt,
+		UpdatedAt:   t.updatedAt,
+		CompletedAt: t.completedAt,
+		DeletedAt:   t.deletedAt,
+		Version:     t.version,
+		Tags:        slices.Clone(t.tags),
+		BlockedBy:   slices.Clone(t.blockedBy),
+		Events:      slices.Clone(t.events),
 	}
 }
@@ -0,0 +1,17 @@
+package domain
+
+import "context"
+
+type expectedVersionKey struct{}
+
+// WithExpectedVersion makes changes made through ctx fail with
+// ErrVersionMismatch unless the task exists and is still at one of the
+// versions vs. Without any versions, a task at any version will do.
+func WithExpectedVersion(ctx context.Context, vs ...int) context.Context {
+	return context.WithValue(ctx, expectedVersionKey{}, vs)
+}
+
+func expectedVersion(ctx context.Context) ([]int, bool) {
+	vs, ok := ctx.Value(expectedVersionKey{}).([]int)
+	return vs, ok
+}
@@ -0,0 +1,39 @@
+package domain
+
+import (
+	"github.com/aviseu/go-sample/internal/app/infrastructure/aggregators"
+	"github.com/google/uuid"
+	"slices"
+	"time"
+)
+
+type webhook struct {
+	id        uuid.UUID
+	url       string
+	events    []string
+	secret    string
+	createdAt time.Time
+}
+
+func newWebhook(id uuid.UUID, url string, events []string, secret string, now time.Time) *webhook {
+	events = append([]string{}, events...)
+	slices.Sort(events)
+
+	return &webhook{
+		id:        id,
+		url:       url,
+		events:    slices.Compact(events),
+		secret:    secret,
+		createdAt: now,
+	}
+}
+
+func (w *webhook) toAggregator() *aggregators.Webhook {
+	return &aggregators.Webhook{
+		ID:        w.id,
+		URL:       w.url,
+		Events:    slices.Clone(w.events),
+		Secret:    w.secret,
+		CreatedAt: w.createdAt,
+	}
+}
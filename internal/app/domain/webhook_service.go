@@ -0,0 +1,121 @@
+package domain
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"github.com/aviseu/go-sample/internal/app/infrastructure"
+	"github.com/aviseu/go-sample/internal/app/infrastructure/aggregators"
+	"github.com/google/uuid"
+	"net/url"
+	"slices"
+	"time"
+)
+
+type WebhookRepository interface {
+	Find(ctx context.Context, id uuid.UUID) (*aggregators.Webhook, error)
+	Save(ctx context.Context, webhook *aggregators.Webhook) error
+	Delete(ctx context.Context, id uuid.UUID) error
+	FindDelivery(ctx context.Context, id uuid.UUID) (*aggregators.WebhookDelivery, error)
+	Redeliver(ctx context.Context, id uuid.UUID) error
+}
+
+type WebhookServiceOptional func(*WebhookService)
+
+func WebhookServiceWithClock(now func() time.Time) WebhookServiceOptional {
+	return func(s *WebhookService) {
+		s.now = now
+	}
+}
+
+type WebhookService struct {
+	r   WebhookRepository
+	now func() time.Time
+}
+
+func NewWebhookService(r WebhookRepository, opts ...WebhookServiceOptional) *WebhookService {
+	s := &WebhookService{
+		r:   r,
+		now: time.Now,
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+
+	return s
+}
+
+// WebhookCreate subscribes to all events when Events is empty. A secret is
+// generated when none is given.
+type WebhookCreate struct {
+	URL    string
+	Events []string
+	Secret string
+}
+
+func (s *WebhookService) Register(ctx context.Context, c WebhookCreate) (*aggregators.Webhook, error) {
+	u, err := url.Parse(c.URL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return nil, ErrInvalidWebhookURL
+	}
+	for _, e := range c.Events {
+		if !slices.Contains(eventTypes, e) {
+			return nil, fmt.Errorf("%w: %s", ErrInvalidEventType, e)
+		}
+	}
+
+	secret := c.Secret
+	if secret == "" {
+		b := make([]byte, 32)
+		if _, err := rand.Read(b); err != nil {
+			return nil, fmt.Errorf("failed to generate webhook secret: %w", err)
+		}
+		secret = hex.EncodeToString(b)
+	}
+
+	webhook := newWebhook(uuid.New(), u.String(), c.Events, secret, s.now()).toAggregator()
+	if err := s.r.Save(ctx, webhook); err != nil {
+		return nil, fmt.Errorf("failed to save webhook: %w", err)
+	}
+
+	return webhook, nil
+}
+
+// Delete removes the webhook together with its deliveries.
+func (s *WebhookService) Delete(ctx context.Context, id uuid.UUID) error {
+	if err := s.r.Delete(ctx, id); err != nil {
+		if errors.Is(err, infrastructure.ErrWebhookNotFound) {
+			return fmt.Errorf("%w: %s", ErrWebhookNotFound, id)
+		}
+		return fmt.Errorf("failed to delete webhook: %w", err)
+	}
+
+	return nil
+}
+
+// Redeliver queues a delivery of the webhook again, whatever became of it, with
+// a fresh budget of attempts.
+func (s *WebhookService) Redeliver(ctx context.Context, webhookID, deliveryID uuid.UUID) error {
+	if _, err := s.r.Find(ctx, webhookID); err != nil {
+		if errors.Is(err, infrastructure.ErrWebhookNotFound) {
+			return fmt.Errorf("%w: %s", ErrWebhookNotFound, webhookID)
+		}
+		return fmt.Errorf("failed to find webhook: %w", err)
+	}
+
+	delivery, err := s.r.FindDelivery(ctx, deliveryID)
+	if err != nil && !errors.Is(err, infrastructure.ErrDeliveryNotFound) {
+		return fmt.Errorf("failed to find webhook delivery: %w", err)
+	}
+	if err != nil || delivery.WebhookID != webhookID {
+		return fmt.Errorf("%w: %s", ErrDeliveryNotFound, deliveryID)
+	}
+
+	if err := s.r.Redeliver(ctx, deliveryID); err != nil {
+		return fmt.Errorf("failed to redeliver webhook delivery: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,189 @@
+package domain_test
+
+import (
+	"context"
+	"errors"
+	"github.com/aviseu/go-sample/internal/app/domain"
+	"github.com/aviseu/go-sample/internal/app/infrastructure"
+	"github.com/aviseu/go-sample/internal/app/infrastructure/aggregators"
+	"github.com/aviseu/go-sample/internal/errs"
+	"github.com/aviseu/go-sample/internal/testutils"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/suite"
+	"testing"
+	"time"
+)
+
+func TestWebhookService(t *testing.T) {
+	t.Parallel()
+	suite.Run(t, new(WebhookServiceSuite))
+}
+
+type WebhookServiceSuite struct {
+	suite.Suite
+}
+
+func (suite *WebhookServiceSuite) TestRegisterSuccess() {
+	// Prepare
+	now := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+	r := testutils.NewWebhookRepository()
+	s := domain.NewWebhookService(r, domain.WebhookServiceWithClock(func() time.Time { return now }))
+
+	// Execute
+	webhook, err := s.Register(context.Background(), domain.WebhookCreate{
+		URL:    "https://example.com/hooks",
+		Events: []string{"task.deleted", "task.created", "task.deleted"},
+		Secret: "s3cret",
+	})
+
+	// Assert result
+	suite.NoError(err)
+	suite.NotEmpty(webhook.ID)
+	suite.Equal("https://example.com/hooks", webhook.URL)
+	suite.Equal([]string{"task.created", "task.deleted"}, webhook.Events)
+	suite.Equal("s3cret", webhook.Secret)
+	suite.Equal(now, webhook.CreatedAt)
+
+	// Assert state
+	found, err := r.Find(context.Background(), webhook.ID)
+	suite.NoError(err)
+	suite.Equal(webhook, found)
+}
+
+func (suite *WebhookServiceSuite) TestRegisterGeneratesSecret() {
+	// Prepare
+	r := testutils.NewWebhookRepository()
+	s := domain.NewWebhookService(r)
+
+	// Execute
+	webhook1, err1 := s.Register(context.Background(), domain.WebhookCreate{URL: "http://localhost:8081"})
+	webhook2, err2 := s.Register(context.Background(), domain.WebhookCreate{URL: "http://localhost:8081"})
+
+	// Assert
+	suite.NoError(err1)
+	suite.NoError(err2)
+	suite.Len(webhook1.Secret, 64)
+	suite.NotEqual(webhook1.Secret, webhook2.Secret)
+	suite.Empty(webhook1.Events)
+}
+
+func (suite *WebhookServiceSuite) TestRegisterFail() {
+	tests := []struct {
+		name       string
+		create     domain.WebhookCreate
+		repoErr    error
+		err        error
+		validation bool
+	}{
+		{name: "url is required", create: domain.WebhookCreate{}, err: domain.ErrInvalidWebhookURL, validation: true},
+		{name: "url is relative", create: domain.WebhookCreate{URL: "/hooks"}, err: domain.ErrInvalidWebhookURL, validation: true},
+		{name: "url is not http", create: domain.WebhookCreate{URL: "ftp://example.com"}, err: domain.ErrInvalidWebhookURL, validation: true},
+		{name: "unknown event", create: domain.WebhookCreate{URL: "https://example.com", Events: []string{"task.exploded"}}, err: domain.ErrInvalidEventType, validation: true},
+		{name: "repository fails", create: domain.WebhookCreate{URL: "https://example.com"}, repoErr: errors.New("boom!")},
+	}
+
+	for _, tt := range tests {
+		suite.Run(tt.name, func() {
+			// Prepare
+			r := testutils.NewWebhookRepository(testutils.WebhookRepositoryWithError(tt.repoErr))
+			s := domain.NewWebhookService(r)
+
+			// Execute
+			webhook, err := s.Register(context.Background(), tt.create)
+
+			// Assert
+			suite.Error(err)
+			suite.Nil(webhook)
+			suite.Equal(tt.validation, errs.IsValidationError(err))
+			if tt.err != nil {
+				suite.ErrorIs(err, tt.err)
+			}
+			if tt.repoErr != nil {
+				suite.ErrorIs(err, tt.repoErr)
+			}
+		})
+	}
+}
+
+func (suite *WebhookServiceSuite) TestDeleteSuccess() {
+	// Prepare
+	id := uuid.New()
+	r := testutils.NewWebhookRepository(
+		testutils.WebhookRepositoryWithWebhook(&aggregators.Webhook{ID: id, URL: "https://example.com"}),
+		testutils.WebhookRepositoryWithDelivery(&aggregators.WebhookDelivery{ID: uuid.New(), WebhookID: id}),
+	)
+	s := domain.NewWebhookService(r)
+
+	// Execute
+	err := s.Delete(context.Background(), id)
+
+	// Assert
+	suite.NoError(err)
+	suite.Empty(r.Records)
+	suite.Empty(r.DeliveryRecords)
+}
+
+func (suite *WebhookServiceSuite) TestDeleteOtherWorkspaceFail() {
+	// Prepare
+	id := uuid.New()
+	r := testutils.NewWebhookRepository(testutils.WebhookRepositoryWithWebhook(&aggregators.Webhook{ID: id, URL: "https://example.com"}))
+	s := domain.NewWebhookService(r)
+
+	// Execute
+	err := s.Delete(infrastructure.WithWorkspace(context.Background(), uuid.New()), id)
+
+	// Assert
+	suite.ErrorIs(err, domain.ErrWebhookNotFound)
+	suite.Len(r.Records, 1)
+}
+
+func (suite *WebhookServiceSuite) TestRedeliverSuccess() {
+	// Prepare
+	id, deliveryID := uuid.New(), uuid.New()
+	r := testutils.NewWebhookRepository(
+		testutils.WebhookRepositoryWithWebhook(&aggregators.Webhook{ID: id, URL: "https://example.com"}),
+		testutils.WebhookRepositoryWithDelivery(&aggregators.WebhookDelivery{ID: deliveryID, WebhookID: id, Status: "dead", Failures: 8}),
+	)
+	s := domain.NewWebhookService(r)
+
+	// Execute
+	err := s.Redeliver(context.Background(), id, deliveryID)
+
+	// Assert
+	suite.NoError(err)
+	suite.Equal("pending", r.DeliveryRecords[0].Status)
+	suite.Equal(0, r.DeliveryRecords[0].Failures)
+}
+
+func (suite *WebhookServiceSuite) TestRedeliverFail() {
+	id, otherID, deliveryID := uuid.New(), uuid.New(), uuid.New()
+	tests := []struct {
+		name       string
+		webhookID  uuid.UUID
+		deliveryID uuid.UUID
+		err        error
+	}{
+		{name: "webhook not found", webhookID: uuid.New(), deliveryID: deliveryID, err: domain.ErrWebhookNotFound},
+		{name: "delivery not found", webhookID: id, deliveryID: uuid.New(), err: domain.ErrDeliveryNotFound},
+		{name: "delivery of other webhook", webhookID: otherID, deliveryID: deliveryID, err: domain.ErrDeliveryNotFound},
+	}
+
+	for _, tt := range tests {
+		suite.Run(tt.name, func() {
+			// Prepare
+			r := testutils.NewWebhookRepository(
+				testutils.WebhookRepositoryWithWebhook(&aggregators.Webhook{ID: id, URL: "https://example.com"}),
+				testutils.WebhookRepositoryWithWebhook(&aggregators.Webhook{ID: otherID, URL: "https://example.org"}),
+				testutils.WebhookRepositoryWithDelivery(&aggregators.WebhookDelivery{ID: deliveryID, WebhookID: id, Status: "dead"}),
+			)
+			s := domain.NewWebhookService(r)
+
+			// Execute
+			err := s.Redeliver(context.Background(), tt.webhookID, tt.deliveryID)
+
+			// Assert
+			suite.ErrorIs(err, tt.err)
+			suite.Equal("dead", r.DeliveryRecords[0].Status)
+		})
+	}
+}
@@ -0,0 +1,22 @@
+package infrastructure
+
+import "context"
+
+// DefaultActor is recorded for changes made without saying by whom.
+const DefaultActor = "anonymous"
+
+type actorKey struct{}
+
+// WithActor records who makes the changes saved through ctx.
+func WithActor(ctx context.Context, actor string) context.Context {
+	return context.WithValue(ctx, actorKey{}, actor)
+}
+
+// Actor returns who makes the changes saved through ctx, or the default actor.
+func Actor(ctx context.Context) string {
+	if actor, ok := ctx.Value(actorKey{}).(string); ok && actor != "" {
+		return actor
+	}
+
+	return DefaultActor
+}
@@ -0,0 +1,19 @@
+package aggregators
+
+import (
+	"encoding/json"
+	"github.com/google/uuid"
+	"time"
+)
+
+// Event is a domain event as it is stored in the outbox and handed to the
+// publishers. Attempts counts the failed deliveries so far.
+type Event struct {
+	ID          uuid.UUID       `db:"id" json:"id"`
+	WorkspaceID uuid.UUID       `db:"workspace_id" json:"workspace_id"`
+	TaskID      uuid.UUID       `db:"task_id" json:"task_id"`
+	Type        string          `db:"type" json:"type"`
+	Payload     json.RawMessage `db:"payload" json:"payload"`
+	OccurredAt  time.Time       `db:"occurred_at" json:"occurred_at"`
+	Attempts    int             `db:"attempts" json:"-"`
+}
@@ -0,0 +1,9 @@
+package aggregators
+
+// IdempotentResponse is the response to the first request made with an
+// idempotency key, which is replayed to the repeats of that request.
+type IdempotentResponse struct {
+	StatusCode int
+	Header     map[string][]string
+	Body       []byte
+}
@@ -0,0 +1,19 @@
+package aggregators
+
+import (
+	"github.com/google/uuid"
+	"time"
+)
+
+// InboxProjectID identifies the project that holds every task which was not
+// filed under another project.
+var InboxProjectID = uuid.MustParse("00000000-0000-0000-0000-000000000001")
+
+type Project struct {
+	ID        uuid.UUID `db:"id" json:"id"`
+	Name      string    `db:"name" json:"name"`
+	Inbox     bool      `db:"inbox" json:"inbox"`
+	CreatedAt time.Time `db:"created_at" json:"created_at"`
+	UpdatedAt time.Time `db:"updated_at" json:"updated_at"`
+	Version   int       `db:"version" json:"version"`
+}
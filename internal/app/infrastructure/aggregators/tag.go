@@ -0,0 +1,8 @@
+package aggregators
+
+import "github.com/google/uuid"
+
+type Tag struct {
+	ID   uuid.UUID `db:"id" json:"id"`
+	Name string    `db:"name" json:"name"`
+}
@@ -2,10 +2,31 @@ package aggregators
 
 import (
 	"github.com/google/uuid"
+	"time"
 )
 
 type Task struct {
-	ID        uuid.UUID `db:"id" json:"id"`
-	Title     string    `db:"title" json:"title"`
-	Completed bool      `db:"completed" json:"completed"`
+	ID          uuid.UUID   `db:"id" json:"id"`
+	ProjectID   uuid.UUID   `db:"project_id" json:"project_id"`
+	ParentID    *uuid.UUID  `db:"parent_id" json:"parent_id,omitempty"`
+	Title       string      `db:"title" json:"title"`
+	Status      string      `db:"status" json:"status"`
+	DueAt       *time.Time  `db:"due_at" json:"due_at,omitempty"`
+	Recurrence  *string     `db:"recurrence" json:"recurrence,omitempty"`
+	SeriesID    *uuid.UUID  `db:"series_id" json:"series_id,omitempty"`
+	Occurrence  int         `db:"occurrence" json:"occurrence,omitempty"`
+	CreatedAt   time.Time   `db:"created_at" json:"created_at"`
+	UpdatedAt   time.Time   `db:"updated_at" json:"updated_at"`
+	CompletedAt *time.Time  `db:"completed_at" json:"completed_at,omitempty"`
+	DeletedAt   *time.Time  `db:"deleted_at" json:"deleted_at,omitempty"`
+	Version     int         `db:"version" json:"version"`
+	Tags        []string    `db:"-" json:"tags,omitempty"`
+	BlockedBy   []uuid.UUID `db:"-" json:"blocked_by,omitempty"`
+	Events      []*Event    `db:"-" json:"-"`
+}
+
+type TaskMatch struct {
+	Task
+	Rank     float64 `db:"rank" json:"rank"`
+	Headline string  `db:"headline" json:"headline"`
 }
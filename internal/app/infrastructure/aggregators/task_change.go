@@ -0,0 +1,19 @@
+package aggregators
+
+import (
+	"encoding/json"
+	"github.com/google/uuid"
+	"time"
+)
+
+// TaskChange is an entry in the history of a task, holding the task as it was
+// before and after the change. Before is null for the change that created it.
+type TaskChange struct {
+	ID        uuid.UUID       `db:"id" json:"id"`
+	TaskID    uuid.UUID       `db:"task_id" json:"task_id"`
+	Action    string          `db:"action" json:"action"`
+	Actor     string          `db:"actor" json:"actor"`
+	Before    json.RawMessage `db:"before" json:"before"`
+	After     json.RawMessage `db:"after" json:"after"`
+	CreatedAt time.Time       `db:"created_at" json:"created_at"`
+}
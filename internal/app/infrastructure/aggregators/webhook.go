@@ -0,0 +1,45 @@
+package aggregators
+
+import (
+	"encoding/json"
+	"github.com/google/uuid"
+	"time"
+)
+
+// Webhook subscribes a URL to the events of its workspace. Without events it
+// receives all of them.
+type Webhook struct {
+	ID        uuid.UUID `db:"id" json:"id"`
+	URL       string    `db:"url" json:"url"`
+	Events    []string  `db:"-" json:"events"`
+	Secret    string    `db:"secret" json:"-"`
+	CreatedAt time.Time `db:"created_at" json:"created_at"`
+}
+
+// WebhookDelivery is an event on its way to a webhook. Failures counts the
+// failed attempts since it was last (re)delivered; URL and Secret are those of
+// the webhook and only filled in for the dispatcher.
+type WebhookDelivery struct {
+	ID        uuid.UUID         `db:"id" json:"id"`
+	WebhookID uuid.UUID         `db:"webhook_id" json:"webhook_id"`
+	EventID   uuid.UUID         `db:"event_id" json:"event_id"`
+	EventType string            `db:"event_type" json:"event_type"`
+	Payload   json.RawMessage   `db:"payload" json:"payload"`
+	Status    string            `db:"status" json:"status"`
+	Failures  int               `db:"failures" json:"failures"`
+	CreatedAt time.Time         `db:"created_at" json:"created_at"`
+	URL       string            `db:"url" json:"-"`
+	Secret    string            `db:"secret" json:"-"`
+	Attempts  []*WebhookAttempt `db:"-" json:"attempts"`
+}
+
+// WebhookAttempt records a single request to a webhook. ResponseCode is missing
+// when the webhook could not be reached at all.
+type WebhookAttempt struct {
+	ID           uuid.UUID `db:"id" json:"id"`
+	DeliveryID   uuid.UUID `db:"delivery_id" json:"-"`
+	Status       string    `db:"status" json:"status"`
+	ResponseCode *int      `db:"response_code" json:"response_code,omitempty"`
+	Error        *string   `db:"error" json:"error,omitempty"`
+	AttemptedAt  time.Time `db:"attempted_at" json:"attempted_at"`
+}
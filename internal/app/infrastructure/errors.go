@@ -5,4 +5,18 @@ import (
 	"github.com/aviseu/go-sample/internal/errs"
 )
 
-var ErrTaskNotFound = errs.NewValidationError(errors.New("task not found"))
+var (
+	ErrTaskNotFound     = errs.NewValidationError(errors.New("task not found"))
+	ErrTaskConflict     = errs.NewConflictError(errors.New("task version conflict"))
+	ErrInvalidCursor    = errs.NewValidationError(errors.New("invalid cursor"))
+	ErrTagNotFound      = errs.NewValidationError(errors.New("tag not found"))
+	ErrTagExists        = errs.NewConflictError(errors.New("tag already exists"))
+	ErrProjectNotFound  = errs.NewValidationError(errors.New("project not found"))
+	ErrProjectConflict  = errs.NewConflictError(errors.New("project version conflict"))
+	ErrWebhookNotFound  = errs.NewValidationError(errors.New("webhook not found"))
+	ErrDeliveryNotFound = errs.NewValidationError(errors.New("webhook delivery not found"))
+
+	ErrIdempotencyKeyReused   = errs.NewValidationError(errors.New("idempotency key was already used for a different request"))
+	ErrIdempotencyKeyInFlight = errs.NewConflictError(errors.New("a request with this idempotency key is still being processed"))
+	ErrIdempotencyKeyLost     = errs.NewConflictError(errors.New("idempotency key was taken over by another request"))
+)
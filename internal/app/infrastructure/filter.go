@@ -0,0 +1,34 @@
+package infrastructure
+
+import "github.com/google/uuid"
+
+type Sort string
+
+const (
+	SortTitle         Sort = "title"
+	SortTitleDesc     Sort = "-title"
+	SortCreatedAt     Sort = "created_at"
+	SortCreatedAtDesc Sort = "-created_at"
+)
+
+func (s Sort) Valid() bool {
+	switch s {
+	case SortTitle, SortTitleDesc, SortCreatedAt, SortCreatedAtDesc:
+		return true
+	}
+
+	return false
+}
+
+func (s Sort) Desc() bool {
+	return s == SortTitleDesc || s == SortCreatedAtDesc
+}
+
+type TaskFilter struct {
+	ProjectID     *uuid.UUID
+	ParentID      *uuid.UUID
+	Completed     *bool
+	TitleContains string
+	Tags          []string
+	Sort          Sort
+}
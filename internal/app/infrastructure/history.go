@@ -0,0 +1,80 @@
+package infrastructure
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/aviseu/go-sample/internal/app/infrastructure/aggregators"
+	"github.com/google/uuid"
+)
+
+const (
+	ActionCreated   = "created"
+	ActionCompleted = "completed"
+	ActionDeleted   = "deleted"
+	ActionRestored  = "restored"
+	ActionUpdated   = "updated"
+)
+
+// NewTaskChange describes how saving after changes before, which is nil for a
+// new task. The change is made by the actor of ctx at after.UpdatedAt and after
+// is recorded with the version it gets once saved.
+func NewTaskChange(ctx context.Context, before, after *aggregators.Task) (*aggregators.TaskChange, error) {
+	saved := *after
+	saved.Version++
+
+	b, err := json.Marshal(before)
+	if err != nil {
+		return nil, fmt.Errorf("failed to record task change: %w", err)
+	}
+	a, err := json.Marshal(&saved)
+	if err != nil {
+		return nil, fmt.Errorf("failed to record task change: %w", err)
+	}
+
+	// version 7 ids keep changes made at the same time in the order they were made
+	id, err := uuid.NewV7()
+	if err != nil {
+		return nil, fmt.Errorf("failed to record task change: %w", err)
+	}
+
+	return &aggregators.TaskChange{
+		ID:        id,
+		TaskID:    after.ID,
+		Action:    ChangeAction(before, after),
+		Actor:     Actor(ctx),
+		Before:    b,
+		After:     a,
+		CreatedAt: after.UpdatedAt,
+	}, nil
+}
+
+// ChangeAction names what saving after does to before, which is nil for a new
+// task.
+func ChangeAction(before, after *aggregators.Task) string {
+	switch {
+	case before == nil:
+		return ActionCreated
+	case before.DeletedAt == nil && after.DeletedAt != nil:
+		return ActionDeleted
+	case before.DeletedAt != nil && after.DeletedAt == nil:
+		return ActionRestored
+	case before.Status != "done" && after.Status == "done":
+		return ActionCompleted
+	default:
+		return ActionUpdated
+	}
+}
+
+// PaginateChanges is Paginate for the history of a task, which is always in the
+// order the changes were made.
+func PaginateChanges(changes []*aggregators.TaskChange, limit int) ([]*aggregators.TaskChange, *Cursor) {
+	if len(changes) <= limit {
+		return changes, nil
+	}
+
+	changes = changes[:limit]
+	last := changes[len(changes)-1]
+
+	return changes, &Cursor{Sort: SortCreatedAt, CreatedAt: last.CreatedAt, ID: last.ID}
+}
@@ -0,0 +1,67 @@
+package infrastructure
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"github.com/aviseu/go-sample/internal/app/infrastructure/aggregators"
+	"github.com/google/uuid"
+	"time"
+)
+
+type Page struct {
+	Limit int
+	After *Cursor
+}
+
+// Cursor is the keyset position of the last task of a page. It only holds the
+// sort key of the ordering it was issued for, together with the id as a tie
+// breaker.
+type Cursor struct {
+	Sort      Sort      `json:"s"`
+	Title     string    `json:"t,omitempty"`
+	CreatedAt time.Time `json:"c,omitempty"`
+	ID        uuid.UUID `json:"i"`
+}
+
+func NewCursor(t *aggregators.Task, s Sort) *Cursor {
+	c := &Cursor{Sort: s, ID: t.ID}
+	switch s {
+	case SortCreatedAt, SortCreatedAtDesc:
+		c.CreatedAt = t.CreatedAt
+	default:
+		c.Title = t.Title
+	}
+
+	return c
+}
+
+func (c *Cursor) Encode() string {
+	b, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+func DecodeCursor(s string) (*Cursor, error) {
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+
+	var c Cursor
+	if err := json.Unmarshal(b, &c); err != nil || c.ID == uuid.Nil || !c.Sort.Valid() {
+		return nil, ErrInvalidCursor
+	}
+
+	return &c, nil
+}
+
+// Paginate trims tasks that were fetched with one row more than limit and
+// returns the cursor of the next page when that extra row was present.
+func Paginate(tasks []*aggregators.Task, limit int, s Sort) ([]*aggregators.Task, *Cursor) {
+	if len(tasks) <= limit {
+		return tasks, nil
+	}
+
+	tasks = tasks[:limit]
+
+	return tasks, NewCursor(tasks[len(tasks)-1], s)
+}
@@ -0,0 +1,222 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/aviseu/go-sample/internal/app/infrastructure"
+	"github.com/aviseu/go-sample/internal/app/infrastructure/aggregators"
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+	"log/slog"
+	"time"
+)
+
+// ChangesChannel is the channel the outbox announces every event added to it
+// on, with the ID of the event as payload.
+const ChangesChannel = "task_changes"
+
+// Bus is told about the tasks that changed together with the events of the
+// change.
+type Bus interface {
+	Notify(tasks ...*aggregators.Task)
+}
+
+type ChangeListenerOptional func(*ChangeListener)
+
+// ChangeListenerWithReconnect sets how long to wait before reconnecting after
+// the connection was lost. The wait doubles after every failed attempt, up to
+// max.
+func ChangeListenerWithReconnect(min, max time.Duration) ChangeListenerOptional {
+	return func(l *ChangeListener) {
+		l.minReconnect = min
+		l.maxReconnect = max
+	}
+}
+
+// ChangeListenerWithPing sets how often the connection is checked, so that a
+// connection that died silently is noticed and replaced.
+func ChangeListenerWithPing(d time.Duration) ChangeListenerOptional {
+	return func(l *ChangeListener) {
+		l.ping = d
+	}
+}
+
+// ChangeListener passes the events announced on ChangesChannel on to the bus
+// together with their task as it is stored by then, so that the subscribers of
+// every instance see the changes made by all of them. Its connection is
+// dedicated to listening and restored whenever it is lost, after which the
+// events added in the meantime are passed on as well.
+type ChangeListener struct {
+	log          *slog.Logger
+	db           *sqlx.DB
+	tasks        *TaskRepository
+	dsn          string
+	bus          Bus
+	minReconnect time.Duration
+	maxReconnect time.Duration
+	ping         time.Duration
+
+	// sequence is the highest outbox sequence passed on. caughtUp holds the
+	// events passed on when catching up, which may still be announced.
+	sequence int64
+	caughtUp map[uuid.UUID]struct{}
+}
+
+func NewChangeListener(log *slog.Logger, db *sqlx.DB, dsn string, bus Bus, opts ...ChangeListenerOptional) *ChangeListener {
+	l := &ChangeListener{
+		log:          log,
+		db:           db,
+		tasks:        NewTaskRepository(db),
+		dsn:          dsn,
+		bus:          bus,
+		minReconnect: time.Second,
+		maxReconnect: time.Minute,
+		ping:         time.Minute,
+	}
+
+	for _, opt := range opts {
+		opt(l)
+	}
+
+	return l
+}
+
+// outboxEvent is an event together with its place in the outbox.
+type outboxEvent struct {
+	aggregators.Event
+	Sequence int64 `db:"sequence"`
+}
+
+const outboxEventColumns = "sequence, id, workspace_id, task_id, type, payload, occurred_at"
+
+// Run listens until ctx is done.
+func (l *ChangeListener) Run(ctx context.Context) {
+	listener := pq.NewListener(l.dsn, l.minReconnect, l.maxReconnect, func(event pq.ListenerEventType, err error) {
+		switch event {
+		case pq.ListenerEventDisconnected:
+			l.log.Warn(fmt.Sprintf("lost connection for change notifications: %s", err))
+		case pq.ListenerEventConnectionAttemptFailed:
+			l.log.Warn(fmt.Sprintf("failed to connect for change notifications: %s", err))
+		case pq.ListenerEventReconnected:
+			l.log.Info("reconnected for change notifications")
+		}
+	})
+	// closing the listener also ends a Listen that waits for a connection
+	stop := context.AfterFunc(ctx, func() { _ = listener.Close() })
+	defer func() {
+		if stop() {
+			_ = listener.Close()
+		}
+	}()
+
+	if err := listener.Listen(ChangesChannel); err != nil {
+		if ctx.Err() == nil {
+			l.log.Error(fmt.Sprintf("failed to listen for changes: %s", err))
+		}
+		return
+	}
+	if err := l.db.GetContext(ctx, &l.sequence, "SELECT coalesce(max(sequence), 0) FROM outbox"); err != nil {
+		l.log.Error(fmt.Sprintf("failed to find latest change: %s", err))
+	}
+
+	ticker := time.NewTicker(l.ping)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case n, ok := <-listener.Notify:
+			if !ok {
+				return
+			}
+
+			// a nil notification follows a reconnect, notifications sent
+			// while disconnected were lost
+			var err error
+			if n == nil {
+				err = l.catchUp(ctx)
+			} else {
+				err = l.pass(ctx, n.Extra)
+			}
+			if err != nil {
+				l.log.Error(err.Error())
+			}
+		case <-ticker.C:
+			// a failed ping makes the listener reconnect
+			_ = listener.Ping()
+		}
+	}
+}
+
+func (l *ChangeListener) pass(ctx context.Context, payload string) error {
+	id, err := uuid.Parse(payload)
+	if err != nil {
+		return fmt.Errorf("invalid change notification %q: %w", payload, err)
+	}
+	if _, ok := l.caughtUp[id]; ok {
+		delete(l.caughtUp, id)
+		return nil
+	}
+
+	var e outboxEvent
+	if err := l.db.GetContext(ctx, &e, "SELECT "+outboxEventColumns+" FROM outbox WHERE id = $1", id); err != nil {
+		return fmt.Errorf("failed to find event %s: %w", id, err)
+	}
+
+	return l.notify(ctx, []*outboxEvent{&e})
+}
+
+// catchUp passes on the events added after the last one passed on. Events
+// that committed out of sequence while disconnected can still be missed.
+func (l *ChangeListener) catchUp(ctx context.Context) error {
+	var events []*outboxEvent
+	err := l.db.SelectContext(ctx, &events,
+		"SELECT "+outboxEventColumns+" FROM outbox WHERE sequence > $1 ORDER BY sequence",
+		l.sequence,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to catch up on changes: %w", err)
+	}
+
+	l.caughtUp = make(map[uuid.UUID]struct{}, len(events))
+	for _, e := range events {
+		l.caughtUp[e.ID] = struct{}{}
+	}
+
+	return l.notify(ctx, events)
+}
+
+// notify hands the events to the bus grouped by task, keeping their order.
+// Events of tasks that were purged since are dropped.
+func (l *ChangeListener) notify(ctx context.Context, events []*outboxEvent) error {
+	var tasks []*aggregators.Task
+	byID := make(map[uuid.UUID]*aggregators.Task)
+	for _, e := range events {
+		l.sequence = max(l.sequence, e.Sequence)
+
+		task, ok := byID[e.TaskID]
+		if !ok {
+			var err error
+			task, err = l.tasks.find(infrastructure.WithWorkspace(ctx, e.WorkspaceID),
+				"SELECT "+taskColumns+" FROM tasks WHERE id = $1 AND workspace_id = $2", e.TaskID)
+			if errors.Is(err, infrastructure.ErrTaskNotFound) {
+				continue
+			}
+			if err != nil {
+				return err
+			}
+			byID[e.TaskID] = task
+			tasks = append(tasks, task)
+		}
+		task.Events = append(task.Events, &e.Event)
+	}
+
+	if len(tasks) > 0 {
+		l.bus.Notify(tasks...)
+	}
+
+	return nil
+}
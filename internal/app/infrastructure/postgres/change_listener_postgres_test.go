@@ -0,0 +1,186 @@
+package postgres_test
+
+import (
+	"context"
+	"github.com/aviseu/go-sample/internal/app/domain"
+	"github.com/aviseu/go-sample/internal/app/infrastructure"
+	"github.com/aviseu/go-sample/internal/app/infrastructure/aggregators"
+	"github.com/aviseu/go-sample/internal/app/infrastructure/postgres"
+	"github.com/aviseu/go-sample/internal/testutils"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/suite"
+	"testing"
+	"time"
+)
+
+func TestChangeListener(t *testing.T) {
+	t.Parallel()
+	suite.Run(t, new(ChangeListenerSuite))
+}
+
+type ChangeListenerSuite struct {
+	testutils.PostgresSuite
+}
+
+// bus passes the tasks it is told about on to a channel.
+type bus chan *aggregators.Task
+
+func (b bus) Notify(tasks ...*aggregators.Task) {
+	for _, task := range tasks {
+		b <- task
+	}
+}
+
+const listening = `SELECT count(*) FROM pg_stat_activity WHERE query = 'LISTEN "` + postgres.ChangesChannel + `"'`
+
+// listen runs a change listener until the test ends and waits for it to
+// listen.
+func (suite *ChangeListenerSuite) listen(b bus) {
+	_, log := testutils.NewLogger()
+	l := postgres.NewChangeListener(log, suite.DB, suite.DSN, b, postgres.ChangeListenerWithReconnect(50*time.Millisecond, 50*time.Millisecond))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		l.Run(ctx)
+	}()
+	suite.T().Cleanup(func() {
+		cancel()
+		<-done
+	})
+
+	suite.Eventually(func() bool {
+		var n int
+		suite.NoError(suite.DB.Get(&n, listening))
+		return n == 1
+	}, 5*time.Second, 10*time.Millisecond)
+}
+
+func (suite *ChangeListenerSuite) save(ws uuid.UUID) *aggregators.Task {
+	id := uuid.New()
+	now := time.Now()
+	task := &aggregators.Task{ID: id, ProjectID: aggregators.InboxProjectID, Title: "task 1", Status: "todo", CreatedAt: now, UpdatedAt: now, Events: []*aggregators.Event{
+		{ID: uuid.New(), TaskID: id, Type: "task.created", Payload: []byte(`{"title":"task 1"}`), OccurredAt: now},
+	}}
+	suite.Require().NoError(postgres.NewTaskRepository(suite.DB).Save(infrastructure.WithWorkspace(context.Background(), ws), task))
+
+	return task
+}
+
+func (suite *ChangeListenerSuite) receive(b bus) *aggregators.Task {
+	select {
+	case task := <-b:
+		return task
+	case <-time.After(5 * time.Second):
+		suite.FailNow("no change was passed on")
+		return nil
+	}
+}
+
+func (suite *ChangeListenerSuite) TestChangesArePassedOn() {
+	// Prepare
+	b := make(bus, 10)
+	suite.listen(b)
+	ws := uuid.New()
+
+	// Execute
+	saved := suite.save(ws)
+
+	// Assert
+	task := suite.receive(b)
+	suite.Equal(saved.ID, task.ID)
+	suite.Equal("task 1", task.Title)
+	suite.Equal(1, task.Version)
+	suite.Require().Len(task.Events, 1)
+	suite.Equal(saved.Events[0].ID, task.Events[0].ID)
+	suite.Equal(ws, task.Events[0].WorkspaceID)
+	suite.Equal("task.created", task.Events[0].Type)
+	suite.JSONEq(`{"title":"task 1"}`, string(task.Events[0].Payload))
+}
+
+func (suite *ChangeListenerSuite) TestCatchesUpAfterReconnect() {
+	// Prepare
+	b := make(bus, 10)
+	suite.listen(b)
+	ws := uuid.New()
+
+	// Execute
+	_, err := suite.DB.Exec(`SELECT pg_terminate_backend(pid) FROM pg_stat_activity WHERE query = 'LISTEN "` + postgres.ChangesChannel + `"'`)
+	suite.Require().NoError(err)
+	saved := suite.save(ws)
+
+	// Assert
+	suite.Equal(saved.ID, suite.receive(b).ID)
+	select {
+	case task := <-b:
+		suite.Failf("change was passed on twice", "task %s", task.ID)
+	case <-time.After(200 * time.Millisecond):
+	}
+}
+
+func (suite *ChangeListenerSuite) TestPurgedTasksAreSkipped() {
+	// Prepare
+	b := make(bus, 10)
+	suite.listen(b)
+	_, err := suite.DB.Exec(
+		"INSERT INTO outbox (id, workspace_id, task_id, type, payload, occurred_at) VALUES ($1, $2, $3, 'task.deleted', '{}', now())",
+		uuid.New(), uuid.New(), uuid.New(),
+	)
+	suite.Require().NoError(err)
+
+	// Execute
+	kept := suite.save(uuid.New())
+
+	// Assert
+	suite.Equal(kept.ID, suite.receive(b).ID)
+}
+
+func (suite *ChangeListenerSuite) TestTagDeletionIsPassedOn() {
+	// Prepare
+	b := make(bus, 10)
+	suite.listen(b)
+	ws, tagID, taskID := uuid.New(), uuid.New(), uuid.New()
+	ctx := infrastructure.WithWorkspace(context.Background(), ws)
+	r := postgres.NewTaskRepository(suite.DB)
+	suite.Require().NoError(r.SaveTag(ctx, &aggregators.Tag{ID: tagID, Name: "work"}))
+	now := time.Now()
+	suite.Require().NoError(r.Save(ctx, &aggregators.Task{ID: taskID, ProjectID: aggregators.InboxProjectID, Title: "task 1", Status: "todo", CreatedAt: now, UpdatedAt: now, Tags: []string{"work"}}))
+
+	// Execute
+	err := domain.NewService(r).DeleteTag(ctx, tagID)
+
+	// Assert
+	suite.Require().NoError(err)
+	task := suite.receive(b)
+	suite.Equal(taskID, task.ID)
+	suite.Empty(task.Tags)
+	suite.Require().Len(task.Events, 1)
+	suite.Equal(ws, task.Events[0].WorkspaceID)
+	suite.Equal("task.updated", task.Events[0].Type)
+}
+
+func (suite *ChangeListenerSuite) TestProjectDeletionIsPassedOn() {
+	// Prepare
+	b := make(bus, 10)
+	suite.listen(b)
+	ws, projectID, taskID := uuid.New(), uuid.New(), uuid.New()
+	ctx := infrastructure.WithWorkspace(context.Background(), ws)
+	r := postgres.NewTaskRepository(suite.DB)
+	pr := postgres.NewProjectRepository(suite.DB, r)
+	suite.Require().NoError(pr.Save(ctx, &aggregators.Project{ID: projectID, Name: "work"}))
+	now := time.Now()
+	suite.Require().NoError(r.Save(ctx, &aggregators.Task{ID: taskID, ProjectID: projectID, Title: "task 1", Status: "todo", CreatedAt: now, UpdatedAt: now}))
+
+	// Execute
+	err := domain.NewProjectService(pr).Delete(ctx, projectID)
+
+	// Assert
+	suite.Require().NoError(err)
+	task := suite.receive(b)
+	suite.Equal(taskID, task.ID)
+	suite.Equal(aggregators.InboxProjectID, task.ProjectID)
+	suite.Require().Len(task.Events, 1)
+	suite.Equal(ws, task.Events[0].WorkspaceID)
+	suite.Equal("task.updated", task.Events[0].Type)
+}
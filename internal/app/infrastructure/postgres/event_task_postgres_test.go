@@ -0,0 +1,291 @@
+package postgres_test
+
+import (
+	"context"
+	"github.com/aviseu/go-sample/internal/app/infrastructure"
+	"github.com/aviseu/go-sample/internal/app/infrastructure/aggregators"
+	"github.com/aviseu/go-sample/internal/app/infrastructure/postgres"
+	"github.com/aviseu/go-sample/internal/testutils"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/suite"
+	"testing"
+	"time"
+)
+
+func TestEventTaskRepository(t *testing.T) {
+	t.Parallel()
+	suite.Run(t, new(EventTaskRepositorySuite))
+}
+
+type EventTaskRepositorySuite struct {
+	testutils.PostgresSuite
+}
+
+func (suite *EventTaskRepositorySuite) TestSaveSuccess() {
+	// Prepare
+	id := uuid.New()
+	createdAt := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+	completedAt := createdAt.Add(time.Hour)
+	r := postgres.NewEventTaskRepository(suite.DB)
+	task := &aggregators.Task{ID: id, ProjectID: aggregators.InboxProjectID, Title: "task 1", Status: "todo", CreatedAt: createdAt, UpdatedAt: createdAt}
+	suite.NoError(r.Save(context.Background(), task))
+	task.Status, task.CompletedAt, task.UpdatedAt = "done", &completedAt, completedAt
+
+	// Execute
+	err := r.Save(context.Background(), task)
+
+	// Assert
+	suite.NoError(err)
+	suite.Equal(2, task.Version)
+	var events []struct {
+		Sequence int    `db:"sequence"`
+		Type     string `db:"type"`
+		Version  int    `db:"version"`
+	}
+	suite.NoError(suite.DB.Select(&events, "SELECT sequence, type, version FROM task_events WHERE task_id = $1 ORDER BY sequence", id))
+	suite.Len(events, 2)
+	suite.Equal(1, events[0].Sequence)
+	suite.Equal("TaskCreated", events[0].Type)
+	suite.Equal(1, events[0].Version)
+	suite.Equal(2, events[1].Sequence)
+	suite.Equal("TaskCompleted", events[1].Type)
+	suite.Equal(2, events[1].Version)
+	var status string
+	suite.NoError(suite.DB.Get(&status, "SELECT status FROM tasks WHERE id = $1", id))
+	suite.Equal("done", status)
+}
+
+func (suite *EventTaskRepositorySuite) TestFindSuccess() {
+	// Prepare
+	id, tagID := uuid.New(), uuid.New()
+	_, err := suite.DB.Exec("INSERT INTO tags (id, name) VALUES ($1, $2)", tagID, "home")
+	suite.NoError(err)
+	createdAt := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+	r := postgres.NewEventTaskRepository(suite.DB)
+	task := &aggregators.Task{ID: id, ProjectID: aggregators.InboxProjectID, Title: "task 1", Status: "todo", CreatedAt: createdAt, UpdatedAt: createdAt, Tags: []string{"home"}}
+	suite.NoError(r.Save(context.Background(), task))
+	task.Title, task.UpdatedAt = "task 1 renamed", createdAt.Add(time.Hour)
+	suite.NoError(r.Save(context.Background(), task))
+
+	// Execute
+	found, err := r.Find(context.Background(), id)
+
+	// Assert
+	suite.NoError(err)
+	suite.Equal(id, found.ID)
+	suite.Equal("task 1 renamed", found.Title)
+	suite.Equal("todo", found.Status)
+	suite.Equal([]string{"home"}, found.Tags)
+	suite.True(createdAt.Equal(found.CreatedAt))
+	suite.True(createdAt.Add(time.Hour).Equal(found.UpdatedAt))
+	suite.Equal(2, found.Version)
+}
+
+func (suite *EventTaskRepositorySuite) TestFindTrashedSuccess() {
+	// Prepare
+	id := uuid.New()
+	now := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+	r := postgres.NewEventTaskRepository(suite.DB)
+	task := &aggregators.Task{ID: id, ProjectID: aggregators.InboxProjectID, Title: "task 1", Status: "todo", CreatedAt: now, UpdatedAt: now}
+	suite.NoError(r.Save(context.Background(), task))
+	task.DeletedAt = &now
+	suite.NoError(r.Save(context.Background(), task))
+
+	// Execute
+	found, err1 := r.Find(context.Background(), id)
+	trashed, err2 := r.FindTrashed(context.Background(), id)
+
+	// Assert
+	suite.Nil(found)
+	suite.ErrorIs(err1, infrastructure.ErrTaskNotFound)
+	suite.NoError(err2)
+	suite.Equal(id, trashed.ID)
+	suite.NotNil(trashed.DeletedAt)
+}
+
+func (suite *EventTaskRepositorySuite) TestFindAtSuccess() {
+	// Prepare
+	id := uuid.New()
+	createdAt := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+	completedAt := createdAt.Add(2 * time.Hour)
+	r := postgres.NewEventTaskRepository(suite.DB)
+	task := &aggregators.Task{ID: id, ProjectID: aggregators.InboxProjectID, Title: "task 1", Status: "todo", CreatedAt: createdAt, UpdatedAt: createdAt}
+	suite.NoError(r.Save(context.Background(), task))
+	task.Status, task.CompletedAt, task.UpdatedAt = "done", &completedAt, completedAt
+	suite.NoError(r.Save(context.Background(), task))
+
+	// Execute
+	before, err1 := r.FindAt(context.Background(), id, createdAt.Add(-time.Minute))
+	between, err2 := r.FindAt(context.Background(), id, createdAt.Add(time.Hour))
+	after, err3 := r.FindAt(context.Background(), id, completedAt)
+
+	// Assert
+	suite.Nil(before)
+	suite.ErrorIs(err1, infrastructure.ErrTaskNotFound)
+	suite.NoError(err2)
+	suite.Equal("todo", between.Status)
+	suite.Nil(between.CompletedAt)
+	suite.Equal(1, between.Version)
+	suite.NoError(err3)
+	suite.Equal("done", after.Status)
+	suite.Equal(2, after.Version)
+}
+
+func (suite *EventTaskRepositorySuite) TestSnapshotSuccess() {
+	// Prepare
+	id := uuid.New()
+	now := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+	r := postgres.NewEventTaskRepository(suite.DB, postgres.EventTaskRepositoryWithSnapshotEvery(2))
+	task := &aggregators.Task{ID: id, ProjectID: aggregators.InboxProjectID, Title: "task 1", Status: "todo", CreatedAt: now, UpdatedAt: now}
+
+	// Execute
+	for _, title := range []string{"task 1", "task 2", "task 3"} {
+		task.Title = title
+		suite.NoError(r.Save(context.Background(), task))
+	}
+	found, err := r.Find(context.Background(), id)
+
+	// Assert
+	suite.NoError(err)
+	suite.Equal("task 3", found.Title)
+	suite.Equal(3, found.Version)
+	var snapshots []int
+	suite.NoError(suite.DB.Select(&snapshots, "SELECT sequence FROM task_snapshots WHERE task_id = $1 ORDER BY sequence", id))
+	suite.Equal([]int{2}, snapshots)
+}
+
+func (suite *EventTaskRepositorySuite) TestSaveStoredTaskSuccess() {
+	// Prepare
+	id := uuid.New()
+	_, err := suite.DB.Exec("INSERT INTO tasks (id, title, status, version) VALUES ($1, $2, $3, $4)", id, "task 1", "todo", 3)
+	suite.NoError(err)
+	r := postgres.NewEventTaskRepository(suite.DB)
+	task, err := r.Find(context.Background(), id)
+	suite.Require().NoError(err)
+	task.Title = "task 1 renamed"
+
+	// Execute
+	err = r.Save(context.Background(), task)
+
+	// Assert
+	suite.NoError(err)
+	var snapshots []int
+	suite.NoError(suite.DB.Select(&snapshots, "SELECT version FROM task_snapshots WHERE task_id = $1 AND sequence = 0", id))
+	suite.Equal([]int{3}, snapshots)
+	found, err := r.Find(context.Background(), id)
+	suite.NoError(err)
+	suite.Equal("task 1 renamed", found.Title)
+	suite.Equal(4, found.Version)
+}
+
+func (suite *EventTaskRepositorySuite) TestSaveStaleVersionConflict() {
+	// Prepare
+	id := uuid.New()
+	now := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+	r := postgres.NewEventTaskRepository(suite.DB)
+	suite.NoError(r.Save(context.Background(), &aggregators.Task{ID: id, ProjectID: aggregators.InboxProjectID, Title: "task 1", Status: "todo", CreatedAt: now, UpdatedAt: now}))
+
+	// Execute
+	err := r.Save(context.Background(), &aggregators.Task{ID: id, ProjectID: aggregators.InboxProjectID, Title: "task 1 renamed", Status: "todo", CreatedAt: now, UpdatedAt: now, Version: 2})
+
+	// Assert
+	suite.ErrorIs(err, infrastructure.ErrTaskConflict)
+	var n int
+	suite.NoError(suite.DB.Get(&n, "SELECT count(*) FROM task_events WHERE task_id = $1", id))
+	suite.Equal(1, n)
+}
+
+func (suite *EventTaskRepositorySuite) TestDeleteTagSuccess() {
+	// Prepare
+	id, tagID := uuid.New(), uuid.New()
+	_, err := suite.DB.Exec("INSERT INTO tags (id, name) VALUES ($1, $2)", tagID, "home")
+	suite.NoError(err)
+	now := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+	r := postgres.NewEventTaskRepository(suite.DB, postgres.EventTaskRepositoryWithSnapshotEvery(2))
+	suite.NoError(r.Save(context.Background(), &aggregators.Task{ID: id, ProjectID: aggregators.InboxProjectID, Title: "task 1", Status: "todo", CreatedAt: now, UpdatedAt: now, Tags: []string{"home"}}))
+
+	tagged, err := r.Tagged(context.Background(), tagID)
+	suite.NoError(err)
+	tagged[0].Tags = nil
+
+	// Execute
+	err = r.DeleteTag(context.Background(), tagID, tagged...)
+
+	// Assert
+	suite.NoError(err)
+	found, err := r.Find(context.Background(), id)
+	suite.NoError(err)
+	suite.Empty(found.Tags)
+	suite.Equal(2, found.Version)
+	var types []string
+	suite.NoError(suite.DB.Select(&types, "SELECT type FROM task_events WHERE task_id = $1 ORDER BY sequence", id))
+	suite.Equal([]string{"TaskCreated", "TaskUpdated"}, types)
+	var snapshots []int
+	suite.NoError(suite.DB.Select(&snapshots, "SELECT sequence FROM task_snapshots WHERE task_id = $1 ORDER BY sequence", id))
+	suite.Equal([]int{2}, snapshots)
+}
+
+func (suite *EventTaskRepositorySuite) TestPurgeSuccess() {
+	// Prepare
+	id, dependentID := uuid.New(), uuid.New()
+	now := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+	r := postgres.NewEventTaskRepository(suite.DB)
+	suite.NoError(r.Save(context.Background(), &aggregators.Task{ID: id, ProjectID: aggregators.InboxProjectID, Title: "blocker", Status: "todo", CreatedAt: now, UpdatedAt: now, DeletedAt: &now}))
+	suite.NoError(r.Save(context.Background(), &aggregators.Task{ID: dependentID, ProjectID: aggregators.InboxProjectID, Title: "dependent", Status: "todo", CreatedAt: now, UpdatedAt: now, BlockedBy: []uuid.UUID{id}}))
+
+	expired, err := r.Expired(context.Background(), now.Add(time.Second))
+	suite.NoError(err)
+	suite.Len(expired, 1)
+	referencing, err := r.Referencing(context.Background(), id)
+	suite.NoError(err)
+	referencing[0].BlockedBy = nil
+
+	// Execute
+	n, err := r.Purge(context.Background(), []uuid.UUID{id}, referencing...)
+
+	// Assert
+	suite.NoError(err)
+	suite.Equal(int64(1), n)
+	found, err := r.Find(context.Background(), dependentID)
+	suite.NoError(err)
+	suite.Empty(found.BlockedBy)
+	suite.Equal(2, found.Version)
+	var types []string
+	suite.NoError(suite.DB.Select(&types, "SELECT type FROM task_events WHERE task_id = $1 ORDER BY sequence", dependentID))
+	suite.Equal([]string{"TaskCreated", "TaskUpdated"}, types)
+
+	found.Title = "dependent renamed"
+	suite.NoError(r.Save(context.Background(), found))
+	found, err = r.Find(context.Background(), dependentID)
+	suite.NoError(err)
+	suite.Equal("dependent renamed", found.Title)
+	suite.Equal(3, found.Version)
+}
+
+func (suite *EventTaskRepositorySuite) TestDeleteProjectSuccess() {
+	// Prepare
+	id, projectID := uuid.New(), uuid.New()
+	_, err := suite.DB.Exec("INSERT INTO projects (id, name) VALUES ($1, $2)", projectID, "work")
+	suite.NoError(err)
+	now := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+	r := postgres.NewEventTaskRepository(suite.DB)
+	pr := postgres.NewProjectRepository(suite.DB, r)
+	suite.NoError(r.Save(context.Background(), &aggregators.Task{ID: id, ProjectID: projectID, Title: "task 1", Status: "todo", CreatedAt: now, UpdatedAt: now}))
+
+	tasks, err := pr.Tasks(context.Background(), projectID)
+	suite.NoError(err)
+	tasks[0].ProjectID = aggregators.InboxProjectID
+
+	// Execute
+	err = pr.Delete(context.Background(), projectID, tasks...)
+
+	// Assert
+	suite.NoError(err)
+	found, err := r.Find(context.Background(), id)
+	suite.NoError(err)
+	suite.Equal(aggregators.InboxProjectID, found.ProjectID)
+	suite.Equal(2, found.Version)
+	var types []string
+	suite.NoError(suite.DB.Select(&types, "SELECT type FROM task_events WHERE task_id = $1 ORDER BY sequence", id))
+	suite.Equal([]string{"TaskCreated", "TaskUpdated"}, types)
+}
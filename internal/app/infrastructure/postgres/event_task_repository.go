@@ -0,0 +1,281 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/aviseu/go-sample/internal/app/infrastructure"
+	"github.com/aviseu/go-sample/internal/app/infrastructure/aggregators"
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"time"
+)
+
+type EventTaskRepositoryOptional func(*EventTaskRepository)
+
+// EventTaskRepositoryWithSnapshotEvery takes a snapshot of a task once n events
+// were appended since its last one.
+func EventTaskRepositoryWithSnapshotEvery(n int) EventTaskRepositoryOptional {
+	return func(r *EventTaskRepository) {
+		r.snapshotEvery = n
+	}
+}
+
+// EventTaskRepository stores every change to a task as an event and rebuilds a
+// task by replaying its events on top of its latest snapshot. The tasks table
+// is kept up to date as a projection in the same transaction, which is what
+// the queries inherited from TaskRepository read. Tasks stored before the
+// switch to events are read from the projection until they are saved again.
+type EventTaskRepository struct {
+	*TaskRepository
+	snapshotEvery int
+}
+
+func NewEventTaskRepository(db *sqlx.DB, opts ...EventTaskRepositoryOptional) *EventTaskRepository {
+	r := &EventTaskRepository{
+		TaskRepository: NewTaskRepository(db),
+		snapshotEvery:  50,
+	}
+
+	for _, opt := range opts {
+		opt(r)
+	}
+
+	return r
+}
+
+// taskStream is a task as rebuilt from its events, together with the sequence
+// of its last event and the one of its latest snapshot.
+type taskStream struct {
+	task     *aggregators.Task
+	sequence int
+	snapshot int
+}
+
+func (r *EventTaskRepository) Find(ctx context.Context, id uuid.UUID) (*aggregators.Task, error) {
+	task, err := r.rebuild(ctx, id, nil)
+	if err != nil {
+		return nil, err
+	}
+	if task == nil {
+		return r.TaskRepository.Find(ctx, id)
+	}
+	if task.DeletedAt != nil {
+		return nil, infrastructure.ErrTaskNotFound
+	}
+
+	return task, nil
+}
+
+func (r *EventTaskRepository) FindTrashed(ctx context.Context, id uuid.UUID) (*aggregators.Task, error) {
+	task, err := r.rebuild(ctx, id, nil)
+	if err != nil {
+		return nil, err
+	}
+	if task == nil {
+		return r.TaskRepository.FindTrashed(ctx, id)
+	}
+	if task.DeletedAt == nil {
+		return nil, infrastructure.ErrTaskNotFound
+	}
+
+	return task, nil
+}
+
+// FindAt returns the task as it was at the given time, trashed or not, by
+// replaying the events up to then.
+func (r *EventTaskRepository) FindAt(ctx context.Context, id uuid.UUID, at time.Time) (*aggregators.Task, error) {
+	task, err := r.rebuild(ctx, id, &at)
+	if err != nil {
+		return nil, err
+	}
+	if task == nil {
+		return nil, infrastructure.ErrTaskNotFound
+	}
+
+	return task, nil
+}
+
+func (r *EventTaskRepository) rebuild(ctx context.Context, id uuid.UUID, at *time.Time) (*aggregators.Task, error) {
+	tx, err := begin(ctx, r.db)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find task: %w", err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	s, err := r.stream(ctx, tx, id, at)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.task, nil
+}
+
+// stream replays the events of a task up to the given time, or all of them
+// when at is nil. The task of the stream is nil when there is nothing to replay.
+func (r *EventTaskRepository) stream(ctx context.Context, tx *sqlx.Tx, id uuid.UUID, at *time.Time) (*taskStream, error) {
+	workspaceID := infrastructure.Workspace(ctx)
+	s := &taskStream{}
+
+	var snapshot struct {
+		Sequence int             `db:"sequence"`
+		Data     json.RawMessage `db:"data"`
+		Version  int             `db:"version"`
+	}
+	found := true
+	err := tx.GetContext(ctx, &snapshot,
+		`SELECT sequence, data, version FROM task_snapshots
+		WHERE task_id = $1 AND workspace_id = $2 AND ($3::timestamptz IS NULL OR created_at <= $3)
+		ORDER BY sequence DESC LIMIT 1`,
+		id, workspaceID, at,
+	)
+	if errors.Is(err, sql.ErrNoRows) {
+		found = false
+	} else if err != nil {
+		return nil, fmt.Errorf("failed to get task snapshot: %w", err)
+	}
+
+	doc := make(map[string]any)
+	if found {
+		if err := json.Unmarshal(snapshot.Data, &doc); err != nil {
+			return nil, fmt.Errorf("failed to decode task snapshot %d of %s: %w", snapshot.Sequence, id, err)
+		}
+		s.sequence, s.snapshot = snapshot.Sequence, snapshot.Sequence
+	}
+
+	var events []*taskEvent
+	err = tx.SelectContext(ctx, &events,
+		`SELECT task_id, sequence, type, data, version, created_at FROM task_events
+		WHERE task_id = $1 AND workspace_id = $2 AND sequence > $3 AND ($4::timestamptz IS NULL OR created_at <= $4)
+		ORDER BY sequence`,
+		id, workspaceID, s.snapshot, at,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get task events: %w", err)
+	}
+	if !found && len(events) == 0 {
+		return s, nil
+	}
+
+	if s.task, err = replay(doc, snapshot.Version, events); err != nil {
+		return nil, err
+	}
+	if len(events) > 0 {
+		s.sequence = events[len(events)-1].Sequence
+	}
+
+	return s, nil
+}
+
+// Save stores the tasks in a single transaction like TaskRepository.Save and
+// appends an event for each of them. A task that was stored before the switch
+// to events first gets a snapshot of its stored state to replay from.
+func (r *EventTaskRepository) Save(ctx context.Context, tasks ...*aggregators.Task) error {
+	tx, err := begin(ctx, r.db)
+	if err != nil {
+		return fmt.Errorf("failed to save task: %w", err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	for _, task := range tasks {
+		if err := r.append(ctx, tx, task); err != nil {
+			return err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to save task: %w", err)
+	}
+
+	for _, task := range tasks {
+		task.Version++
+	}
+
+	return nil
+}
+
+// DeleteTag removes the tag like TaskRepository.DeleteTag, appending an event
+// for each of the tasks that had it.
+func (r *EventTaskRepository) DeleteTag(ctx context.Context, id uuid.UUID, tasks ...*aggregators.Task) error {
+	return deleteTag(ctx, r.db, r, id, tasks)
+}
+
+// Purge removes the trashed tasks like TaskRepository.Purge, appending an
+// event for each of the tasks that referenced them.
+func (r *EventTaskRepository) Purge(ctx context.Context, ids []uuid.UUID, tasks ...*aggregators.Task) (int64, error) {
+	return purge(ctx, r.db, r, ids, tasks)
+}
+
+func (r *EventTaskRepository) write(ctx context.Context, tx *sqlx.Tx, task *aggregators.Task) error {
+	return r.append(ctx, tx, task)
+}
+
+func (r *EventTaskRepository) append(ctx context.Context, tx *sqlx.Tx, task *aggregators.Task) error {
+	s, err := r.stream(ctx, tx, task.ID, nil)
+	if err != nil {
+		return err
+	}
+
+	before := s.task
+	if before == nil && task.Version != 0 {
+		if before, err = r.stored(ctx, tx, task.ID); err != nil {
+			return err
+		}
+		if before == nil {
+			return infrastructure.ErrTaskConflict
+		}
+		if err := r.snapshot(ctx, tx, before, 0); err != nil {
+			return err
+		}
+	}
+	if before != nil && before.Version != task.Version {
+		return infrastructure.ErrTaskConflict
+	}
+
+	if err := r.save(ctx, tx, task); err != nil {
+		return err
+	}
+
+	saved := *task
+	saved.Version++
+	e, err := newTaskEvent(before, &saved, s.sequence+1)
+	if err != nil {
+		return err
+	}
+	_, err = tx.ExecContext(ctx,
+		"INSERT INTO task_events (task_id, sequence, workspace_id, type, data, version, created_at) VALUES ($1, $2, $3, $4, $5, $6, $7)",
+		e.TaskID, e.Sequence, infrastructure.Workspace(ctx), e.Type, string(e.Data), e.Version, e.CreatedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to save task event: %w", err)
+	}
+
+	if e.Sequence-s.snapshot >= r.snapshotEvery {
+		return r.snapshot(ctx, tx, &saved, e.Sequence)
+	}
+
+	return nil
+}
+
+func (r *EventTaskRepository) snapshot(ctx context.Context, tx *sqlx.Tx, task *aggregators.Task, sequence int) error {
+	doc, err := document(task)
+	if err != nil {
+		return err
+	}
+	data, err := json.Marshal(doc)
+	if err != nil {
+		return fmt.Errorf("failed to encode task snapshot: %w", err)
+	}
+
+	_, err = tx.ExecContext(ctx,
+		"INSERT INTO task_snapshots (task_id, sequence, workspace_id, data, version, created_at) VALUES ($1, $2, $3, $4, $5, $6)",
+		task.ID, sequence, infrastructure.Workspace(ctx), string(data), task.Version, task.UpdatedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to save task snapshot: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,181 @@
+package postgres_test
+
+import (
+	"context"
+	"github.com/aviseu/go-sample/internal/app/infrastructure"
+	"github.com/aviseu/go-sample/internal/app/infrastructure/aggregators"
+	"github.com/aviseu/go-sample/internal/app/infrastructure/postgres"
+	"github.com/aviseu/go-sample/internal/testutils"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/suite"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestIdempotencyRepository(t *testing.T) {
+	t.Parallel()
+	suite.Run(t, new(IdempotencyRepositorySuite))
+}
+
+type IdempotencyRepositorySuite struct {
+	testutils.PostgresSuite
+}
+
+func (suite *IdempotencyRepositorySuite) TestClaimThenReplay() {
+	// Prepare
+	ctx := infrastructure.WithWorkspace(context.Background(), uuid.New())
+	r := postgres.NewIdempotencyRepository(suite.DB)
+	resp := &aggregators.IdempotentResponse{StatusCode: 201, Header: map[string][]string{"Etag": {`"1"`}}, Body: []byte(`{"id":"1"}`)}
+
+	// Execute
+	token, claimed, err1 := r.Claim(ctx, "key-1", "fp", time.Minute, time.Hour)
+	err2 := r.Complete(ctx, "key-1", token, resp)
+	_, replayed, err3 := r.Claim(ctx, "key-1", "fp", time.Minute, time.Hour)
+
+	// Assert
+	suite.NoError(err1)
+	suite.NoError(err2)
+	suite.NoError(err3)
+	suite.NotEqual(uuid.Nil, token)
+	suite.Nil(claimed)
+	suite.Equal(resp, replayed)
+}
+
+func (suite *IdempotencyRepositorySuite) TestClaimFailures() {
+	// Prepare
+	ctx := infrastructure.WithWorkspace(context.Background(), uuid.New())
+	r := postgres.NewIdempotencyRepository(suite.DB)
+	_, _, err := r.Claim(ctx, "key-1", "fp", time.Minute, time.Hour)
+	suite.Require().NoError(err)
+
+	// Execute
+	_, _, inFlight := r.Claim(ctx, "key-1", "fp", time.Minute, time.Hour)
+	_, _, reused := r.Claim(ctx, "key-1", "other", time.Minute, time.Hour)
+
+	// Assert
+	suite.ErrorIs(inFlight, infrastructure.ErrIdempotencyKeyInFlight)
+	suite.ErrorIs(reused, infrastructure.ErrIdempotencyKeyReused)
+}
+
+func (suite *IdempotencyRepositorySuite) TestConcurrentClaimsLetOneThrough() {
+	// Prepare
+	ctx := infrastructure.WithWorkspace(context.Background(), uuid.New())
+	r := postgres.NewIdempotencyRepository(suite.DB)
+	errs := make([]error, 10)
+
+	// Execute
+	var wg sync.WaitGroup
+	for i := range errs {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, _, errs[i] = r.Claim(ctx, "key-1", "fp", time.Minute, time.Hour)
+		}()
+	}
+	wg.Wait()
+
+	// Assert
+	claimed := 0
+	for _, err := range errs {
+		if err == nil {
+			claimed++
+			continue
+		}
+		suite.ErrorIs(err, infrastructure.ErrIdempotencyKeyInFlight)
+	}
+	suite.Equal(1, claimed)
+}
+
+func (suite *IdempotencyRepositorySuite) TestKeysAreScopedToWorkspace() {
+	// Prepare
+	r := postgres.NewIdempotencyRepository(suite.DB)
+	_, _, err := r.Claim(infrastructure.WithWorkspace(context.Background(), uuid.New()), "key-1", "fp", time.Minute, time.Hour)
+	suite.Require().NoError(err)
+
+	// Execute
+	_, resp, err := r.Claim(infrastructure.WithWorkspace(context.Background(), uuid.New()), "key-1", "other", time.Minute, time.Hour)
+
+	// Assert
+	suite.NoError(err)
+	suite.Nil(resp)
+}
+
+func (suite *IdempotencyRepositorySuite) TestTakeOver() {
+	// Prepare
+	ctx := infrastructure.WithWorkspace(context.Background(), uuid.New())
+	r := postgres.NewIdempotencyRepository(suite.DB)
+	_, _, err := r.Claim(ctx, "abandoned", "fp", 0, time.Hour)
+	suite.Require().NoError(err)
+	token, _, err := r.Claim(ctx, "expired", "fp", time.Minute, 0)
+	suite.Require().NoError(err)
+	suite.Require().NoError(r.Complete(ctx, "expired", token, &aggregators.IdempotentResponse{StatusCode: 201}))
+	token, _, err = r.Claim(ctx, "released", "fp", time.Minute, time.Hour)
+	suite.Require().NoError(err)
+	suite.Require().NoError(r.Release(ctx, "released", token))
+
+	// Execute
+	_, abandoned, err1 := r.Claim(ctx, "abandoned", "fp", time.Minute, time.Hour)
+	_, expired, err2 := r.Claim(ctx, "expired", "other", time.Minute, time.Hour)
+	_, released, err3 := r.Claim(ctx, "released", "fp", time.Minute, time.Hour)
+
+	// Assert
+	suite.NoError(err1)
+	suite.NoError(err2)
+	suite.NoError(err3)
+	suite.Nil(abandoned)
+	suite.Nil(expired)
+	suite.Nil(released)
+}
+
+func (suite *IdempotencyRepositorySuite) TestTakenOverClaimIsKept() {
+	// Prepare
+	ctx := infrastructure.WithWorkspace(context.Background(), uuid.New())
+	r := postgres.NewIdempotencyRepository(suite.DB)
+	stale, _, err := r.Claim(ctx, "key-1", "fp", 0, time.Hour)
+	suite.Require().NoError(err)
+	token, _, err := r.Claim(ctx, "key-1", "fp", time.Minute, time.Hour)
+	suite.Require().NoError(err)
+
+	// Execute
+	err1 := r.Complete(ctx, "key-1", stale, &aggregators.IdempotentResponse{StatusCode: 500})
+	err2 := r.Release(ctx, "key-1", stale)
+	_, _, err3 := r.Claim(ctx, "key-1", "fp", time.Minute, time.Hour)
+	err4 := r.Complete(ctx, "key-1", token, &aggregators.IdempotentResponse{StatusCode: 201})
+
+	// Assert
+	suite.ErrorIs(err1, infrastructure.ErrIdempotencyKeyLost)
+	suite.NoError(err2)
+	suite.ErrorIs(err3, infrastructure.ErrIdempotencyKeyInFlight)
+	suite.NoError(err4)
+}
+
+func (suite *IdempotencyRepositorySuite) TestPurge() {
+	// Prepare
+	ctx := infrastructure.WithWorkspace(context.Background(), uuid.New())
+	r := postgres.NewIdempotencyRepository(suite.DB)
+	_, _, err := r.Claim(ctx, "expired", "fp", time.Minute, 0)
+	suite.Require().NoError(err)
+	_, _, err = r.Claim(ctx, "kept", "fp", time.Minute, time.Hour)
+	suite.Require().NoError(err)
+
+	// Execute
+	n, err := r.Purge(context.Background())
+
+	// Assert
+	suite.NoError(err)
+	suite.Equal(int64(1), n)
+	_, _, err = r.Claim(ctx, "kept", "fp", time.Minute, time.Hour)
+	suite.ErrorIs(err, infrastructure.ErrIdempotencyKeyInFlight)
+}
+
+func (suite *IdempotencyRepositorySuite) TestBadConnection() {
+	// Prepare
+	r := postgres.NewIdempotencyRepository(suite.BadDB)
+
+	// Execute
+	_, _, err := r.Claim(context.Background(), "key-1", "fp", time.Minute, time.Hour)
+
+	// Assert
+	suite.ErrorContains(err, "failed to claim idempotency key")
+}
@@ -0,0 +1,172 @@
+package postgres
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/aviseu/go-sample/internal/app/infrastructure"
+	"github.com/aviseu/go-sample/internal/app/infrastructure/aggregators"
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"time"
+)
+
+// IdempotencyRepository remembers the responses to requests made with an
+// idempotency key, scoped to the workspace of the context.
+type IdempotencyRepository struct {
+	db *sqlx.DB
+}
+
+func NewIdempotencyRepository(db *sqlx.DB) *IdempotencyRepository {
+	return &IdempotencyRepository{db: db}
+}
+
+type idempotencyRow struct {
+	Fingerprint string `db:"fingerprint"`
+	StatusCode  *int   `db:"status_code"`
+	Header      []byte `db:"header"`
+	Body        []byte `db:"body"`
+}
+
+// Claim reserves the key for the request with the fingerprint, so that it is
+// handled only once. The key is kept for ttl and, as long as no response was
+// stored for it, is reserved for lease, after which a repeat of the request
+// may take it over. The returned token has to be passed along to complete or
+// release the claim. When the key was already used for the same request, the
+// response to it is returned instead.
+func (r *IdempotencyRepository) Claim(ctx context.Context, key, fingerprint string, lease, ttl time.Duration) (uuid.UUID, *aggregators.IdempotentResponse, error) {
+	tx, err := begin(ctx, r.db)
+	if err != nil {
+		return uuid.Nil, nil, fmt.Errorf("failed to claim idempotency key: %w", err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	workspaceID := infrastructure.Workspace(ctx)
+	token := uuid.New()
+	res, err := tx.ExecContext(ctx,
+		`INSERT INTO idempotency_keys (workspace_id, key, token, fingerprint, locked_until, expires_at)
+		VALUES ($1, $2, $6, $3, now() + $4 * interval '1 microsecond', now() + $5 * interval '1 microsecond')
+		ON CONFLICT (workspace_id, key) DO UPDATE
+		SET token = excluded.token, fingerprint = excluded.fingerprint, status_code = NULL, header = NULL, body = NULL,
+			locked_until = excluded.locked_until, expires_at = excluded.expires_at, created_at = now()
+		WHERE idempotency_keys.expires_at <= now()
+			OR (idempotency_keys.status_code IS NULL AND idempotency_keys.locked_until <= now() AND idempotency_keys.fingerprint = excluded.fingerprint)`,
+		workspaceID, key, fingerprint, lease.Microseconds(), ttl.Microseconds(), token,
+	)
+	if err != nil {
+		return uuid.Nil, nil, fmt.Errorf("failed to claim idempotency key: %w", err)
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return uuid.Nil, nil, fmt.Errorf("failed to claim idempotency key: %w", err)
+	}
+	if n == 1 {
+		if err := tx.Commit(); err != nil {
+			return uuid.Nil, nil, fmt.Errorf("failed to claim idempotency key: %w", err)
+		}
+
+		return token, nil, nil
+	}
+
+	var row idempotencyRow
+	err = tx.GetContext(ctx, &row,
+		"SELECT fingerprint, status_code, header, body FROM idempotency_keys WHERE workspace_id = $1 AND key = $2",
+		workspaceID, key,
+	)
+	if err != nil {
+		return uuid.Nil, nil, fmt.Errorf("failed to claim idempotency key: %w", err)
+	}
+	if row.Fingerprint != fingerprint {
+		return uuid.Nil, nil, infrastructure.ErrIdempotencyKeyReused
+	}
+	if row.StatusCode == nil {
+		return uuid.Nil, nil, infrastructure.ErrIdempotencyKeyInFlight
+	}
+
+	resp := &aggregators.IdempotentResponse{StatusCode: *row.StatusCode, Body: row.Body}
+	if err := json.Unmarshal(row.Header, &resp.Header); err != nil {
+		return uuid.Nil, nil, fmt.Errorf("failed to claim idempotency key: %w", err)
+	}
+
+	return uuid.Nil, resp, nil
+}
+
+// Complete stores the response to the request that claimed the key with the
+// token. A claim that was taken over in the meantime fails with
+// ErrIdempotencyKeyLost.
+func (r *IdempotencyRepository) Complete(ctx context.Context, key string, token uuid.UUID, resp *aggregators.IdempotentResponse) error {
+	header, err := json.Marshal(resp.Header)
+	if err != nil {
+		return fmt.Errorf("failed to complete idempotency key: %w", err)
+	}
+
+	tx, err := begin(ctx, r.db)
+	if err != nil {
+		return fmt.Errorf("failed to complete idempotency key: %w", err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	res, err := tx.ExecContext(ctx,
+		`UPDATE idempotency_keys SET status_code = $4, header = $5, body = $6
+		WHERE workspace_id = $1 AND key = $2 AND token = $3 AND status_code IS NULL`,
+		infrastructure.Workspace(ctx), key, token, resp.StatusCode, string(header), resp.Body,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to complete idempotency key: %w", err)
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to complete idempotency key: %w", err)
+	}
+	if n == 0 {
+		return infrastructure.ErrIdempotencyKeyLost
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to complete idempotency key: %w", err)
+	}
+
+	return nil
+}
+
+// Release gives up the claim with the token on a key without a response, so
+// that the request can be retried right away. A claim that was taken over in
+// the meantime is left alone.
+func (r *IdempotencyRepository) Release(ctx context.Context, key string, token uuid.UUID) error {
+	tx, err := begin(ctx, r.db)
+	if err != nil {
+		return fmt.Errorf("failed to release idempotency key: %w", err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	_, err = tx.ExecContext(ctx,
+		"DELETE FROM idempotency_keys WHERE workspace_id = $1 AND key = $2 AND token = $3 AND status_code IS NULL",
+		infrastructure.Workspace(ctx), key, token,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to release idempotency key: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to release idempotency key: %w", err)
+	}
+
+	return nil
+}
+
+// Purge removes the expired keys of all workspaces.
+func (r *IdempotencyRepository) Purge(ctx context.Context) (int64, error) {
+	res, err := r.db.ExecContext(ctx, "DELETE FROM idempotency_keys WHERE expires_at <= now()")
+	if err != nil {
+		return 0, fmt.Errorf("failed to purge idempotency keys: %w", err)
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("failed to purge idempotency keys: %w", err)
+	}
+
+	return n, nil
+}
@@ -0,0 +1,205 @@
+package postgres_test
+
+import (
+	"context"
+	"github.com/aviseu/go-sample/internal/app/infrastructure"
+	"github.com/aviseu/go-sample/internal/app/infrastructure/aggregators"
+	"github.com/aviseu/go-sample/internal/app/infrastructure/postgres"
+	"github.com/aviseu/go-sample/internal/testutils"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/suite"
+	"testing"
+	"time"
+)
+
+func TestOutboxRepository(t *testing.T) {
+	t.Parallel()
+	suite.Run(t, new(OutboxRepositorySuite))
+}
+
+type OutboxRepositorySuite struct {
+	testutils.PostgresSuite
+}
+
+func (suite *OutboxRepositorySuite) insertEvent(taskID uuid.UUID, typ string) uuid.UUID {
+	id := uuid.New()
+	_, err := suite.DB.Exec(
+		"INSERT INTO outbox (id, workspace_id, task_id, type, payload, occurred_at) VALUES ($1, $2, $3, $4, '{}', now())",
+		id, infrastructure.DefaultWorkspaceID, taskID, typ,
+	)
+	suite.NoError(err)
+
+	return id
+}
+
+func (suite *OutboxRepositorySuite) TestSaveAddsEventsToOutbox() {
+	// Prepare
+	id, ws := uuid.New(), uuid.New()
+	occurredAt := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+	r := postgres.NewTaskRepository(suite.DB)
+	task := &aggregators.Task{ID: id, ProjectID: aggregators.InboxProjectID, Title: "task 1", Status: "todo", CreatedAt: occurredAt, UpdatedAt: occurredAt, Events: []*aggregators.Event{
+		{ID: uuid.New(), TaskID: id, Type: "task.created", Payload: []byte(`{"title":"task 1"}`), OccurredAt: occurredAt},
+	}}
+
+	// Execute
+	err := r.Save(infrastructure.WithWorkspace(context.Background(), ws), task)
+
+	// Assert
+	suite.NoError(err)
+	events, err := postgres.NewOutboxRepository(suite.DB).Claim(context.Background(), 10, time.Minute)
+	suite.NoError(err)
+	suite.Require().Len(events, 1)
+	suite.Equal(task.Events[0].ID, events[0].ID)
+	suite.Equal(ws, events[0].WorkspaceID)
+	suite.Equal(id, events[0].TaskID)
+	suite.Equal("task.created", events[0].Type)
+	suite.JSONEq(`{"title":"task 1"}`, string(events[0].Payload))
+	suite.True(occurredAt.Equal(events[0].OccurredAt))
+	suite.Equal(0, events[0].Attempts)
+}
+
+func (suite *OutboxRepositorySuite) TestDeleteTagAddsEventsToOutbox() {
+	// Prepare
+	id, tagID, ws := uuid.New(), uuid.New(), uuid.New()
+	ctx := infrastructure.WithWorkspace(context.Background(), ws)
+	now := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+	r := postgres.NewTaskRepository(suite.DB)
+	suite.NoError(r.SaveTag(ctx, &aggregators.Tag{ID: tagID, Name: "work"}))
+	task := &aggregators.Task{ID: id, ProjectID: aggregators.InboxProjectID, Title: "task 1", Status: "todo", CreatedAt: now, UpdatedAt: now, Tags: []string{"work"}}
+	suite.NoError(r.Save(ctx, task))
+	task.Tags = nil
+	task.Events = []*aggregators.Event{{ID: uuid.New(), TaskID: id, Type: "task.updated", Payload: []byte(`{}`), OccurredAt: now}}
+
+	// Execute
+	err := r.DeleteTag(ctx, tagID, task)
+
+	// Assert
+	suite.NoError(err)
+	events, err := postgres.NewOutboxRepository(suite.DB).Claim(context.Background(), 10, time.Minute)
+	suite.NoError(err)
+	suite.Require().Len(events, 1)
+	suite.Equal(task.Events[0].ID, events[0].ID)
+	suite.Equal(ws, events[0].WorkspaceID)
+	suite.Equal("task.updated", events[0].Type)
+}
+
+func (suite *OutboxRepositorySuite) TestDeleteProjectAddsEventsToOutbox() {
+	// Prepare
+	id, projectID, ws := uuid.New(), uuid.New(), uuid.New()
+	ctx := infrastructure.WithWorkspace(context.Background(), ws)
+	now := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+	r := postgres.NewTaskRepository(suite.DB)
+	pr := postgres.NewProjectRepository(suite.DB, r)
+	suite.NoError(pr.Save(ctx, &aggregators.Project{ID: projectID, Name: "work", CreatedAt: now, UpdatedAt: now}))
+	task := &aggregators.Task{ID: id, ProjectID: projectID, Title: "task 1", Status: "todo", CreatedAt: now, UpdatedAt: now}
+	suite.NoError(r.Save(ctx, task))
+	task.ProjectID = aggregators.InboxProjectID
+	task.Events = []*aggregators.Event{{ID: uuid.New(), TaskID: id, Type: "task.updated", Payload: []byte(`{}`), OccurredAt: now}}
+
+	// Execute
+	err := pr.Delete(ctx, projectID, task)
+
+	// Assert
+	suite.NoError(err)
+	events, err := postgres.NewOutboxRepository(suite.DB).Claim(context.Background(), 10, time.Minute)
+	suite.NoError(err)
+	suite.Require().Len(events, 1)
+	suite.Equal(task.Events[0].ID, events[0].ID)
+	suite.Equal(ws, events[0].WorkspaceID)
+	suite.Equal("task.updated", events[0].Type)
+}
+
+func (suite *OutboxRepositorySuite) TestClaimInOrderPerTask() {
+	// Prepare
+	task1, task2 := uuid.New(), uuid.New()
+	created1 := suite.insertEvent(task1, "task.created")
+	completed1 := suite.insertEvent(task1, "task.completed")
+	created2 := suite.insertEvent(task2, "task.created")
+	r := postgres.NewOutboxRepository(suite.DB)
+
+	// Execute
+	first, err1 := r.Claim(context.Background(), 10, time.Minute)
+	suite.NoError(r.Delivered(context.Background(), created1))
+	second, err2 := r.Claim(context.Background(), 10, time.Minute)
+
+	// Assert
+	suite.NoError(err1)
+	suite.NoError(err2)
+	suite.Require().Len(first, 2)
+	suite.ElementsMatch([]uuid.UUID{created1, created2}, []uuid.UUID{first[0].ID, first[1].ID})
+	suite.Require().Len(second, 1)
+	suite.Equal(completed1, second[0].ID)
+}
+
+func (suite *OutboxRepositorySuite) TestClaimSkipsLeasedEvents() {
+	// Prepare
+	suite.insertEvent(uuid.New(), "task.created")
+	r := postgres.NewOutboxRepository(suite.DB)
+	_, err := r.Claim(context.Background(), 10, time.Minute)
+	suite.NoError(err)
+
+	// Execute
+	events, err := r.Claim(context.Background(), 10, time.Minute)
+
+	// Assert
+	suite.NoError(err)
+	suite.Empty(events)
+}
+
+func (suite *OutboxRepositorySuite) TestFailedRetriesLater() {
+	// Prepare
+	id := suite.insertEvent(uuid.New(), "task.created")
+	r := postgres.NewOutboxRepository(suite.DB)
+
+	// Execute
+	err := r.Failed(context.Background(), id, "unavailable", time.Now().Add(time.Hour), false)
+
+	// Assert
+	suite.NoError(err)
+	var row struct {
+		Status    string `db:"status"`
+		Attempts  int    `db:"attempts"`
+		LastError string `db:"last_error"`
+	}
+	suite.NoError(suite.DB.Get(&row, "SELECT status, attempts, last_error FROM outbox WHERE id = $1", id))
+	suite.Equal("pending", row.Status)
+	suite.Equal(1, row.Attempts)
+	suite.Equal("unavailable", row.LastError)
+	events, err := r.Claim(context.Background(), 10, time.Minute)
+	suite.NoError(err)
+	suite.Empty(events)
+}
+
+func (suite *OutboxRepositorySuite) TestFailedDeadUnblocksTask() {
+	// Prepare
+	taskID := uuid.New()
+	created := suite.insertEvent(taskID, "task.created")
+	completed := suite.insertEvent(taskID, "task.completed")
+	r := postgres.NewOutboxRepository(suite.DB)
+
+	// Execute
+	err := r.Failed(context.Background(), created, "rejected", time.Now(), true)
+
+	// Assert
+	suite.NoError(err)
+	var status string
+	suite.NoError(suite.DB.Get(&status, "SELECT status FROM outbox WHERE id = $1", created))
+	suite.Equal("dead", status)
+	events, err := r.Claim(context.Background(), 10, time.Minute)
+	suite.NoError(err)
+	suite.Require().Len(events, 1)
+	suite.Equal(completed, events[0].ID)
+}
+
+func (suite *OutboxRepositorySuite) TestClaimFail() {
+	// Prepare
+	r := postgres.NewOutboxRepository(suite.BadDB)
+
+	// Execute
+	events, err := r.Claim(context.Background(), 10, time.Minute)
+
+	// Assert
+	suite.Error(err)
+	suite.Nil(events)
+	suite.ErrorContains(err, "sql: database is closed")
+}
@@ -0,0 +1,74 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"github.com/aviseu/go-sample/internal/app/infrastructure/aggregators"
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"time"
+)
+
+// OutboxRepository hands the events in the outbox to the relay. It works across
+// all workspaces and therefore never switches to the tenant role.
+type OutboxRepository struct {
+	db *sqlx.DB
+}
+
+func NewOutboxRepository(db *sqlx.DB) *OutboxRepository {
+	return &OutboxRepository{db: db}
+}
+
+// Claim leases up to limit events that are due for delivery. Only the oldest
+// pending event of a task is ever claimed, so the events of a task are
+// delivered in order. A claimed event is not claimed again until its lease
+// expires, which makes a relay that stopped halfway deliver it again.
+func (r *OutboxRepository) Claim(ctx context.Context, limit int, lease time.Duration) ([]*aggregators.Event, error) {
+	var events []*aggregators.Event
+	err := r.db.SelectContext(ctx, &events,
+		`UPDATE outbox SET locked_until = now() + $2 * interval '1 microsecond'
+		WHERE id IN (
+			SELECT o.id FROM outbox o
+			WHERE o.status = 'pending' AND o.available_at <= now() AND (o.locked_until IS NULL OR o.locked_until <= now())
+				AND NOT EXISTS (SELECT 1 FROM outbox p WHERE p.task_id = o.task_id AND p.status = 'pending' AND p.sequence < o.sequence)
+			ORDER BY o.sequence
+			LIMIT $1
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING id, workspace_id, task_id, type, payload, occurred_at, attempts`,
+		limit, lease.Microseconds(),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to claim events: %w", err)
+	}
+
+	return events, nil
+}
+
+func (r *OutboxRepository) Delivered(ctx context.Context, id uuid.UUID) error {
+	_, err := r.db.ExecContext(ctx,
+		"UPDATE outbox SET status = 'delivered', delivered_at = now(), locked_until = NULL WHERE id = $1",
+		id,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to mark event as delivered: %w", err)
+	}
+
+	return nil
+}
+
+// Failed records a failed delivery. The event is retried from retryAt on,
+// unless it is dead, which also unblocks the events of its task that follow.
+func (r *OutboxRepository) Failed(ctx context.Context, id uuid.UUID, reason string, retryAt time.Time, dead bool) error {
+	_, err := r.db.ExecContext(ctx,
+		`UPDATE outbox SET attempts = attempts + 1, last_error = $2, available_at = $3, locked_until = NULL,
+			status = CASE WHEN $4 THEN 'dead' ELSE 'pending' END
+		WHERE id = $1`,
+		id, reason, retryAt, dead,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to mark event as failed: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,186 @@
+package postgres_test
+
+import (
+	"context"
+	"github.com/aviseu/go-sample/internal/app/infrastructure"
+	"github.com/aviseu/go-sample/internal/app/infrastructure/aggregators"
+	"github.com/aviseu/go-sample/internal/app/infrastructure/postgres"
+	"github.com/aviseu/go-sample/internal/testutils"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/suite"
+	"testing"
+	"time"
+)
+
+func TestProjectRepository(t *testing.T) {
+	t.Parallel()
+	suite.Run(t, new(ProjectRepositorySuite))
+}
+
+type ProjectRepositorySuite struct {
+	testutils.PostgresSuite
+}
+
+func (suite *ProjectRepositorySuite) TestAllSuccess() {
+	// Prepare
+	id1, id2 := uuid.New(), uuid.New()
+	_, err := suite.DB.Exec("INSERT INTO projects (id, name) VALUES ($1, $2), ($3, $4)", id2, "work", id1, "home")
+	suite.NoError(err)
+	r := postgres.NewProjectRepository(suite.DB, postgres.NewTaskRepository(suite.DB))
+
+	// Execute
+	projects, err := r.All(context.Background())
+
+	// Assert
+	suite.NoError(err)
+	suite.Len(projects, 3)
+	suite.Equal(aggregators.InboxProjectID, projects[0].ID)
+	suite.Equal("Inbox", projects[0].Name)
+	suite.True(projects[0].Inbox)
+	suite.Equal(id1, projects[1].ID)
+	suite.Equal(id2, projects[2].ID)
+}
+
+func (suite *ProjectRepositorySuite) TestAllRepositoryFail() {
+	// Prepare
+	r := postgres.NewProjectRepository(suite.BadDB, postgres.NewTaskRepository(suite.BadDB))
+
+	// Execute
+	projects, err := r.All(context.Background())
+
+	// Assert
+	suite.Nil(projects)
+	suite.ErrorContains(err, "failed to get projects")
+	suite.ErrorContains(err, "sql: database is closed")
+}
+
+func (suite *ProjectRepositorySuite) TestFindNotFound() {
+	// Prepare
+	r := postgres.NewProjectRepository(suite.DB, postgres.NewTaskRepository(suite.DB))
+
+	// Execute
+	project, err := r.Find(context.Background(), uuid.New())
+
+	// Assert
+	suite.Nil(project)
+	suite.ErrorIs(err, infrastructure.ErrProjectNotFound)
+}
+
+func (suite *ProjectRepositorySuite) TestSaveSuccess() {
+	// Prepare
+	now := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+	project := &aggregators.Project{ID: uuid.New(), Name: "work", CreatedAt: now, UpdatedAt: now}
+	r := postgres.NewProjectRepository(suite.DB, postgres.NewTaskRepository(suite.DB))
+
+	// Execute
+	err1 := r.Save(context.Background(), project)
+	project.Name = "office"
+	err2 := r.Save(context.Background(), project)
+
+	// Assert
+	suite.NoError(err1)
+	suite.NoError(err2)
+	suite.Equal(2, project.Version)
+
+	found, err := r.Find(context.Background(), project.ID)
+	suite.NoError(err)
+	suite.Equal("office", found.Name)
+	suite.False(found.Inbox)
+	suite.True(now.Equal(found.CreatedAt))
+	suite.Equal(2, found.Version)
+}
+
+func (suite *ProjectRepositorySuite) TestSaveStaleVersionConflict() {
+	// Prepare
+	id := uuid.New()
+	_, err := suite.DB.Exec("INSERT INTO projects (id, name, version) VALUES ($1, $2, $3)", id, "work", 3)
+	suite.NoError(err)
+	project := &aggregators.Project{ID: id, Name: "office", Version: 2}
+	r := postgres.NewProjectRepository(suite.DB, postgres.NewTaskRepository(suite.DB))
+
+	// Execute
+	err = r.Save(context.Background(), project)
+
+	// Assert
+	suite.ErrorIs(err, infrastructure.ErrProjectConflict)
+	suite.Equal(2, project.Version)
+}
+
+func (suite *ProjectRepositorySuite) TestDeleteSuccess() {
+	// Prepare
+	id, taskID := uuid.New(), uuid.New()
+	_, err := suite.DB.Exec("INSERT INTO projects (id, name) VALUES ($1, $2)", id, "work")
+	suite.NoError(err)
+	_, err = suite.DB.Exec("INSERT INTO tasks (id, project_id, title, status, deleted_at) VALUES ($1, $2, $3, $4, now())", taskID, id, "task 1", "todo")
+	suite.NoError(err)
+	r := postgres.NewProjectRepository(suite.DB, postgres.NewTaskRepository(suite.DB))
+	tasks, err := r.Tasks(context.Background(), id)
+	suite.NoError(err)
+	suite.Len(tasks, 1)
+	tasks[0].ProjectID = aggregators.InboxProjectID
+
+	// Execute
+	err = r.Delete(context.Background(), id, tasks...)
+
+	// Assert
+	suite.NoError(err)
+	_, err = r.Find(context.Background(), id)
+	suite.ErrorIs(err, infrastructure.ErrProjectNotFound)
+
+	var task aggregators.Task
+	suite.NoError(suite.DB.Get(&task, "SELECT id, project_id, version FROM tasks WHERE id = $1", taskID))
+	suite.Equal(aggregators.InboxProjectID, task.ProjectID)
+	suite.Equal(2, task.Version)
+	suite.Equal(2, tasks[0].Version)
+
+	var actions []string
+	suite.NoError(suite.DB.Select(&actions, "SELECT action FROM task_history WHERE task_id = $1", taskID))
+	suite.Equal([]string{infrastructure.ActionUpdated}, actions)
+}
+
+func (suite *ProjectRepositorySuite) TestDeleteFail() {
+	// Prepare
+	id := uuid.New()
+	_, err := suite.DB.Exec("INSERT INTO projects (id, name) VALUES ($1, $2)", id, "work")
+	suite.NoError(err)
+	_, err = suite.DB.Exec("INSERT INTO tasks (id, project_id, title, status) VALUES ($1, $2, $3, $4)", uuid.New(), id, "task 1", "todo")
+	suite.NoError(err)
+	r := postgres.NewProjectRepository(suite.DB, postgres.NewTaskRepository(suite.DB))
+
+	// Execute
+	err1 := r.Delete(context.Background(), uuid.New())
+	err2 := r.Delete(context.Background(), aggregators.InboxProjectID)
+	err3 := r.Delete(context.Background(), id)
+
+	// Assert
+	suite.ErrorIs(err1, infrastructure.ErrProjectNotFound)
+	suite.ErrorIs(err2, infrastructure.ErrProjectNotFound)
+	suite.ErrorIs(err3, infrastructure.ErrProjectConflict)
+	_, err = r.Find(context.Background(), aggregators.InboxProjectID)
+	suite.NoError(err)
+	_, err = r.Find(context.Background(), id)
+	suite.NoError(err)
+}
+
+func (suite *ProjectRepositorySuite) TestWorkspaceIsolation() {
+	// Prepare
+	workspaceID := uuid.New()
+	id := uuid.New()
+	_, err := suite.DB.Exec("INSERT INTO projects (id, workspace_id, name) VALUES ($1, $2, $3)", id, workspaceID, "work")
+	suite.NoError(err)
+	r := postgres.NewProjectRepository(suite.DB, postgres.NewTaskRepository(suite.DB))
+
+	// Execute
+	own, err1 := r.All(infrastructure.WithWorkspace(context.Background(), workspaceID))
+	project, err2 := r.Find(context.Background(), id)
+	inbox, err3 := r.Find(context.Background(), aggregators.InboxProjectID)
+
+	// Assert
+	suite.NoError(err1)
+	suite.Len(own, 2)
+	suite.Equal(id, own[1].ID)
+	suite.Nil(project)
+	suite.ErrorIs(err2, infrastructure.ErrProjectNotFound)
+	suite.NoError(err3)
+	suite.True(inbox.Inbox)
+}
@@ -0,0 +1,165 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"github.com/aviseu/go-sample/internal/app/infrastructure"
+	"github.com/aviseu/go-sample/internal/app/infrastructure/aggregators"
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+)
+
+const projectColumns = "id, name, inbox, created_at, updated_at, version"
+
+// ProjectRepository scopes every query to the workspace of the context. The
+// inbox belongs to no workspace and is shared by all of them. The tasks of a
+// deleted project are written through the task repository they are stored
+// with.
+type ProjectRepository struct {
+	db    *sqlx.DB
+	tasks TaskStore
+}
+
+func NewProjectRepository(db *sqlx.DB, tasks TaskStore) *ProjectRepository {
+	return &ProjectRepository{db: db, tasks: tasks}
+}
+
+// All returns the inbox followed by the other projects by name.
+func (r *ProjectRepository) All(ctx context.Context) ([]*aggregators.Project, error) {
+	var projects []*aggregators.Project
+	err := r.db.SelectContext(ctx, &projects,
+		"SELECT "+projectColumns+" FROM projects WHERE workspace_id = $1 OR inbox ORDER BY inbox DESC, name, id",
+		infrastructure.Workspace(ctx),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get projects: %w", err)
+	}
+
+	return projects, nil
+}
+
+func (r *ProjectRepository) Find(ctx context.Context, id uuid.UUID) (*aggregators.Project, error) {
+	var project aggregators.Project
+	err := r.db.GetContext(ctx, &project,
+		"SELECT "+projectColumns+" FROM projects WHERE id = $1 AND (workspace_id = $2 OR inbox)",
+		id, infrastructure.Workspace(ctx),
+	)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, infrastructure.ErrProjectNotFound
+		}
+		return nil, fmt.Errorf("failed to find project: %w", err)
+	}
+
+	return &project, nil
+}
+
+// Save inserts a project that has no version yet and otherwise updates it only
+// when the stored version still matches, bumping project.Version on success.
+func (r *ProjectRepository) Save(ctx context.Context, project *aggregators.Project) error {
+	query := `UPDATE projects SET name = :name, updated_at = :updated_at, version = version + 1
+		WHERE id = :id AND workspace_id = :workspace_id AND version = :version`
+	if project.Version == 0 {
+		query = `INSERT INTO projects (id, workspace_id, name, created_at, updated_at, version)
+		VALUES (:id, :workspace_id, :name, :created_at, :updated_at, 1)
+		ON CONFLICT DO NOTHING`
+	}
+
+	res, err := r.db.NamedExecContext(ctx, query, struct {
+		*aggregators.Project
+		WorkspaceID uuid.UUID `db:"workspace_id"`
+	}{project, infrastructure.Workspace(ctx)})
+	if err != nil {
+		return fmt.Errorf("failed to save project: %w", err)
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to save project: %w", err)
+	}
+	if n == 0 {
+		return infrastructure.ErrProjectConflict
+	}
+
+	project.Version++
+
+	return nil
+}
+
+// Tasks returns the tasks in the project, trashed ones included.
+func (r *ProjectRepository) Tasks(ctx context.Context, id uuid.UUID) ([]*aggregators.Task, error) {
+	tx, err := begin(ctx, r.db)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get project tasks: %w", err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	var tasks []*aggregators.Task
+	err = tx.SelectContext(ctx, &tasks,
+		"SELECT "+taskColumns+" FROM tasks WHERE project_id = $1 AND workspace_id = $2 ORDER BY id",
+		id, infrastructure.Workspace(ctx),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get project tasks: %w", err)
+	}
+	if err := r.tasks.loadRelations(ctx, tx, tasks...); err != nil {
+		return nil, err
+	}
+
+	return tasks, nil
+}
+
+// Delete removes the project after writing its tasks, which should have been
+// moved out of it, through the task repository in the same transaction. The
+// project is locked first, and a task that was added to it in the meantime
+// makes it fail with a conflict.
+func (r *ProjectRepository) Delete(ctx context.Context, id uuid.UUID, tasks ...*aggregators.Task) error {
+	tx, err := begin(ctx, r.db)
+	if err != nil {
+		return fmt.Errorf("failed to delete project: %w", err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	workspaceID := infrastructure.Workspace(ctx)
+	var found bool
+	err = tx.GetContext(ctx, &found,
+		"SELECT EXISTS (SELECT 1 FROM projects WHERE id = $1 AND workspace_id = $2 FOR UPDATE)",
+		id, workspaceID,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to delete project: %w", err)
+	}
+	if !found {
+		return infrastructure.ErrProjectNotFound
+	}
+
+	for _, task := range tasks {
+		if err := r.tasks.write(ctx, tx, task); err != nil {
+			return err
+		}
+	}
+
+	var filed bool
+	if err := tx.GetContext(ctx, &filed, "SELECT EXISTS (SELECT 1 FROM tasks WHERE project_id = $1)", id); err != nil {
+		return fmt.Errorf("failed to delete project: %w", err)
+	}
+	if filed {
+		return infrastructure.ErrProjectConflict
+	}
+
+	if _, err := tx.ExecContext(ctx, "DELETE FROM projects WHERE id = $1 AND workspace_id = $2", id, workspaceID); err != nil {
+		return fmt.Errorf("failed to delete project: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to delete project: %w", err)
+	}
+
+	for _, task := range tasks {
+		task.Version++
+	}
+
+	return nil
+}
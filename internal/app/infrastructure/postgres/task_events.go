@@ -0,0 +1,119 @@
+package postgres
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/aviseu/go-sample/internal/app/infrastructure"
+	"github.com/aviseu/go-sample/internal/app/infrastructure/aggregators"
+	"github.com/google/uuid"
+	"reflect"
+	"time"
+)
+
+var eventTypes = map[string]string{
+	infrastructure.ActionCreated:   "TaskCreated",
+	infrastructure.ActionCompleted: "TaskCompleted",
+	infrastructure.ActionDeleted:   "TaskDeleted",
+	infrastructure.ActionRestored:  "TaskRestored",
+	infrastructure.ActionUpdated:   "TaskUpdated",
+}
+
+// taskEvent is what a single save did to a task. Its data is a JSON merge
+// patch on the task without its version, which the event holds itself.
+type taskEvent struct {
+	TaskID    uuid.UUID       `db:"task_id"`
+	Sequence  int             `db:"sequence"`
+	Type      string          `db:"type"`
+	Data      json.RawMessage `db:"data"`
+	Version   int             `db:"version"`
+	CreatedAt time.Time       `db:"created_at"`
+}
+
+// newTaskEvent describes how before, which is nil for a new task, became after.
+// After has to hold the version the task gets once saved.
+func newTaskEvent(before, after *aggregators.Task, sequence int) (*taskEvent, error) {
+	from, err := document(before)
+	if err != nil {
+		return nil, err
+	}
+	to, err := document(after)
+	if err != nil {
+		return nil, err
+	}
+
+	patch := make(map[string]any)
+	for k, v := range to {
+		if old, ok := from[k]; !ok || !reflect.DeepEqual(old, v) {
+			patch[k] = v
+		}
+	}
+	for k := range from {
+		if _, ok := to[k]; !ok {
+			patch[k] = nil
+		}
+	}
+
+	data, err := json.Marshal(patch)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode task event: %w", err)
+	}
+
+	return &taskEvent{
+		TaskID:    after.ID,
+		Sequence:  sequence,
+		Type:      eventTypes[infrastructure.ChangeAction(before, after)],
+		Data:      data,
+		Version:   after.Version,
+		CreatedAt: after.UpdatedAt,
+	}, nil
+}
+
+// document is the JSON object of a task that events patch and snapshots hold.
+func document(t *aggregators.Task) (map[string]any, error) {
+	doc := make(map[string]any)
+	if t == nil {
+		return doc, nil
+	}
+
+	b, err := json.Marshal(t)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode task: %w", err)
+	}
+	if err := json.Unmarshal(b, &doc); err != nil {
+		return nil, fmt.Errorf("failed to encode task: %w", err)
+	}
+	delete(doc, "version")
+
+	return doc, nil
+}
+
+// replay applies the events in order to the document of a snapshot with the
+// given version, which is empty for a task without a snapshot.
+func replay(doc map[string]any, version int, events []*taskEvent) (*aggregators.Task, error) {
+	for _, e := range events {
+		var patch map[string]any
+		if err := json.Unmarshal(e.Data, &patch); err != nil {
+			return nil, fmt.Errorf("failed to decode task event %d of %s: %w", e.Sequence, e.TaskID, err)
+		}
+		for k, v := range patch {
+			if v == nil {
+				delete(doc, k)
+			} else {
+				doc[k] = v
+			}
+		}
+		version = e.Version
+	}
+
+	b, err := json.Marshal(doc)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode task: %w", err)
+	}
+	var task aggregators.Task
+	if err := json.Unmarshal(b, &task); err != nil {
+		return nil, fmt.Errorf("failed to decode task: %w", err)
+	}
+	task.Version = version
+
+	return &task, nil
+}
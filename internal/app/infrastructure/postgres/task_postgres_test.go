@@ -9,6 +9,7 @@ import (
 	"github.com/google/uuid"
 	"github.com/stretchr/testify/suite"
 	"testing"
+	"time"
 )
 
 func TestTaskRepository(t *testing.T) {
@@ -20,24 +21,25 @@ type TaskRepositorySuite struct {
 	testutils.PostgresSuite
 }
 
-func (suite *TaskRepositorySuite) TestAllSuccess() {
+func (suite *TaskRepositorySuite) TestListSuccess() {
 	// Prepare
 	id3 := uuid.New()
-	_, err := suite.DB.Exec("INSERT INTO tasks (id, title, completed) VALUES ($1, $2, $3)", id3.String(), "task 3", false)
+	_, err := suite.DB.Exec("INSERT INTO tasks (id, title, status) VALUES ($1, $2, $3)", id3.String(), "task 3", "todo")
 	suite.NoError(err)
 	id1 := uuid.New()
-	_, err = suite.DB.Exec("INSERT INTO tasks (id, title, completed) VALUES ($1, $2, $3)", id1.String(), "task 1", false)
+	_, err = suite.DB.Exec("INSERT INTO tasks (id, title, status) VALUES ($1, $2, $3)", id1.String(), "task 1", "todo")
 	suite.NoError(err)
 	id2 := uuid.New()
-	_, err = suite.DB.Exec("INSERT INTO tasks (id, title, completed) VALUES ($1, $2, $3)", id2.String(), "task 2", false)
+	_, err = suite.DB.Exec("INSERT INTO tasks (id, title, status) VALUES ($1, $2, $3)", id2.String(), "task 2", "todo")
 	suite.NoError(err)
 	r := postgres.NewTaskRepository(suite.DB)
 
 	// Execute
-	tasks, err := r.All(context.Background())
+	tasks, next, err := r.List(context.Background(), infrastructure.TaskFilter{Sort: infrastructure.SortTitle}, infrastructure.Page{Limit: 10})
 
 	// Assert
 	suite.NoError(err)
+	suite.Nil(next)
 	suite.Len(tasks, 3)
 	suite.Equal(id1, tasks[0].ID)
 	suite.Equal("task 1", tasks[0].Title)
@@ -47,15 +49,109 @@ func (suite *TaskRepositorySuite) TestAllSuccess() {
 	suite.Equal("task 3", tasks[2].Title)
 }
 
-func (suite *TaskRepositorySuite) TestAllRepositoryFail() {
+func (suite *TaskRepositorySuite) TestListPaginatedSuccess() {
+	// Prepare
+	id1 := uuid.MustParse("00000000-0000-0000-0000-000000000001")
+	id2 := uuid.MustParse("00000000-0000-0000-0000-000000000002")
+	id3 := uuid.New()
+	for _, t := range []struct {
+		id    uuid.UUID
+		title string
+	}{{id3, "task 2"}, {id2, "task 1"}, {id1, "task 1"}} {
+		_, err := suite.DB.Exec("INSERT INTO tasks (id, title, status) VALUES ($1, $2, $3)", t.id.String(), t.title, "todo")
+		suite.NoError(err)
+	}
+	r := postgres.NewTaskRepository(suite.DB)
+
+	// Execute
+	page1, next1, err1 := r.List(context.Background(), infrastructure.TaskFilter{Sort: infrastructure.SortTitle}, infrastructure.Page{Limit: 2})
+	page2, next2, err2 := r.List(context.Background(), infrastructure.TaskFilter{Sort: infrastructure.SortTitle}, infrastructure.Page{Limit: 2, After: next1})
+
+	// Assert
+	suite.NoError(err1)
+	suite.Len(page1, 2)
+	suite.Equal(id1, page1[0].ID)
+	suite.Equal(id2, page1[1].ID)
+	suite.Equal(&infrastructure.Cursor{Sort: infrastructure.SortTitle, Title: "task 1", ID: id2}, next1)
+
+	suite.NoError(err2)
+	suite.Len(page2, 1)
+	suite.Equal(id3, page2[0].ID)
+	suite.Nil(next2)
+}
+
+func (suite *TaskRepositorySuite) TestListFilteredSuccess() {
+	// Prepare
+	id1 := uuid.New()
+	_, err := suite.DB.Exec("INSERT INTO tasks (id, title, status) VALUES ($1, $2, $3)", id1.String(), "buy milk", "todo")
+	suite.NoError(err)
+	id2 := uuid.New()
+	_, err = suite.DB.Exec("INSERT INTO tasks (id, title, status) VALUES ($1, $2, $3)", id2.String(), "Buy 100% cotton", "todo")
+	suite.NoError(err)
+	_, err = suite.DB.Exec("INSERT INTO tasks (id, title, status) VALUES ($1, $2, $3)", uuid.New().String(), "buy bread", "done")
+	suite.NoError(err)
+	_, err = suite.DB.Exec("INSERT INTO tasks (id, title, status) VALUES ($1, $2, $3)", uuid.New().String(), "walk dog", "todo")
+	suite.NoError(err)
+	r := postgres.NewTaskRepository(suite.DB)
+	completed := false
+
+	// Execute
+	tasks, next, err := r.List(context.Background(), infrastructure.TaskFilter{Completed: &completed, TitleContains: "BUY", Sort: infrastructure.SortTitleDesc}, infrastructure.Page{Limit: 10})
+	escaped, _, escapedErr := r.List(context.Background(), infrastructure.TaskFilter{TitleContains: "0%", Sort: infrastructure.SortTitle}, infrastructure.Page{Limit: 10})
+
+	// Assert
+	suite.NoError(err)
+	suite.Nil(next)
+	suite.Len(tasks, 2)
+	suite.Equal(id1, tasks[0].ID)
+	suite.Equal(id2, tasks[1].ID)
+
+	suite.NoError(escapedErr)
+	suite.Len(escaped, 1)
+	suite.Equal(id2, escaped[0].ID)
+}
+
+func (suite *TaskRepositorySuite) TestListSortedByCreatedAtSuccess() {
+	// Prepare
+	id1 := uuid.New()
+	_, err := suite.DB.Exec("INSERT INTO tasks (id, title, status, created_at) VALUES ($1, $2, $3, now() - interval '2 hours')", id1.String(), "task b", "todo")
+	suite.NoError(err)
+	id2 := uuid.New()
+	_, err = suite.DB.Exec("INSERT INTO tasks (id, title, status, created_at) VALUES ($1, $2, $3, now() - interval '1 hour')", id2.String(), "task a", "todo")
+	suite.NoError(err)
+	id3 := uuid.New()
+	_, err = suite.DB.Exec("INSERT INTO tasks (id, title, status, created_at) VALUES ($1, $2, $3, now())", id3.String(), "task c", "todo")
+	suite.NoError(err)
+	r := postgres.NewTaskRepository(suite.DB)
+	f := infrastructure.TaskFilter{Sort: infrastructure.SortCreatedAtDesc}
+
+	// Execute
+	page1, next1, err1 := r.List(context.Background(), f, infrastructure.Page{Limit: 2})
+	page2, next2, err2 := r.List(context.Background(), f, infrastructure.Page{Limit: 2, After: next1})
+
+	// Assert
+	suite.NoError(err1)
+	suite.Len(page1, 2)
+	suite.Equal(id3, page1[0].ID)
+	suite.Equal(id2, page1[1].ID)
+	suite.NotNil(next1)
+
+	suite.NoError(err2)
+	suite.Len(page2, 1)
+	suite.Equal(id1, page2[0].ID)
+	suite.Nil(next2)
+}
+
+func (suite *TaskRepositorySuite) TestListRepositoryFail() {
 	// Prepare
 	r := postgres.NewTaskRepository(suite.BadDB)
 
 	// Execute
-	tasks, err := r.All(context.Background())
+	tasks, next, err := r.List(context.Background(), infrastructure.TaskFilter{Sort: infrastructure.SortTitle}, infrastructure.Page{Limit: 10})
 
 	// Assert
 	suite.Error(err)
+	suite.Nil(next)
 	suite.Nil(tasks)
 	suite.ErrorContains(err, "failed to get tasks")
 	suite.ErrorContains(err, "sql: database is closed")
@@ -64,7 +160,7 @@ func (suite *TaskRepositorySuite) TestAllRepositoryFail() {
 func (suite *TaskRepositorySuite) TestFindSuccess() {
 	// Prepare
 	id := uuid.New()
-	_, err := suite.DB.Exec("INSERT INTO tasks (id, title, completed) VALUES ($1, $2, $3)", id.String(), "task 1", false)
+	_, err := suite.DB.Exec("INSERT INTO tasks (id, title, status) VALUES ($1, $2, $3)", id.String(), "task 1", "todo")
 	suite.NoError(err)
 	r := postgres.NewTaskRepository(suite.DB)
 
@@ -75,7 +171,7 @@ func (suite *TaskRepositorySuite) TestFindSuccess() {
 	suite.NoError(err)
 	suite.Equal(id, task.ID)
 	suite.Equal("task 1", task.Title)
-	suite.False(task.Completed)
+	suite.Equal("todo", task.Status)
 }
 
 func (suite *TaskRepositorySuite) TestFindNotFound() {
@@ -108,7 +204,7 @@ func (suite *TaskRepositorySuite) TestFindRepositoryFail() {
 func (suite *TaskRepositorySuite) TestSaveNewSuccess() {
 	// Prepare
 	id := uuid.New()
-	task := &aggregators.Task{ID: id, Title: "task 1"}
+	task := &aggregators.Task{ID: id, ProjectID: aggregators.InboxProjectID, Title: "task 1", Status: "todo"}
 	r := postgres.NewTaskRepository(suite.DB)
 
 	// Execute
@@ -118,25 +214,26 @@ func (suite *TaskRepositorySuite) TestSaveNewSuccess() {
 	suite.NoError(err)
 	suite.Equal(id, task.ID)
 	suite.Equal("task 1", task.Title)
-	suite.False(task.Completed)
+	suite.Equal("todo", task.Status)
+	suite.Equal(1, task.Version)
 
 	// Assert state
 	var dbTasks []*aggregators.Task
-	err = suite.DB.Select(&dbTasks, "SELECT * FROM tasks")
+	err = suite.DB.Select(&dbTasks, "SELECT id, title, status, due_at, created_at, updated_at, completed_at, deleted_at, version FROM tasks")
 	suite.NoError(err)
 	suite.Len(dbTasks, 1)
 	suite.Equal(id, dbTasks[0].ID)
 	suite.Equal("task 1", dbTasks[0].Title)
-	suite.False(dbTasks[0].Completed)
+	suite.Equal("todo", dbTasks[0].Status)
 }
 
 func (suite *TaskRepositorySuite) TestSaveExistingSuccess() {
 	// Prepare
 	id := uuid.New()
-	_, err := suite.DB.Exec("INSERT INTO tasks (id, title, completed) VALUES ($1, $2, $3)", id.String(), "task 1", false)
+	_, err := suite.DB.Exec("INSERT INTO tasks (id, title, status) VALUES ($1, $2, $3)", id.String(), "task 1", "todo")
 	suite.NoError(err)
 
-	task := &aggregators.Task{ID: id, Title: "task 1 updated", Completed: true}
+	task := &aggregators.Task{ID: id, ProjectID: aggregators.InboxProjectID, Title: "task 1 updated", Status: "done", Version: 1}
 	r := postgres.NewTaskRepository(suite.DB)
 
 	// Execute
@@ -146,16 +243,18 @@ func (suite *TaskRepositorySuite) TestSaveExistingSuccess() {
 	suite.NoError(err)
 	suite.Equal(id, task.ID)
 	suite.Equal("task 1 updated", task.Title)
-	suite.True(task.Completed)
+	suite.Equal("done", task.Status)
+	suite.Equal(2, task.Version)
 
 	// Assert state
 	var dbTasks []*aggregators.Task
-	err = suite.DB.Select(&dbTasks, "SELECT * FROM tasks")
+	err = suite.DB.Select(&dbTasks, "SELECT id, title, status, due_at, created_at, updated_at, completed_at, deleted_at, version FROM tasks")
 	suite.NoError(err)
 	suite.Len(dbTasks, 1)
 	suite.Equal(id, dbTasks[0].ID)
 	suite.Equal("task 1 updated", dbTasks[0].Title)
-	suite.True(dbTasks[0].Completed)
+	suite.Equal("done", dbTasks[0].Status)
+	suite.Equal(2, dbTasks[0].Version)
 }
 
 func (suite *TaskRepositorySuite) TestSaveRepositoryFail() {
@@ -170,3 +269,933 @@ func (suite *TaskRepositorySuite) TestSaveRepositoryFail() {
 	suite.ErrorContains(err, "failed to save task")
 	suite.ErrorContains(err, "sql: database is closed")
 }
+
+func (suite *TaskRepositorySuite) TestSaveInvalidStatusFail() {
+	// Prepare
+	r := postgres.NewTaskRepository(suite.DB)
+
+	// Execute
+	err := r.Save(context.Background(), &aggregators.Task{ID: uuid.New(), ProjectID: aggregators.InboxProjectID, Title: "task 1", Status: "unknown"})
+
+	// Assert
+	suite.Error(err)
+	suite.ErrorContains(err, "failed to save task")
+	suite.ErrorContains(err, "tasks_status_check")
+}
+
+func (suite *TaskRepositorySuite) TestListExcludesTrashed() {
+	// Prepare
+	id1 := uuid.New()
+	_, err := suite.DB.Exec("INSERT INTO tasks (id, title, status) VALUES ($1, $2, $3)", id1.String(), "task 1", "todo")
+	suite.NoError(err)
+	id2 := uuid.New()
+	_, err = suite.DB.Exec("INSERT INTO tasks (id, title, status, deleted_at) VALUES ($1, $2, $3, now())", id2.String(), "task 2", "todo")
+	suite.NoError(err)
+	r := postgres.NewTaskRepository(suite.DB)
+
+	// Execute
+	tasks, _, err := r.List(context.Background(), infrastructure.TaskFilter{Sort: infrastructure.SortTitle}, infrastructure.Page{Limit: 10})
+
+	// Assert
+	suite.NoError(err)
+	suite.Len(tasks, 1)
+	suite.Equal(id1, tasks[0].ID)
+}
+
+func (suite *TaskRepositorySuite) TestFindTrashedNotFound() {
+	// Prepare
+	id := uuid.New()
+	_, err := suite.DB.Exec("INSERT INTO tasks (id, title, status, deleted_at) VALUES ($1, $2, $3, now())", id.String(), "task 1", "todo")
+	suite.NoError(err)
+	r := postgres.NewTaskRepository(suite.DB)
+
+	// Execute
+	task, err := r.Find(context.Background(), id)
+
+	// Assert
+	suite.Nil(task)
+	suite.ErrorIs(err, infrastructure.ErrTaskNotFound)
+}
+
+func (suite *TaskRepositorySuite) TestTrashSuccess() {
+	// Prepare
+	id1 := uuid.New()
+	_, err := suite.DB.Exec("INSERT INTO tasks (id, title, status, deleted_at) VALUES ($1, $2, $3, now() - interval '1 hour')", id1.String(), "task 1", "todo")
+	suite.NoError(err)
+	id2 := uuid.New()
+	_, err = suite.DB.Exec("INSERT INTO tasks (id, title, status, deleted_at) VALUES ($1, $2, $3, now())", id2.String(), "task 2", "todo")
+	suite.NoError(err)
+	_, err = suite.DB.Exec("INSERT INTO tasks (id, title, status) VALUES ($1, $2, $3)", uuid.New().String(), "task 3", "todo")
+	suite.NoError(err)
+	r := postgres.NewTaskRepository(suite.DB)
+
+	// Execute
+	tasks, err := r.Trash(context.Background())
+
+	// Assert
+	suite.NoError(err)
+	suite.Len(tasks, 2)
+	suite.Equal(id2, tasks[0].ID)
+	suite.NotNil(tasks[0].DeletedAt)
+	suite.Equal(id1, tasks[1].ID)
+}
+
+func (suite *TaskRepositorySuite) TestFindTrashedSuccess() {
+	// Prepare
+	id := uuid.New()
+	_, err := suite.DB.Exec("INSERT INTO tasks (id, title, status, deleted_at) VALUES ($1, $2, $3, now())", id.String(), "task 1", "todo")
+	suite.NoError(err)
+	r := postgres.NewTaskRepository(suite.DB)
+
+	// Execute
+	task, err := r.FindTrashed(context.Background(), id)
+
+	// Assert
+	suite.NoError(err)
+	suite.Equal(id, task.ID)
+	suite.NotNil(task.DeletedAt)
+}
+
+func (suite *TaskRepositorySuite) TestExpiredSuccess() {
+	// Prepare
+	id1 := uuid.New()
+	_, err := suite.DB.Exec("INSERT INTO tasks (id, title, status, deleted_at) VALUES ($1, $2, $3, now() - interval '2 days')", id1.String(), "task 1", "todo")
+	suite.NoError(err)
+	id2 := uuid.New()
+	_, err = suite.DB.Exec("INSERT INTO tasks (id, title, status, deleted_at) VALUES ($1, $2, $3, now())", id2.String(), "task 2", "todo")
+	suite.NoError(err)
+	id3 := uuid.New()
+	_, err = suite.DB.Exec("INSERT INTO tasks (id, title, status) VALUES ($1, $2, $3)", id3.String(), "task 3", "todo")
+	suite.NoError(err)
+	r := postgres.NewTaskRepository(suite.DB)
+
+	// Execute
+	tasks, err := r.Expired(context.Background(), time.Now().Add(-24*time.Hour))
+
+	// Assert
+	suite.NoError(err)
+	suite.Len(tasks, 1)
+	suite.Equal(id1, tasks[0].ID)
+}
+
+func (suite *TaskRepositorySuite) TestExpiredKeepsParentOfLiveChild() {
+	// Prepare
+	parentID := uuid.New()
+	_, err := suite.DB.Exec("INSERT INTO tasks (id, title, status, deleted_at) VALUES ($1, $2, $3, now() - interval '2 days')", parentID.String(), "parent", "todo")
+	suite.NoError(err)
+	childID := uuid.New()
+	_, err = suite.DB.Exec("INSERT INTO tasks (id, parent_id, title, status) VALUES ($1, $2, $3, $4)", childID.String(), parentID.String(), "child", "todo")
+	suite.NoError(err)
+	r := postgres.NewTaskRepository(suite.DB)
+
+	// Execute
+	tasks, err := r.Expired(context.Background(), time.Now().Add(-24*time.Hour))
+
+	// Assert
+	suite.NoError(err)
+	suite.Empty(tasks)
+}
+
+func (suite *TaskRepositorySuite) TestReferencingSuccess() {
+	// Prepare
+	id, childID, dependentID := uuid.New(), uuid.New(), uuid.New()
+	_, err := suite.DB.Exec("INSERT INTO tasks (id, title, status, deleted_at) VALUES ($1, $2, $3, now())", id.String(), "task 1", "todo")
+	suite.NoError(err)
+	_, err = suite.DB.Exec("INSERT INTO tasks (id, parent_id, title, status, deleted_at) VALUES ($1, $2, $3, $4, now())", childID.String(), id.String(), "child", "todo")
+	suite.NoError(err)
+	_, err = suite.DB.Exec("INSERT INTO tasks (id, title, status) VALUES ($1, $2, $3)", dependentID.String(), "dependent", "todo")
+	suite.NoError(err)
+	_, err = suite.DB.Exec("INSERT INTO task_dependencies (task_id, depends_on_id) VALUES ($1, $2)", dependentID.String(), id.String())
+	suite.NoError(err)
+	_, err = suite.DB.Exec("INSERT INTO tasks (id, title, status) VALUES ($1, $2, $3)", uuid.New().String(), "other", "todo")
+	suite.NoError(err)
+	r := postgres.NewTaskRepository(suite.DB)
+
+	// Execute
+	tasks, err := r.Referencing(context.Background(), id)
+
+	// Assert
+	suite.NoError(err)
+	ids := make([]uuid.UUID, len(tasks))
+	for i, task := range tasks {
+		ids[i] = task.ID
+	}
+	suite.ElementsMatch([]uuid.UUID{childID, dependentID}, ids)
+}
+
+func (suite *TaskRepositorySuite) TestPurgeSuccess() {
+	// Prepare
+	id, dependentID := uuid.New(), uuid.New()
+	_, err := suite.DB.Exec("INSERT INTO tasks (id, title, status, deleted_at) VALUES ($1, $2, $3, now() - interval '2 days')", id.String(), "task 1", "todo")
+	suite.NoError(err)
+	_, err = suite.DB.Exec("INSERT INTO tasks (id, title, status) VALUES ($1, $2, $3)", dependentID.String(), "dependent", "todo")
+	suite.NoError(err)
+	_, err = suite.DB.Exec("INSERT INTO task_dependencies (task_id, depends_on_id) VALUES ($1, $2)", dependentID.String(), id.String())
+	suite.NoError(err)
+	r := postgres.NewTaskRepository(suite.DB)
+	referencing, err := r.Referencing(context.Background(), id)
+	suite.NoError(err)
+	referencing[0].BlockedBy = nil
+
+	// Execute
+	n, err := r.Purge(context.Background(), []uuid.UUID{id}, referencing...)
+
+	// Assert result
+	suite.NoError(err)
+	suite.Equal(int64(1), n)
+
+	// Assert state
+	_, err = r.FindTrashed(context.Background(), id)
+	suite.ErrorIs(err, infrastructure.ErrTaskNotFound)
+	task, err := r.Find(context.Background(), dependentID)
+	suite.NoError(err)
+	suite.Nil(task.BlockedBy)
+	suite.Equal(2, task.Version)
+
+	var actions []string
+	suite.NoError(suite.DB.Select(&actions, "SELECT action FROM task_history WHERE task_id = $1", dependentID))
+	suite.Equal([]string{infrastructure.ActionUpdated}, actions)
+}
+
+func (suite *TaskRepositorySuite) TestPurgeReferencedConflict() {
+	// Prepare
+	id, childID := uuid.New(), uuid.New()
+	_, err := suite.DB.Exec("INSERT INTO tasks (id, title, status, deleted_at) VALUES ($1, $2, $3, now() - interval '2 days')", id.String(), "parent", "todo")
+	suite.NoError(err)
+	_, err = suite.DB.Exec("INSERT INTO tasks (id, parent_id, title, status, deleted_at) VALUES ($1, $2, $3, $4, now())", childID.String(), id.String(), "child", "todo")
+	suite.NoError(err)
+	r := postgres.NewTaskRepository(suite.DB)
+
+	// Execute
+	n, err := r.Purge(context.Background(), []uuid.UUID{id})
+
+	// Assert
+	suite.Zero(n)
+	suite.ErrorIs(err, infrastructure.ErrTaskConflict)
+	child, err := r.FindTrashed(context.Background(), childID)
+	suite.NoError(err)
+	suite.Equal(&id, child.ParentID)
+}
+
+func (suite *TaskRepositorySuite) TestPurgeRestoredConflict() {
+	// Prepare
+	id := uuid.New()
+	_, err := suite.DB.Exec("INSERT INTO tasks (id, title, status) VALUES ($1, $2, $3)", id.String(), "task 1", "todo")
+	suite.NoError(err)
+	r := postgres.NewTaskRepository(suite.DB)
+
+	// Execute
+	n, err := r.Purge(context.Background(), []uuid.UUID{id})
+
+	// Assert
+	suite.Zero(n)
+	suite.ErrorIs(err, infrastructure.ErrTaskConflict)
+	_, err = r.Find(context.Background(), id)
+	suite.NoError(err)
+}
+
+func (suite *TaskRepositorySuite) TestPurgeRepositoryFail() {
+	// Prepare
+	r := postgres.NewTaskRepository(suite.BadDB)
+
+	// Execute
+	n, err := r.Purge(context.Background(), []uuid.UUID{uuid.New()})
+
+	// Assert
+	suite.Zero(n)
+	suite.ErrorContains(err, "failed to purge tasks")
+	suite.ErrorContains(err, "sql: database is closed")
+}
+
+func (suite *TaskRepositorySuite) TestSaveStaleVersionConflict() {
+	// Prepare
+	id := uuid.New()
+	_, err := suite.DB.Exec("INSERT INTO tasks (id, title, status, version) VALUES ($1, $2, $3, $4)", id.String(), "task 1", "todo", 3)
+	suite.NoError(err)
+	task := &aggregators.Task{ID: id, ProjectID: aggregators.InboxProjectID, Title: "task 1 updated", Status: "done", Version: 2}
+	r := postgres.NewTaskRepository(suite.DB)
+
+	// Execute
+	err = r.Save(context.Background(), task)
+
+	// Assert result
+	suite.ErrorIs(err, infrastructure.ErrTaskConflict)
+	suite.Equal(2, task.Version)
+
+	// Assert state
+	var dbTask aggregators.Task
+	err = suite.DB.Get(&dbTask, "SELECT id, title, status, due_at, created_at, updated_at, completed_at, deleted_at, version FROM tasks WHERE id = $1", id)
+	suite.NoError(err)
+	suite.Equal("task 1", dbTask.Title)
+	suite.Equal(3, dbTask.Version)
+}
+
+func (suite *TaskRepositorySuite) TestSaveNewExistingIDConflict() {
+	// Prepare
+	id := uuid.New()
+	_, err := suite.DB.Exec("INSERT INTO tasks (id, title, status) VALUES ($1, $2, $3)", id.String(), "task 1", "todo")
+	suite.NoError(err)
+	r := postgres.NewTaskRepository(suite.DB)
+
+	// Execute
+	err = r.Save(context.Background(), &aggregators.Task{ID: id, ProjectID: aggregators.InboxProjectID, Title: "task 2", Status: "todo"})
+
+	// Assert
+	suite.ErrorIs(err, infrastructure.ErrTaskConflict)
+}
+
+func (suite *TaskRepositorySuite) TestSearchSuccess() {
+	// Prepare
+	id1 := uuid.New()
+	_, err := suite.DB.Exec("INSERT INTO tasks (id, title, status) VALUES ($1, $2, $3)", id1.String(), "Buy milk and buy bread", "todo")
+	suite.NoError(err)
+	id2 := uuid.New()
+	_, err = suite.DB.Exec("INSERT INTO tasks (id, title, status) VALUES ($1, $2, $3)", id2.String(), "Buying a bike", "todo")
+	suite.NoError(err)
+	_, err = suite.DB.Exec("INSERT INTO tasks (id, title, status, deleted_at) VALUES ($1, $2, $3, now())", uuid.New().String(), "Buy a car", "todo")
+	suite.NoError(err)
+	_, err = suite.DB.Exec("INSERT INTO tasks (id, title, status) VALUES ($1, $2, $3)", uuid.New().String(), "Walk the dog", "todo")
+	suite.NoError(err)
+	r := postgres.NewTaskRepository(suite.DB)
+
+	// Execute
+	matches, err := r.Search(context.Background(), "buy", 10)
+
+	// Assert
+	suite.NoError(err)
+	suite.Len(matches, 2)
+	suite.Equal(id1, matches[0].ID)
+	suite.Equal("Buy milk and buy bread", matches[0].Title)
+	suite.Equal("<b>Buy</b> milk and <b>buy</b> bread", matches[0].Headline)
+	suite.Equal(id2, matches[1].ID)
+	suite.Equal("<b>Buying</b> a bike", matches[1].Headline)
+	suite.Greater(matches[0].Rank, matches[1].Rank)
+}
+
+func (suite *TaskRepositorySuite) TestSearchRepositoryFail() {
+	// Prepare
+	r := postgres.NewTaskRepository(suite.BadDB)
+
+	// Execute
+	matches, err := r.Search(context.Background(), "buy", 10)
+
+	// Assert
+	suite.Nil(matches)
+	suite.ErrorContains(err, "failed to search tasks")
+	suite.ErrorContains(err, "sql: database is closed")
+}
+
+func (suite *TaskRepositorySuite) TestSaveTimestampsSuccess() {
+	// Prepare
+	id := uuid.New()
+	createdAt := time.Date(2025, 2, 1, 9, 0, 0, 0, time.UTC)
+	completedAt := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+	task := &aggregators.Task{ID: id, ProjectID: aggregators.InboxProjectID, Title: "task 1", Status: "done", CreatedAt: createdAt, UpdatedAt: completedAt, CompletedAt: &completedAt}
+	r := postgres.NewTaskRepository(suite.DB)
+
+	// Execute
+	err := r.Save(context.Background(), task)
+
+	// Assert result
+	suite.NoError(err)
+
+	// Assert state
+	dbTask, err := r.Find(context.Background(), id)
+	suite.NoError(err)
+	suite.True(createdAt.Equal(dbTask.CreatedAt))
+	suite.True(completedAt.Equal(dbTask.UpdatedAt))
+	suite.NotNil(dbTask.CompletedAt)
+	suite.True(completedAt.Equal(*dbTask.CompletedAt))
+}
+
+func (suite *TaskRepositorySuite) TestDueSuccess() {
+	// Prepare
+	now := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+	id1 := uuid.New()
+	_, err := suite.DB.Exec("INSERT INTO tasks (id, title, status, due_at) VALUES ($1, $2, $3, $4)", id1.String(), "task 1", "todo", now.Add(2*time.Hour))
+	suite.NoError(err)
+	id2 := uuid.New()
+	_, err = suite.DB.Exec("INSERT INTO tasks (id, title, status, due_at) VALUES ($1, $2, $3, $4)", id2.String(), "task 2", "in_progress", now)
+	suite.NoError(err)
+	_, err = suite.DB.Exec("INSERT INTO tasks (id, title, status, due_at) VALUES ($1, $2, $3, $4)", uuid.New().String(), "task 3", "done", now.Add(time.Hour))
+	suite.NoError(err)
+	_, err = suite.DB.Exec("INSERT INTO tasks (id, title, status, due_at, deleted_at) VALUES ($1, $2, $3, $4, now())", uuid.New().String(), "task 4", "todo", now.Add(time.Hour))
+	suite.NoError(err)
+	_, err = suite.DB.Exec("INSERT INTO tasks (id, title, status, due_at) VALUES ($1, $2, $3, $4)", uuid.New().String(), "task 5", "todo", now.Add(3*time.Hour))
+	suite.NoError(err)
+	_, err = suite.DB.Exec("INSERT INTO tasks (id, title, status) VALUES ($1, $2, $3)", uuid.New().String(), "task 6", "todo")
+	suite.NoError(err)
+	r := postgres.NewTaskRepository(suite.DB)
+
+	// Execute
+	tasks, err := r.Due(context.Background(), now, now.Add(3*time.Hour))
+
+	// Assert
+	suite.NoError(err)
+	suite.Len(tasks, 2)
+	suite.Equal(id2, tasks[0].ID)
+	suite.Equal(id1, tasks[1].ID)
+	suite.True(now.Add(2 * time.Hour).Equal(*tasks[1].DueAt))
+}
+
+func (suite *TaskRepositorySuite) TestDueRepositoryFail() {
+	// Prepare
+	r := postgres.NewTaskRepository(suite.BadDB)
+
+	// Execute
+	tasks, err := r.Due(context.Background(), time.Time{}, time.Now())
+
+	// Assert
+	suite.Nil(tasks)
+	suite.ErrorContains(err, "failed to get due tasks")
+	suite.ErrorContains(err, "sql: database is closed")
+}
+
+func (suite *TaskRepositorySuite) TestSaveTagsSuccess() {
+	// Prepare
+	id := uuid.New()
+	_, err := suite.DB.Exec("INSERT INTO tasks (id, title, status) VALUES ($1, $2, $3)", id.String(), "task 1", "todo")
+	suite.NoError(err)
+	for _, name := range []string{"home", "urgent", "work"} {
+		_, err = suite.DB.Exec("INSERT INTO tags (id, name) VALUES ($1, $2)", uuid.New().String(), name)
+		suite.NoError(err)
+	}
+	r := postgres.NewTaskRepository(suite.DB)
+
+	// Execute
+	err1 := r.Save(context.Background(), &aggregators.Task{ID: id, ProjectID: aggregators.InboxProjectID, Title: "task 1", Status: "todo", Version: 1, Tags: []string{"home", "urgent"}})
+	err2 := r.Save(context.Background(), &aggregators.Task{ID: id, ProjectID: aggregators.InboxProjectID, Title: "task 1", Status: "todo", Version: 2, Tags: []string{"urgent", "work"}})
+	task, err3 := r.Find(context.Background(), id)
+
+	// Assert
+	suite.NoError(err1)
+	suite.NoError(err2)
+	suite.NoError(err3)
+	suite.Equal([]string{"urgent", "work"}, task.Tags)
+	suite.Equal(3, task.Version)
+}
+
+func (suite *TaskRepositorySuite) TestSaveUnknownTagFail() {
+	// Prepare
+	id := uuid.New()
+	_, err := suite.DB.Exec("INSERT INTO tasks (id, title, status) VALUES ($1, $2, $3)", id.String(), "task 1", "todo")
+	suite.NoError(err)
+	r := postgres.NewTaskRepository(suite.DB)
+
+	// Execute
+	err = r.Save(context.Background(), &aggregators.Task{ID: id, ProjectID: aggregators.InboxProjectID, Title: "task 1 updated", Status: "todo", Version: 1, Tags: []string{"unknown"}})
+
+	// Assert result
+	suite.ErrorIs(err, infrastructure.ErrTagNotFound)
+
+	// Assert state
+	var title string
+	suite.NoError(suite.DB.Get(&title, "SELECT title FROM tasks WHERE id = $1", id))
+	suite.Equal("task 1", title)
+}
+
+func (suite *TaskRepositorySuite) TestListTaggedSuccess() {
+	// Prepare
+	homeID, workID := uuid.New(), uuid.New()
+	_, err := suite.DB.Exec("INSERT INTO tags (id, name) VALUES ($1, 'home'), ($2, 'work')", homeID.String(), workID.String())
+	suite.NoError(err)
+	id1, id2, id3 := uuid.New(), uuid.New(), uuid.New()
+	for _, t := range []struct {
+		id    uuid.UUID
+		title string
+		tags  []uuid.UUID
+	}{{id1, "task 1", []uuid.UUID{homeID, workID}}, {id2, "task 2", []uuid.UUID{workID}}, {id3, "task 3", nil}} {
+		_, err := suite.DB.Exec("INSERT INTO tasks (id, title, status) VALUES ($1, $2, $3)", t.id.String(), t.title, "todo")
+		suite.NoError(err)
+		for _, tag := range t.tags {
+			_, err := suite.DB.Exec("INSERT INTO task_tags (task_id, tag_id) VALUES ($1, $2)", t.id.String(), tag.String())
+			suite.NoError(err)
+		}
+	}
+	r := postgres.NewTaskRepository(suite.DB)
+
+	// Execute
+	all, _, err1 := r.List(context.Background(), infrastructure.TaskFilter{Sort: infrastructure.SortTitle}, infrastructure.Page{Limit: 10})
+	work, _, err2 := r.List(context.Background(), infrastructure.TaskFilter{Tags: []string{"work"}, Sort: infrastructure.SortTitle}, infrastructure.Page{Limit: 10})
+	both, _, err3 := r.List(context.Background(), infrastructure.TaskFilter{Tags: []string{"work", "home"}, Sort: infrastructure.SortTitle}, infrastructure.Page{Limit: 10})
+
+	// Assert
+	suite.NoError(err1)
+	suite.Len(all, 3)
+	suite.Equal([]string{"home", "work"}, all[0].Tags)
+	suite.Equal([]string{"work"}, all[1].Tags)
+	suite.Nil(all[2].Tags)
+
+	suite.NoError(err2)
+	suite.Len(work, 2)
+	suite.Equal(id1, work[0].ID)
+	suite.Equal(id2, work[1].ID)
+
+	suite.NoError(err3)
+	suite.Len(both, 1)
+	suite.Equal(id1, both[0].ID)
+}
+
+func (suite *TaskRepositorySuite) TestTagsSuccess() {
+	// Prepare
+	r := postgres.NewTaskRepository(suite.DB)
+	work := &aggregators.Tag{ID: uuid.New(), Name: "work"}
+	home := &aggregators.Tag{ID: uuid.New(), Name: "home"}
+
+	// Execute
+	err1 := r.SaveTag(context.Background(), work)
+	err2 := r.SaveTag(context.Background(), home)
+	err3 := r.SaveTag(context.Background(), &aggregators.Tag{ID: uuid.New(), Name: "work"})
+	tags, err4 := r.Tags(context.Background())
+
+	// Assert
+	suite.NoError(err1)
+	suite.NoError(err2)
+	suite.ErrorIs(err3, infrastructure.ErrTagExists)
+	suite.NoError(err4)
+	suite.Equal([]*aggregators.Tag{home, work}, tags)
+}
+
+func (suite *TaskRepositorySuite) TestTaggedSuccess() {
+	// Prepare
+	tagID, taskID, trashedID := uuid.New(), uuid.New(), uuid.New()
+	_, err := suite.DB.Exec("INSERT INTO tags (id, name) VALUES ($1, 'work')", tagID.String())
+	suite.NoError(err)
+	_, err = suite.DB.Exec("INSERT INTO tasks (id, title, status) VALUES ($1, $2, $3), ($4, $5, $6), ($7, $8, $9)",
+		taskID.String(), "task 1", "todo", trashedID.String(), "task 2", "todo", uuid.New().String(), "task 3", "todo")
+	suite.NoError(err)
+	_, err = suite.DB.Exec("UPDATE tasks SET deleted_at = now() WHERE id = $1", trashedID.String())
+	suite.NoError(err)
+	_, err = suite.DB.Exec("INSERT INTO task_tags (task_id, tag_id) VALUES ($1, $3), ($2, $3)", taskID.String(), trashedID.String(), tagID.String())
+	suite.NoError(err)
+	r := postgres.NewTaskRepository(suite.DB)
+
+	// Execute
+	tag, err1 := r.FindTag(context.Background(), tagID)
+	tasks, err2 := r.Tagged(context.Background(), tagID)
+	_, err3 := r.FindTag(context.Background(), uuid.New())
+
+	// Assert
+	suite.NoError(err1)
+	suite.Equal("work", tag.Name)
+	suite.NoError(err2)
+	suite.ElementsMatch([]uuid.UUID{taskID, trashedID}, []uuid.UUID{tasks[0].ID, tasks[1].ID})
+	suite.Equal([]string{"work"}, tasks[0].Tags)
+	suite.ErrorIs(err3, infrastructure.ErrTagNotFound)
+}
+
+func (suite *TaskRepositorySuite) TestDeleteTagSuccess() {
+	// Prepare
+	tagID, taskID := uuid.New(), uuid.New()
+	_, err := suite.DB.Exec("INSERT INTO tags (id, name) VALUES ($1, 'work')", tagID.String())
+	suite.NoError(err)
+	_, err = suite.DB.Exec("INSERT INTO tasks (id, title, status) VALUES ($1, $2, $3)", taskID.String(), "task 1", "todo")
+	suite.NoError(err)
+	_, err = suite.DB.Exec("INSERT INTO task_tags (task_id, tag_id) VALUES ($1, $2)", taskID.String(), tagID.String())
+	suite.NoError(err)
+	r := postgres.NewTaskRepository(suite.DB)
+	tagged, err := r.Tagged(context.Background(), tagID)
+	suite.NoError(err)
+	tagged[0].Tags = nil
+
+	// Execute
+	err1 := r.DeleteTag(context.Background(), tagID, tagged...)
+	err2 := r.DeleteTag(context.Background(), tagID)
+
+	// Assert
+	suite.NoError(err1)
+	suite.ErrorIs(err2, infrastructure.ErrTagNotFound)
+
+	task, err := r.Find(context.Background(), taskID)
+	suite.NoError(err)
+	suite.Nil(task.Tags)
+	suite.Equal(2, task.Version)
+
+	var actions []string
+	suite.NoError(suite.DB.Select(&actions, "SELECT action FROM task_history WHERE task_id = $1", taskID))
+	suite.Equal([]string{infrastructure.ActionUpdated}, actions)
+}
+
+func (suite *TaskRepositorySuite) TestDeleteTagTaggedConflict() {
+	// Prepare
+	tagID, taskID := uuid.New(), uuid.New()
+	_, err := suite.DB.Exec("INSERT INTO tags (id, name) VALUES ($1, 'work')", tagID.String())
+	suite.NoError(err)
+	_, err = suite.DB.Exec("INSERT INTO tasks (id, title, status) VALUES ($1, $2, $3)", taskID.String(), "task 1", "todo")
+	suite.NoError(err)
+	_, err = suite.DB.Exec("INSERT INTO task_tags (task_id, tag_id) VALUES ($1, $2)", taskID.String(), tagID.String())
+	suite.NoError(err)
+	r := postgres.NewTaskRepository(suite.DB)
+
+	// Execute
+	err = r.DeleteTag(context.Background(), tagID)
+
+	// Assert
+	suite.ErrorIs(err, infrastructure.ErrTaskConflict)
+	_, err = r.FindTag(context.Background(), tagID)
+	suite.NoError(err)
+	task, err := r.Find(context.Background(), taskID)
+	suite.NoError(err)
+	suite.Equal([]string{"work"}, task.Tags)
+}
+
+func (suite *TaskRepositorySuite) TestTagsRepositoryFail() {
+	// Prepare
+	r := postgres.NewTaskRepository(suite.BadDB)
+
+	// Execute
+	tags, err := r.Tags(context.Background())
+
+	// Assert
+	suite.Nil(tags)
+	suite.ErrorContains(err, "failed to get tags")
+	suite.ErrorContains(err, "sql: database is closed")
+}
+
+func (suite *TaskRepositorySuite) TestSaveParentSuccess() {
+	// Prepare
+	parentID := uuid.New()
+	_, err := suite.DB.Exec("INSERT INTO tasks (id, title, status) VALUES ($1, $2, $3)", parentID.String(), "parent", "todo")
+	suite.NoError(err)
+	task := &aggregators.Task{ID: uuid.New(), ProjectID: aggregators.InboxProjectID, ParentID: &parentID, Title: "child", Status: "todo"}
+	r := postgres.NewTaskRepository(suite.DB)
+
+	// Execute
+	err = r.Save(context.Background(), task)
+
+	// Assert
+	suite.NoError(err)
+	found, err := r.Find(context.Background(), task.ID)
+	suite.NoError(err)
+	suite.Equal(&parentID, found.ParentID)
+}
+
+func (suite *TaskRepositorySuite) TestHierarchySuccess() {
+	// Prepare
+	rootID, childID, grandchildID, siblingID, trashedID := uuid.New(), uuid.New(), uuid.New(), uuid.New(), uuid.New()
+	for _, t := range []struct {
+		id       uuid.UUID
+		parentID *uuid.UUID
+		title    string
+		trashed  bool
+	}{
+		{rootID, nil, "root", false},
+		{childID, &rootID, "child b", false},
+		{siblingID, &rootID, "child a", false},
+		{grandchildID, &childID, "grandchild", false},
+		{trashedID, &rootID, "child c", true},
+	} {
+		var deletedAt *time.Time
+		if t.trashed {
+			now := time.Now()
+			deletedAt = &now
+		}
+		_, err := suite.DB.Exec("INSERT INTO tasks (id, parent_id, title, status, deleted_at) VALUES ($1, $2, $3, $4, $5)", t.id, t.parentID, t.title, "todo", deletedAt)
+		suite.NoError(err)
+	}
+	r := postgres.NewTaskRepository(suite.DB)
+
+	// Execute
+	ancestors, err1 := r.Ancestors(context.Background(), grandchildID)
+	descendants, err2 := r.Descendants(context.Background(), rootID)
+	children, _, err3 := r.List(context.Background(), infrastructure.TaskFilter{ParentID: &rootID, Sort: infrastructure.SortTitle}, infrastructure.Page{Limit: 10})
+
+	// Assert
+	suite.NoError(err1)
+	suite.Len(ancestors, 2)
+	suite.Equal(childID, ancestors[0].ID)
+	suite.Equal(rootID, ancestors[1].ID)
+
+	suite.NoError(err2)
+	suite.Len(descendants, 3)
+	suite.Equal(siblingID, descendants[0].ID)
+	suite.Equal(childID, descendants[1].ID)
+	suite.Equal(grandchildID, descendants[2].ID)
+
+	suite.NoError(err3)
+	suite.Len(children, 2)
+	suite.Equal(siblingID, children[0].ID)
+	suite.Equal(childID, children[1].ID)
+}
+
+func (suite *TaskRepositorySuite) TestDescendantsRepositoryFail() {
+	// Prepare
+	r := postgres.NewTaskRepository(suite.BadDB)
+
+	// Execute
+	tasks, err := r.Descendants(context.Background(), uuid.New())
+
+	// Assert
+	suite.Nil(tasks)
+	suite.ErrorContains(err, "failed to get descendants")
+	suite.ErrorContains(err, "sql: database is closed")
+}
+
+func (suite *TaskRepositorySuite) TestDependenciesSuccess() {
+	// Prepare
+	id1, id2, id3 := uuid.New(), uuid.New(), uuid.New()
+	for _, t := range []struct {
+		id     uuid.UUID
+		status string
+	}{{id1, "done"}, {id2, "todo"}, {id3, "todo"}} {
+		_, err := suite.DB.Exec("INSERT INTO tasks (id, title, status) VALUES ($1, $2, $3)", t.id, "task", t.status)
+		suite.NoError(err)
+	}
+	r := postgres.NewTaskRepository(suite.DB)
+
+	// Execute
+	err1 := r.Save(context.Background(), &aggregators.Task{ID: id2, ProjectID: aggregators.InboxProjectID, Title: "task", Status: "todo", Version: 1, BlockedBy: []uuid.UUID{id1}})
+	err2 := r.Save(context.Background(), &aggregators.Task{ID: id3, ProjectID: aggregators.InboxProjectID, Title: "task", Status: "todo", Version: 1, BlockedBy: []uuid.UUID{id2}})
+	blockers, err3 := r.Blockers(context.Background(), id3)
+	open, err4 := r.Open(context.Background())
+
+	// Assert
+	suite.NoError(err1)
+	suite.NoError(err2)
+
+	suite.NoError(err3)
+	suite.Len(blockers, 2)
+	suite.ElementsMatch([]uuid.UUID{id1, id2}, []uuid.UUID{blockers[0].ID, blockers[1].ID})
+
+	suite.NoError(err4)
+	suite.Len(open, 2)
+	for _, task := range open {
+		if task.ID == id3 {
+			suite.Equal([]uuid.UUID{id2}, task.BlockedBy)
+		}
+	}
+}
+
+func (suite *TaskRepositorySuite) TestSaveUnknownDependencyFail() {
+	// Prepare
+	id := uuid.New()
+	_, err := suite.DB.Exec("INSERT INTO tasks (id, title, status) VALUES ($1, $2, $3)", id, "task 1", "todo")
+	suite.NoError(err)
+	r := postgres.NewTaskRepository(suite.DB)
+
+	// Execute
+	err = r.Save(context.Background(), &aggregators.Task{ID: id, ProjectID: aggregators.InboxProjectID, Title: "task 1", Status: "todo", Version: 1, BlockedBy: []uuid.UUID{uuid.New()}})
+
+	// Assert
+	suite.ErrorIs(err, infrastructure.ErrTaskNotFound)
+}
+
+func (suite *TaskRepositorySuite) TestSaveSeriesSuccess() {
+	// Prepare
+	id, nextID := uuid.New(), uuid.New()
+	recurrence := "FREQ=DAILY"
+	_, err := suite.DB.Exec("INSERT INTO tasks (id, title, status, recurrence, series_id, occurrence) VALUES ($1, $2, $3, $4, $5, $6)",
+		id, "task 1", "todo", recurrence, id, 1)
+	suite.NoError(err)
+	completed := &aggregators.Task{ID: id, ProjectID: aggregators.InboxProjectID, Title: "task 1", Status: "done", Recurrence: &recurrence, SeriesID: &id, Occurrence: 1, Version: 1}
+	next := &aggregators.Task{ID: nextID, ProjectID: aggregators.InboxProjectID, Title: "task 1", Status: "todo", Recurrence: &recurrence, SeriesID: &id, Occurrence: 2}
+	r := postgres.NewTaskRepository(suite.DB)
+
+	// Execute
+	err = r.Save(context.Background(), completed, next)
+
+	// Assert
+	suite.NoError(err)
+	suite.Equal(2, completed.Version)
+	suite.Equal(1, next.Version)
+
+	found, err := r.Find(context.Background(), nextID)
+	suite.NoError(err)
+	suite.Equal(&recurrence, found.Recurrence)
+	suite.Equal(&id, found.SeriesID)
+	suite.Equal(2, found.Occurrence)
+}
+
+func (suite *TaskRepositorySuite) TestSaveSeriesConflict() {
+	// Prepare
+	id := uuid.New()
+	recurrence := "FREQ=DAILY"
+	for i, taskID := range []uuid.UUID{id, uuid.New()} {
+		_, err := suite.DB.Exec("INSERT INTO tasks (id, title, status, recurrence, series_id, occurrence) VALUES ($1, $2, $3, $4, $5, $6)",
+			taskID, "task 1", "todo", recurrence, id, i+1)
+		suite.NoError(err)
+	}
+	completed := &aggregators.Task{ID: id, ProjectID: aggregators.InboxProjectID, Title: "task 1", Status: "done", Recurrence: &recurrence, SeriesID: &id, Occurrence: 1, Version: 1}
+	next := &aggregators.Task{ID: uuid.New(), ProjectID: aggregators.InboxProjectID, Title: "task 1", Status: "todo", Recurrence: &recurrence, SeriesID: &id, Occurrence: 2}
+	r := postgres.NewTaskRepository(suite.DB)
+
+	// Execute
+	err := r.Save(context.Background(), completed, next)
+
+	// Assert
+	suite.ErrorIs(err, infrastructure.ErrTaskConflict)
+	suite.Equal(1, completed.Version)
+
+	var status string
+	suite.NoError(suite.DB.Get(&status, "SELECT status FROM tasks WHERE id = $1", id))
+	suite.Equal("todo", status)
+}
+
+func (suite *TaskRepositorySuite) TestHasOccurrence() {
+	// Prepare
+	id := uuid.New()
+	_, err := suite.DB.Exec("INSERT INTO tasks (id, title, status, recurrence, series_id, occurrence, deleted_at) VALUES ($1, $2, $3, $4, $5, $6, now())",
+		id, "task 1", "todo", "FREQ=DAILY", id, 1)
+	suite.NoError(err)
+	r := postgres.NewTaskRepository(suite.DB)
+
+	// Execute
+	first, err1 := r.HasOccurrence(context.Background(), id, 1)
+	second, err2 := r.HasOccurrence(context.Background(), id, 2)
+
+	// Assert
+	suite.NoError(err1)
+	suite.NoError(err2)
+	suite.True(first)
+	suite.False(second)
+}
+
+func (suite *TaskRepositorySuite) TestListProjectSuccess() {
+	// Prepare
+	projectID := uuid.New()
+	_, err := suite.DB.Exec("INSERT INTO projects (id, name) VALUES ($1, $2)", projectID, "work")
+	suite.NoError(err)
+	id1, id2 := uuid.New(), uuid.New()
+	_, err = suite.DB.Exec("INSERT INTO tasks (id, project_id, title, status) VALUES ($1, $2, $3, $4)", id1, projectID, "task 1", "todo")
+	suite.NoError(err)
+	_, err = suite.DB.Exec("INSERT INTO tasks (id, title, status) VALUES ($1, $2, $3)", id2, "task 2", "todo")
+	suite.NoError(err)
+	r := postgres.NewTaskRepository(suite.DB)
+
+	// Execute
+	work, _, err1 := r.List(context.Background(), infrastructure.TaskFilter{ProjectID: &projectID, Sort: infrastructure.SortTitle}, infrastructure.Page{Limit: 10})
+	inbox, _, err2 := r.List(context.Background(), infrastructure.TaskFilter{ProjectID: &aggregators.InboxProjectID, Sort: infrastructure.SortTitle}, infrastructure.Page{Limit: 10})
+
+	// Assert
+	suite.NoError(err1)
+	suite.Len(work, 1)
+	suite.Equal(id1, work[0].ID)
+	suite.Equal(projectID, work[0].ProjectID)
+	suite.NoError(err2)
+	suite.Len(inbox, 1)
+	suite.Equal(id2, inbox[0].ID)
+	suite.Equal(aggregators.InboxProjectID, inbox[0].ProjectID)
+}
+
+func (suite *TaskRepositorySuite) TestSaveUnknownProjectFail() {
+	// Prepare
+	r := postgres.NewTaskRepository(suite.DB)
+
+	// Execute
+	err := r.Save(context.Background(), &aggregators.Task{ID: uuid.New(), ProjectID: uuid.New(), Title: "task 1", Status: "todo"})
+
+	// Assert
+	suite.ErrorIs(err, infrastructure.ErrProjectNotFound)
+
+	var count int
+	suite.NoError(suite.DB.Get(&count, "SELECT count(*) FROM tasks"))
+	suite.Equal(0, count)
+}
+
+func (suite *TaskRepositorySuite) TestWorkspaceIsolation() {
+	// Prepare
+	workspaceID := uuid.New()
+	id := uuid.New()
+	_, err := suite.DB.Exec("INSERT INTO tasks (id, workspace_id, title, status) VALUES ($1, $2, $3, $4)", id, workspaceID, "task 1", "todo")
+	suite.NoError(err)
+	r := postgres.NewTaskRepository(suite.DB)
+	ctx := infrastructure.WithWorkspace(context.Background(), workspaceID)
+
+	// Execute
+	own, err1 := r.Find(ctx, id)
+	other, err2 := r.Find(context.Background(), id)
+	tasks, _, err3 := r.List(context.Background(), infrastructure.TaskFilter{}, infrastructure.Page{Limit: 10})
+
+	// Assert
+	suite.NoError(err1)
+	suite.Equal(id, own.ID)
+	suite.Nil(other)
+	suite.ErrorIs(err2, infrastructure.ErrTaskNotFound)
+	suite.NoError(err3)
+	suite.Empty(tasks)
+}
+
+func (suite *TaskRepositorySuite) TestSaveOtherWorkspaceConflict() {
+	// Prepare
+	id := uuid.New()
+	_, err := suite.DB.Exec("INSERT INTO tasks (id, workspace_id, title, status) VALUES ($1, $2, $3, $4)", id, uuid.New(), "task 1", "todo")
+	suite.NoError(err)
+	r := postgres.NewTaskRepository(suite.DB)
+
+	// Execute
+	err = r.Save(context.Background(), &aggregators.Task{ID: id, ProjectID: aggregators.InboxProjectID, Title: "task 1 renamed", Status: "todo", Version: 1})
+
+	// Assert
+	suite.ErrorIs(err, infrastructure.ErrTaskConflict)
+	var title string
+	suite.NoError(suite.DB.Get(&title, "SELECT title FROM tasks WHERE id = $1", id))
+	suite.Equal("task 1", title)
+}
+
+func (suite *TaskRepositorySuite) TestSaveRecordsHistory() {
+	// Prepare
+	id := uuid.New()
+	createdAt := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+	completedAt := createdAt.Add(time.Hour)
+	r := postgres.NewTaskRepository(suite.DB)
+	ctx := infrastructure.WithActor(context.Background(), "jane")
+	suite.NoError(r.Save(ctx, &aggregators.Task{ID: id, ProjectID: aggregators.InboxProjectID, Title: "task 1", Status: "todo", CreatedAt: createdAt, UpdatedAt: createdAt}))
+
+	// Execute
+	err1 := r.Save(ctx, &aggregators.Task{ID: id, ProjectID: aggregators.InboxProjectID, Title: "task 1", Status: "done", CreatedAt: createdAt, UpdatedAt: completedAt, CompletedAt: &completedAt, Version: 1})
+	changes, next, err2 := r.History(context.Background(), id, infrastructure.Page{Limit: 10})
+
+	// Assert
+	suite.NoError(err1)
+	suite.NoError(err2)
+	suite.Nil(next)
+	suite.Require().Len(changes, 2)
+	suite.Equal(id, changes[0].TaskID)
+	suite.Equal(infrastructure.ActionCreated, changes[0].Action)
+	suite.Equal("jane", changes[0].Actor)
+	suite.JSONEq("null", string(changes[0].Before))
+	suite.True(createdAt.Equal(changes[0].CreatedAt))
+	suite.Equal(infrastructure.ActionCompleted, changes[1].Action)
+	suite.Contains(string(changes[1].Before), `"status": "todo"`)
+	suite.Contains(string(changes[1].After), `"status": "done"`)
+	suite.Contains(string(changes[1].After), `"version": 2`)
+	suite.True(completedAt.Equal(changes[1].CreatedAt))
+}
+
+func (suite *TaskRepositorySuite) TestHistoryPaginatedSuccess() {
+	// Prepare
+	id := uuid.New()
+	now := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+	r := postgres.NewTaskRepository(suite.DB)
+	task := &aggregators.Task{ID: id, ProjectID: aggregators.InboxProjectID, Title: "task 1", Status: "todo", CreatedAt: now, UpdatedAt: now}
+	suite.NoError(r.Save(context.Background(), task))
+	task.Title = "task 1 renamed"
+	suite.NoError(r.Save(context.Background(), task))
+
+	// Execute
+	first, next, err1 := r.History(context.Background(), id, infrastructure.Page{Limit: 1})
+	suite.Require().NotNil(next)
+	second, last, err2 := r.History(context.Background(), id, infrastructure.Page{Limit: 1, After: next})
+
+	// Assert
+	suite.NoError(err1)
+	suite.Require().Len(first, 1)
+	suite.Equal(infrastructure.ActionCreated, first[0].Action)
+	suite.NoError(err2)
+	suite.Require().Len(second, 1)
+	suite.Equal(infrastructure.ActionUpdated, second[0].Action)
+	suite.Nil(last)
+}
+
+func (suite *TaskRepositorySuite) TestHistoryNotFound() {
+	// Prepare
+	r := postgres.NewTaskRepository(suite.DB)
+
+	// Execute
+	changes, next, err := r.History(context.Background(), uuid.New(), infrastructure.Page{Limit: 10})
+
+	// Assert
+	suite.Nil(changes)
+	suite.Nil(next)
+	suite.ErrorIs(err, infrastructure.ErrTaskNotFound)
+}
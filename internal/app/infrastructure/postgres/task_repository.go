@@ -9,9 +9,18 @@ import (
 	"github.com/aviseu/go-sample/internal/app/infrastructure/aggregators"
 	"github.com/google/uuid"
 	"github.com/jmoiron/sqlx"
-	"sort"
+	"github.com/lib/pq"
+	"strconv"
+	"strings"
+	"time"
 )
 
+const taskColumns = "id, project_id, parent_id, title, status, due_at, recurrence, series_id, occurrence, created_at, updated_at, completed_at, deleted_at, version"
+
+// TaskRepository scopes every query to the workspace of the context, so tasks
+// and tags of other workspaces can neither be seen nor changed. Queries on
+// tasks run in a tenant transaction, see begin, so row-level security enforces
+// the same boundary in the database.
 type TaskRepository struct {
 	db *sqlx.DB
 }
@@ -20,44 +29,843 @@ func NewTaskRepository(db *sqlx.DB) *TaskRepository {
 	return &TaskRepository{db: db}
 }
 
-func (r *TaskRepository) All(ctx context.Context) ([]*aggregators.Task, error) {
+func (r *TaskRepository) List(ctx context.Context, f infrastructure.TaskFilter, p infrastructure.Page) ([]*aggregators.Task, *infrastructure.Cursor, error) {
+	tx, err := begin(ctx, r.db)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to get tasks: %w", err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	var args []any
+	arg := func(v any) string {
+		args = append(args, v)
+		return "$" + strconv.Itoa(len(args))
+	}
+
+	where := []string{"workspace_id = " + arg(infrastructure.Workspace(ctx)), "deleted_at IS NULL"}
+	if f.ProjectID != nil {
+		where = append(where, "project_id = "+arg(*f.ProjectID))
+	}
+	if f.ParentID != nil {
+		where = append(where, "parent_id = "+arg(*f.ParentID))
+	}
+	if f.Completed != nil {
+		if *f.Completed {
+			where = append(where, "status = 'done'")
+		} else {
+			where = append(where, "status <> 'done'")
+		}
+	}
+	if f.TitleContains != "" {
+		where = append(where, "title ILIKE "+arg("%"+escapeLike(f.TitleContains)+"%"))
+	}
+	for _, tag := range f.Tags {
+		where = append(where, "EXISTS (SELECT 1 FROM task_tags tt JOIN tags g ON g.id = tt.tag_id WHERE tt.task_id = tasks.id AND g.name = "+arg(tag)+")")
+	}
+
+	column, dir, cmp := "title", "ASC", ">"
+	if f.Sort == infrastructure.SortCreatedAt || f.Sort == infrastructure.SortCreatedAtDesc {
+		column = "created_at"
+	}
+	if f.Sort.Desc() {
+		dir, cmp = "DESC", "<"
+	}
+
+	if p.After != nil {
+		var key any = p.After.Title
+		if column == "created_at" {
+			key = p.After.CreatedAt
+		}
+		where = append(where, fmt.Sprintf("(%s, id) %s (%s, %s)", column, cmp, arg(key), arg(p.After.ID)))
+	}
+
+	query := fmt.Sprintf("SELECT %s FROM tasks WHERE %s ORDER BY %s %s, id %s LIMIT %s",
+		taskColumns, strings.Join(where, " AND "), column, dir, dir, arg(p.Limit+1))
+
+	var tasks []*aggregators.Task
+	if err := tx.SelectContext(ctx, &tasks, query, args...); err != nil {
+		return nil, nil, fmt.Errorf("failed to get tasks: %w", err)
+	}
+
+	tasks, next := infrastructure.Paginate(tasks, p.Limit, f.Sort)
+	if err := r.loadRelations(ctx, tx, tasks...); err != nil {
+		return nil, nil, err
+	}
+
+	return tasks, next, nil
+}
+
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(s)
+}
+
+// Search ranks tasks whose title matches the web search style query q and
+// highlights the matching words in the headline.
+func (r *TaskRepository) Search(ctx context.Context, q string, limit int) ([]*aggregators.TaskMatch, error) {
+	tx, err := begin(ctx, r.db)
+	if err != nil {
+		return nil, fmt.Errorf("failed to search tasks: %w", err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	var matches []*aggregators.TaskMatch
+	err = tx.SelectContext(ctx, &matches,
+		`SELECT `+taskColumns+`,
+			ts_rank(search, query) AS rank,
+			ts_headline('english', title, query) AS headline
+		FROM tasks, websearch_to_tsquery('english', $1) query
+		WHERE workspace_id = $3 AND deleted_at IS NULL AND search @@ query
+		ORDER BY rank DESC, id
+		LIMIT $2`,
+		q, limit, infrastructure.Workspace(ctx),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to search tasks: %w", err)
+	}
+
+	tasks := make([]*aggregators.Task, len(matches))
+	for i, m := range matches {
+		tasks[i] = &m.Task
+	}
+	if err := r.loadRelations(ctx, tx, tasks...); err != nil {
+		return nil, err
+	}
+
+	return matches, nil
+}
+
+// Due returns the open tasks that fall due in [from, to), earliest first.
+func (r *TaskRepository) Due(ctx context.Context, from, to time.Time) ([]*aggregators.Task, error) {
+	tx, err := begin(ctx, r.db)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get due tasks: %w", err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	var tasks []*aggregators.Task
+	err = tx.SelectContext(ctx, &tasks,
+		`SELECT `+taskColumns+` FROM tasks
+		WHERE workspace_id = $3 AND deleted_at IS NULL AND status NOT IN ('done', 'cancelled') AND due_at >= $1 AND due_at < $2
+		ORDER BY due_at, id`,
+		from, to, infrastructure.Workspace(ctx),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get due tasks: %w", err)
+	}
+	if err := r.loadRelations(ctx, tx, tasks...); err != nil {
+		return nil, err
+	}
+
+	return tasks, nil
+}
+
+// Ancestors returns the chain of parents of a task, nearest first.
+func (r *TaskRepository) Ancestors(ctx context.Context, id uuid.UUID) ([]*aggregators.Task, error) {
+	tx, err := begin(ctx, r.db)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get ancestors: %w", err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	var tasks []*aggregators.Task
+	err = tx.SelectContext(ctx, &tasks,
+		`WITH RECURSIVE ancestors AS (
+			SELECT p.*, 1 AS depth FROM tasks t JOIN tasks p ON p.id = t.parent_id
+			WHERE t.id = $1 AND t.workspace_id = $2 AND p.workspace_id = $2
+			UNION ALL
+			SELECT p.*, a.depth + 1 FROM ancestors a JOIN tasks p ON p.id = a.parent_id WHERE p.workspace_id = $2
+		)
+		SELECT `+taskColumns+` FROM ancestors ORDER BY depth`,
+		id, infrastructure.Workspace(ctx),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get ancestors: %w", err)
+	}
+	if err := r.loadRelations(ctx, tx, tasks...); err != nil {
+		return nil, err
+	}
+
+	return tasks, nil
+}
+
+// Descendants returns all subtasks below a task that are not in the trash,
+// level by level and ordered by title within a level.
+func (r *TaskRepository) Descendants(ctx context.Context, id uuid.UUID) ([]*aggregators.Task, error) {
+	tx, err := begin(ctx, r.db)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get descendants: %w", err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	var tasks []*aggregators.Task
+	err = tx.SelectContext(ctx, &tasks,
+		`WITH RECURSIVE descendants AS (
+			SELECT t.*, 1 AS depth FROM tasks t WHERE t.parent_id = $1 AND t.workspace_id = $2 AND t.deleted_at IS NULL
+			UNION ALL
+			SELECT t.*, d.depth + 1 FROM descendants d JOIN tasks t ON t.parent_id = d.id
+			WHERE t.workspace_id = $2 AND t.deleted_at IS NULL
+		)
+		SELECT `+taskColumns+` FROM descendants ORDER BY depth, title, id`,
+		id, infrastructure.Workspace(ctx),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get descendants: %w", err)
+	}
+	if err := r.loadRelations(ctx, tx, tasks...); err != nil {
+		return nil, err
+	}
+
+	return tasks, nil
+}
+
+// Blockers returns every task the given task depends on, directly or through
+// other tasks, including the ones in the trash.
+func (r *TaskRepository) Blockers(ctx context.Context, id uuid.UUID) ([]*aggregators.Task, error) {
+	tx, err := begin(ctx, r.db)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get blockers: %w", err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	var tasks []*aggregators.Task
+	err = tx.SelectContext(ctx, &tasks,
+		`WITH RECURSIVE blockers AS (
+			SELECT depends_on_id AS id FROM task_dependencies WHERE task_id = $1
+			UNION
+			SELECT d.depends_on_id FROM blockers b JOIN task_dependencies d ON d.task_id = b.id
+		)
+		SELECT `+taskColumns+` FROM tasks WHERE workspace_id = $2 AND id IN (SELECT id FROM blockers) ORDER BY id`,
+		id, infrastructure.Workspace(ctx),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get blockers: %w", err)
+	}
+	if err := r.loadRelations(ctx, tx, tasks...); err != nil {
+		return nil, err
+	}
+
+	return tasks, nil
+}
+
+// HasOccurrence reports whether the series already has the given occurrence,
+// including in the trash.
+func (r *TaskRepository) HasOccurrence(ctx context.Context, seriesID uuid.UUID, occurrence int) (bool, error) {
+	tx, err := begin(ctx, r.db)
+	if err != nil {
+		return false, fmt.Errorf("failed to find occurrence: %w", err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	var exists bool
+	err = tx.GetContext(ctx, &exists,
+		"SELECT EXISTS (SELECT 1 FROM tasks WHERE workspace_id = $1 AND series_id = $2 AND occurrence = $3)",
+		infrastructure.Workspace(ctx), seriesID, occurrence,
+	)
+	if err != nil {
+		return false, fmt.Errorf("failed to find occurrence: %w", err)
+	}
+
+	return exists, nil
+}
+
+// Open returns all tasks that still have to be done.
+func (r *TaskRepository) Open(ctx context.Context) ([]*aggregators.Task, error) {
+	tx, err := begin(ctx, r.db)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get open tasks: %w", err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
 	var tasks []*aggregators.Task
-	err := r.db.SelectContext(ctx, &tasks, "SELECT * FROM tasks ORDER BY title")
+	err = tx.SelectContext(ctx, &tasks,
+		"SELECT "+taskColumns+" FROM tasks WHERE workspace_id = $1 AND deleted_at IS NULL AND status NOT IN ('done', 'cancelled') ORDER BY id",
+		infrastructure.Workspace(ctx),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get open tasks: %w", err)
+	}
+	if err := r.loadRelations(ctx, tx, tasks...); err != nil {
+		return nil, err
+	}
+
+	return tasks, nil
+}
+
+func (r *TaskRepository) Trash(ctx context.Context) ([]*aggregators.Task, error) {
+	tx, err := begin(ctx, r.db)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get tasks: %w", err)
+		return nil, fmt.Errorf("failed to get trashed tasks: %w", err)
 	}
+	defer func() { _ = tx.Rollback() }()
 
-	sort.Slice(tasks, func(i, j int) bool {
-		return tasks[i].Title < tasks[j].Title
-	})
+	var tasks []*aggregators.Task
+	err = tx.SelectContext(ctx, &tasks,
+		"SELECT "+taskColumns+" FROM tasks WHERE workspace_id = $1 AND deleted_at IS NOT NULL ORDER BY deleted_at DESC, id",
+		infrastructure.Workspace(ctx),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get trashed tasks: %w", err)
+	}
+	if err := r.loadRelations(ctx, tx, tasks...); err != nil {
+		return nil, err
+	}
 
 	return tasks, nil
 }
 
 func (r *TaskRepository) Find(ctx context.Context, id uuid.UUID) (*aggregators.Task, error) {
+	return r.find(ctx, "SELECT "+taskColumns+" FROM tasks WHERE id = $1 AND workspace_id = $2 AND deleted_at IS NULL", id)
+}
+
+func (r *TaskRepository) FindTrashed(ctx context.Context, id uuid.UUID) (*aggregators.Task, error) {
+	return r.find(ctx, "SELECT "+taskColumns+" FROM tasks WHERE id = $1 AND workspace_id = $2 AND deleted_at IS NOT NULL", id)
+}
+
+func (r *TaskRepository) find(ctx context.Context, query string, id uuid.UUID) (*aggregators.Task, error) {
+	tx, err := begin(ctx, r.db)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find task: %w", err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
 	var task aggregators.Task
-	err := r.db.GetContext(ctx, &task, "SELECT * FROM tasks WHERE id = $1", id)
+	err = tx.GetContext(ctx, &task, query, id, infrastructure.Workspace(ctx))
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
 			return nil, infrastructure.ErrTaskNotFound
 		}
 		return nil, fmt.Errorf("failed to find task: %w", err)
 	}
+	if err := r.loadRelations(ctx, tx, &task); err != nil {
+		return nil, err
+	}
 
 	return &task, nil
 }
 
-func (r *TaskRepository) Save(ctx context.Context, task *aggregators.Task) error {
-	_, err := r.db.NamedExecContext(ctx,
-		`INSERT INTO tasks (id, title, completed)
-		VALUES (:id, :title, :completed)
-		ON CONFLICT (id) DO UPDATE SET title = :title, completed = :completed
-		RETURNING id`,
-		task,
+// loadRelations fills in the tags and dependencies of all given tasks with a
+// single query each.
+func (r *TaskRepository) loadRelations(ctx context.Context, tx *sqlx.Tx, tasks ...*aggregators.Task) error {
+	if len(tasks) == 0 {
+		return nil
+	}
+
+	ids := make([]string, len(tasks))
+	byID := make(map[uuid.UUID]*aggregators.Task, len(tasks))
+	for i, task := range tasks {
+		ids[i] = task.ID.String()
+		byID[task.ID] = task
+	}
+
+	var rows []struct {
+		TaskID uuid.UUID `db:"task_id"`
+		Name   string    `db:"name"`
+	}
+	err := tx.SelectContext(ctx, &rows,
+		`SELECT tt.task_id, g.name FROM task_tags tt JOIN tags g ON g.id = tt.tag_id
+		WHERE tt.task_id = ANY($1::uuid[])
+		ORDER BY g.name COLLATE "C"`,
+		pq.Array(ids),
 	)
+	if err != nil {
+		return fmt.Errorf("failed to get task tags: %w", err)
+	}
+
+	for _, row := range rows {
+		task := byID[row.TaskID]
+		task.Tags = append(task.Tags, row.Name)
+	}
+
+	var deps []struct {
+		TaskID      uuid.UUID `db:"task_id"`
+		DependsOnID uuid.UUID `db:"depends_on_id"`
+	}
+	err = tx.SelectContext(ctx, &deps,
+		"SELECT task_id, depends_on_id FROM task_dependencies WHERE task_id = ANY($1::uuid[]) ORDER BY depends_on_id",
+		pq.Array(ids),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to get task dependencies: %w", err)
+	}
+
+	for _, dep := range deps {
+		task := byID[dep.TaskID]
+		task.BlockedBy = append(task.BlockedBy, dep.DependsOnID)
+	}
+
+	return nil
+}
+
+// Save stores the tasks in a single transaction. A task that has no version
+// yet is inserted and otherwise updated only when the stored version still
+// matches, bumping task.Version on success. The task's project has to exist and
+// its tags and dependencies are replaced by the given ones, all of which have
+// to exist in the same workspace. Every saved task gets an entry in its history
+// and the events it recorded are added to the outbox.
+func (r *TaskRepository) Save(ctx context.Context, tasks ...*aggregators.Task) error {
+	tx, err := begin(ctx, r.db)
 	if err != nil {
 		return fmt.Errorf("failed to save task: %w", err)
 	}
+	defer func() { _ = tx.Rollback() }()
+
+	for _, task := range tasks {
+		if err := r.save(ctx, tx, task); err != nil {
+			return err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to save task: %w", err)
+	}
+
+	for _, task := range tasks {
+		task.Version++
+	}
 
 	return nil
 }
+
+// TaskStore is a task repository that other repositories write tasks through
+// as part of their own transaction, see ProjectRepository.Delete.
+type TaskStore interface {
+	write(ctx context.Context, tx *sqlx.Tx, task *aggregators.Task) error
+	loadRelations(ctx context.Context, tx *sqlx.Tx, tasks ...*aggregators.Task) error
+}
+
+func (r *TaskRepository) write(ctx context.Context, tx *sqlx.Tx, task *aggregators.Task) error {
+	return r.save(ctx, tx, task)
+}
+
+func (r *TaskRepository) save(ctx context.Context, tx *sqlx.Tx, task *aggregators.Task) error {
+	workspaceID := infrastructure.Workspace(ctx)
+
+	var known bool
+	err := tx.GetContext(ctx, &known,
+		"SELECT EXISTS (SELECT 1 FROM projects WHERE id = $1 AND (workspace_id = $2 OR inbox))",
+		task.ProjectID, workspaceID,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to save task: %w", err)
+	}
+	if !known {
+		return infrastructure.ErrProjectNotFound
+	}
+
+	var before *aggregators.Task
+	if task.Version != 0 {
+		if before, err = r.stored(ctx, tx, task.ID); err != nil {
+			return err
+		}
+		if before == nil {
+			return infrastructure.ErrTaskConflict
+		}
+	}
+
+	query := `UPDATE tasks SET project_id = :project_id, parent_id = :parent_id, title = :title, status = :status, due_at = :due_at,
+			recurrence = :recurrence, series_id = :series_id, occurrence = :occurrence, updated_at = :updated_at,
+			completed_at = :completed_at, deleted_at = :deleted_at, version = version + 1
+		WHERE id = :id AND workspace_id = :workspace_id AND version = :version`
+	if task.Version == 0 {
+		query = `INSERT INTO tasks (id, workspace_id, project_id, parent_id, title, status, due_at, recurrence, series_id, occurrence,
+			created_at, updated_at, completed_at, deleted_at, version)
+		VALUES (:id, :workspace_id, :project_id, :parent_id, :title, :status, :due_at, :recurrence, :series_id, :occurrence,
+			:created_at, :updated_at, :completed_at, :deleted_at, 1)
+		ON CONFLICT DO NOTHING`
+	}
+
+	res, err := tx.NamedExecContext(ctx, query, struct {
+		*aggregators.Task
+		WorkspaceID uuid.UUID `db:"workspace_id"`
+	}{task, workspaceID})
+	if err != nil {
+		var pqErr *pq.Error
+		if errors.As(err, &pqErr) && pqErr.Constraint == "tasks_project_id_fkey" {
+			return infrastructure.ErrProjectNotFound
+		}
+		return fmt.Errorf("failed to save task: %w", err)
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to save task: %w", err)
+	}
+	if n == 0 {
+		return infrastructure.ErrTaskConflict
+	}
+
+	if _, err := tx.ExecContext(ctx, "DELETE FROM task_tags WHERE task_id = $1", task.ID); err != nil {
+		return fmt.Errorf("failed to save task tags: %w", err)
+	}
+	if len(task.Tags) > 0 {
+		res, err := tx.ExecContext(ctx,
+			"INSERT INTO task_tags (task_id, tag_id) SELECT $1::uuid, id FROM tags WHERE workspace_id = $3 AND name = ANY($2)",
+			task.ID, pq.Array(task.Tags), workspaceID,
+		)
+		if err != nil {
+			return fmt.Errorf("failed to save task tags: %w", err)
+		}
+		n, err := res.RowsAffected()
+		if err != nil {
+			return fmt.Errorf("failed to save task tags: %w", err)
+		}
+		if n != int64(len(task.Tags)) {
+			return infrastructure.ErrTagNotFound
+		}
+	}
+
+	if _, err := tx.ExecContext(ctx, "DELETE FROM task_dependencies WHERE task_id = $1", task.ID); err != nil {
+		return fmt.Errorf("failed to save task dependencies: %w", err)
+	}
+	if len(task.BlockedBy) > 0 {
+		ids := make([]string, len(task.BlockedBy))
+		for i, id := range task.BlockedBy {
+			ids[i] = id.String()
+		}
+		res, err := tx.ExecContext(ctx,
+			"INSERT INTO task_dependencies (task_id, depends_on_id) SELECT $1::uuid, id FROM tasks WHERE workspace_id = $3 AND id = ANY($2::uuid[])",
+			task.ID, pq.Array(ids), workspaceID,
+		)
+		if err != nil {
+			return fmt.Errorf("failed to save task dependencies: %w", err)
+		}
+		n, err := res.RowsAffected()
+		if err != nil {
+			return fmt.Errorf("failed to save task dependencies: %w", err)
+		}
+		if n != int64(len(ids)) {
+			return infrastructure.ErrTaskNotFound
+		}
+	}
+
+	change, err := infrastructure.NewTaskChange(ctx, before, task)
+	if err != nil {
+		return err
+	}
+	_, err = tx.ExecContext(ctx,
+		"INSERT INTO task_history (id, workspace_id, task_id, action, actor, before, after, created_at) VALUES ($1, $2, $3, $4, $5, $6, $7, $8)",
+		change.ID, workspaceID, change.TaskID, change.Action, change.Actor, string(change.Before), string(change.After), change.CreatedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to save task history: %w", err)
+	}
+
+	for _, e := range task.Events {
+		_, err := tx.ExecContext(ctx,
+			"INSERT INTO outbox (id, workspace_id, task_id, type, payload, occurred_at) VALUES ($1, $2, $3, $4, $5, $6)",
+			e.ID, workspaceID, e.TaskID, e.Type, string(e.Payload), e.OccurredAt,
+		)
+		if err != nil {
+			return fmt.Errorf("failed to save task events: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// stored returns the task as it is stored, trashed or not, and locks it for the
+// rest of the transaction. It returns nil when there is no such task.
+func (r *TaskRepository) stored(ctx context.Context, tx *sqlx.Tx, id uuid.UUID) (*aggregators.Task, error) {
+	var task aggregators.Task
+	err := tx.GetContext(ctx, &task, "SELECT "+taskColumns+" FROM tasks WHERE id = $1 AND workspace_id = $2 FOR UPDATE", id, infrastructure.Workspace(ctx))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to save task: %w", err)
+	}
+	if err := r.loadRelations(ctx, tx, &task); err != nil {
+		return nil, err
+	}
+
+	return &task, nil
+}
+
+// History returns the changes made to a task, oldest first. The history of a
+// task in the trash can still be read, the one of a purged task can not.
+func (r *TaskRepository) History(ctx context.Context, id uuid.UUID, p infrastructure.Page) ([]*aggregators.TaskChange, *infrastructure.Cursor, error) {
+	tx, err := begin(ctx, r.db)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to get task history: %w", err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	workspaceID := infrastructure.Workspace(ctx)
+
+	var known bool
+	err = tx.GetContext(ctx, &known, "SELECT EXISTS (SELECT 1 FROM tasks WHERE id = $1 AND workspace_id = $2)", id, workspaceID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to get task history: %w", err)
+	}
+	if !known {
+		return nil, nil, infrastructure.ErrTaskNotFound
+	}
+
+	args := []any{id, workspaceID}
+	arg := func(v any) string {
+		args = append(args, v)
+		return "$" + strconv.Itoa(len(args))
+	}
+
+	where := "task_id = $1 AND workspace_id = $2"
+	if p.After != nil {
+		where += fmt.Sprintf(" AND (created_at, id) > (%s, %s)", arg(p.After.CreatedAt), arg(p.After.ID))
+	}
+
+	var changes []*aggregators.TaskChange
+	err = tx.SelectContext(ctx, &changes,
+		"SELECT id, task_id, action, actor, before, after, created_at FROM task_history WHERE "+where+" ORDER BY created_at, id LIMIT "+arg(p.Limit+1),
+		args...,
+	)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to get task history: %w", err)
+	}
+
+	changes, next := infrastructure.PaginateChanges(changes, p.Limit)
+
+	return changes, next, nil
+}
+
+// Expired returns the tasks that were trashed before the given time. A
+// trashed parent is left out while it has live subtasks, which would otherwise
+// lose their parent.
+func (r *TaskRepository) Expired(ctx context.Context, before time.Time) ([]*aggregators.Task, error) {
+	tx, err := begin(ctx, r.db)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get expired tasks: %w", err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	var tasks []*aggregators.Task
+	err = tx.SelectContext(ctx, &tasks,
+		`SELECT `+taskColumns+` FROM tasks t WHERE t.workspace_id = $1 AND t.deleted_at < $2
+		AND NOT EXISTS (SELECT 1 FROM tasks c WHERE c.parent_id = t.id AND c.deleted_at IS NULL)
+		ORDER BY t.deleted_at, t.id`,
+		infrastructure.Workspace(ctx), before,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get expired tasks: %w", err)
+	}
+	if err := r.loadRelations(ctx, tx, tasks...); err != nil {
+		return nil, err
+	}
+
+	return tasks, nil
+}
+
+// Referencing returns the other tasks that are subtasks of, or depend on, any
+// of the given tasks, trashed ones included.
+func (r *TaskRepository) Referencing(ctx context.Context, ids ...uuid.UUID) ([]*aggregators.Task, error) {
+	tx, err := begin(ctx, r.db)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get referencing tasks: %w", err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	refs := make([]string, len(ids))
+	for i, id := range ids {
+		refs[i] = id.String()
+	}
+
+	var tasks []*aggregators.Task
+	err = tx.SelectContext(ctx, &tasks,
+		`SELECT `+taskColumns+` FROM tasks t WHERE t.workspace_id = $1 AND NOT t.id = ANY($2::uuid[])
+		AND (t.parent_id = ANY($2::uuid[]) OR EXISTS (SELECT 1 FROM task_dependencies d WHERE d.task_id = t.id AND d.depends_on_id = ANY($2::uuid[])))
+		ORDER BY t.id`,
+		infrastructure.Workspace(ctx), pq.Array(refs),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get referencing tasks: %w", err)
+	}
+	if err := r.loadRelations(ctx, tx, tasks...); err != nil {
+		return nil, err
+	}
+
+	return tasks, nil
+}
+
+// Purge permanently removes the trashed tasks after saving the tasks that
+// referenced them, which should no longer do so, in the same transaction. A
+// task that is restored or referenced in the meantime makes it fail with a
+// conflict.
+func (r *TaskRepository) Purge(ctx context.Context, ids []uuid.UUID, tasks ...*aggregators.Task) (int64, error) {
+	return purge(ctx, r.db, r, ids, tasks)
+}
+
+func (r *TaskRepository) Tags(ctx context.Context) ([]*aggregators.Tag, error) {
+	var tags []*aggregators.Tag
+	err := r.db.SelectContext(ctx, &tags, "SELECT id, name FROM tags WHERE workspace_id = $1 ORDER BY name, id", infrastructure.Workspace(ctx))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get tags: %w", err)
+	}
+
+	return tags, nil
+}
+
+func (r *TaskRepository) SaveTag(ctx context.Context, tag *aggregators.Tag) error {
+	res, err := r.db.ExecContext(ctx,
+		"INSERT INTO tags (id, workspace_id, name) VALUES ($1, $2, $3) ON CONFLICT DO NOTHING",
+		tag.ID, infrastructure.Workspace(ctx), tag.Name,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to save tag: %w", err)
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to save tag: %w", err)
+	}
+	if n == 0 {
+		return infrastructure.ErrTagExists
+	}
+
+	return nil
+}
+
+func (r *TaskRepository) FindTag(ctx context.Context, id uuid.UUID) (*aggregators.Tag, error) {
+	var tag aggregators.Tag
+	err := r.db.GetContext(ctx, &tag, "SELECT id, name FROM tags WHERE id = $1 AND workspace_id = $2", id, infrastructure.Workspace(ctx))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, infrastructure.ErrTagNotFound
+		}
+		return nil, fmt.Errorf("failed to find tag: %w", err)
+	}
+
+	return &tag, nil
+}
+
+// Tagged returns the tasks that have the tag, trashed ones included.
+func (r *TaskRepository) Tagged(ctx context.Context, id uuid.UUID) ([]*aggregators.Task, error) {
+	tx, err := begin(ctx, r.db)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get tagged tasks: %w", err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	var tasks []*aggregators.Task
+	err = tx.SelectContext(ctx, &tasks,
+		"SELECT "+taskColumns+" FROM tasks WHERE workspace_id = $2 AND id IN (SELECT task_id FROM task_tags WHERE tag_id = $1) ORDER BY id",
+		id, infrastructure.Workspace(ctx),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get tagged tasks: %w", err)
+	}
+	if err := r.loadRelations(ctx, tx, tasks...); err != nil {
+		return nil, err
+	}
+
+	return tasks, nil
+}
+
+// DeleteTag removes the tag after saving the tasks that had it, which should
+// no longer have it, in the same transaction. A task that is tagged in the
+// meantime makes it fail with a conflict.
+func (r *TaskRepository) DeleteTag(ctx context.Context, id uuid.UUID, tasks ...*aggregators.Task) error {
+	return deleteTag(ctx, r.db, r, id, tasks)
+}
+
+// deleteTag deletes the tag and writes the tasks through s, so that each kind
+// of task repository stores them the way it stores any other change.
+func deleteTag(ctx context.Context, db *sqlx.DB, s TaskStore, id uuid.UUID, tasks []*aggregators.Task) error {
+	tx, err := begin(ctx, db)
+	if err != nil {
+		return fmt.Errorf("failed to delete tag: %w", err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	for _, task := range tasks {
+		if err := s.write(ctx, tx, task); err != nil {
+			return err
+		}
+	}
+
+	var tagged bool
+	if err := tx.GetContext(ctx, &tagged, "SELECT EXISTS (SELECT 1 FROM task_tags WHERE tag_id = $1)", id); err != nil {
+		return fmt.Errorf("failed to delete tag: %w", err)
+	}
+	if tagged {
+		return infrastructure.ErrTaskConflict
+	}
+
+	res, err := tx.ExecContext(ctx, "DELETE FROM tags WHERE id = $1 AND workspace_id = $2", id, infrastructure.Workspace(ctx))
+	if err != nil {
+		return fmt.Errorf("failed to delete tag: %w", err)
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to delete tag: %w", err)
+	}
+	if n == 0 {
+		return infrastructure.ErrTagNotFound
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to delete tag: %w", err)
+	}
+
+	for _, task := range tasks {
+		task.Version++
+	}
+
+	return nil
+}
+
+func purge(ctx context.Context, db *sqlx.DB, s TaskStore, ids []uuid.UUID, tasks []*aggregators.Task) (int64, error) {
+	tx, err := begin(ctx, db)
+	if err != nil {
+		return 0, fmt.Errorf("failed to purge tasks: %w", err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	for _, task := range tasks {
+		if err := s.write(ctx, tx, task); err != nil {
+			return 0, err
+		}
+	}
+
+	refs := make([]string, len(ids))
+	for i, id := range ids {
+		refs[i] = id.String()
+	}
+
+	var referenced bool
+	err = tx.GetContext(ctx, &referenced,
+		`SELECT EXISTS (SELECT 1 FROM tasks WHERE parent_id = ANY($1::uuid[]) AND NOT id = ANY($1::uuid[]))
+		OR EXISTS (SELECT 1 FROM task_dependencies WHERE depends_on_id = ANY($1::uuid[]) AND NOT task_id = ANY($1::uuid[]))`,
+		pq.Array(refs),
+	)
+	if err != nil {
+		return 0, fmt.Errorf("failed to purge tasks: %w", err)
+	}
+	if referenced {
+		return 0, infrastructure.ErrTaskConflict
+	}
+
+	res, err := tx.ExecContext(ctx,
+		"DELETE FROM tasks WHERE id = ANY($1::uuid[]) AND workspace_id = $2 AND deleted_at IS NOT NULL",
+		pq.Array(refs), infrastructure.Workspace(ctx),
+	)
+	if err != nil {
+		return 0, fmt.Errorf("failed to purge tasks: %w", err)
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("failed to purge tasks: %w", err)
+	}
+	if n != int64(len(ids)) {
+		return 0, infrastructure.ErrTaskConflict
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("failed to purge tasks: %w", err)
+	}
+
+	for _, task := range tasks {
+		task.Version++
+	}
+
+	return n, nil
+}
@@ -0,0 +1,28 @@
+package postgres
+
+import (
+	"context"
+	"github.com/aviseu/go-sample/internal/app/infrastructure"
+	"github.com/jmoiron/sqlx"
+)
+
+// begin starts a transaction as the app_tenant role with app.tenant_id set to
+// the workspace of ctx. Row-level security then hides the tasks of every other
+// workspace, even from a query that forgets to filter on it.
+func begin(ctx context.Context, db *sqlx.DB) (*sqlx.Tx, error) {
+	tx, err := db.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := tx.ExecContext(ctx, "SET LOCAL ROLE app_tenant"); err != nil {
+		_ = tx.Rollback()
+		return nil, err
+	}
+	if _, err := tx.ExecContext(ctx, "SELECT set_config('app.tenant_id', $1, true)", infrastructure.Workspace(ctx).String()); err != nil {
+		_ = tx.Rollback()
+		return nil, err
+	}
+
+	return tx, nil
+}
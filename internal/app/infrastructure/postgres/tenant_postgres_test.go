@@ -0,0 +1,76 @@
+package postgres_test
+
+import (
+	"github.com/aviseu/go-sample/internal/testutils"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/suite"
+	"testing"
+)
+
+func TestRowLevelSecurity(t *testing.T) {
+	t.Parallel()
+	suite.Run(t, new(RowLevelSecuritySuite))
+}
+
+type RowLevelSecuritySuite struct {
+	testutils.PostgresSuite
+}
+
+func (suite *RowLevelSecuritySuite) TestWithoutTenantReturnsNothing() {
+	// Prepare
+	_, err := suite.DB.Exec("INSERT INTO tasks (id, title, status) VALUES ($1, $2, $3)", uuid.New(), "task 1", "todo")
+	suite.NoError(err)
+	tx, err := suite.DB.Beginx()
+	suite.NoError(err)
+	defer func() { _ = tx.Rollback() }()
+	_, err = tx.Exec("SET LOCAL ROLE app_tenant")
+	suite.NoError(err)
+
+	// Execute
+	var n int
+	err = tx.Get(&n, "SELECT count(*) FROM tasks")
+
+	// Assert
+	suite.NoError(err)
+	suite.Zero(n)
+}
+
+func (suite *RowLevelSecuritySuite) TestOtherTenantReturnsNothing() {
+	// Prepare
+	workspaceID := uuid.New()
+	_, err := suite.DB.Exec("INSERT INTO tasks (id, workspace_id, title, status) VALUES ($1, $2, $3, $4), ($5, $6, $7, $8)",
+		uuid.New(), workspaceID, "task 1", "todo", uuid.New(), uuid.New(), "task 2", "todo")
+	suite.NoError(err)
+	tx, err := suite.DB.Beginx()
+	suite.NoError(err)
+	defer func() { _ = tx.Rollback() }()
+	_, err = tx.Exec("SET LOCAL ROLE app_tenant")
+	suite.NoError(err)
+	_, err = tx.Exec("SELECT set_config('app.tenant_id', $1, true)", workspaceID.String())
+	suite.NoError(err)
+
+	// Execute
+	var titles []string
+	err = tx.Select(&titles, "SELECT title FROM tasks")
+
+	// Assert
+	suite.NoError(err)
+	suite.Equal([]string{"task 1"}, titles)
+}
+
+func (suite *RowLevelSecuritySuite) TestInsertForOtherTenantFail() {
+	// Prepare
+	tx, err := suite.DB.Beginx()
+	suite.NoError(err)
+	defer func() { _ = tx.Rollback() }()
+	_, err = tx.Exec("SET LOCAL ROLE app_tenant")
+	suite.NoError(err)
+	_, err = tx.Exec("SELECT set_config('app.tenant_id', $1, true)", uuid.New().String())
+	suite.NoError(err)
+
+	// Execute
+	_, err = tx.Exec("INSERT INTO tasks (id, workspace_id, title, status) VALUES ($1, $2, $3, $4)", uuid.New(), uuid.New(), "task 1", "todo")
+
+	// Assert
+	suite.ErrorContains(err, "violates row-level security policy")
+}
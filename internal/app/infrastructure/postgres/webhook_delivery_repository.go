@@ -0,0 +1,107 @@
+package postgres
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/aviseu/go-sample/internal/app/infrastructure/aggregators"
+	"github.com/jmoiron/sqlx"
+	"time"
+)
+
+// WebhookDeliveryRepository queues events for the webhooks that subscribed to
+// them and hands the deliveries to the dispatcher. Like the outbox it works
+// across all workspaces.
+type WebhookDeliveryRepository struct {
+	db *sqlx.DB
+}
+
+func NewWebhookDeliveryRepository(db *sqlx.DB) *WebhookDeliveryRepository {
+	return &WebhookDeliveryRepository{db: db}
+}
+
+// Enqueue adds a delivery of the event for every webhook of its workspace that
+// subscribed to it. An event that was enqueued before is skipped, so the outbox
+// relay can safely hand it over again.
+func (r *WebhookDeliveryRepository) Enqueue(ctx context.Context, e *aggregators.Event) error {
+	payload, err := json.Marshal(e)
+	if err != nil {
+		return fmt.Errorf("failed to enqueue webhook deliveries: %w", err)
+	}
+
+	_, err = r.db.ExecContext(ctx,
+		`INSERT INTO webhook_deliveries (id, webhook_id, workspace_id, event_id, event_type, payload)
+		SELECT gen_random_uuid(), w.id, w.workspace_id, $1, $2, $3
+		FROM webhooks w
+		WHERE w.workspace_id = $4 AND (cardinality(w.events) = 0 OR $2 = ANY(w.events))
+		ON CONFLICT (webhook_id, event_id) DO NOTHING`,
+		e.ID, e.Type, string(payload), e.WorkspaceID,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to enqueue webhook deliveries: %w", err)
+	}
+
+	return nil
+}
+
+// Claim leases up to limit deliveries that are due, oldest first, together
+// with the URL and secret of their webhook.
+func (r *WebhookDeliveryRepository) Claim(ctx context.Context, limit int, lease time.Duration) ([]*aggregators.WebhookDelivery, error) {
+	var deliveries []*aggregators.WebhookDelivery
+	err := r.db.SelectContext(ctx, &deliveries,
+		`WITH claimed AS (
+			UPDATE webhook_deliveries SET locked_until = now() + $2 * interval '1 microsecond'
+			WHERE id IN (
+				SELECT id FROM webhook_deliveries
+				WHERE status = 'pending' AND available_at <= now() AND (locked_until IS NULL OR locked_until <= now())
+				ORDER BY available_at, id
+				LIMIT $1
+				FOR UPDATE SKIP LOCKED
+			)
+			RETURNING `+deliveryColumns+`
+		)
+		SELECT c.*, w.url, w.secret FROM claimed c JOIN webhooks w ON w.id = c.webhook_id`,
+		limit, lease.Microseconds(),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to claim webhook deliveries: %w", err)
+	}
+
+	return deliveries, nil
+}
+
+// Record adds the attempt to the delivery and moves the delivery to the given
+// status. A failed attempt counts as a failure and, while the delivery is still
+// pending, makes it due again at retryAt.
+func (r *WebhookDeliveryRepository) Record(ctx context.Context, a *aggregators.WebhookAttempt, status string, retryAt time.Time) error {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to record webhook attempt: %w", err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	_, err = tx.ExecContext(ctx,
+		`UPDATE webhook_deliveries SET status = $2, failures = failures + CASE WHEN $3 = 'failed' THEN 1 ELSE 0 END,
+			available_at = $4, locked_until = NULL
+		WHERE id = $1`,
+		a.DeliveryID, status, a.Status, retryAt,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to record webhook attempt: %w", err)
+	}
+
+	_, err = tx.ExecContext(ctx,
+		`INSERT INTO webhook_attempts (id, delivery_id, workspace_id, status, response_code, error, attempted_at)
+		SELECT $1, id, workspace_id, $3, $4, $5, $6 FROM webhook_deliveries WHERE id = $2`,
+		a.ID, a.DeliveryID, a.Status, a.ResponseCode, a.Error, a.AttemptedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to record webhook attempt: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to record webhook attempt: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,214 @@
+package postgres_test
+
+import (
+	"context"
+	"github.com/aviseu/go-sample/internal/app/infrastructure"
+	"github.com/aviseu/go-sample/internal/app/infrastructure/aggregators"
+	"github.com/aviseu/go-sample/internal/app/infrastructure/postgres"
+	"github.com/aviseu/go-sample/internal/testutils"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/suite"
+	"testing"
+	"time"
+)
+
+func TestWebhookRepository(t *testing.T) {
+	t.Parallel()
+	suite.Run(t, new(WebhookRepositorySuite))
+}
+
+type WebhookRepositorySuite struct {
+	testutils.PostgresSuite
+}
+
+func (suite *WebhookRepositorySuite) saveWebhook(ctx context.Context, events ...string) *aggregators.Webhook {
+	webhook := &aggregators.Webhook{ID: uuid.New(), URL: "https://example.com/hooks", Events: events, Secret: "s3cret", CreatedAt: time.Now()}
+	suite.NoError(postgres.NewWebhookRepository(suite.DB).Save(ctx, webhook))
+
+	return webhook
+}
+
+func (suite *WebhookRepositorySuite) TestSaveSuccess() {
+	// Prepare
+	createdAt := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+	r := postgres.NewWebhookRepository(suite.DB)
+	webhook := &aggregators.Webhook{ID: uuid.New(), URL: "https://example.com/hooks", Events: []string{"task.created"}, Secret: "s3cret", CreatedAt: createdAt}
+
+	// Execute
+	err := r.Save(context.Background(), webhook)
+
+	// Assert
+	suite.NoError(err)
+	found, err := r.Find(context.Background(), webhook.ID)
+	suite.NoError(err)
+	suite.Equal(webhook.ID, found.ID)
+	suite.Equal("https://example.com/hooks", found.URL)
+	suite.Equal([]string{"task.created"}, found.Events)
+	suite.Equal("s3cret", found.Secret)
+	suite.True(createdAt.Equal(found.CreatedAt))
+}
+
+func (suite *WebhookRepositorySuite) TestWorkspaceIsolation() {
+	// Prepare
+	ctx := infrastructure.WithWorkspace(context.Background(), uuid.New())
+	webhook := suite.saveWebhook(ctx)
+	r := postgres.NewWebhookRepository(suite.DB)
+
+	// Execute
+	all, err1 := r.All(context.Background())
+	found, err2 := r.Find(context.Background(), webhook.ID)
+	err3 := r.Delete(context.Background(), webhook.ID)
+
+	// Assert
+	suite.NoError(err1)
+	suite.Empty(all)
+	suite.ErrorIs(err2, infrastructure.ErrWebhookNotFound)
+	suite.Nil(found)
+	suite.ErrorIs(err3, infrastructure.ErrWebhookNotFound)
+	all, err := r.All(ctx)
+	suite.NoError(err)
+	suite.Len(all, 1)
+	suite.Equal([]string{}, all[0].Events)
+}
+
+func (suite *WebhookRepositorySuite) TestEnqueueSubscribedWebhooks() {
+	// Prepare
+	ws := uuid.New()
+	ctx := infrastructure.WithWorkspace(context.Background(), ws)
+	all := suite.saveWebhook(ctx)
+	created := suite.saveWebhook(ctx, "task.created")
+	suite.saveWebhook(ctx, "task.deleted")
+	suite.saveWebhook(context.Background())
+	r := postgres.NewWebhookDeliveryRepository(suite.DB)
+	e := &aggregators.Event{ID: uuid.New(), WorkspaceID: ws, TaskID: uuid.New(), Type: "task.created", Payload: []byte(`{}`), OccurredAt: time.Now()}
+
+	// Execute
+	err1 := r.Enqueue(context.Background(), e)
+	err2 := r.Enqueue(context.Background(), e)
+
+	// Assert
+	suite.NoError(err1)
+	suite.NoError(err2)
+	var webhooks []uuid.UUID
+	suite.NoError(suite.DB.Select(&webhooks, "SELECT webhook_id FROM webhook_deliveries WHERE event_id = $1", e.ID))
+	suite.ElementsMatch([]uuid.UUID{all.ID, created.ID}, webhooks)
+}
+
+func (suite *WebhookRepositorySuite) TestClaimAndRecord() {
+	// Prepare
+	ws := uuid.New()
+	ctx := infrastructure.WithWorkspace(context.Background(), ws)
+	webhook := suite.saveWebhook(ctx)
+	r := postgres.NewWebhookDeliveryRepository(suite.DB)
+	e := &aggregators.Event{ID: uuid.New(), WorkspaceID: ws, TaskID: uuid.New(), Type: "task.created", Payload: []byte(`{}`), OccurredAt: time.Now()}
+	suite.NoError(r.Enqueue(context.Background(), e))
+
+	// Execute
+	claimed, err1 := r.Claim(context.Background(), 10, time.Minute)
+	again, err2 := r.Claim(context.Background(), 10, time.Minute)
+	suite.Require().Len(claimed, 1)
+	code, reason := 500, "unexpected response status 500"
+	attemptedAt := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+	err3 := r.Record(context.Background(), &aggregators.WebhookAttempt{
+		ID: uuid.New(), DeliveryID: claimed[0].ID, Status: "failed", ResponseCode: &code, Error: &reason, AttemptedAt: attemptedAt,
+	}, "pending", time.Now().Add(time.Hour))
+
+	// Assert
+	suite.NoError(err1)
+	suite.NoError(err2)
+	suite.NoError(err3)
+	suite.Equal(webhook.ID, claimed[0].WebhookID)
+	suite.Equal(webhook.URL, claimed[0].URL)
+	suite.Equal("s3cret", claimed[0].Secret)
+	suite.Contains(string(claimed[0].Payload), e.ID.String())
+	suite.Empty(again)
+	deliveries, err := postgres.NewWebhookRepository(suite.DB).Deliveries(ctx, webhook.ID, 10)
+	suite.NoError(err)
+	suite.Require().Len(deliveries, 1)
+	suite.Equal("pending", deliveries[0].Status)
+	suite.Equal(1, deliveries[0].Failures)
+	suite.Require().Len(deliveries[0].Attempts, 1)
+	suite.Equal("failed", deliveries[0].Attempts[0].Status)
+	suite.Equal(500, *deliveries[0].Attempts[0].ResponseCode)
+	suite.Equal(reason, *deliveries[0].Attempts[0].Error)
+	suite.True(attemptedAt.Equal(deliveries[0].Attempts[0].AttemptedAt))
+	claimed, err = r.Claim(context.Background(), 10, time.Minute)
+	suite.NoError(err)
+	suite.Empty(claimed)
+}
+
+func (suite *WebhookRepositorySuite) TestRedeliverSuccess() {
+	// Prepare
+	ws := uuid.New()
+	ctx := infrastructure.WithWorkspace(context.Background(), ws)
+	suite.saveWebhook(ctx)
+	dr := postgres.NewWebhookDeliveryRepository(suite.DB)
+	suite.NoError(dr.Enqueue(context.Background(), &aggregators.Event{ID: uuid.New(), WorkspaceID: ws, Type: "task.created", Payload: []byte(`{}`)}))
+	claimed, err := dr.Claim(context.Background(), 10, time.Minute)
+	suite.Require().NoError(err)
+	suite.Require().Len(claimed, 1)
+	suite.NoError(dr.Record(context.Background(), &aggregators.WebhookAttempt{ID: uuid.New(), DeliveryID: claimed[0].ID, Status: "failed", AttemptedAt: time.Now()}, "dead", time.Now()))
+	r := postgres.NewWebhookRepository(suite.DB)
+
+	// Execute
+	err = r.Redeliver(ctx, claimed[0].ID)
+
+	// Assert
+	suite.NoError(err)
+	delivery, err := r.FindDelivery(ctx, claimed[0].ID)
+	suite.NoError(err)
+	suite.Equal("pending", delivery.Status)
+	suite.Equal(0, delivery.Failures)
+	suite.Len(delivery.Attempts, 1)
+	claimed, err = dr.Claim(context.Background(), 10, time.Minute)
+	suite.NoError(err)
+	suite.Len(claimed, 1)
+}
+
+func (suite *WebhookRepositorySuite) TestRedeliverOtherWorkspaceFail() {
+	// Prepare
+	ws := uuid.New()
+	suite.saveWebhook(infrastructure.WithWorkspace(context.Background(), ws))
+	dr := postgres.NewWebhookDeliveryRepository(suite.DB)
+	suite.NoError(dr.Enqueue(context.Background(), &aggregators.Event{ID: uuid.New(), WorkspaceID: ws, Type: "task.created", Payload: []byte(`{}`)}))
+	var id uuid.UUID
+	suite.NoError(suite.DB.Get(&id, "SELECT id FROM webhook_deliveries"))
+	r := postgres.NewWebhookRepository(suite.DB)
+
+	// Execute
+	err := r.Redeliver(context.Background(), id)
+
+	// Assert
+	suite.ErrorIs(err, infrastructure.ErrDeliveryNotFound)
+}
+
+func (suite *WebhookRepositorySuite) TestDeleteRemovesDeliveries() {
+	// Prepare
+	ws := uuid.New()
+	ctx := infrastructure.WithWorkspace(context.Background(), ws)
+	webhook := suite.saveWebhook(ctx)
+	suite.NoError(postgres.NewWebhookDeliveryRepository(suite.DB).Enqueue(context.Background(), &aggregators.Event{ID: uuid.New(), WorkspaceID: ws, Type: "task.created", Payload: []byte(`{}`)}))
+	r := postgres.NewWebhookRepository(suite.DB)
+
+	// Execute
+	err := r.Delete(ctx, webhook.ID)
+
+	// Assert
+	suite.NoError(err)
+	var count int
+	suite.NoError(suite.DB.Get(&count, "SELECT count(*) FROM webhook_deliveries"))
+	suite.Equal(0, count)
+}
+
+func (suite *WebhookRepositorySuite) TestAllFail() {
+	// Prepare
+	r := postgres.NewWebhookRepository(suite.BadDB)
+
+	// Execute
+	webhooks, err := r.All(context.Background())
+
+	// Assert
+	suite.Error(err)
+	suite.Nil(webhooks)
+	suite.ErrorContains(err, "sql: database is closed")
+}
@@ -0,0 +1,252 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"github.com/aviseu/go-sample/internal/app/infrastructure"
+	"github.com/aviseu/go-sample/internal/app/infrastructure/aggregators"
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+)
+
+const webhookColumns = "id, url, events, secret, created_at"
+
+const deliveryColumns = "id, webhook_id, event_id, event_type, payload, status, failures, created_at"
+
+// webhookRow scans the events of a webhook, which are stored as an array.
+type webhookRow struct {
+	aggregators.Webhook
+	Events pq.StringArray `db:"events"`
+}
+
+func (row *webhookRow) toAggregator() *aggregators.Webhook {
+	webhook := row.Webhook
+	webhook.Events = row.Events
+	if webhook.Events == nil {
+		webhook.Events = []string{}
+	}
+
+	return &webhook
+}
+
+// WebhookRepository manages the webhooks of the workspace of the context and
+// lets it look into their deliveries.
+type WebhookRepository struct {
+	db *sqlx.DB
+}
+
+func NewWebhookRepository(db *sqlx.DB) *WebhookRepository {
+	return &WebhookRepository{db: db}
+}
+
+func (r *WebhookRepository) All(ctx context.Context) ([]*aggregators.Webhook, error) {
+	tx, err := begin(ctx, r.db)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get webhooks: %w", err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	var rows []*webhookRow
+	err = tx.SelectContext(ctx, &rows,
+		"SELECT "+webhookColumns+" FROM webhooks WHERE workspace_id = $1 ORDER BY created_at, id",
+		infrastructure.Workspace(ctx),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get webhooks: %w", err)
+	}
+
+	webhooks := make([]*aggregators.Webhook, len(rows))
+	for i, row := range rows {
+		webhooks[i] = row.toAggregator()
+	}
+
+	return webhooks, nil
+}
+
+func (r *WebhookRepository) Find(ctx context.Context, id uuid.UUID) (*aggregators.Webhook, error) {
+	tx, err := begin(ctx, r.db)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find webhook: %w", err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	var row webhookRow
+	err = tx.GetContext(ctx, &row,
+		"SELECT "+webhookColumns+" FROM webhooks WHERE id = $1 AND workspace_id = $2",
+		id, infrastructure.Workspace(ctx),
+	)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, infrastructure.ErrWebhookNotFound
+		}
+		return nil, fmt.Errorf("failed to find webhook: %w", err)
+	}
+
+	return row.toAggregator(), nil
+}
+
+func (r *WebhookRepository) Save(ctx context.Context, webhook *aggregators.Webhook) error {
+	tx, err := begin(ctx, r.db)
+	if err != nil {
+		return fmt.Errorf("failed to save webhook: %w", err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	events := webhook.Events
+	if events == nil {
+		events = []string{}
+	}
+
+	_, err = tx.ExecContext(ctx,
+		"INSERT INTO webhooks (id, workspace_id, url, events, secret, created_at) VALUES ($1, $2, $3, $4, $5, $6)",
+		webhook.ID, infrastructure.Workspace(ctx), webhook.URL, pq.Array(events), webhook.Secret, webhook.CreatedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to save webhook: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to save webhook: %w", err)
+	}
+
+	return nil
+}
+
+func (r *WebhookRepository) Delete(ctx context.Context, id uuid.UUID) error {
+	tx, err := begin(ctx, r.db)
+	if err != nil {
+		return fmt.Errorf("failed to delete webhook: %w", err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	res, err := tx.ExecContext(ctx, "DELETE FROM webhooks WHERE id = $1 AND workspace_id = $2", id, infrastructure.Workspace(ctx))
+	if err != nil {
+		return fmt.Errorf("failed to delete webhook: %w", err)
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to delete webhook: %w", err)
+	}
+	if n == 0 {
+		return infrastructure.ErrWebhookNotFound
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to delete webhook: %w", err)
+	}
+
+	return nil
+}
+
+// Deliveries returns the latest deliveries of a webhook, newest first, each
+// with its attempts in the order they were made.
+func (r *WebhookRepository) Deliveries(ctx context.Context, webhookID uuid.UUID, limit int) ([]*aggregators.WebhookDelivery, error) {
+	tx, err := begin(ctx, r.db)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get webhook deliveries: %w", err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	var deliveries []*aggregators.WebhookDelivery
+	err = tx.SelectContext(ctx, &deliveries,
+		"SELECT "+deliveryColumns+" FROM webhook_deliveries WHERE webhook_id = $1 AND workspace_id = $2 ORDER BY created_at DESC, id DESC LIMIT $3",
+		webhookID, infrastructure.Workspace(ctx), limit,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get webhook deliveries: %w", err)
+	}
+	if err := loadAttempts(ctx, tx, deliveries...); err != nil {
+		return nil, err
+	}
+
+	return deliveries, nil
+}
+
+func (r *WebhookRepository) FindDelivery(ctx context.Context, id uuid.UUID) (*aggregators.WebhookDelivery, error) {
+	tx, err := begin(ctx, r.db)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find webhook delivery: %w", err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	var delivery aggregators.WebhookDelivery
+	err = tx.GetContext(ctx, &delivery,
+		"SELECT "+deliveryColumns+" FROM webhook_deliveries WHERE id = $1 AND workspace_id = $2",
+		id, infrastructure.Workspace(ctx),
+	)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, infrastructure.ErrDeliveryNotFound
+		}
+		return nil, fmt.Errorf("failed to find webhook delivery: %w", err)
+	}
+	if err := loadAttempts(ctx, tx, &delivery); err != nil {
+		return nil, err
+	}
+
+	return &delivery, nil
+}
+
+// Redeliver makes a delivery pending again right away and resets its failures.
+func (r *WebhookRepository) Redeliver(ctx context.Context, id uuid.UUID) error {
+	tx, err := begin(ctx, r.db)
+	if err != nil {
+		return fmt.Errorf("failed to redeliver webhook delivery: %w", err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	res, err := tx.ExecContext(ctx,
+		`UPDATE webhook_deliveries SET status = 'pending', failures = 0, available_at = now(), locked_until = NULL
+		WHERE id = $1 AND workspace_id = $2`,
+		id, infrastructure.Workspace(ctx),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to redeliver webhook delivery: %w", err)
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to redeliver webhook delivery: %w", err)
+	}
+	if n == 0 {
+		return infrastructure.ErrDeliveryNotFound
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to redeliver webhook delivery: %w", err)
+	}
+
+	return nil
+}
+
+func loadAttempts(ctx context.Context, tx *sqlx.Tx, deliveries ...*aggregators.WebhookDelivery) error {
+	if len(deliveries) == 0 {
+		return nil
+	}
+
+	ids := make([]uuid.UUID, len(deliveries))
+	byID := make(map[uuid.UUID]*aggregators.WebhookDelivery, len(deliveries))
+	for i, d := range deliveries {
+		ids[i] = d.ID
+		byID[d.ID] = d
+		d.Attempts = []*aggregators.WebhookAttempt{}
+	}
+
+	var attempts []*aggregators.WebhookAttempt
+	err := tx.SelectContext(ctx, &attempts,
+		"SELECT id, delivery_id, status, response_code, error, attempted_at FROM webhook_attempts WHERE delivery_id = ANY($1) ORDER BY attempted_at, id",
+		pq.Array(ids),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to get webhook attempts: %w", err)
+	}
+	for _, a := range attempts {
+		byID[a.DeliveryID].Attempts = append(byID[a.DeliveryID].Attempts, a)
+	}
+
+	return nil
+}
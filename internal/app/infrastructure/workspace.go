@@ -0,0 +1,26 @@
+package infrastructure
+
+import (
+	"context"
+	"github.com/google/uuid"
+)
+
+// DefaultWorkspaceID is the workspace of everything that is not scoped to
+// another one, which is all data of a single-tenant deployment.
+var DefaultWorkspaceID = uuid.Nil
+
+type workspaceKey struct{}
+
+// WithWorkspace scopes the repositories used through ctx to the workspace.
+func WithWorkspace(ctx context.Context, id uuid.UUID) context.Context {
+	return context.WithValue(ctx, workspaceKey{}, id)
+}
+
+// Workspace returns the workspace ctx is scoped to, or the default one.
+func Workspace(ctx context.Context) uuid.UUID {
+	if id, ok := ctx.Value(workspaceKey{}).(uuid.UUID); ok {
+		return id
+	}
+
+	return DefaultWorkspaceID
+}
@@ -0,0 +1,24 @@
+package errs
+
+import "errors"
+
+type ConflictError struct {
+	err error
+}
+
+func NewConflictError(err error) error {
+	return &ConflictError{err: err}
+}
+
+func (e *ConflictError) Error() string {
+	return e.err.Error()
+}
+
+func (e *ConflictError) Is(target error) bool {
+	return errors.Is(e.err, target)
+}
+
+func IsConflictError(err error) bool {
+	var target *ConflictError
+	return errors.As(err, &target)
+}
@@ -0,0 +1,26 @@
+package errs_test
+
+import (
+	"errors"
+	"github.com/aviseu/go-sample/internal/errs"
+	"github.com/stretchr/testify/suite"
+	"testing"
+)
+
+func TestConflict(t *testing.T) {
+	suite.Run(t, new(ConflictSuite))
+}
+
+type ConflictSuite struct {
+	suite.Suite
+}
+
+func (suite *ConflictSuite) Test_Create_Success() {
+	// Prepare
+	err := errs.NewConflictError(errors.New("already done"))
+
+	// Assert
+	suite.ErrorContains(err, "already done")
+	suite.True(errs.IsConflictError(err))
+	suite.False(errs.IsValidationError(err))
+}
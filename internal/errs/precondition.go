@@ -0,0 +1,24 @@
+package errs
+
+import "errors"
+
+type PreconditionError struct {
+	err error
+}
+
+func NewPreconditionError(err error) error {
+	return &PreconditionError{err: err}
+}
+
+func (e *PreconditionError) Error() string {
+	return e.err.Error()
+}
+
+func (e *PreconditionError) Is(target error) bool {
+	return errors.Is(e.err, target)
+}
+
+func IsPreconditionError(err error) bool {
+	var target *PreconditionError
+	return errors.As(err, &target)
+}
@@ -0,0 +1,26 @@
+package errs_test
+
+import (
+	"errors"
+	"github.com/aviseu/go-sample/internal/errs"
+	"github.com/stretchr/testify/suite"
+	"testing"
+)
+
+func TestPrecondition(t *testing.T) {
+	suite.Run(t, new(PreconditionSuite))
+}
+
+type PreconditionSuite struct {
+	suite.Suite
+}
+
+func (suite *PreconditionSuite) Test_Create_Success() {
+	// Prepare
+	err := errs.NewPreconditionError(errors.New("version mismatch"))
+
+	// Assert
+	suite.ErrorContains(err, "version mismatch")
+	suite.True(errs.IsPreconditionError(err))
+	suite.False(errs.IsConflictError(err))
+}
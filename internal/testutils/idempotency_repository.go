@@ -0,0 +1,147 @@
+package testutils
+
+import (
+	"context"
+	"github.com/aviseu/go-sample/internal/app/infrastructure"
+	"github.com/aviseu/go-sample/internal/app/infrastructure/aggregators"
+	"github.com/google/uuid"
+	"sync"
+	"time"
+)
+
+type IdempotencyRepositoryOptional func(*IdempotencyRepository)
+
+func IdempotencyRepositoryWithError(err error) IdempotencyRepositoryOptional {
+	return func(r *IdempotencyRepository) {
+		r.err = err
+	}
+}
+
+func IdempotencyRepositoryWithClock(now func() time.Time) IdempotencyRepositoryOptional {
+	return func(r *IdempotencyRepository) {
+		r.now = now
+	}
+}
+
+// IdempotencyRepositoryWithKey adds a key of the default workspace, which is in
+// flight until resp is set.
+func IdempotencyRepositoryWithKey(key, fingerprint string, resp *aggregators.IdempotentResponse) IdempotencyRepositoryOptional {
+	return func(r *IdempotencyRepository) {
+		r.records[idempotencyKey{infrastructure.DefaultWorkspaceID, key}] = &IdempotencyRecord{
+			Token:       uuid.New(),
+			Fingerprint: fingerprint,
+			Response:    resp,
+			LockedUntil: r.now().Add(time.Minute),
+			ExpiresAt:   r.now().Add(time.Hour),
+		}
+	}
+}
+
+type idempotencyKey struct {
+	workspaceID uuid.UUID
+	key         string
+}
+
+// IdempotencyRecord is a key as stored, without a response while in flight.
+type IdempotencyRecord struct {
+	Token       uuid.UUID
+	Fingerprint string
+	Response    *aggregators.IdempotentResponse
+	LockedUntil time.Time
+	ExpiresAt   time.Time
+}
+
+// IdempotencyRepository keeps the keys of each workspace apart like the other
+// repositories. It is safe for concurrent use.
+type IdempotencyRepository struct {
+	mu      sync.Mutex
+	records map[idempotencyKey]*IdempotencyRecord
+	now     func() time.Time
+	err     error
+}
+
+func NewIdempotencyRepository(opts ...IdempotencyRepositoryOptional) *IdempotencyRepository {
+	r := &IdempotencyRepository{
+		records: make(map[idempotencyKey]*IdempotencyRecord),
+		now:     time.Now,
+	}
+	for _, opt := range opts {
+		opt(r)
+	}
+
+	return r
+}
+
+// Record returns the stored key of the workspace of ctx, if any.
+func (r *IdempotencyRepository) Record(ctx context.Context, key string) *IdempotencyRecord {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return r.records[idempotencyKey{infrastructure.Workspace(ctx), key}]
+}
+
+func (r *IdempotencyRepository) Claim(ctx context.Context, key, fingerprint string, lease, ttl time.Duration) (uuid.UUID, *aggregators.IdempotentResponse, error) {
+	if r.err != nil {
+		return uuid.Nil, nil, r.err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := r.now()
+	k := idempotencyKey{infrastructure.Workspace(ctx), key}
+	if rec, ok := r.records[k]; ok && now.Before(rec.ExpiresAt) {
+		if rec.Fingerprint != fingerprint {
+			return uuid.Nil, nil, infrastructure.ErrIdempotencyKeyReused
+		}
+		if rec.Response != nil {
+			return uuid.Nil, rec.Response, nil
+		}
+		if now.Before(rec.LockedUntil) {
+			return uuid.Nil, nil, infrastructure.ErrIdempotencyKeyInFlight
+		}
+	}
+
+	token := uuid.New()
+	r.records[k] = &IdempotencyRecord{
+		Token:       token,
+		Fingerprint: fingerprint,
+		LockedUntil: now.Add(lease),
+		ExpiresAt:   now.Add(ttl),
+	}
+
+	return token, nil, nil
+}
+
+func (r *IdempotencyRepository) Complete(ctx context.Context, key string, token uuid.UUID, resp *aggregators.IdempotentResponse) error {
+	if r.err != nil {
+		return r.err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	rec, ok := r.records[idempotencyKey{infrastructure.Workspace(ctx), key}]
+	if !ok || rec.Token != token || rec.Response != nil {
+		return infrastructure.ErrIdempotencyKeyLost
+	}
+	rec.Response = resp
+
+	return nil
+}
+
+func (r *IdempotencyRepository) Release(ctx context.Context, key string, token uuid.UUID) error {
+	if r.err != nil {
+		return r.err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	k := idempotencyKey{infrastructure.Workspace(ctx), key}
+	if rec, ok := r.records[k]; ok && rec.Token == token && rec.Response == nil {
+		delete(r.records, k)
+	}
+
+	return nil
+}
@@ -0,0 +1,125 @@
+package testutils
+
+import (
+	"context"
+	"github.com/aviseu/go-sample/internal/app/infrastructure/aggregators"
+	"github.com/google/uuid"
+	"time"
+)
+
+const (
+	OutboxPending   = "pending"
+	OutboxDelivered = "delivered"
+	OutboxDead      = "dead"
+)
+
+type OutboxRepositoryOptional func(*OutboxRepository)
+
+func OutboxRepositoryWithError(err error) OutboxRepositoryOptional {
+	return func(r *OutboxRepository) {
+		r.err = err
+	}
+}
+
+func OutboxRepositoryWithClock(now func() time.Time) OutboxRepositoryOptional {
+	return func(r *OutboxRepository) {
+		r.now = now
+	}
+}
+
+// OutboxEntry is the delivery state of an event in the outbox.
+type OutboxEntry struct {
+	Status      string
+	LastError   string
+	AvailableAt time.Time
+	LockedUntil time.Time
+}
+
+// OutboxRepository relays the EventRecords of the task repository, so that
+// events saved with a task show up in the outbox.
+type OutboxRepository struct {
+	tasks   *TaskRepository
+	Entries map[uuid.UUID]*OutboxEntry
+	now     func() time.Time
+	err     error
+}
+
+func NewOutboxRepository(tasks *TaskRepository, opts ...OutboxRepositoryOptional) *OutboxRepository {
+	r := &OutboxRepository{
+		tasks:   tasks,
+		Entries: make(map[uuid.UUID]*OutboxEntry),
+		now:     time.Now,
+	}
+	for _, opt := range opts {
+		opt(r)
+	}
+
+	return r
+}
+
+func (r *OutboxRepository) entry(id uuid.UUID) *OutboxEntry {
+	if _, ok := r.Entries[id]; !ok {
+		r.Entries[id] = &OutboxEntry{Status: OutboxPending}
+	}
+
+	return r.Entries[id]
+}
+
+func (r *OutboxRepository) Claim(_ context.Context, limit int, lease time.Duration) ([]*aggregators.Event, error) {
+	if r.err != nil {
+		return nil, r.err
+	}
+
+	now := r.now()
+	blocked := make(map[uuid.UUID]bool)
+	events := make([]*aggregators.Event, 0, limit)
+	for _, e := range r.tasks.EventRecords {
+		entry := r.entry(e.ID)
+		if entry.Status != OutboxPending || blocked[e.TaskID] {
+			continue
+		}
+		blocked[e.TaskID] = true
+		if len(events) == limit || entry.AvailableAt.After(now) || entry.LockedUntil.After(now) {
+			continue
+		}
+
+		entry.LockedUntil = now.Add(lease)
+		events = append(events, e)
+	}
+
+	return events, nil
+}
+
+func (r *OutboxRepository) Delivered(_ context.Context, id uuid.UUID) error {
+	if r.err != nil {
+		return r.err
+	}
+
+	entry := r.entry(id)
+	entry.Status = OutboxDelivered
+	entry.LockedUntil = time.Time{}
+
+	return nil
+}
+
+func (r *OutboxRepository) Failed(_ context.Context, id uuid.UUID, reason string, retryAt time.Time, dead bool) error {
+	if r.err != nil {
+		return r.err
+	}
+
+	for _, e := range r.tasks.EventRecords {
+		if e.ID == id {
+			e.Attempts++
+		}
+	}
+
+	entry := r.entry(id)
+	entry.LastError = reason
+	entry.AvailableAt = retryAt
+	entry.LockedUntil = time.Time{}
+	if dead {
+		entry.Status = OutboxDead
+	}
+
+	return nil
+}
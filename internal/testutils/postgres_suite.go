@@ -21,15 +21,16 @@ type PostgresSuite struct {
 	m         *migrate.Migrate
 	DB        *sqlx.DB
 	BadDB     *sqlx.DB
+	DSN       string
 }
 
 func (suite *PostgresSuite) SetupSuite() {
 	ctx := context.Background()
 
-	suite.container, suite.m, suite.DB, suite.BadDB = suite.createDependencies(ctx)
+	suite.container, suite.m, suite.DB, suite.BadDB, suite.DSN = suite.createDependencies(ctx)
 }
 
-func (suite *PostgresSuite) createDependencies(ctx context.Context) (testcontainers.Container, *migrate.Migrate, *sqlx.DB, *sqlx.DB) {
+func (suite *PostgresSuite) createDependencies(ctx context.Context) (testcontainers.Container, *migrate.Migrate, *sqlx.DB, *sqlx.DB, string) {
 	c, err := postgres.Run(
 		ctx,
 		"postgres:17",
@@ -58,7 +59,7 @@ func (suite *PostgresSuite) createDependencies(ctx context.Context) (testcontain
 	m, err := migrate.NewWithDatabaseInstance("file://../../../../configs/migrations", "postgres", driver)
 	suite.NoError(err)
 
-	return c, m, db, badDB
+	return c, m, db, badDB, dsn
 }
 
 func (suite *PostgresSuite) TearDownSuite() {
@@ -0,0 +1,140 @@
+package testutils
+
+import (
+	"context"
+	"github.com/aviseu/go-sample/internal/app/infrastructure"
+	"github.com/aviseu/go-sample/internal/app/infrastructure/aggregators"
+	"github.com/google/uuid"
+	"sort"
+)
+
+type ProjectRepositoryOptional func(*ProjectRepository)
+
+func ProjectRepositoryWithError(err error) ProjectRepositoryOptional {
+	return func(r *ProjectRepository) {
+		r.err = err
+	}
+}
+
+func ProjectRepositoryWithSaveError(err error) ProjectRepositoryOptional {
+	return func(r *ProjectRepository) {
+		r.saveErr = err
+	}
+}
+
+// ProjectRepository stores its projects in the ProjectRecords of the task
+// repository, so that deleting a project can move its tasks into the inbox.
+type ProjectRepository struct {
+	tasks   *TaskRepository
+	err     error
+	saveErr error
+}
+
+func NewProjectRepository(tasks *TaskRepository, opts ...ProjectRepositoryOptional) *ProjectRepository {
+	r := &ProjectRepository{tasks: tasks}
+	for _, opt := range opts {
+		opt(r)
+	}
+
+	return r
+}
+
+func (r *ProjectRepository) All(ctx context.Context) ([]*aggregators.Project, error) {
+	if r.err != nil {
+		return nil, r.err
+	}
+
+	projects := make([]*aggregators.Project, 0, len(r.tasks.ProjectRecords))
+	for _, project := range r.tasks.ProjectRecords {
+		if r.visible(ctx, project) {
+			projects = append(projects, project)
+		}
+	}
+
+	sort.Slice(projects, func(i, j int) bool {
+		if projects[i].Inbox != projects[j].Inbox {
+			return projects[i].Inbox
+		}
+		if projects[i].Name != projects[j].Name {
+			return projects[i].Name < projects[j].Name
+		}
+		return projects[i].ID.String() < projects[j].ID.String()
+	})
+
+	return projects, nil
+}
+
+func (r *ProjectRepository) Find(ctx context.Context, id uuid.UUID) (*aggregators.Project, error) {
+	if r.err != nil {
+		return nil, r.err
+	}
+
+	project, ok := r.tasks.ProjectRecords[id]
+	if !ok || !r.visible(ctx, project) {
+		return nil, infrastructure.ErrProjectNotFound
+	}
+
+	return project, nil
+}
+
+func (r *ProjectRepository) Save(ctx context.Context, project *aggregators.Project) error {
+	if r.err != nil {
+		return r.err
+	}
+	if r.saveErr != nil {
+		return r.saveErr
+	}
+
+	if existing, ok := r.tasks.ProjectRecords[project.ID]; ok && (!r.tasks.owns(ctx, project.ID) || existing.Version != project.Version) {
+		return infrastructure.ErrProjectConflict
+	}
+
+	project.Version++
+	r.tasks.ProjectRecords[project.ID] = project
+	r.tasks.workspaces[project.ID] = infrastructure.Workspace(ctx)
+
+	return nil
+}
+
+func (r *ProjectRepository) Tasks(ctx context.Context, id uuid.UUID) ([]*aggregators.Task, error) {
+	if r.err != nil {
+		return nil, r.err
+	}
+
+	var tasks []*aggregators.Task
+	for _, task := range r.tasks.tasks(ctx) {
+		if task.ProjectID == id {
+			tasks = append(tasks, task)
+		}
+	}
+
+	return tasks, nil
+}
+
+// Delete saves the tasks like TaskRepository.Save before removing the project,
+// which none of the stored tasks may be in anymore.
+func (r *ProjectRepository) Delete(ctx context.Context, id uuid.UUID, tasks ...*aggregators.Task) error {
+	if r.err != nil {
+		return r.err
+	}
+
+	if _, ok := r.tasks.ProjectRecords[id]; !ok || !r.tasks.owns(ctx, id) {
+		return infrastructure.ErrProjectNotFound
+	}
+
+	if err := r.tasks.Save(ctx, tasks...); err != nil {
+		return err
+	}
+	for _, task := range r.tasks.tasks(ctx) {
+		if task.ProjectID == id {
+			return infrastructure.ErrProjectConflict
+		}
+	}
+	delete(r.tasks.ProjectRecords, id)
+
+	return nil
+}
+
+func (r *ProjectRepository) visible(ctx context.Context, project *aggregators.Project) bool {
+	return project.Inbox || r.tasks.owns(ctx, project.ID)
+}
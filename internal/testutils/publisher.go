@@ -0,0 +1,31 @@
+package testutils
+
+import (
+	"context"
+	"github.com/aviseu/go-sample/internal/app/infrastructure/aggregators"
+)
+
+// Publisher remembers the events it was handed. Each call takes the next of the
+// given errors, failing unless it is nil, until there are none left.
+type Publisher struct {
+	Published []*aggregators.Event
+	errs      []error
+}
+
+func NewPublisher(errs ...error) *Publisher {
+	return &Publisher{errs: errs}
+}
+
+func (p *Publisher) Publish(_ context.Context, e *aggregators.Event) error {
+	if len(p.errs) > 0 {
+		err := p.errs[0]
+		p.errs = p.errs[1:]
+		if err != nil {
+			return err
+		}
+	}
+
+	p.Published = append(p.Published, e)
+
+	return nil
+}
@@ -1,11 +1,16 @@
 package testutils
 
 import (
+	"bytes"
 	"context"
 	"github.com/aviseu/go-sample/internal/app/infrastructure"
 	"github.com/aviseu/go-sample/internal/app/infrastructure/aggregators"
 	"github.com/google/uuid"
+	"regexp"
+	"slices"
 	"sort"
+	"strings"
+	"time"
 )
 
 type TaskRepositoryOptional func(*TaskRepository)
@@ -16,19 +21,83 @@ func TaskRepositoryWithError(err error) TaskRepositoryOptional {
 	}
 }
 
+func TaskRepositoryWithSaveError(err error) TaskRepositoryOptional {
+	return func(r *TaskRepository) {
+		r.saveErr = err
+	}
+}
+
+// TaskRepositoryWithBus tells b about the tasks that recorded events once they
+// were saved, the way the outbox and a postgres.ChangeListener do.
+func TaskRepositoryWithBus(b Bus) TaskRepositoryOptional {
+	return func(r *TaskRepository) {
+		r.bus = b
+	}
+}
+
+// TaskRepositoryWithTask files a task without a project in the inbox, the way
+// the database defaults it.
 func TaskRepositoryWithTask(t *aggregators.Task) TaskRepositoryOptional {
+	return TaskRepositoryWithWorkspaceTask(infrastructure.DefaultWorkspaceID, t)
+}
+
+func TaskRepositoryWithWorkspaceTask(workspaceID uuid.UUID, t *aggregators.Task) TaskRepositoryOptional {
 	return func(r *TaskRepository) {
+		if t.ProjectID == uuid.Nil {
+			t.ProjectID = aggregators.InboxProjectID
+		}
 		r.Records[t.ID] = t
+		r.workspaces[t.ID] = workspaceID
+	}
+}
+
+func TaskRepositoryWithTag(t *aggregators.Tag) TaskRepositoryOptional {
+	return func(r *TaskRepository) {
+		r.TagRecords[t.ID] = t
+		r.workspaces[t.ID] = infrastructure.DefaultWorkspaceID
+	}
+}
+
+func TaskRepositoryWithProject(p *aggregators.Project) TaskRepositoryOptional {
+	return func(r *TaskRepository) {
+		r.ProjectRecords[p.ID] = p
+		r.workspaces[p.ID] = infrastructure.DefaultWorkspaceID
 	}
 }
 
+// TaskRepository keeps the projects next to the tasks, starting out with the
+// inbox, so that a ProjectRepository on top of it sees the same data. Every
+// record but the shared inbox belongs to the workspace it was stored in and is
+// only visible through a context scoped to that workspace. EventRecords is the
+// outbox, in the order the events were saved.
 type TaskRepository struct {
-	Records map[uuid.UUID]*aggregators.Task
-	err     error
+	Records        map[uuid.UUID]*aggregators.Task
+	TagRecords     map[uuid.UUID]*aggregators.Tag
+	ProjectRecords map[uuid.UUID]*aggregators.Project
+	HistoryRecords map[uuid.UUID][]*aggregators.TaskChange
+	EventRecords   []*aggregators.Event
+	workspaces     map[uuid.UUID]uuid.UUID
+	err            error
+	saveErr        error
+	bus            Bus
+}
+
+// Bus is told about the tasks that changed together with the events of the
+// change.
+type Bus interface {
+	Notify(tasks ...*aggregators.Task)
 }
 
 func NewTaskRepository(opts ...TaskRepositoryOptional) *TaskRepository {
-	r := &TaskRepository{Records: make(map[uuid.UUID]*aggregators.Task)}
+	r := &TaskRepository{
+		Records:    make(map[uuid.UUID]*aggregators.Task),
+		TagRecords: make(map[uuid.UUID]*aggregators.Tag),
+		ProjectRecords: map[uuid.UUID]*aggregators.Project{
+			aggregators.InboxProjectID: {ID: aggregators.InboxProjectID, Name: "Inbox", Inbox: true, Version: 1},
+		},
+		HistoryRecords: make(map[uuid.UUID][]*aggregators.TaskChange),
+		workspaces:     make(map[uuid.UUID]uuid.UUID),
+	}
 	for _, opt := range opts {
 		opt(r)
 	}
@@ -36,42 +105,562 @@ func NewTaskRepository(opts ...TaskRepositoryOptional) *TaskRepository {
 	return r
 }
 
-func (r *TaskRepository) All(_ context.Context) ([]*aggregators.Task, error) {
+// owns reports whether the record with the given id belongs to the workspace
+// ctx is scoped to.
+func (r *TaskRepository) owns(ctx context.Context, id uuid.UUID) bool {
+	workspaceID, ok := r.workspaces[id]
+	return ok && workspaceID == infrastructure.Workspace(ctx)
+}
+
+// tasks returns the tasks of the workspace ctx is scoped to.
+func (r *TaskRepository) tasks(ctx context.Context) []*aggregators.Task {
+	tasks := make([]*aggregators.Task, 0, len(r.Records))
+	for id, task := range r.Records {
+		if r.owns(ctx, id) {
+			tasks = append(tasks, task)
+		}
+	}
+
+	return tasks
+}
+
+func (r *TaskRepository) List(ctx context.Context, f infrastructure.TaskFilter, p infrastructure.Page) ([]*aggregators.Task, *infrastructure.Cursor, error) {
 	if r.err != nil {
-		return nil, r.err
+		return nil, nil, r.err
 	}
 
 	tasks := make([]*aggregators.Task, 0, len(r.Records))
-	for _, task := range r.Records {
-		tasks = append(tasks, task)
+	for _, task := range r.tasks(ctx) {
+		if task.DeletedAt == nil && matches(task, f) && (p.After == nil || before(p.After, task, f.Sort)) {
+			tasks = append(tasks, task)
+		}
 	}
 
 	sort.Slice(tasks, func(i, j int) bool {
-		return tasks[i].Title < tasks[j].Title
+		return before(infrastructure.NewCursor(tasks[i], f.Sort), tasks[j], f.Sort)
+	})
+
+	if len(tasks) > p.Limit+1 {
+		tasks = tasks[:p.Limit+1]
+	}
+	tasks, next := infrastructure.Paginate(tasks, p.Limit, f.Sort)
+
+	return tasks, next, nil
+}
+
+func matches(task *aggregators.Task, f infrastructure.TaskFilter) bool {
+	if f.ProjectID != nil && task.ProjectID != *f.ProjectID {
+		return false
+	}
+	if f.ParentID != nil && (task.ParentID == nil || *task.ParentID != *f.ParentID) {
+		return false
+	}
+	if f.Completed != nil && (task.Status == "done") != *f.Completed {
+		return false
+	}
+	if f.TitleContains != "" && !strings.Contains(strings.ToLower(task.Title), strings.ToLower(f.TitleContains)) {
+		return false
+	}
+	for _, tag := range f.Tags {
+		if !slices.Contains(task.Tags, tag) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// before reports whether the position c comes before task in the given order.
+func before(c *infrastructure.Cursor, task *aggregators.Task, s infrastructure.Sort) bool {
+	var cmp int
+	switch s {
+	case infrastructure.SortCreatedAt, infrastructure.SortCreatedAtDesc:
+		cmp = c.CreatedAt.Compare(task.CreatedAt)
+	default:
+		cmp = strings.Compare(c.Title, task.Title)
+	}
+	if cmp == 0 {
+		cmp = strings.Compare(c.ID.String(), task.ID.String())
+	}
+	if s.Desc() {
+		cmp = -cmp
+	}
+
+	return cmp < 0
+}
+
+// Search falls back to case-insensitive substring matching on the title and
+// highlights the matches the same way ts_headline does.
+func (r *TaskRepository) Search(ctx context.Context, q string, limit int) ([]*aggregators.TaskMatch, error) {
+	if r.err != nil {
+		return nil, r.err
+	}
+
+	matches := make([]*aggregators.TaskMatch, 0)
+	pattern := regexp.MustCompile("(?i)" + regexp.QuoteMeta(q))
+	for _, task := range r.tasks(ctx) {
+		if task.DeletedAt != nil || !pattern.MatchString(task.Title) {
+			continue
+		}
+		matches = append(matches, &aggregators.TaskMatch{
+			Task:     *task,
+			Rank:     float64(len(pattern.FindAllString(task.Title, -1))),
+			Headline: pattern.ReplaceAllString(task.Title, "<b>$0</b>"),
+		})
+	}
+
+	sort.Slice(matches, func(i, j int) bool {
+		if matches[i].Rank != matches[j].Rank {
+			return matches[i].Rank > matches[j].Rank
+		}
+		return matches[i].ID.String() < matches[j].ID.String()
 	})
 
+	if len(matches) > limit {
+		matches = matches[:limit]
+	}
+
+	return matches, nil
+}
+
+func (r *TaskRepository) Due(ctx context.Context, from, to time.Time) ([]*aggregators.Task, error) {
+	if r.err != nil {
+		return nil, r.err
+	}
+
+	tasks := make([]*aggregators.Task, 0)
+	for _, task := range r.tasks(ctx) {
+		if task.DeletedAt != nil || task.Status == "done" || task.Status == "cancelled" || task.DueAt == nil {
+			continue
+		}
+		if !task.DueAt.Before(from) && task.DueAt.Before(to) {
+			tasks = append(tasks, task)
+		}
+	}
+
+	sort.Slice(tasks, func(i, j int) bool {
+		if !tasks[i].DueAt.Equal(*tasks[j].DueAt) {
+			return tasks[i].DueAt.Before(*tasks[j].DueAt)
+		}
+		return tasks[i].ID.String() < tasks[j].ID.String()
+	})
+
+	return tasks, nil
+}
+
+func (r *TaskRepository) Ancestors(ctx context.Context, id uuid.UUID) ([]*aggregators.Task, error) {
+	if r.err != nil {
+		return nil, r.err
+	}
+
+	tasks := make([]*aggregators.Task, 0)
+	task, ok := r.Records[id], r.owns(ctx, id)
+	for ok && task.ParentID != nil {
+		if task, ok = r.Records[*task.ParentID], r.owns(ctx, *task.ParentID); ok {
+			tasks = append(tasks, task)
+		}
+	}
+
+	return tasks, nil
+}
+
+func (r *TaskRepository) Descendants(ctx context.Context, id uuid.UUID) ([]*aggregators.Task, error) {
+	if r.err != nil {
+		return nil, r.err
+	}
+
+	tasks := make([]*aggregators.Task, 0)
+	level := []uuid.UUID{id}
+	for len(level) > 0 {
+		children := make([]*aggregators.Task, 0)
+		for _, task := range r.tasks(ctx) {
+			if task.DeletedAt == nil && task.ParentID != nil && slices.Contains(level, *task.ParentID) {
+				children = append(children, task)
+			}
+		}
+
+		sort.Slice(children, func(i, j int) bool {
+			return before(infrastructure.NewCursor(children[i], infrastructure.SortTitle), children[j], infrastructure.SortTitle)
+		})
+
+		level = level[:0]
+		for _, task := range children {
+			level = append(level, task.ID)
+		}
+		tasks = append(tasks, children...)
+	}
+
 	return tasks, nil
 }
 
-func (r *TaskRepository) Find(_ context.Context, id uuid.UUID) (*aggregators.Task, error) {
+func (r *TaskRepository) Blockers(ctx context.Context, id uuid.UUID) ([]*aggregators.Task, error) {
+	if r.err != nil {
+		return nil, r.err
+	}
+
+	seen := make(map[uuid.UUID]bool)
+	queue := []uuid.UUID{id}
+	tasks := make([]*aggregators.Task, 0)
+	for len(queue) > 0 {
+		task, ok := r.Records[queue[0]]
+		queue = queue[1:]
+		if !ok {
+			continue
+		}
+		for _, dep := range task.BlockedBy {
+			if blocker, ok := r.Records[dep]; ok && r.owns(ctx, dep) && !seen[dep] {
+				seen[dep] = true
+				tasks = append(tasks, blocker)
+				queue = append(queue, dep)
+			}
+		}
+	}
+
+	return tasks, nil
+}
+
+func (r *TaskRepository) Open(ctx context.Context) ([]*aggregators.Task, error) {
+	if r.err != nil {
+		return nil, r.err
+	}
+
+	tasks := make([]*aggregators.Task, 0)
+	for _, task := range r.tasks(ctx) {
+		if task.DeletedAt == nil && task.Status != "done" && task.Status != "cancelled" {
+			tasks = append(tasks, task)
+		}
+	}
+
+	return tasks, nil
+}
+
+func (r *TaskRepository) Trash(ctx context.Context) ([]*aggregators.Task, error) {
+	if r.err != nil {
+		return nil, r.err
+	}
+
+	tasks := make([]*aggregators.Task, 0)
+	for _, task := range r.tasks(ctx) {
+		if task.DeletedAt != nil {
+			tasks = append(tasks, task)
+		}
+	}
+
+	sort.Slice(tasks, func(i, j int) bool {
+		return tasks[i].DeletedAt.After(*tasks[j].DeletedAt)
+	})
+
+	return tasks, nil
+}
+
+func (r *TaskRepository) Find(ctx context.Context, id uuid.UUID) (*aggregators.Task, error) {
+	if r.err != nil {
+		return nil, r.err
+	}
+
+	task, ok := r.Records[id]
+	if !ok || !r.owns(ctx, id) || task.DeletedAt != nil {
+		return nil, infrastructure.ErrTaskNotFound
+	}
+
+	return task, nil
+}
+
+func (r *TaskRepository) FindTrashed(ctx context.Context, id uuid.UUID) (*aggregators.Task, error) {
 	if r.err != nil {
 		return nil, r.err
 	}
 
 	task, ok := r.Records[id]
-	if !ok {
+	if !ok || !r.owns(ctx, id) || task.DeletedAt == nil {
 		return nil, infrastructure.ErrTaskNotFound
 	}
 
 	return task, nil
 }
 
-func (r *TaskRepository) Save(_ context.Context, task *aggregators.Task) error {
+func (r *TaskRepository) Save(ctx context.Context, tasks ...*aggregators.Task) error {
 	if r.err != nil {
 		return r.err
 	}
+	if r.saveErr != nil {
+		return r.saveErr
+	}
+
+	for _, task := range tasks {
+		if existing, ok := r.Records[task.ID]; ok && (!r.owns(ctx, task.ID) || existing.Version != task.Version) {
+			return infrastructure.ErrTaskConflict
+		}
+		if task.Version == 0 && task.SeriesID != nil && r.occurs(*task.SeriesID, task.Occurrence) {
+			return infrastructure.ErrTaskConflict
+		}
+		if project, ok := r.ProjectRecords[task.ProjectID]; !ok || (!project.Inbox && !r.owns(ctx, project.ID)) {
+			return infrastructure.ErrProjectNotFound
+		}
+		for _, name := range task.Tags {
+			if r.tag(ctx, name) == nil {
+				return infrastructure.ErrTagNotFound
+			}
+		}
+		for _, id := range task.BlockedBy {
+			if _, ok := r.Records[id]; !ok || !r.owns(ctx, id) {
+				return infrastructure.ErrTaskNotFound
+			}
+		}
+	}
+
+	changes := make([]*aggregators.TaskChange, len(tasks))
+	for i, task := range tasks {
+		change, err := infrastructure.NewTaskChange(ctx, r.Records[task.ID], task)
+		if err != nil {
+			return err
+		}
+		changes[i] = change
+	}
+
+	var changed []*aggregators.Task
+	for i, task := range tasks {
+		task.Version++
+		r.Records[task.ID] = task
+		r.workspaces[task.ID] = infrastructure.Workspace(ctx)
+		r.HistoryRecords[task.ID] = append(r.HistoryRecords[task.ID], changes[i])
+		for _, e := range task.Events {
+			e.WorkspaceID = infrastructure.Workspace(ctx)
+			r.EventRecords = append(r.EventRecords, e)
+		}
+		if len(task.Events) > 0 {
+			changed = append(changed, task)
+		}
+	}
+	if r.bus != nil && len(changed) > 0 {
+		r.bus.Notify(changed...)
+	}
+
+	return nil
+}
+
+func (r *TaskRepository) History(ctx context.Context, id uuid.UUID, p infrastructure.Page) ([]*aggregators.TaskChange, *infrastructure.Cursor, error) {
+	if r.err != nil {
+		return nil, nil, r.err
+	}
+
+	if _, ok := r.Records[id]; !ok || !r.owns(ctx, id) {
+		return nil, nil, infrastructure.ErrTaskNotFound
+	}
+
+	changes := make([]*aggregators.TaskChange, 0, len(r.HistoryRecords[id]))
+	for _, change := range r.HistoryRecords[id] {
+		if p.After == nil || change.CreatedAt.After(p.After.CreatedAt) ||
+			(change.CreatedAt.Equal(p.After.CreatedAt) && bytes.Compare(change.ID[:], p.After.ID[:]) > 0) {
+			changes = append(changes, change)
+		}
+	}
+
+	if len(changes) > p.Limit+1 {
+		changes = changes[:p.Limit+1]
+	}
+	changes, next := infrastructure.PaginateChanges(changes, p.Limit)
+
+	return changes, next, nil
+}
+
+func (r *TaskRepository) HasOccurrence(ctx context.Context, seriesID uuid.UUID, occurrence int) (bool, error) {
+	if r.err != nil {
+		return false, r.err
+	}
+
+	return r.occurs(seriesID, occurrence), nil
+}
+
+func (r *TaskRepository) occurs(seriesID uuid.UUID, occurrence int) bool {
+	for _, task := range r.Records {
+		if task.SeriesID != nil && *task.SeriesID == seriesID && task.Occurrence == occurrence {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (r *TaskRepository) hasLiveChildren(id uuid.UUID) bool {
+	for _, task := range r.Records {
+		if task.ParentID != nil && *task.ParentID == id && task.DeletedAt == nil {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (r *TaskRepository) Expired(ctx context.Context, before time.Time) ([]*aggregators.Task, error) {
+	if r.err != nil {
+		return nil, r.err
+	}
+
+	tasks := make([]*aggregators.Task, 0)
+	for _, task := range r.tasks(ctx) {
+		if task.DeletedAt != nil && task.DeletedAt.Before(before) && !r.hasLiveChildren(task.ID) {
+			tasks = append(tasks, task)
+		}
+	}
+
+	return tasks, nil
+}
+
+func (r *TaskRepository) Referencing(ctx context.Context, ids ...uuid.UUID) ([]*aggregators.Task, error) {
+	if r.err != nil {
+		return nil, r.err
+	}
+
+	tasks := make([]*aggregators.Task, 0)
+	for _, task := range r.tasks(ctx) {
+		if !slices.Contains(ids, task.ID) && r.references(task, ids) {
+			tasks = append(tasks, task)
+		}
+	}
+
+	return tasks, nil
+}
+
+func (r *TaskRepository) references(task *aggregators.Task, ids []uuid.UUID) bool {
+	if task.ParentID != nil && slices.Contains(ids, *task.ParentID) {
+		return true
+	}
 
-	r.Records[task.ID] = task
+	return slices.ContainsFunc(task.BlockedBy, func(id uuid.UUID) bool {
+		return slices.Contains(ids, id)
+	})
+}
+
+// Purge saves the tasks like Save before removing the trashed tasks, which
+// none of the other stored tasks may reference anymore.
+func (r *TaskRepository) Purge(ctx context.Context, ids []uuid.UUID, tasks ...*aggregators.Task) (int64, error) {
+	if r.err != nil {
+		return 0, r.err
+	}
+
+	for _, id := range ids {
+		if task, ok := r.Records[id]; !ok || !r.owns(ctx, id) || task.DeletedAt == nil {
+			return 0, infrastructure.ErrTaskConflict
+		}
+	}
+
+	if err := r.Save(ctx, tasks...); err != nil {
+		return 0, err
+	}
+	for _, task := range r.tasks(ctx) {
+		if !slices.Contains(ids, task.ID) && r.references(task, ids) {
+			return 0, infrastructure.ErrTaskConflict
+		}
+	}
+	for _, id := range ids {
+		delete(r.Records, id)
+	}
+
+	return int64(len(ids)), nil
+}
+
+func (r *TaskRepository) Tags(ctx context.Context) ([]*aggregators.Tag, error) {
+	if r.err != nil {
+		return nil, r.err
+	}
+
+	tags := make([]*aggregators.Tag, 0, len(r.TagRecords))
+	for id, tag := range r.TagRecords {
+		if r.owns(ctx, id) {
+			tags = append(tags, tag)
+		}
+	}
+
+	sort.Slice(tags, func(i, j int) bool {
+		return tags[i].Name < tags[j].Name
+	})
+
+	return tags, nil
+}
+
+func (r *TaskRepository) SaveTag(ctx context.Context, tag *aggregators.Tag) error {
+	if r.err != nil {
+		return r.err
+	}
+	if r.saveErr != nil {
+		return r.saveErr
+	}
+
+	if r.tag(ctx, tag.Name) != nil {
+		return infrastructure.ErrTagExists
+	}
+
+	r.TagRecords[tag.ID] = tag
+	r.workspaces[tag.ID] = infrastructure.Workspace(ctx)
+
+	return nil
+}
+
+func (r *TaskRepository) FindTag(ctx context.Context, id uuid.UUID) (*aggregators.Tag, error) {
+	if r.err != nil {
+		return nil, r.err
+	}
+
+	tag, ok := r.TagRecords[id]
+	if !ok || !r.owns(ctx, id) {
+		return nil, infrastructure.ErrTagNotFound
+	}
+
+	return tag, nil
+}
+
+func (r *TaskRepository) Tagged(ctx context.Context, id uuid.UUID) ([]*aggregators.Task, error) {
+	if r.err != nil {
+		return nil, r.err
+	}
+
+	tag, ok := r.TagRecords[id]
+	if !ok || !r.owns(ctx, id) {
+		return nil, nil
+	}
+
+	var tasks []*aggregators.Task
+	for _, task := range r.tasks(ctx) {
+		if slices.Contains(task.Tags, tag.Name) {
+			tasks = append(tasks, task)
+		}
+	}
+
+	return tasks, nil
+}
+
+// DeleteTag saves the tasks like Save before removing the tag, which none of
+// the stored tasks may have left.
+func (r *TaskRepository) DeleteTag(ctx context.Context, id uuid.UUID, tasks ...*aggregators.Task) error {
+	if r.err != nil {
+		return r.err
+	}
+
+	tag, ok := r.TagRecords[id]
+	if !ok || !r.owns(ctx, id) {
+		return infrastructure.ErrTagNotFound
+	}
+
+	if err := r.Save(ctx, tasks...); err != nil {
+		return err
+	}
+	for _, task := range r.tasks(ctx) {
+		if slices.Contains(task.Tags, tag.Name) {
+			return infrastructure.ErrTaskConflict
+		}
+	}
+	delete(r.TagRecords, id)
+
+	return nil
+}
+
+func (r *TaskRepository) tag(ctx context.Context, name string) *aggregators.Tag {
+	for id, tag := range r.TagRecords {
+		if r.owns(ctx, id) && tag.Name == name {
+			return tag
+		}
+	}
 
 	return nil
 }
@@ -0,0 +1,261 @@
+package testutils
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/aviseu/go-sample/internal/app/infrastructure"
+	"github.com/aviseu/go-sample/internal/app/infrastructure/aggregators"
+	"github.com/google/uuid"
+	"slices"
+	"sort"
+	"time"
+)
+
+type WebhookRepositoryOptional func(*WebhookRepository)
+
+func WebhookRepositoryWithError(err error) WebhookRepositoryOptional {
+	return func(r *WebhookRepository) {
+		r.err = err
+	}
+}
+
+func WebhookRepositoryWithClock(now func() time.Time) WebhookRepositoryOptional {
+	return func(r *WebhookRepository) {
+		r.now = now
+	}
+}
+
+func WebhookRepositoryWithWebhook(w *aggregators.Webhook) WebhookRepositoryOptional {
+	return func(r *WebhookRepository) {
+		r.Records[w.ID] = w
+		r.workspaces[w.ID] = infrastructure.DefaultWorkspaceID
+	}
+}
+
+func WebhookRepositoryWithDelivery(d *aggregators.WebhookDelivery) WebhookRepositoryOptional {
+	return func(r *WebhookRepository) {
+		if d.Status == "" {
+			d.Status = "pending"
+		}
+		r.DeliveryRecords = append(r.DeliveryRecords, d)
+		r.workspaces[d.ID] = r.workspaces[d.WebhookID]
+	}
+}
+
+// WebhookRepository serves both the API, scoped to the workspace of the context
+// like the other repositories, and the outbox and dispatcher, which work across
+// all workspaces. DeliveryRecords are kept in the order they were enqueued.
+type WebhookRepository struct {
+	Records         map[uuid.UUID]*aggregators.Webhook
+	DeliveryRecords []*aggregators.WebhookDelivery
+	workspaces      map[uuid.UUID]uuid.UUID
+	availableAt     map[uuid.UUID]time.Time
+	now             func() time.Time
+	err             error
+}
+
+func NewWebhookRepository(opts ...WebhookRepositoryOptional) *WebhookRepository {
+	r := &WebhookRepository{
+		Records:     make(map[uuid.UUID]*aggregators.Webhook),
+		workspaces:  make(map[uuid.UUID]uuid.UUID),
+		availableAt: make(map[uuid.UUID]time.Time),
+		now:         time.Now,
+	}
+	for _, opt := range opts {
+		opt(r)
+	}
+
+	return r
+}
+
+func (r *WebhookRepository) owns(ctx context.Context, id uuid.UUID) bool {
+	workspaceID, ok := r.workspaces[id]
+	return ok && workspaceID == infrastructure.Workspace(ctx)
+}
+
+func (r *WebhookRepository) All(ctx context.Context) ([]*aggregators.Webhook, error) {
+	if r.err != nil {
+		return nil, r.err
+	}
+
+	webhooks := make([]*aggregators.Webhook, 0, len(r.Records))
+	for id, w := range r.Records {
+		if r.owns(ctx, id) {
+			webhooks = append(webhooks, w)
+		}
+	}
+	sort.Slice(webhooks, func(i, j int) bool {
+		return webhooks[i].CreatedAt.Before(webhooks[j].CreatedAt)
+	})
+
+	return webhooks, nil
+}
+
+func (r *WebhookRepository) Find(ctx context.Context, id uuid.UUID) (*aggregators.Webhook, error) {
+	if r.err != nil {
+		return nil, r.err
+	}
+
+	w, ok := r.Records[id]
+	if !ok || !r.owns(ctx, id) {
+		return nil, infrastructure.ErrWebhookNotFound
+	}
+
+	return w, nil
+}
+
+func (r *WebhookRepository) Save(ctx context.Context, w *aggregators.Webhook) error {
+	if r.err != nil {
+		return r.err
+	}
+
+	r.Records[w.ID] = w
+	r.workspaces[w.ID] = infrastructure.Workspace(ctx)
+
+	return nil
+}
+
+func (r *WebhookRepository) Delete(ctx context.Context, id uuid.UUID) error {
+	if r.err != nil {
+		return r.err
+	}
+
+	if _, ok := r.Records[id]; !ok || !r.owns(ctx, id) {
+		return infrastructure.ErrWebhookNotFound
+	}
+	delete(r.Records, id)
+	r.DeliveryRecords = slices.DeleteFunc(r.DeliveryRecords, func(d *aggregators.WebhookDelivery) bool {
+		return d.WebhookID == id
+	})
+
+	return nil
+}
+
+func (r *WebhookRepository) Deliveries(ctx context.Context, webhookID uuid.UUID, limit int) ([]*aggregators.WebhookDelivery, error) {
+	if r.err != nil {
+		return nil, r.err
+	}
+
+	var deliveries []*aggregators.WebhookDelivery
+	for _, d := range slices.Backward(r.DeliveryRecords) {
+		if d.WebhookID == webhookID && r.owns(ctx, d.ID) && len(deliveries) < limit {
+			deliveries = append(deliveries, d)
+		}
+	}
+
+	return deliveries, nil
+}
+
+func (r *WebhookRepository) FindDelivery(ctx context.Context, id uuid.UUID) (*aggregators.WebhookDelivery, error) {
+	if r.err != nil {
+		return nil, r.err
+	}
+
+	for _, d := range r.DeliveryRecords {
+		if d.ID == id && r.owns(ctx, id) {
+			return d, nil
+		}
+	}
+
+	return nil, infrastructure.ErrDeliveryNotFound
+}
+
+func (r *WebhookRepository) Redeliver(ctx context.Context, id uuid.UUID) error {
+	d, err := r.FindDelivery(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	d.Status = "pending"
+	d.Failures = 0
+	delete(r.availableAt, id)
+
+	return nil
+}
+
+func (r *WebhookRepository) Enqueue(_ context.Context, e *aggregators.Event) error {
+	if r.err != nil {
+		return r.err
+	}
+
+	payload, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+
+	webhooks := make([]*aggregators.Webhook, 0, len(r.Records))
+	for id, w := range r.Records {
+		if r.workspaces[id] == e.WorkspaceID && (len(w.Events) == 0 || slices.Contains(w.Events, e.Type)) {
+			webhooks = append(webhooks, w)
+		}
+	}
+	sort.Slice(webhooks, func(i, j int) bool {
+		return webhooks[i].CreatedAt.Before(webhooks[j].CreatedAt)
+	})
+
+	for _, w := range webhooks {
+		if slices.ContainsFunc(r.DeliveryRecords, func(d *aggregators.WebhookDelivery) bool {
+			return d.WebhookID == w.ID && d.EventID == e.ID
+		}) {
+			continue
+		}
+
+		d := &aggregators.WebhookDelivery{
+			ID:        uuid.New(),
+			WebhookID: w.ID,
+			EventID:   e.ID,
+			EventType: e.Type,
+			Payload:   payload,
+			Status:    "pending",
+			CreatedAt: r.now(),
+			Attempts:  []*aggregators.WebhookAttempt{},
+		}
+		r.DeliveryRecords = append(r.DeliveryRecords, d)
+		r.workspaces[d.ID] = e.WorkspaceID
+	}
+
+	return nil
+}
+
+// Claim returns copies of the due deliveries with the URL and secret of their
+// webhook, as they would come out of the database.
+func (r *WebhookRepository) Claim(_ context.Context, limit int, _ time.Duration) ([]*aggregators.WebhookDelivery, error) {
+	if r.err != nil {
+		return nil, r.err
+	}
+
+	var deliveries []*aggregators.WebhookDelivery
+	for _, d := range r.DeliveryRecords {
+		if d.Status != "pending" || r.availableAt[d.ID].After(r.now()) || len(deliveries) == limit {
+			continue
+		}
+
+		claimed := *d
+		claimed.URL = r.Records[d.WebhookID].URL
+		claimed.Secret = r.Records[d.WebhookID].Secret
+		deliveries = append(deliveries, &claimed)
+	}
+
+	return deliveries, nil
+}
+
+func (r *WebhookRepository) Record(_ context.Context, a *aggregators.WebhookAttempt, status string, retryAt time.Time) error {
+	if r.err != nil {
+		return r.err
+	}
+
+	for _, d := range r.DeliveryRecords {
+		if d.ID != a.DeliveryID {
+			continue
+		}
+
+		d.Attempts = append(d.Attempts, a)
+		d.Status = status
+		if a.Status == "failed" {
+			d.Failures++
+		}
+		r.availableAt[d.ID] = retryAt
+	}
+
+	return nil
+}